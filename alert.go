@@ -17,16 +17,21 @@ import (
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 	alertservice "github.com/influxdata/kapacitor/services/alert"
+	"github.com/influxdata/kapacitor/services/alertmanager"
+	"github.com/influxdata/kapacitor/services/amqp"
+	"github.com/influxdata/kapacitor/services/discord"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/httppost"
 	"github.com/influxdata/kapacitor/services/mqtt"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/pagerduty"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
 	"github.com/influxdata/kapacitor/services/pushover"
 	"github.com/influxdata/kapacitor/services/sensu"
 	"github.com/influxdata/kapacitor/services/slack"
 	"github.com/influxdata/kapacitor/services/smtp"
 	"github.com/influxdata/kapacitor/services/snmptrap"
+	"github.com/influxdata/kapacitor/services/teams"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/victorops"
 	"github.com/influxdata/kapacitor/tick/ast"
@@ -130,16 +135,55 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 		an.handlers = append(an.handlers, h)
 	}
 
+	for _, sl := range n.SyslogHandlers {
+		c := alertservice.DefaultSyslogHandlerConfig()
+		c.Address = sl.Address
+		if sl.Network != "" {
+			c.Network = sl.Network
+		}
+		if sl.Facility != "" {
+			c.Facility = sl.Facility
+		}
+		if sl.AppName != "" {
+			c.AppName = sl.AppName
+		}
+		c.Hostname = sl.Hostname
+		if sl.StructuredDataID != "" {
+			c.StructuredDataID = sl.StructuredDataID
+		}
+		c.SSLCA = sl.SslCa
+		c.SSLCert = sl.SslCert
+		c.SSLKey = sl.SslKey
+		c.InsecureSkipVerify = sl.IsInsecureSkipVerify
+		h, err := alertservice.NewSyslogHandler(c, l)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create syslog alert handler")
+		}
+		an.handlers = append(an.handlers, h)
+	}
+
 	for _, email := range n.EmailHandlers {
 		c := smtp.HandlerConfig{
-			To: email.ToList,
+			Workspace: email.Workspace,
+			To:        email.ToList,
+			Template:  email.Template,
+			Subject:   email.Subject,
+			Text:      email.Text,
+			HTML:      email.Html,
+			AttachCSV: email.IsAttachCSV,
+		}
+		h, err := et.tm.SMTPService.Handler(c, l)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create email alert handler")
 		}
-		h := et.tm.SMTPService.Handler(c, l)
 		an.handlers = append(an.handlers, h)
 	}
 	if len(n.EmailHandlers) == 0 && (et.tm.SMTPService != nil && et.tm.SMTPService.Global()) {
 		c := smtp.HandlerConfig{}
-		h := et.tm.SMTPService.Handler(c, l)
+		h, err := et.tm.SMTPService.Handler(c, l)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create email alert handler")
+		}
 		an.handlers = append(an.handlers, h)
 	}
 	// If email has been configured with state changes only set it.
@@ -174,6 +218,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 
 	for _, vo := range n.VictorOpsHandlers {
 		c := victorops.HandlerConfig{
+			Workspace:  vo.Workspace,
 			RoutingKey: vo.RoutingKey,
 		}
 		h := et.tm.VictorOpsService.Handler(c, l)
@@ -187,6 +232,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 
 	for _, pd := range n.PagerDutyHandlers {
 		c := pagerduty.HandlerConfig{
+			Workspace:  pd.Workspace,
 			ServiceKey: pd.ServiceKey,
 		}
 		h := et.tm.PagerDutyService.Handler(c, l)
@@ -198,6 +244,26 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 		an.handlers = append(an.handlers, h)
 	}
 
+	for _, pd := range n.PagerDuty2Handlers {
+		c := pagerduty2.HandlerConfig{
+			Workspace:  pd.Workspace,
+			RoutingKey: pd.RoutingKey,
+		}
+		for _, link := range pd.Links {
+			c.Links = append(c.Links, pagerduty2.Link{Href: link.Href, Text: link.Text})
+		}
+		for _, img := range pd.Images {
+			c.Images = append(c.Images, pagerduty2.Image{Src: img.Src, Href: img.Href, Alt: img.Alt})
+		}
+		h := et.tm.PagerDuty2Service.Handler(c, l)
+		an.handlers = append(an.handlers, h)
+	}
+	if len(n.PagerDuty2Handlers) == 0 && (et.tm.PagerDuty2Service != nil && et.tm.PagerDuty2Service.Global()) {
+		c := pagerduty2.HandlerConfig{}
+		h := et.tm.PagerDuty2Service.Handler(c, l)
+		an.handlers = append(an.handlers, h)
+	}
+
 	for _, s := range n.SensuHandlers {
 		c := sensu.HandlerConfig{
 			Source:   s.Source,
@@ -212,6 +278,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 
 	for _, s := range n.SlackHandlers {
 		c := slack.HandlerConfig{
+			Workspace: s.Workspace,
 			Channel:   s.Channel,
 			Username:  s.Username,
 			IconEmoji: s.IconEmoji,
@@ -232,6 +299,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 
 	for _, t := range n.TelegramHandlers {
 		c := telegram.HandlerConfig{
+			Workspace:             t.Workspace,
 			ChatId:                t.ChatId,
 			ParseMode:             t.ParseMode,
 			DisableWebPagePreview: t.IsDisableWebPagePreview,
@@ -275,8 +343,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 
 	for _, hc := range n.HipChatHandlers {
 		c := hipchat.HandlerConfig{
-			Room:  hc.Room,
-			Token: hc.Token,
+			Workspace: hc.Workspace,
+			Room:      hc.Room,
+			Token:     hc.Token,
 		}
 		h := et.tm.HipChatService.Handler(c, l)
 		an.handlers = append(an.handlers, h)
@@ -326,8 +395,48 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 		an.handlers = append(an.handlers, h)
 	}
 
+	for _, am := range n.AlertManagerHandlers {
+		c := alertmanager.HandlerConfig{
+			Workspace: am.Workspace,
+			Labels:    am.Labels,
+		}
+		h := et.tm.AlertManagerService.Handler(c, l)
+		an.handlers = append(an.handlers, h)
+	}
+	if len(n.AlertManagerHandlers) == 0 && (et.tm.AlertManagerService != nil && et.tm.AlertManagerService.Global()) {
+		c := alertmanager.HandlerConfig{}
+		h := et.tm.AlertManagerService.Handler(c, l)
+		an.handlers = append(an.handlers, h)
+	}
+
+	for _, a := range n.AMQPHandlers {
+		c := amqp.HandlerConfig{
+			BrokerName: a.BrokerName,
+			Exchange:   a.Exchange,
+			RoutingKey: a.RoutingKey,
+		}
+		h, err := et.tm.AMQPService.Handler(c, l)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create AMQP handler")
+		}
+		an.handlers = append(an.handlers, h)
+	}
+
+	for _, d := range n.DiscordHandlers {
+		c := discord.HandlerConfig{
+			Workspace:  d.Workspace,
+			Username:   d.Username,
+			AvatarURL:  d.AvatarURL,
+			EmbedTitle: d.EmbedTitle,
+		}
+		h := et.tm.DiscordService.Handler(c, l)
+		an.handlers = append(an.handlers, h)
+	}
+
 	for _, p := range n.PushoverHandlers {
-		c := pushover.HandlerConfig{}
+		c := pushover.HandlerConfig{
+			Workspace: p.Workspace,
+		}
 		if p.Device != "" {
 			c.Device = p.Device
 		}
@@ -349,16 +458,22 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 
 	for _, p := range n.HTTPPostHandlers {
 		c := httppost.HandlerConfig{
-			URL:      p.URL,
-			Endpoint: p.Endpoint,
-			Headers:  p.Headers,
+			URL:         p.URL,
+			Endpoint:    p.Endpoint,
+			Headers:     p.Headers,
+			Template:    p.Template,
+			ContentType: p.ContentType,
+		}
+		h, err := et.tm.HTTPPostService.Handler(c, l)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create HTTP POST handler")
 		}
-		h := et.tm.HTTPPostService.Handler(c, l)
 		an.handlers = append(an.handlers, h)
 	}
 
 	for _, og := range n.OpsGenieHandlers {
 		c := opsgenie.HandlerConfig{
+			Workspace:      og.Workspace,
 			TeamsList:      og.TeamsList,
 			RecipientsList: og.RecipientsList,
 		}
@@ -376,6 +491,26 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 		an.handlers = append(an.handlers, h)
 	}
 
+	for _, t := range n.TeamsHandlers {
+		c := teams.HandlerConfig{
+			Workspace:  t.Workspace,
+			ChannelURL: t.ChannelURL,
+			CardType:   t.CardType,
+		}
+		h := et.tm.TeamsService.Handler(c, l)
+		an.handlers = append(an.handlers, h)
+	}
+	if len(n.TeamsHandlers) == 0 && (et.tm.TeamsService != nil && et.tm.TeamsService.Global()) {
+		h := et.tm.TeamsService.Handler(teams.HandlerConfig{}, l)
+		an.handlers = append(an.handlers, h)
+	}
+	// If teams has been configured with state changes only set it.
+	if et.tm.TeamsService != nil &&
+		et.tm.TeamsService.Global() &&
+		et.tm.TeamsService.StateChangesOnly() {
+		n.IsStateChangesOnly = true
+	}
+
 	for _, m := range n.MQTTHandlers {
 		c := mqtt.HandlerConfig{
 			BrokerName: m.BrokerName,
@@ -386,6 +521,11 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 		h := et.tm.MQTTService.Handler(c, l)
 		an.handlers = append(an.handlers, h)
 	}
+	// All handlers call out to external services,
+	// so they must skip events marked NoExternal, i.e. during maintenance windows.
+	for i, h := range an.handlers {
+		an.handlers[i] = alertservice.NewExternalHandler(h)
+	}
 	// Parse level expressions
 	an.levels = make([]stateful.Expression, alert.Critical+1)
 	an.scopePools = make([]stateful.ScopePool, alert.Critical+1)
@@ -0,0 +1,68 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// Inhibition suppresses handling of events on target topics while a source topic is at or above a level.
+// With Equal only the firing events of the source topic whose tags equal the tags of the target event count.
+// Inhibited events are still collected and their state is updated with the reason they were inhibited,
+// but no handlers are called for them.
+type Inhibition struct {
+	ID string
+	// SourceTopic is the topic whose level inhibits events.
+	SourceTopic string
+	// Level is the minimum level of the source topic for the inhibition to apply.
+	Level Level
+	// TargetTopic is a glob pattern matched against the topic ID of events, empty matches all topics.
+	// Events of the source topic are never inhibited by their own rule.
+	TargetTopic string
+	// Tags must all be present with equal values on the event.
+	Tags map[string]string
+	// Equal is a list of tag keys whose values must be the same on the target event and a firing event of the source topic.
+	// A tag missing from both events is considered equal.
+	Equal []string
+
+	Comment string
+}
+
+func (i Inhibition) Validate() error {
+	if i.ID == "" {
+		return errors.New("inhibition ID must not be empty")
+	}
+	if i.SourceTopic == "" {
+		return errors.New("inhibition source topic must not be empty")
+	}
+	if i.Level == OK {
+		return errors.New("inhibition level must be more severe than OK")
+	}
+	if _, err := path.Match(i.TargetTopic, ""); err != nil {
+		return errors.New("invalid target topic pattern: " + err.Error())
+	}
+	for _, k := range i.Equal {
+		if k == "" {
+			return errors.New("inhibition equal tag keys must not be empty")
+		}
+	}
+	return nil
+}
+
+// Match reports whether the inhibition applies to the event, ignoring the state of the source topic.
+func (i Inhibition) Match(event Event) bool {
+	if event.Topic == i.SourceTopic || !PatternMatch(i.TargetTopic, event.Topic) {
+		return false
+	}
+	for k, v := range i.Tags {
+		if t, ok := event.Data.Tags[k]; !ok || t != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Reason describes why an event is inhibited given the level of the source topic.
+func (i Inhibition) Reason(level Level) string {
+	return fmt.Sprintf("inhibited by %q: topic %q is %v", i.ID, i.SourceTopic, level)
+}
@@ -0,0 +1,129 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/gorhill/cronexpr"
+)
+
+// MaintenanceWindow suppresses external handling of matching events while it is active.
+// Events are still collected and handled by internal handlers, but they are flagged as NoExternal
+// and their state records the window that suppressed them.
+type MaintenanceWindow struct {
+	ID string
+	// Topic is a glob pattern matched against the topic ID, empty matches all topics.
+	Topic string
+	// Event is a glob pattern matched against the event ID, empty matches all events.
+	Event string
+	// Tags must all be present on the event with values matching the glob patterns.
+	Tags map[string]string
+	// Cron is a cron expression for recurring windows, each occurrence starts a window lasting Duration.
+	// Windows without a cron expression are active once, from Start to Stop.
+	Cron     string
+	Duration time.Duration
+	// Location in which the cron expression is evaluated, defaults to UTC.
+	Location *time.Location
+	// Start and Stop bound the time the window is active, Stop is exclusive.
+	// They are optional for recurring windows.
+	Start time.Time
+	Stop  time.Time
+
+	Comment string
+
+	// schedule is the parsed cron expression.
+	schedule *cronexpr.Expression
+}
+
+func (w MaintenanceWindow) Validate() error {
+	if w.ID == "" {
+		return errors.New("maintenance window ID must not be empty")
+	}
+	if _, err := path.Match(w.Topic, ""); err != nil {
+		return errors.New("invalid topic pattern: " + err.Error())
+	}
+	if _, err := path.Match(w.Event, ""); err != nil {
+		return errors.New("invalid event pattern: " + err.Error())
+	}
+	for k, v := range w.Tags {
+		if _, err := path.Match(v, ""); err != nil {
+			return fmt.Errorf("invalid pattern for tag %q: %v", k, err)
+		}
+	}
+	if w.Cron == "" {
+		if w.Start.IsZero() || w.Stop.IsZero() {
+			return errors.New("maintenance window without a cron expression must have a start and stop")
+		}
+		if w.Duration != 0 {
+			return errors.New("maintenance window duration is only allowed with a cron expression")
+		}
+	} else {
+		if _, err := cronexpr.Parse(w.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression %q: %v", w.Cron, err)
+		}
+		if w.Duration <= 0 {
+			return errors.New("recurring maintenance window must have a positive duration")
+		}
+	}
+	if !w.Start.IsZero() && !w.Stop.IsZero() && !w.Stop.After(w.Start) {
+		return errors.New("maintenance window stop must be after start")
+	}
+	return nil
+}
+
+// compile returns a copy of the window with its cron expression parsed.
+// The window must be valid.
+func (w MaintenanceWindow) compile() MaintenanceWindow {
+	if w.Cron != "" {
+		w.schedule, _ = cronexpr.Parse(w.Cron)
+	}
+	return w
+}
+
+// Active reports whether the window is active at time now.
+func (w MaintenanceWindow) Active(now time.Time) bool {
+	if !w.Start.IsZero() && now.Before(w.Start) {
+		return false
+	}
+	if w.Expired(now) {
+		return false
+	}
+	if w.Cron == "" {
+		return true
+	}
+	schedule := w.schedule
+	if schedule == nil {
+		var err error
+		schedule, err = cronexpr.Parse(w.Cron)
+		if err != nil {
+			return false
+		}
+	}
+	loc := w.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	// The window is active if an occurrence started within the last duration.
+	next := schedule.Next(now.Add(-w.Duration).In(loc))
+	return !next.IsZero() && !next.After(now)
+}
+
+// Expired reports whether the window will never be active again.
+func (w MaintenanceWindow) Expired(now time.Time) bool {
+	return !w.Stop.IsZero() && !now.Before(w.Stop)
+}
+
+// Match reports whether the window applies to the event, ignoring whether it is active.
+func (w MaintenanceWindow) Match(event Event) bool {
+	if !PatternMatch(w.Topic, event.Topic) || !PatternMatch(w.Event, event.State.ID) {
+		return false
+	}
+	for k, pattern := range w.Tags {
+		if v, ok := event.Data.Tags[k]; !ok || !PatternMatch(pattern, v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,151 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OnCallSchedule decides who is on call at any time from rotations and overrides.
+type OnCallSchedule struct {
+	ID string
+	// Members of the schedule, their names are referenced by the rotations and overrides.
+	Members []OnCallMember
+	// Rotations are evaluated in order, the first rotation covering the time of day decides who is on call.
+	Rotations []Rotation
+	// Overrides take precedence over the rotations while they are active.
+	Overrides []OnCallOverride
+	// Location in which handoffs and times of day are evaluated, defaults to UTC.
+	Location *time.Location
+}
+
+// OnCallMember is a person that can be on call.
+type OnCallMember struct {
+	Name string
+	// Contacts are the addresses of the member keyed by handler kind,
+	// for example an email address for the smtp kind.
+	Contacts map[string]string
+}
+
+// Rotation hands off being on call from one member to the next at a fixed interval.
+type Rotation struct {
+	// Members take turns in order, starting with the first one at Handoff.
+	Members []string
+	// Handoff is the start of the shift of the first member.
+	Handoff time.Time
+	// Shift is how long each member is on call.
+	// Shifts of whole days always hand off at the local time of day of Handoff, across daylight saving changes.
+	Shift time.Duration
+	// Start and Stop are offsets from midnight restricting the time of day the rotation covers, Stop is exclusive.
+	// If Stop is before Start the range wraps past midnight.
+	// If both are zero the rotation covers all times of day.
+	Start time.Duration
+	Stop  time.Duration
+}
+
+// OnCallOverride puts a member on call from Start until Stop.
+type OnCallOverride struct {
+	Member string
+	Start  time.Time
+	Stop   time.Time
+}
+
+func (s OnCallSchedule) Validate() error {
+	if s.ID == "" {
+		return errors.New("on-call schedule ID must not be empty")
+	}
+	members := make(map[string]bool, len(s.Members))
+	for _, m := range s.Members {
+		if m.Name == "" {
+			return errors.New("member name must not be empty")
+		}
+		if members[m.Name] {
+			return fmt.Errorf("duplicate member %q", m.Name)
+		}
+		members[m.Name] = true
+	}
+	for i, r := range s.Rotations {
+		if len(r.Members) == 0 {
+			return fmt.Errorf("rotation %d must have members", i)
+		}
+		for _, m := range r.Members {
+			if !members[m] {
+				return fmt.Errorf("rotation %d references unknown member %q", i, m)
+			}
+		}
+		if r.Handoff.IsZero() {
+			return fmt.Errorf("rotation %d must have a handoff time", i)
+		}
+		if r.Shift <= 0 {
+			return fmt.Errorf("rotation %d must have a positive shift", i)
+		}
+		if err := validateTimeOfDay(r.Start, r.Stop); err != nil {
+			return fmt.Errorf("rotation %d %v", i, err)
+		}
+	}
+	for i, o := range s.Overrides {
+		if !members[o.Member] {
+			return fmt.Errorf("override %d references unknown member %q", i, o.Member)
+		}
+		if !o.Stop.After(o.Start) {
+			return fmt.Errorf("override %d stop must be after start", i)
+		}
+	}
+	return nil
+}
+
+// OnCall returns the member that is on call at time now.
+// If nobody is on call false is returned.
+func (s OnCallSchedule) OnCall(now time.Time) (OnCallMember, bool) {
+	name, ok := s.onCallName(now)
+	if !ok {
+		return OnCallMember{}, false
+	}
+	for _, m := range s.Members {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return OnCallMember{}, false
+}
+
+func (s OnCallSchedule) onCallName(now time.Time) (string, bool) {
+	for _, o := range s.Overrides {
+		if !now.Before(o.Start) && now.Before(o.Stop) {
+			return o.Member, true
+		}
+	}
+	tod := timeOfDay(now, s.Location)
+	for _, r := range s.Rotations {
+		if inTimeOfDay(r.Start, r.Stop, tod) {
+			return r.onCall(now, s.Location), true
+		}
+	}
+	return "", false
+}
+
+// onCall returns the name of the member whose shift includes now.
+func (r Rotation) onCall(now time.Time, loc *time.Location) string {
+	i := int64(now.Sub(r.Handoff) / r.Shift)
+	// Correct the estimate for shifts that are not exactly Shift long because of daylight saving changes.
+	for r.shiftStart(i, loc).After(now) {
+		i--
+	}
+	for !r.shiftStart(i+1, loc).After(now) {
+		i++
+	}
+	n := int64(len(r.Members))
+	return r.Members[((i%n)+n)%n]
+}
+
+// shiftStart returns the start of the i-th shift after the handoff.
+func (r Rotation) shiftStart(i int64, loc *time.Location) time.Time {
+	day := 24 * time.Hour
+	if r.Shift%day != 0 {
+		return r.Handoff.Add(time.Duration(i) * r.Shift)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return r.Handoff.In(loc).AddDate(0, 0, int(i*int64(r.Shift/day)))
+}
@@ -0,0 +1,195 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+)
+
+// Routing is an ordered tree of routes deciding which handlers of a topic receive an event.
+// Once a topic has a routing its handlers only receive the events that are routed to them.
+// The recovery of an event is not routed, it is sent to the handlers the event was routed to since it was last OK.
+type Routing struct {
+	Topic string
+	// Routes are evaluated in order.
+	Routes []Route
+	// Default are the handlers of events that match none of the routes.
+	Default []string
+	// Location in which the time of day of events is evaluated, defaults to UTC.
+	Location *time.Location
+}
+
+// Route matches events and sends them to handlers or to child routes.
+type Route struct {
+	// Levels the event must have one of, empty matches all levels.
+	Levels []Level
+	// Tags must all be present with equal values on the event.
+	Tags map[string]string
+	// TaskName is a glob pattern matched against the name of the task of the event, empty matches all tasks.
+	TaskName string
+	// Start and Stop are offsets from midnight bounding the time of day of the event, Stop is exclusive.
+	// If Stop is before Start the range wraps past midnight.
+	// If both are zero all times of day match.
+	Start time.Duration
+	Stop  time.Duration
+	// Routes are evaluated in order once the route matches.
+	Routes []Route
+	// Handlers receive the matching events for which none of the child routes match.
+	Handlers []string
+	// Continue evaluating the following routes once the route matches,
+	// otherwise evaluation stops at the first matching route.
+	Continue bool
+}
+
+func (r Routing) Validate() error {
+	if r.Topic == "" {
+		return errors.New("routing topic must not be empty")
+	}
+	if err := validateHandlerIDs(r.Default); err != nil {
+		return fmt.Errorf("invalid default route: %v", err)
+	}
+	return validateRoutes(r.Routes, "routes")
+}
+
+func validateRoutes(routes []Route, prefix string) error {
+	for i, route := range routes {
+		p := fmt.Sprintf("%s[%d]", prefix, i)
+		if err := route.Validate(); err != nil {
+			return fmt.Errorf("invalid route %s: %v", p, err)
+		}
+		if err := validateRoutes(route.Routes, p+".routes"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateHandlerIDs(handlers []string) error {
+	for _, h := range handlers {
+		if h == "" {
+			return errors.New("handler ID must not be empty")
+		}
+	}
+	return nil
+}
+
+// Validate validates the route, but not its child routes.
+func (r Route) Validate() error {
+	if len(r.Handlers) == 0 && len(r.Routes) == 0 {
+		return errors.New("route must have handlers or routes")
+	}
+	if err := validateHandlerIDs(r.Handlers); err != nil {
+		return err
+	}
+	if _, err := path.Match(r.TaskName, ""); err != nil {
+		return errors.New("invalid task name pattern: " + err.Error())
+	}
+	if err := validateTimeOfDay(r.Start, r.Stop); err != nil {
+		return errors.New("route " + err.Error())
+	}
+	return nil
+}
+
+// validateTimeOfDay validates start and stop offsets from midnight.
+func validateTimeOfDay(start, stop time.Duration) error {
+	day := 24 * time.Hour
+	if start < 0 || start >= day || stop < 0 || stop >= day {
+		return errors.New("start and stop must be within a day")
+	}
+	if start == stop && start != 0 {
+		return errors.New("start and stop must not be equal")
+	}
+	return nil
+}
+
+// inTimeOfDay reports whether the time of day is within start and stop,
+// wrapping past midnight if stop is before start.
+// If both are zero all times of day are within.
+func inTimeOfDay(start, stop, timeOfDay time.Duration) bool {
+	switch {
+	case start == stop:
+		return true
+	case start < stop:
+		return timeOfDay >= start && timeOfDay < stop
+	default:
+		return timeOfDay >= start || timeOfDay < stop
+	}
+}
+
+// timeOfDay returns the offset of t from midnight in the given location.
+func timeOfDay(t time.Time, loc *time.Location) time.Duration {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	hour, min, sec := t.Clock()
+	return time.Duration(hour)*time.Hour +
+		time.Duration(min)*time.Minute +
+		time.Duration(sec)*time.Second +
+		time.Duration(t.Nanosecond())
+}
+
+// Match reports whether the event matches the route, ignoring its child routes.
+// The time of day of the event is given as the offset from midnight.
+func (r Route) Match(event Event, timeOfDay time.Duration) bool {
+	if len(r.Levels) > 0 {
+		found := false
+		for _, l := range r.Levels {
+			if l == event.State.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !PatternMatch(r.TaskName, event.Data.TaskName) {
+		return false
+	}
+	for k, v := range r.Tags {
+		if t, ok := event.Data.Tags[k]; !ok || t != v {
+			return false
+		}
+	}
+	return inTimeOfDay(r.Start, r.Stop, timeOfDay)
+}
+
+// Handlers returns the IDs of the handlers the event is routed to, in order and without duplicates.
+func (r Routing) Handlers(event Event) []string {
+	var handlers []string
+	if !routeEvent(r.Routes, event, timeOfDay(event.State.Time, r.Location), &handlers) {
+		handlers = append(handlers, r.Default...)
+	}
+
+	// Remove duplicates keeping the first occurrence
+	seen := make(map[string]bool, len(handlers))
+	unique := handlers[:0]
+	for _, h := range handlers {
+		if !seen[h] {
+			seen[h] = true
+			unique = append(unique, h)
+		}
+	}
+	return unique
+}
+
+// routeEvent appends the handlers of the routes the event is routed to,
+// reporting whether any of the routes matched.
+func routeEvent(routes []Route, event Event, timeOfDay time.Duration, handlers *[]string) bool {
+	matched := false
+	for _, r := range routes {
+		if !r.Match(event, timeOfDay) {
+			continue
+		}
+		matched = true
+		if !routeEvent(r.Routes, event, timeOfDay, handlers) {
+			*handlers = append(*handlers, r.Handlers...)
+		}
+		if !r.Continue {
+			break
+		}
+	}
+	return matched
+}
@@ -0,0 +1,68 @@
+package alert
+
+import (
+	"errors"
+	"path"
+	"time"
+)
+
+// Silence suppresses handling of matching events for a period of time.
+// Events are still collected and their state is updated while silenced,
+// but no handlers are called for them.
+type Silence struct {
+	ID string
+	// Topic is a glob pattern matched against the topic ID, empty matches all topics.
+	Topic string
+	// Event is a glob pattern matched against the event ID, empty matches all events.
+	Event string
+	// Tags must all be present with equal values on the event.
+	Tags map[string]string
+	// Start and Stop bound the time the silence is active, Stop is exclusive.
+	Start time.Time
+	Stop  time.Time
+
+	CreatedBy string
+	Comment   string
+}
+
+func (s Silence) Validate() error {
+	if s.ID == "" {
+		return errors.New("silence ID must not be empty")
+	}
+	if !s.Stop.After(s.Start) {
+		return errors.New("silence stop must be after start")
+	}
+	if _, err := path.Match(s.Topic, ""); err != nil {
+		return errors.New("invalid topic pattern: " + err.Error())
+	}
+	if _, err := path.Match(s.Event, ""); err != nil {
+		return errors.New("invalid event pattern: " + err.Error())
+	}
+	return nil
+}
+
+// Active reports whether the silence is active at time now.
+func (s Silence) Active(now time.Time) bool {
+	return !now.Before(s.Start) && now.Before(s.Stop)
+}
+
+// Expired reports whether the silence will never be active again.
+func (s Silence) Expired(now time.Time) bool {
+	return !now.Before(s.Stop)
+}
+
+// Match reports whether the silence applies to the event at time now.
+func (s Silence) Match(event Event, now time.Time) bool {
+	if !s.Active(now) {
+		return false
+	}
+	if !PatternMatch(s.Topic, event.Topic) || !PatternMatch(s.Event, event.State.ID) {
+		return false
+	}
+	for k, v := range s.Tags {
+		if t, ok := event.Data.Tags[k]; !ok || t != v {
+			return false
+		}
+	}
+	return true
+}
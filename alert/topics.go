@@ -6,6 +6,7 @@ import (
 	"path"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/server/vars"
@@ -21,13 +22,25 @@ type Topics struct {
 
 	topics map[string]*Topic
 
+	silences map[string]Silence
+
+	inhibitions map[string]Inhibition
+
+	maintenanceWindows map[string]MaintenanceWindow
+
+	routings map[string]Routing
+
 	logger *log.Logger
 }
 
 func NewTopics(l *log.Logger) *Topics {
 	s := &Topics{
-		topics: make(map[string]*Topic),
-		logger: l,
+		topics:             make(map[string]*Topic),
+		silences:           make(map[string]Silence),
+		inhibitions:        make(map[string]Inhibition),
+		maintenanceWindows: make(map[string]MaintenanceWindow),
+		routings:           make(map[string]Routing),
+		logger:             l,
 	}
 	return s
 }
@@ -85,12 +98,239 @@ func (s *Topics) EventState(topic, event string) (EventState, bool) {
 	return t.EventState(event)
 }
 
+// Acknowledge acknowledges the current state of an event.
+// Handlers skip the event until its level changes.
+func (s *Topics) Acknowledge(topic, event string, ack Acknowledgement) (EventState, bool) {
+	s.mu.RLock()
+	t, ok := s.topics[topic]
+	s.mu.RUnlock()
+	if !ok {
+		return EventState{}, false
+	}
+	return t.acknowledge(event, ack)
+}
+
+// SetSilence creates or replaces a silence.
+func (s *Topics) SetSilence(silence Silence) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silences[silence.ID] = silence
+}
+
+// DeleteSilence removes a silence.
+func (s *Topics) DeleteSilence(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.silences, id)
+}
+
+// Silence returns the silence with the given ID.
+func (s *Topics) Silence(id string) (Silence, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	silence, ok := s.silences[id]
+	return silence, ok
+}
+
+// Silences returns all silences sorted by ID.
+func (s *Topics) Silences() []Silence {
+	s.mu.RLock()
+	silences := make([]Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		silences = append(silences, silence)
+	}
+	s.mu.RUnlock()
+	sort.Sort(sortedSilences(silences))
+	return silences
+}
+
+// DeleteExpiredSilences removes the silences that expired at time now and returns their IDs.
+func (s *Topics) DeleteExpiredSilences(now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []string
+	for id, silence := range s.silences {
+		if silence.Expired(now) {
+			expired = append(expired, id)
+			delete(s.silences, id)
+		}
+	}
+	return expired
+}
+
+// silenced reports whether any silence matches the event.
+// Caller must have the read lock.
+func (s *Topics) silenced(event Event, now time.Time) bool {
+	for _, silence := range s.silences {
+		if silence.Match(event, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetInhibition creates or replaces an inhibition.
+func (s *Topics) SetInhibition(inhibition Inhibition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inhibitions[inhibition.ID] = inhibition
+}
+
+// DeleteInhibition removes an inhibition.
+func (s *Topics) DeleteInhibition(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inhibitions, id)
+}
+
+// Inhibition returns the inhibition with the given ID.
+func (s *Topics) Inhibition(id string) (Inhibition, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	inhibition, ok := s.inhibitions[id]
+	return inhibition, ok
+}
+
+// Inhibitions returns all inhibitions sorted by ID.
+func (s *Topics) Inhibitions() []Inhibition {
+	s.mu.RLock()
+	inhibitions := make([]Inhibition, 0, len(s.inhibitions))
+	for _, inhibition := range s.inhibitions {
+		inhibitions = append(inhibitions, inhibition)
+	}
+	s.mu.RUnlock()
+	sort.Sort(sortedInhibitions(inhibitions))
+	return inhibitions
+}
+
+// inhibited returns the reason the event is inhibited, or an empty string if it is not inhibited.
+// If more than one inhibition applies the reason of the one with the lowest ID is returned.
+// Caller must have the read lock.
+func (s *Topics) inhibited(event Event) string {
+	reason := ""
+	matchID := ""
+	for id, i := range s.inhibitions {
+		if reason != "" && matchID < id {
+			continue
+		}
+		if !i.Match(event) {
+			continue
+		}
+		source, ok := s.topics[i.SourceTopic]
+		if !ok {
+			continue
+		}
+		level := source.MaxLevel()
+		if len(i.Equal) > 0 {
+			level = source.maxLevelEqual(event.Data.Tags, i.Equal)
+		}
+		if level >= i.Level {
+			reason = i.Reason(level)
+			matchID = id
+		}
+	}
+	return reason
+}
+
+// SetMaintenanceWindow creates or replaces a maintenance window.
+// The window must be valid.
+func (s *Topics) SetMaintenanceWindow(window MaintenanceWindow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maintenanceWindows[window.ID] = window.compile()
+}
+
+// DeleteMaintenanceWindow removes a maintenance window.
+func (s *Topics) DeleteMaintenanceWindow(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.maintenanceWindows, id)
+}
+
+// MaintenanceWindow returns the maintenance window with the given ID.
+func (s *Topics) MaintenanceWindow(id string) (MaintenanceWindow, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	window, ok := s.maintenanceWindows[id]
+	return window, ok
+}
+
+// MaintenanceWindows returns all maintenance windows sorted by ID.
+func (s *Topics) MaintenanceWindows() []MaintenanceWindow {
+	s.mu.RLock()
+	windows := make([]MaintenanceWindow, 0, len(s.maintenanceWindows))
+	for _, window := range s.maintenanceWindows {
+		windows = append(windows, window)
+	}
+	s.mu.RUnlock()
+	sort.Sort(sortedMaintenanceWindows(windows))
+	return windows
+}
+
+// inMaintenance returns the ID of the active maintenance window matching the event,
+// or an empty string if there is none.
+// If more than one window applies the one with the lowest ID is returned.
+// Caller must have the read lock.
+func (s *Topics) inMaintenance(event Event, now time.Time) string {
+	match := ""
+	for id, window := range s.maintenanceWindows {
+		if match != "" && match < id {
+			continue
+		}
+		if window.Match(event) && window.Active(now) {
+			match = id
+		}
+	}
+	return match
+}
+
+// SetRouting creates or replaces the routing of a topic.
+// The routing must be valid.
+func (s *Topics) SetRouting(routing Routing) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routings[routing.Topic] = routing
+}
+
+// DeleteRouting removes the routing of a topic.
+func (s *Topics) DeleteRouting(topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.routings, topic)
+}
+
+// Routing returns the routing of a topic.
+func (s *Topics) Routing(topic string) (Routing, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	routing, ok := s.routings[topic]
+	return routing, ok
+}
+
 // Collect collects an event and handles the event.
-func (s *Topics) Collect(event Event) error {
+// The stored state and the previous state of the event are returned,
+// the previous state is only valid if the event had a state before it was collected.
+func (s *Topics) Collect(event Event) (EventState, EventState, bool, error) {
+	now := time.Now()
 	s.mu.RLock()
 	topic := s.topics[event.Topic]
+	silenced := s.silenced(event, now)
+	event.State.Inhibited = s.inhibited(event)
+	event.State.Maintenance = s.inMaintenance(event, now)
+	routing, hasRouting := s.routings[event.Topic]
 	s.mu.RUnlock()
 
+	var routes []string
+	if hasRouting {
+		// Route the event once, for all handlers of the topic.
+		routes = routing.Handlers(event)
+	}
+
+	if event.State.Maintenance != "" {
+		// Only internal handlers handle events during maintenance.
+		event.NoExternal = true
+	}
+
 	if topic == nil {
 		// Create the empty topic
 		s.mu.Lock()
@@ -103,7 +343,7 @@ func (s *Topics) Collect(event Event) error {
 		s.mu.Unlock()
 	}
 
-	return topic.collect(event)
+	return topic.collect(event, silenced, hasRouting, routes)
 }
 
 func (s *Topics) DeleteTopic(topic string) {
@@ -195,6 +435,10 @@ type Topic struct {
 
 	events map[string]*EventState
 	sorted []*EventState
+	// tags of the last event collected for each event ID.
+	tags map[string]map[string]string
+	// routed are the handlers each event ID has been routed to since it was last OK.
+	routed map[string][]string
 
 	collected *expvar.Int
 	statsKey  string
@@ -206,6 +450,8 @@ func newTopic(id string) *Topic {
 	t := &Topic{
 		id:        id,
 		events:    make(map[string]*EventState),
+		tags:      make(map[string]map[string]string),
+		routed:    make(map[string][]string),
 		collected: new(expvar.Int),
 	}
 	statsKey, statsMap := vars.NewStatistic("topics", map[string]string{
@@ -236,6 +482,33 @@ func (t *Topic) MaxLevel() Level {
 	return level
 }
 
+// maxLevelEqual returns the max level of the events whose tags have the same values for the keys as the given tags.
+// Events restored without their tags only match when the keys are missing from the given tags.
+func (t *Topic) maxLevelEqual(tags map[string]string, keys []string) Level {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	// Events are sorted by descending level
+	for _, e := range t.sorted {
+		if e.Level == OK {
+			break
+		}
+		if equalTags(t.tags[e.ID], tags, keys) {
+			return e.Level
+		}
+	}
+	return OK
+}
+
+// equalTags reports whether the tags have the same values for all keys, missing tags are empty.
+func equalTags(a, b map[string]string, keys []string) bool {
+	for _, k := range keys {
+		if a[k] != b[k] {
+			return false
+		}
+	}
+	return true
+}
+
 func (t *Topic) addHandler(h Handler) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -269,6 +542,8 @@ func (t *Topic) restoreEventStates(eventStates map[string]EventState) {
 	defer t.mu.Unlock()
 	t.events = make(map[string]*EventState, len(eventStates))
 	t.sorted = make([]*EventState, 0, len(eventStates))
+	t.tags = make(map[string]map[string]string)
+	t.routed = make(map[string][]string)
 	for id, state := range eventStates {
 		e := new(EventState)
 		*e = state
@@ -312,14 +587,68 @@ func (t *Topic) close() {
 	vars.DeleteStatistic(t.statsKey)
 }
 
-func (t *Topic) collect(event Event) error {
-	prev, ok := t.updateEvent(event.State)
+func (t *Topic) collect(event Event, silenced, routing bool, routes []string) (EventState, EventState, bool, error) {
+	t.mu.Lock()
+	state, prev, ok := t.updateEventLocked(event.State)
+	t.tags[state.ID] = event.Data.Tags
+	if routing {
+		event.routing = true
+		event.routes = t.route(state, routes)
+	}
+	t.mu.Unlock()
+	event.State = state
 	if ok {
 		event.previousState = prev
 	}
 
 	t.collected.Add(1)
-	return t.handleEvent(event)
+	if silenced || event.State.Ack.Acked() || event.State.Inhibited != "" {
+		return state, prev, ok, nil
+	}
+	return state, prev, ok, t.handleEvent(event)
+}
+
+// route returns the handlers that receive the event, given the handlers its routing sends it to.
+// A recovery is sent to the handlers the event was routed to since it was last OK,
+// so that they receive the resolution of what they were sent.
+// Caller must have the lock.
+func (t *Topic) route(state EventState, routes []string) []string {
+	if state.Level != OK {
+		routed := t.routed[state.ID]
+		for _, h := range routes {
+			if !containsHandler(routed, h) {
+				routed = append(routed, h)
+			}
+		}
+		t.routed[state.ID] = routed
+		return routes
+	}
+	routed, ok := t.routed[state.ID]
+	if !ok {
+		return routes
+	}
+	delete(t.routed, state.ID)
+	return routed
+}
+
+func containsHandler(handlers []string, handler string) bool {
+	for _, h := range handlers {
+		if h == handler {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Topic) acknowledge(event string, ack Acknowledgement) (EventState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cur, ok := t.events[event]
+	if !ok {
+		return EventState{}, false
+	}
+	cur.Ack = ack
+	return *cur, true
 }
 
 func (t *Topic) handleEvent(event Event) error {
@@ -346,10 +675,16 @@ func (t *Topic) Collected() int64 {
 }
 
 // updateEvent will store the latest state for the given ID.
-func (t *Topic) updateEvent(state EventState) (EventState, bool) {
-	var hasPrev, needSort bool
+// The stored state and the previous state are returned.
+func (t *Topic) updateEvent(state EventState) (EventState, EventState, bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	return t.updateEventLocked(state)
+}
+
+// updateEventLocked is updateEvent for callers that have the lock.
+func (t *Topic) updateEventLocked(state EventState) (EventState, EventState, bool) {
+	var hasPrev, needSort bool
 	cur := t.events[state.ID]
 	if cur == nil {
 		needSort = true
@@ -361,13 +696,18 @@ func (t *Topic) updateEvent(state EventState) (EventState, bool) {
 	}
 	needSort = needSort || cur.Level != state.Level
 
+	if hasPrev && cur.Level == state.Level && !state.Ack.Acked() {
+		// Keep the acknowledgement until the level changes.
+		state.Ack = cur.Ack
+	}
+
 	prev := *cur
 	*cur = state
 
 	if needSort {
 		sort.Sort(sortedStates(t.sorted))
 	}
-	return prev, hasPrev
+	return state, prev, hasPrev
 }
 
 type sortedStates []*EventState
@@ -381,6 +721,30 @@ func (e sortedStates) Less(i int, j int) bool {
 	return e[i].ID < e[j].ID
 }
 
+type sortedInhibitions []Inhibition
+
+func (s sortedInhibitions) Len() int          { return len(s) }
+func (s sortedInhibitions) Swap(i int, j int) { s[i], s[j] = s[j], s[i] }
+func (s sortedInhibitions) Less(i int, j int) bool {
+	return s[i].ID < s[j].ID
+}
+
+type sortedMaintenanceWindows []MaintenanceWindow
+
+func (s sortedMaintenanceWindows) Len() int          { return len(s) }
+func (s sortedMaintenanceWindows) Swap(i int, j int) { s[i], s[j] = s[j], s[i] }
+func (s sortedMaintenanceWindows) Less(i int, j int) bool {
+	return s[i].ID < s[j].ID
+}
+
+type sortedSilences []Silence
+
+func (s sortedSilences) Len() int          { return len(s) }
+func (s sortedSilences) Swap(i int, j int) { s[i], s[j] = s[j], s[i] }
+func (s sortedSilences) Less(i int, j int) bool {
+	return s[i].ID < s[j].ID
+}
+
 // bufHandler wraps a Handler implementation in order to provide buffering and non-blocking event handling.
 type bufHandler struct {
 	h        Handler
@@ -0,0 +1,221 @@
+package alert_test
+
+import (
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+// recordingHandler records the ID and level of every event it handles.
+type recordingHandler struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHandler) Handle(event alert.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event.State.ID+":"+event.State.Level.String())
+}
+
+func (h *recordingHandler) handled() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.events
+}
+
+func newTopics(topic string) (*alert.Topics, *recordingHandler) {
+	topics := alert.NewTopics(log.New(os.Stderr, "[alert] ", log.LstdFlags))
+	h := new(recordingHandler)
+	topics.RegisterHandler(topic, h)
+	return topics, h
+}
+
+func collect(t *testing.T, topics *alert.Topics, topic, id string, level alert.Level, tags map[string]string) {
+	if _, _, _, err := topics.Collect(alert.Event{
+		Topic: topic,
+		State: alert.EventState{
+			ID:    id,
+			Level: level,
+			Time:  time.Now(),
+		},
+		Data: alert.EventData{
+			Tags: tags,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// closeAndCheck closes the topics, so all buffered events are handled, and compares the handled events.
+func closeAndCheck(t *testing.T, topics *alert.Topics, h *recordingHandler, exp []string) {
+	topics.Close()
+	got := h.handled()
+	if len(got) != len(exp) {
+		t.Fatalf("unexpected handled events:\ngot %v\nexp %v", got, exp)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Fatalf("unexpected handled events:\ngot %v\nexp %v", got, exp)
+		}
+	}
+}
+
+func TestTopics_Silence(t *testing.T) {
+	topics, h := newTopics("t")
+	now := time.Now()
+	topics.SetSilence(alert.Silence{
+		ID:    "s",
+		Topic: "t",
+		Event: "silenced*",
+		Start: now.Add(-time.Minute),
+		Stop:  now.Add(time.Hour),
+	})
+
+	collect(t, topics, "t", "silenced", alert.Critical, nil)
+	collect(t, topics, "t", "other", alert.Critical, nil)
+
+	// The state of silenced events is still updated.
+	if state, ok := topics.EventState("t", "silenced"); !ok || state.Level != alert.Critical {
+		t.Errorf("unexpected state of silenced event: %v %v", state, ok)
+	}
+	closeAndCheck(t, topics, h, []string{"other:CRITICAL"})
+}
+
+func TestTopics_Silence_Expired(t *testing.T) {
+	topics, h := newTopics("t")
+	now := time.Now()
+	topics.SetSilence(alert.Silence{
+		ID:    "expired",
+		Start: now.Add(-2 * time.Hour),
+		Stop:  now.Add(-time.Hour),
+	})
+	topics.SetSilence(alert.Silence{
+		ID:    "future",
+		Start: now.Add(time.Hour),
+		Stop:  now.Add(2 * time.Hour),
+	})
+
+	collect(t, topics, "t", "e", alert.Critical, nil)
+
+	expired := topics.DeleteExpiredSilences(now)
+	if len(expired) != 1 || expired[0] != "expired" {
+		t.Errorf("unexpected expired silences: %v", expired)
+	}
+	if _, ok := topics.Silence("expired"); ok {
+		t.Error("expected the expired silence to be deleted")
+	}
+	if _, ok := topics.Silence("future"); !ok {
+		t.Error("expected the future silence to be kept")
+	}
+	closeAndCheck(t, topics, h, []string{"e:CRITICAL"})
+}
+
+func TestTopics_Acknowledge(t *testing.T) {
+	topics, h := newTopics("t")
+
+	collect(t, topics, "t", "e", alert.Critical, nil)
+	if _, ok := topics.Acknowledge("t", "e", alert.Acknowledgement{By: "me", Time: time.Now()}); !ok {
+		t.Fatal("expected the event to be acknowledged")
+	}
+	// Skipped while the level is unchanged.
+	collect(t, topics, "t", "e", alert.Critical, nil)
+	collect(t, topics, "t", "e", alert.Critical, nil)
+	// A level change clears the acknowledgement.
+	collect(t, topics, "t", "e", alert.Warning, nil)
+	collect(t, topics, "t", "e", alert.Warning, nil)
+
+	if state, _ := topics.EventState("t", "e"); state.Ack.Acked() {
+		t.Error("expected the acknowledgement to be cleared")
+	}
+	closeAndCheck(t, topics, h, []string{"e:CRITICAL", "e:WARNING", "e:WARNING"})
+}
+
+func TestTopics_Inhibition_Equal(t *testing.T) {
+	topics, h := newTopics("hosts")
+	topics.SetInhibition(alert.Inhibition{
+		ID:          "outage",
+		SourceTopic: "outage",
+		Level:       alert.Critical,
+		TargetTopic: "hosts",
+		Equal:       []string{"dc"},
+	})
+	west := map[string]string{"dc": "west"}
+	east := map[string]string{"dc": "east"}
+
+	collect(t, topics, "outage", "o", alert.Critical, west)
+	// Only events of the same datacenter as the firing source event are inhibited.
+	collect(t, topics, "hosts", "h1", alert.Critical, west)
+	collect(t, topics, "hosts", "h2", alert.Critical, east)
+	if state, _ := topics.EventState("hosts", "h1"); state.Inhibited == "" {
+		t.Error("expected the event to be inhibited")
+	}
+	// Once the source event recovers the target event is handled.
+	collect(t, topics, "outage", "o", alert.OK, west)
+	collect(t, topics, "hosts", "h1", alert.Critical, west)
+
+	closeAndCheck(t, topics, h, []string{"h2:CRITICAL", "h1:CRITICAL"})
+}
+
+// routedHandler records the events the routing of the topic sends to the named handler.
+type routedHandler struct {
+	recordingHandler
+	name string
+}
+
+func (h *routedHandler) Handle(event alert.Event) {
+	if event.RoutedTo(h.name) {
+		h.recordingHandler.Handle(event)
+	}
+}
+
+func TestTopics_Routing_Recovery(t *testing.T) {
+	topics := alert.NewTopics(log.New(os.Stderr, "[alert] ", log.LstdFlags))
+	pager := &routedHandler{name: "pager"}
+	chat := &routedHandler{name: "chat"}
+	topics.RegisterHandler("t", pager)
+	topics.RegisterHandler("t", chat)
+	topics.SetRouting(alert.Routing{
+		Topic: "t",
+		Routes: []alert.Route{{
+			Levels:   []alert.Level{alert.Critical},
+			Handlers: []string{"pager"},
+		}},
+		Default: []string{"chat"},
+	})
+
+	// The recovery goes to the handler of the critical event, not to the default route.
+	collect(t, topics, "t", "c", alert.Critical, nil)
+	collect(t, topics, "t", "c", alert.OK, nil)
+	// The recovery goes to every handler the event was routed to while it was not OK,
+	// once recovered OK events are routed again.
+	collect(t, topics, "t", "w", alert.Warning, nil)
+	collect(t, topics, "t", "w", alert.Critical, nil)
+	collect(t, topics, "t", "w", alert.OK, nil)
+	collect(t, topics, "t", "w", alert.OK, nil)
+	topics.Close()
+
+	for _, tc := range []struct {
+		h   *routedHandler
+		exp []string
+	}{
+		{h: pager, exp: []string{"c:CRITICAL", "c:OK", "w:CRITICAL", "w:OK"}},
+		{h: chat, exp: []string{"w:WARNING", "w:OK", "w:OK"}},
+	} {
+		got := tc.h.handled()
+		if len(got) != len(tc.exp) {
+			t.Errorf("unexpected events handled by %s:\ngot %v\nexp %v", tc.h.name, got, tc.exp)
+			continue
+		}
+		for i := range tc.exp {
+			if got[i] != tc.exp[i] {
+				t.Errorf("unexpected events handled by %s:\ngot %v\nexp %v", tc.h.name, got, tc.exp)
+				break
+			}
+		}
+	}
+}
@@ -15,6 +15,10 @@ type Event struct {
 	Data          EventData
 	NoExternal    bool
 	previousState EventState
+	// routing is whether the topic of the event has a routing,
+	// routes are the handlers the routing sends the event to.
+	routing bool
+	routes  []string
 }
 
 func (e Event) AlertData() Data {
@@ -33,6 +37,20 @@ func (e Event) PreviousState() EventState {
 	return e.previousState
 }
 
+// RoutedTo reports whether the routing of the topic of the event sends the event to the handler.
+// Events of topics without a routing are sent to all handlers.
+func (e Event) RoutedTo(handler string) bool {
+	if !e.routing {
+		return true
+	}
+	for _, h := range e.routes {
+		if h == handler {
+			return true
+		}
+	}
+	return false
+}
+
 func (e Event) TemplateData() TemplateData {
 	return TemplateData{
 		ID:       e.State.ID,
@@ -53,6 +71,14 @@ type Handler interface {
 	Handle(event Event)
 }
 
+// FallibleHandler is a Handler that can report when it failed to deliver an event,
+// so that the delivery can be retried.
+type FallibleHandler interface {
+	Handler
+	// TryHandle is like Handle but returns an error instead of logging it.
+	TryHandle(event Event) error
+}
+
 type EventState struct {
 	ID       string
 	Message  string
@@ -60,6 +86,25 @@ type EventState struct {
 	Time     time.Time
 	Duration time.Duration
 	Level    Level
+	// Ack is the acknowledgement of the event, it is cleared when the level changes.
+	Ack Acknowledgement
+	// Inhibited is the reason the event was inhibited when it was last collected, empty if it was not.
+	Inhibited string
+	// Maintenance is the ID of the maintenance window that suppressed external handling of the event
+	// when it was last collected, empty if there was none.
+	Maintenance string
+}
+
+// Acknowledgement records who acknowledged an event, when and why.
+type Acknowledgement struct {
+	By      string
+	Time    time.Time
+	Comment string
+}
+
+// Acked reports whether the event has been acknowledged.
+func (a Acknowledgement) Acked() bool {
+	return !a.Time.IsZero()
 }
 
 type EventData struct {
@@ -0,0 +1,383 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/sketch"
+	"github.com/pkg/errors"
+)
+
+// madScale scales the median absolute deviation to be a consistent estimator of the standard deviation
+// for normally distributed data.
+const madScale = 1.4826
+
+// baselineCompression is the compression of the digests used to compute medians.
+// Each bucket of each season keeps its own digest so the compression is kept small.
+const baselineCompression = 50
+
+type BaselineNode struct {
+	node
+	b *pipeline.BaselineNode
+
+	// mu protects the state of all groups, since snapshots are taken concurrently.
+	mu sync.Mutex
+	// groups contains the learned state of each group.
+	groups map[models.GroupID]*baselineGroup
+	// restored contains the learned state of groups from a snapshot, which have not yet received data.
+	restored map[models.GroupID]baselineState
+	// expiry drops the restored states of groups that do not receive data again.
+	expiry restoredGroups
+
+	// location whose wall clock time aligns the seasons.
+	location *time.Location
+}
+
+// Create a new baseline node.
+func newBaselineNode(et *ExecutingTask, n *pipeline.BaselineNode, l *log.Logger) (*BaselineNode, error) {
+	location, err := time.LoadLocation(n.Location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid location %q", n.Location)
+	}
+	bn := &BaselineNode{
+		node:     node{Node: n, et: et, logger: l},
+		b:        n,
+		groups:   make(map[models.GroupID]*baselineGroup),
+		location: location,
+	}
+	bn.node.runF = bn.runBaseline
+	return bn, nil
+}
+
+func (n *BaselineNode) runBaseline(snapshot []byte) error {
+	if err := n.restore(snapshot); err != nil {
+		return err
+	}
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+// baselineState is the persisted state of a single group.
+type baselineState struct {
+	// Buckets maps the bucket index to the statistics of each season of that bucket.
+	Buckets map[int64][]baselineSeasonState `json:"buckets"`
+}
+
+type baselineSeasonState struct {
+	Season int64   `json:"season"`
+	Count  float64 `json:"count"`
+	Mean   float64 `json:"mean"`
+	M2     float64 `json:"m2"`
+	Digest string  `json:"digest,omitempty"`
+}
+
+func (n *BaselineNode) snapshot() ([]byte, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.expiry.snapshotted() {
+		n.restored = nil
+	}
+	states := make(map[models.GroupID]baselineState, len(n.groups)+len(n.restored))
+	for id, s := range n.restored {
+		states[id] = s
+	}
+	for id, g := range n.groups {
+		s, err := g.state()
+		if err != nil {
+			return nil, err
+		}
+		states[id] = s
+	}
+	return json.Marshal(states)
+}
+
+func (n *BaselineNode) restore(snapshot []byte) error {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := json.Unmarshal(snapshot, &n.restored); err != nil {
+		return errors.Wrap(err, "failed to restore baseline snapshot")
+	}
+	return nil
+}
+
+func (n *BaselineNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g, err := n.newGroup(group.ID)
+	if err != nil {
+		return nil, err
+	}
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, g),
+	), nil
+}
+
+func (n *BaselineNode) newGroup(id models.GroupID) (*baselineGroup, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.expiry.received()
+	g := &baselineGroup{
+		n:       n,
+		id:      id,
+		buckets: make(map[int64][]*baselineSeason),
+	}
+	if s, ok := n.restored[id]; ok {
+		if err := g.setState(s); err != nil {
+			return nil, errors.Wrapf(err, "failed to restore baseline of group %s", id)
+		}
+		delete(n.restored, id)
+	}
+	n.groups[id] = g
+	return g, nil
+}
+
+// baselineSeason contains the statistics of a single bucket within a single season.
+type baselineSeason struct {
+	season int64
+	// Welford's online mean and variance
+	count float64
+	mean  float64
+	m2    float64
+	// digest is only used to compute medians
+	digest *sketch.TDigest
+}
+
+func (s *baselineSeason) add(value float64) {
+	s.count++
+	delta := value - s.mean
+	s.mean += delta / s.count
+	s.m2 += delta * (value - s.mean)
+	if s.digest != nil {
+		s.digest.Add(value)
+	}
+}
+
+type baselineGroup struct {
+	n  *BaselineNode
+	id models.GroupID
+
+	// buckets maps the bucket index to its seasons ordered by season.
+	buckets map[int64][]*baselineSeason
+}
+
+func (g *baselineGroup) state() (baselineState, error) {
+	s := baselineState{
+		Buckets: make(map[int64][]baselineSeasonState, len(g.buckets)),
+	}
+	for idx, seasons := range g.buckets {
+		states := make([]baselineSeasonState, len(seasons))
+		for i, season := range seasons {
+			states[i] = baselineSeasonState{
+				Season: season.season,
+				Count:  season.count,
+				Mean:   season.mean,
+				M2:     season.m2,
+			}
+			if season.digest != nil {
+				d, err := sketch.EncodeTDigest(season.digest)
+				if err != nil {
+					return baselineState{}, err
+				}
+				states[i].Digest = d
+			}
+		}
+		s.Buckets[idx] = states
+	}
+	return s, nil
+}
+
+func (g *baselineGroup) setState(s baselineState) error {
+	for idx, states := range s.Buckets {
+		seasons := make([]*baselineSeason, len(states))
+		for i, state := range states {
+			seasons[i] = &baselineSeason{
+				season: state.Season,
+				count:  state.Count,
+				mean:   state.Mean,
+				m2:     state.M2,
+			}
+			if g.n.b.Method == pipeline.BaselineMedian {
+				if state.Digest == "" {
+					// The method changed, start with an empty digest.
+					seasons[i].digest = sketch.NewTDigest(baselineCompression)
+					continue
+				}
+				d, err := sketch.DecodeTDigest(state.Digest)
+				if err != nil {
+					return err
+				}
+				seasons[i].digest = d
+			}
+		}
+		g.buckets[idx] = seasons
+	}
+	return nil
+}
+
+func (g *baselineGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	return begin, nil
+}
+
+func (g *baselineGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if g.compare(bp) {
+		return bp, nil
+	}
+	return nil, nil
+}
+
+func (g *baselineGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *baselineGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if g.compare(p) {
+		return p, nil
+	}
+	return nil, nil
+}
+
+// compare sets the baseline fields on p and learns its value.
+// Returns whether p should be emitted.
+func (g *baselineGroup) compare(p edge.FieldsTagsTimeSetter) bool {
+	value, ok := numToFloat(p.Fields()[g.n.b.Field])
+	if !ok {
+		g.n.incrementErrorCount()
+		g.n.logger.Printf("E! field %q is missing or not numeric", g.n.b.Field)
+		return false
+	}
+	season, idx := g.n.bucketOf(p.Time())
+
+	g.n.mu.Lock()
+	center, spread, count := g.stats(idx, season)
+	g.learn(idx, season, value)
+	g.n.mu.Unlock()
+
+	if count < float64(g.n.b.MinCount) {
+		return false
+	}
+
+	spread = math.Max(spread, g.n.b.MinSpread)
+	var zscore float64
+	if spread > 0 {
+		zscore = (value - center) / spread
+	}
+
+	fields := p.Fields().Copy()
+	fields[g.n.b.BaselineAs] = center
+	fields[g.n.b.ZScoreAs] = zscore
+	fields[g.n.b.UpperAs] = center + g.n.b.Sigma*spread
+	fields[g.n.b.LowerAs] = center - g.n.b.Sigma*spread
+	p.SetFields(fields)
+	return true
+}
+
+// bucketOf returns the season number and bucket index of the wall clock time of t.
+func (n *BaselineNode) bucketOf(t time.Time) (season, idx int64) {
+	_, zoneOffset := t.In(n.location).Zone()
+	ns := t.UnixNano() + int64(zoneOffset)*int64(time.Second)
+	seasonNs := int64(n.b.Season)
+	season = ns / seasonNs
+	offset := ns % seasonNs
+	if offset < 0 {
+		season--
+		offset += seasonNs
+	}
+	return season, offset / int64(n.b.Bucket)
+}
+
+// stats returns the baseline center, spread and number of points of the bucket
+// from the history seasons before the given season.
+func (g *baselineGroup) stats(idx, season int64) (center, spread, count float64) {
+	var mean, m2 float64
+	var digest *sketch.TDigest
+	for _, s := range g.buckets[idx] {
+		if s.season >= season || s.season < season-g.n.b.History {
+			continue
+		}
+		// Combine the variances using the parallel algorithm.
+		total := count + s.count
+		delta := s.mean - mean
+		mean += delta * s.count / total
+		m2 += s.m2 + delta*delta*count*s.count/total
+		count = total
+		if s.digest != nil {
+			if digest == nil {
+				digest = sketch.NewTDigest(baselineCompression)
+			}
+			digest.Merge(s.digest)
+		}
+	}
+	if count == 0 {
+		return 0, 0, 0
+	}
+	if g.n.b.Method == pipeline.BaselineMedian && digest != nil {
+		median := digest.Quantile(0.5)
+		// Estimate the median absolute deviation from the centroids of the digest.
+		deviations := sketch.NewTDigest(baselineCompression)
+		for _, c := range digest.Centroids() {
+			deviations.AddWeighted(math.Abs(c.Mean-median), c.Weight)
+		}
+		return median, madScale * deviations.Quantile(0.5), count
+	}
+	if count > 1 {
+		spread = math.Sqrt(m2 / (count - 1))
+	}
+	return mean, spread, count
+}
+
+// learn adds the value to the bucket of the season and expires seasons outside of the history.
+func (g *baselineGroup) learn(idx, season int64, value float64) {
+	seasons := g.buckets[idx]
+	// Points mostly arrive in order, so search from the newest season.
+	i := len(seasons)
+	for i > 0 && seasons[i-1].season > season {
+		i--
+	}
+	var s *baselineSeason
+	if i > 0 && seasons[i-1].season == season {
+		s = seasons[i-1]
+	} else {
+		s = &baselineSeason{season: season}
+		if g.n.b.Method == pipeline.BaselineMedian {
+			s.digest = sketch.NewTDigest(baselineCompression)
+		}
+		seasons = append(seasons, nil)
+		copy(seasons[i+1:], seasons[i:])
+		seasons[i] = s
+	}
+	s.add(value)
+
+	// Keep the history seasons and the current season.
+	newest := seasons[len(seasons)-1].season
+	j := 0
+	for j < len(seasons) && seasons[j].season < newest-g.n.b.History {
+		j++
+	}
+	g.buckets[idx] = seasons[j:]
+}
+
+func (g *baselineGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *baselineGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.n.mu.Lock()
+	delete(g.n.groups, g.id)
+	g.n.mu.Unlock()
+	return d, nil
+}
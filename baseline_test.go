@@ -0,0 +1,43 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestBaselineNode_BucketOf_Location(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	n := &BaselineNode{
+		b: &pipeline.BaselineNode{
+			Season: 24 * time.Hour,
+			Bucket: time.Hour,
+		},
+		location: newYork,
+	}
+	testCases := []struct {
+		t   time.Time
+		idx int64
+	}{
+		{t: time.Date(2017, 1, 10, 0, 0, 0, 0, newYork), idx: 0},
+		{t: time.Date(2017, 1, 10, 23, 30, 0, 0, newYork), idx: 23},
+		// Daylight saving time
+		{t: time.Date(2017, 7, 10, 0, 0, 0, 0, newYork), idx: 0},
+		{t: time.Date(2017, 7, 10, 9, 15, 0, 0, newYork), idx: 9},
+	}
+	for _, tc := range testCases {
+		if _, idx := n.bucketOf(tc.t); idx != tc.idx {
+			t.Errorf("unexpected bucket of %v: got %d exp %d", tc.t, idx, tc.idx)
+		}
+	}
+	// Local midnight starts a new season.
+	s1, _ := n.bucketOf(time.Date(2017, 1, 10, 23, 59, 0, 0, newYork))
+	s2, _ := n.bucketOf(time.Date(2017, 1, 11, 0, 0, 0, 0, newYork))
+	if s2 != s1+1 {
+		t.Errorf("expected local midnight to start a new season: got %d and %d", s1, s2)
+	}
+}
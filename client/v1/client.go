@@ -47,6 +47,16 @@ const (
 	topicsPath        = alertsPath + "/topics"
 	topicEventsPath   = "events"
 	topicHandlersPath = "handlers"
+	escalationsPath   = "escalations"
+	eventAckPath      = "ack"
+	eventHistoryPath  = "history"
+	topicRoutingPath  = "routing"
+	outboxPath        = "outbox"
+	outboxReplayPath  = "replay"
+	silencesPath      = alertsPath + "/silences"
+	inhibitionsPath   = alertsPath + "/inhibitions"
+	maintenancePath   = alertsPath + "/maintenance-windows"
+	onCallPath        = alertsPath + "/oncall-schedules"
 	storagePath       = basePath + "/storage"
 	storesPath        = storagePath + "/stores"
 	backupPath        = storagePath + "/backup"
@@ -714,12 +724,42 @@ func (c *Client) TopicEventLink(topic, event string) Link {
 	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicEventsPath, event)}
 }
 
+func (c *Client) TopicEscalationsLink(topic string) Link {
+	return Link{Relation: Self, Href: path.Join(topicsPath, topic, escalationsPath)}
+}
+
+func (c *Client) TopicEventHistoryLink(topic, event string) Link {
+	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicEventsPath, event, eventHistoryPath)}
+}
+
+func (c *Client) TopicRoutingLink(topic string) Link {
+	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicRoutingPath)}
+}
+
 func (c *Client) TopicHandlersLink(topic string) Link {
 	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicHandlersPath)}
 }
 func (c *Client) TopicHandlerLink(topic, id string) Link {
 	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicHandlersPath, id)}
 }
+func (c *Client) HandlerOutboxLink(topic, handler string) Link {
+	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicHandlersPath, handler, outboxPath)}
+}
+func (c *Client) OutboxEntryLink(topic, handler, id string) Link {
+	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicHandlersPath, handler, outboxPath, id)}
+}
+func (c *Client) SilenceLink(id string) Link {
+	return Link{Relation: Self, Href: path.Join(silencesPath, id)}
+}
+func (c *Client) InhibitionLink(id string) Link {
+	return Link{Relation: Self, Href: path.Join(inhibitionsPath, id)}
+}
+func (c *Client) MaintenanceWindowLink(id string) Link {
+	return Link{Relation: Self, Href: path.Join(maintenancePath, id)}
+}
+func (c *Client) OnCallScheduleLink(id string) Link {
+	return Link{Relation: Self, Href: path.Join(onCallPath, id)}
+}
 func (c *Client) StorageLink(name string) Link {
 	return Link{Relation: Self, Href: path.Join(storesPath, name)}
 }
@@ -1670,6 +1710,12 @@ type ServiceTestResult struct {
 	Message string `json:"message"`
 }
 
+type ServicePreviewResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Preview string `json:"preview"`
+}
+
 type ListServiceTestsOptions struct {
 	Pattern string
 }
@@ -1759,6 +1805,35 @@ func (c *Client) DoServiceTest(link Link, sto ServiceTestOptions) (ServiceTestRe
 	return r, nil
 }
 
+// DoServicePreview renders what a test for a service would send, without sending it.
+// Only some services support previews.
+func (c *Client) DoServicePreview(link Link, sto ServiceTestOptions) (ServicePreviewResult, error) {
+	if link.Href == "" {
+		return ServicePreviewResult{}, fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href + "/preview"
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(sto)
+	if err != nil {
+		return ServicePreviewResult{}, err
+	}
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return ServicePreviewResult{}, err
+	}
+
+	r := ServicePreviewResult{}
+	_, err = c.Do(req, &r, http.StatusOK)
+	if err != nil {
+		return ServicePreviewResult{}, err
+	}
+	return r, nil
+}
+
 type ListTopicsOptions struct {
 	Pattern  string
 	MinLevel string
@@ -1860,11 +1935,22 @@ type TopicEvent struct {
 }
 
 type EventState struct {
-	Message  string    `json:"message"`
-	Details  string    `json:"details"`
-	Time     time.Time `json:"time"`
-	Duration Duration  `json:"duration"`
-	Level    string    `json:"level"`
+	Message  string           `json:"message"`
+	Details  string           `json:"details"`
+	Time     time.Time        `json:"time"`
+	Duration Duration         `json:"duration"`
+	Level    string           `json:"level"`
+	Ack      *Acknowledgement `json:"ack,omitempty"`
+	// Inhibited is the reason the event was inhibited, empty if it was not.
+	Inhibited string `json:"inhibited,omitempty"`
+	// Maintenance is the ID of the maintenance window that suppressed external handling of the event, empty if there was none.
+	Maintenance string `json:"maintenance,omitempty"`
+}
+
+type Acknowledgement struct {
+	By      string    `json:"by"`
+	Time    time.Time `json:"time"`
+	Comment string    `json:"comment"`
 }
 
 // TopicEvent retrieves details for a single event of a topic
@@ -1887,6 +1973,87 @@ func (c *Client) TopicEvent(link Link) (TopicEvent, error) {
 	return e, err
 }
 
+type TopicEventHistory struct {
+	Link  Link   `json:"link"`
+	Topic string `json:"topic"`
+	ID    string `json:"id"`
+	// History contains the states of the event when its level changed, in time order.
+	History []EventState `json:"history"`
+}
+
+type TopicEventHistoryOptions struct {
+	// Start and Stop bound the time range of the history, zero times leave the range open.
+	Start time.Time
+	Stop  time.Time
+}
+
+func (o *TopicEventHistoryOptions) Values() *url.Values {
+	v := &url.Values{}
+	if !o.Start.IsZero() {
+		v.Set("start", o.Start.Format(time.RFC3339Nano))
+	}
+	if !o.Stop.IsZero() {
+		v.Set("stop", o.Stop.Format(time.RFC3339Nano))
+	}
+	return v
+}
+
+// TopicEventHistory returns the history of state changes of an event.
+func (c *Client) TopicEventHistory(link Link, opt *TopicEventHistoryOptions) (TopicEventHistory, error) {
+	h := TopicEventHistory{}
+	if link.Href == "" {
+		return h, fmt.Errorf("invalid link %v", link)
+	}
+
+	if opt == nil {
+		opt = new(TopicEventHistoryOptions)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+	u.RawQuery = opt.Values().Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return h, err
+	}
+
+	_, err = c.Do(req, &h, http.StatusOK)
+	return h, err
+}
+
+type AckEventOptions struct {
+	By      string `json:"by"`
+	Comment string `json:"comment"`
+}
+
+// AckTopicEvent acknowledges the current state of an event.
+// Handlers skip the event until its level changes.
+func (c *Client) AckTopicEvent(link Link, opt AckEventOptions) (TopicEvent, error) {
+	e := TopicEvent{}
+	if link.Href == "" {
+		return e, fmt.Errorf("invalid link %v", link)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return e, err
+	}
+
+	u := *c.url
+	u.Path = path.Join(link.Href, eventAckPath)
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return e, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &e, http.StatusOK)
+	return e, err
+}
+
 type ListTopicEventsOptions struct {
 	MinLevel string
 }
@@ -1928,6 +2095,249 @@ func (c *Client) ListTopicEvents(link Link, opt *ListTopicEventsOptions) (TopicE
 	return t, err
 }
 
+type TopicEscalations struct {
+	Link        Link              `json:"link"`
+	Topic       string            `json:"topic"`
+	Escalations []TopicEscalation `json:"escalations"`
+}
+
+// TopicEscalation is a pending escalation of an event by an escalate handler.
+type TopicEscalation struct {
+	Handler string     `json:"handler"`
+	EventID string     `json:"event-id"`
+	State   EventState `json:"state"`
+	// Step is the index of the next step of the escalation.
+	Step int `json:"step"`
+	// NextTopic is the topic of the next step of the escalation.
+	// It is empty once all steps have been published.
+	NextTopic string    `json:"next-topic"`
+	Start     time.Time `json:"start"`
+	Next      time.Time `json:"next"`
+	// Published is the list of topics the event has been escalated to.
+	Published []string `json:"published"`
+}
+
+// ListTopicEscalations returns the pending escalations of events within a topic.
+func (c *Client) ListTopicEscalations(link Link) (TopicEscalations, error) {
+	t := TopicEscalations{}
+	if link.Href == "" {
+		return t, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return t, err
+	}
+
+	_, err = c.Do(req, &t, http.StatusOK)
+	return t, err
+}
+
+// HandlerOutbox holds the deliveries of a handler that failed and are being retried,
+// and the dead letters that could not be delivered within the max age.
+// Only topic handlers have an outbox, handlers defined in TICKscript alert nodes do not.
+type HandlerOutbox struct {
+	Link    Link   `json:"link"`
+	Topic   string `json:"topic"`
+	Handler string `json:"handler"`
+	// QueueDepth is the number of deliveries being retried.
+	QueueDepth int `json:"queue-depth"`
+	// DeadLetters is the number of deliveries that are no longer retried.
+	DeadLetters int `json:"dead-letters"`
+	// Entries in the order they were queued.
+	Entries []OutboxEntry `json:"entries"`
+}
+
+// OutboxEntry is an event whose delivery by a handler failed.
+type OutboxEntry struct {
+	Link      Link       `json:"link"`
+	ID        string     `json:"id"`
+	EventID   string     `json:"event-id"`
+	State     EventState `json:"state"`
+	Queued    time.Time  `json:"queued"`
+	Attempts  int        `json:"attempts"`
+	Next      time.Time  `json:"next"`
+	LastError string     `json:"last-error"`
+	Dead      bool       `json:"dead"`
+}
+
+// HandlerOutbox returns the outbox of a handler.
+func (c *Client) HandlerOutbox(link Link) (HandlerOutbox, error) {
+	o := HandlerOutbox{}
+	if link.Href == "" {
+		return o, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return o, err
+	}
+
+	_, err = c.Do(req, &o, http.StatusOK)
+	return o, err
+}
+
+// ReplayDeadLetter queues a dead letter to be delivered again.
+func (c *Client) ReplayDeadLetter(link Link) (OutboxEntry, error) {
+	e := OutboxEntry{}
+	if link.Href == "" {
+		return e, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = path.Join(link.Href, outboxReplayPath)
+
+	req, err := http.NewRequest("POST", u.String(), nil)
+	if err != nil {
+		return e, err
+	}
+
+	_, err = c.Do(req, &e, http.StatusOK)
+	return e, err
+}
+
+// ReplayDeadLetters queues all dead letters of the outbox of a handler to be delivered again.
+func (c *Client) ReplayDeadLetters(link Link) (HandlerOutbox, error) {
+	o := HandlerOutbox{}
+	if link.Href == "" {
+		return o, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = path.Join(link.Href, outboxReplayPath)
+
+	req, err := http.NewRequest("POST", u.String(), nil)
+	if err != nil {
+		return o, err
+	}
+
+	_, err = c.Do(req, &o, http.StatusOK)
+	return o, err
+}
+
+// DeleteDeadLetter discards a dead letter.
+func (c *Client) DeleteDeadLetter(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type TopicRouting struct {
+	Link  Link   `json:"link"`
+	Topic string `json:"topic"`
+	// Routes are evaluated in order.
+	Routes []TopicRoute `json:"routes"`
+	// Default are the handlers of events that match none of the routes.
+	Default  []string `json:"default"`
+	Timezone string   `json:"timezone"`
+}
+
+// TopicRoute matches events and sends them to handlers or to child routes.
+type TopicRoute struct {
+	// Levels the event must have one of, empty matches all levels.
+	Levels []string          `json:"levels,omitempty" yaml:"levels"`
+	Tags   map[string]string `json:"tags,omitempty" yaml:"tags"`
+	// TaskName is a glob pattern, empty matches all tasks.
+	TaskName string `json:"task-name,omitempty" yaml:"task-name"`
+	// Start and Stop bound the time of day of the event and are of the form 15:04.
+	// If Stop is before Start the range wraps past midnight.
+	Start string `json:"start,omitempty" yaml:"start"`
+	Stop  string `json:"stop,omitempty" yaml:"stop"`
+	// Routes are evaluated in order once the route matches.
+	Routes []TopicRoute `json:"routes,omitempty" yaml:"routes"`
+	// Handlers receive the matching events for which none of the child routes match.
+	Handlers []string `json:"handlers,omitempty" yaml:"handlers"`
+	// Continue evaluating the following routes once the route matches.
+	Continue bool `json:"continue,omitempty" yaml:"continue"`
+}
+
+type TopicRoutingOptions struct {
+	Routes  []TopicRoute `json:"routes" yaml:"routes"`
+	Default []string     `json:"default" yaml:"default"`
+	// Timezone in which the time of day of events is evaluated, defaults to UTC.
+	Timezone string `json:"timezone,omitempty" yaml:"timezone"`
+}
+
+// TopicRouting retrieves the routing of a topic.
+// Errors if the topic has no routing.
+func (c *Client) TopicRouting(link Link) (TopicRouting, error) {
+	r := TopicRouting{}
+	if link.Href == "" {
+		return r, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return r, err
+	}
+
+	_, err = c.Do(req, &r, http.StatusOK)
+	return r, err
+}
+
+// ReplaceTopicRouting creates or replaces the routing of a topic.
+func (c *Client) ReplaceTopicRouting(link Link, opt TopicRoutingOptions) (TopicRouting, error) {
+	r := TopicRouting{}
+	if link.Href == "" {
+		return r, fmt.Errorf("invalid link %v", link)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return r, err
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("PUT", u.String(), &buf)
+	if err != nil {
+		return r, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &r, http.StatusOK)
+	return r, err
+}
+
+// DeleteTopicRouting deletes the routing of a topic,
+// so that all of its handlers receive all events again.
+func (c *Client) DeleteTopicRouting(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
 type TopicHandlers struct {
 	Link     Link           `json:"link"`
 	Topic    string         `json:"topic"`
@@ -2097,6 +2507,468 @@ func (c *Client) ListTopicHandlers(link Link, opt *ListTopicHandlersOptions) (To
 	return handlers, nil
 }
 
+type Silences struct {
+	Link     Link      `json:"link"`
+	Silences []Silence `json:"silences"`
+}
+
+type Silence struct {
+	Link      Link              `json:"link"`
+	ID        string            `json:"id"`
+	Topic     string            `json:"topic"`
+	Event     string            `json:"event"`
+	Tags      map[string]string `json:"tags"`
+	Start     time.Time         `json:"start"`
+	Stop      time.Time         `json:"stop"`
+	CreatedBy string            `json:"created-by"`
+	Comment   string            `json:"comment"`
+}
+
+type SilenceOptions struct {
+	// ID of the silence, if empty a random ID is chosen.
+	ID string `json:"id,omitempty"`
+	// Topic and Event are glob patterns, empty matches everything.
+	Topic string            `json:"topic,omitempty"`
+	Event string            `json:"event,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	// Start defaults to now.
+	Start time.Time `json:"start,omitempty"`
+	// Either Stop or Duration must be set.
+	Stop      time.Time `json:"stop,omitempty"`
+	Duration  Duration  `json:"duration,omitempty"`
+	CreatedBy string    `json:"created-by,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+}
+
+// CreateSilence creates a new silence.
+// Errors if the silence already exists.
+func (c *Client) CreateSilence(opt SilenceOptions) (Silence, error) {
+	s := Silence{}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return s, err
+	}
+
+	u := *c.url
+	u.Path = silencesPath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return s, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &s, http.StatusOK)
+	return s, err
+}
+
+// Silence retrieves a silence.
+// Errors if no silence exists.
+func (c *Client) Silence(link Link) (Silence, error) {
+	s := Silence{}
+	if link.Href == "" {
+		return s, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return s, err
+	}
+
+	_, err = c.Do(req, &s, http.StatusOK)
+	return s, err
+}
+
+// ListSilences returns all silences.
+func (c *Client) ListSilences() (Silences, error) {
+	silences := Silences{}
+
+	u := *c.url
+	u.Path = silencesPath
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return silences, err
+	}
+
+	_, err = c.Do(req, &silences, http.StatusOK)
+	return silences, err
+}
+
+// DeleteSilence deletes a silence.
+func (c *Client) DeleteSilence(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type Inhibitions struct {
+	Link        Link         `json:"link"`
+	Inhibitions []Inhibition `json:"inhibitions"`
+}
+
+type Inhibition struct {
+	Link        Link              `json:"link"`
+	ID          string            `json:"id"`
+	SourceTopic string            `json:"source-topic"`
+	Level       string            `json:"level"`
+	TargetTopic string            `json:"target-topic"`
+	Tags        map[string]string `json:"tags"`
+	Equal       []string          `json:"equal"`
+	Comment     string            `json:"comment"`
+}
+
+type InhibitionOptions struct {
+	// ID of the inhibition, if empty a random ID is chosen.
+	ID string `json:"id,omitempty"`
+	// SourceTopic inhibits events while it is at or above Level.
+	SourceTopic string `json:"source-topic"`
+	// Level defaults to CRITICAL.
+	Level string `json:"level,omitempty"`
+	// TargetTopic is a glob pattern, empty matches all topics.
+	TargetTopic string            `json:"target-topic,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	// Equal is a list of tag keys whose values must be the same on the target event and a firing event of the source topic.
+	Equal   []string `json:"equal,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+}
+
+// CreateInhibition creates a new inhibition.
+// Errors if the inhibition already exists.
+func (c *Client) CreateInhibition(opt InhibitionOptions) (Inhibition, error) {
+	i := Inhibition{}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return i, err
+	}
+
+	u := *c.url
+	u.Path = inhibitionsPath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return i, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &i, http.StatusOK)
+	return i, err
+}
+
+// Inhibition retrieves an inhibition.
+// Errors if no inhibition exists.
+func (c *Client) Inhibition(link Link) (Inhibition, error) {
+	i := Inhibition{}
+	if link.Href == "" {
+		return i, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return i, err
+	}
+
+	_, err = c.Do(req, &i, http.StatusOK)
+	return i, err
+}
+
+// ListInhibitions returns all inhibitions.
+func (c *Client) ListInhibitions() (Inhibitions, error) {
+	inhibitions := Inhibitions{}
+
+	u := *c.url
+	u.Path = inhibitionsPath
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return inhibitions, err
+	}
+
+	_, err = c.Do(req, &inhibitions, http.StatusOK)
+	return inhibitions, err
+}
+
+// DeleteInhibition deletes an inhibition.
+func (c *Client) DeleteInhibition(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type MaintenanceWindows struct {
+	Link               Link                `json:"link"`
+	MaintenanceWindows []MaintenanceWindow `json:"maintenance-windows"`
+}
+
+type MaintenanceWindow struct {
+	Link     Link              `json:"link"`
+	ID       string            `json:"id"`
+	Topic    string            `json:"topic"`
+	Event    string            `json:"event"`
+	Tags     map[string]string `json:"tags"`
+	Cron     string            `json:"cron"`
+	Duration Duration          `json:"duration"`
+	Timezone string            `json:"timezone"`
+	Start    time.Time         `json:"start"`
+	Stop     time.Time         `json:"stop"`
+	Comment  string            `json:"comment"`
+}
+
+type MaintenanceWindowOptions struct {
+	// ID of the maintenance window, if empty a random ID is chosen.
+	ID string `json:"id,omitempty"`
+	// Topic, Event and the tag values are glob patterns, empty matches everything.
+	Topic string            `json:"topic,omitempty"`
+	Event string            `json:"event,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	// Cron is a cron expression, each occurrence starts a window lasting Duration.
+	// If empty the window is active once from Start to Stop.
+	Cron     string   `json:"cron,omitempty"`
+	Duration Duration `json:"duration,omitempty"`
+	// Timezone in which the cron expression is evaluated, defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
+	// Start and Stop are optional for recurring windows.
+	Start   time.Time `json:"start,omitempty"`
+	Stop    time.Time `json:"stop,omitempty"`
+	Comment string    `json:"comment,omitempty"`
+}
+
+// CreateMaintenanceWindow creates a new maintenance window.
+// Errors if the maintenance window already exists.
+func (c *Client) CreateMaintenanceWindow(opt MaintenanceWindowOptions) (MaintenanceWindow, error) {
+	m := MaintenanceWindow{}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return m, err
+	}
+
+	u := *c.url
+	u.Path = maintenancePath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return m, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &m, http.StatusOK)
+	return m, err
+}
+
+// MaintenanceWindow retrieves a maintenance window.
+// Errors if no maintenance window exists.
+func (c *Client) MaintenanceWindow(link Link) (MaintenanceWindow, error) {
+	m := MaintenanceWindow{}
+	if link.Href == "" {
+		return m, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return m, err
+	}
+
+	_, err = c.Do(req, &m, http.StatusOK)
+	return m, err
+}
+
+// ListMaintenanceWindows returns all maintenance windows.
+func (c *Client) ListMaintenanceWindows() (MaintenanceWindows, error) {
+	windows := MaintenanceWindows{}
+
+	u := *c.url
+	u.Path = maintenancePath
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return windows, err
+	}
+
+	_, err = c.Do(req, &windows, http.StatusOK)
+	return windows, err
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window.
+func (c *Client) DeleteMaintenanceWindow(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type OnCallSchedules struct {
+	Link      Link             `json:"link"`
+	Schedules []OnCallSchedule `json:"schedules"`
+}
+
+type OnCallSchedule struct {
+	Link      Link             `json:"link"`
+	ID        string           `json:"id"`
+	Members   []OnCallMember   `json:"members"`
+	Rotations []OnCallRotation `json:"rotations"`
+	Overrides []OnCallOverride `json:"overrides"`
+	Timezone  string           `json:"timezone"`
+	// OnCall is the name of the member currently on call, empty if nobody is.
+	OnCall string `json:"oncall"`
+}
+
+type OnCallMember struct {
+	Name string `json:"name" yaml:"name"`
+	// Contacts are the addresses of the member keyed by handler kind, i.e. smtp, slack or pushover.
+	Contacts map[string]string `json:"contacts,omitempty" yaml:"contacts"`
+}
+
+type OnCallRotation struct {
+	// Members take turns in order, starting with the first one at the handoff time.
+	Members []string  `json:"members" yaml:"members"`
+	Handoff time.Time `json:"handoff" yaml:"handoff"`
+	// Shift is how long each member is on call.
+	Shift Duration `json:"shift" yaml:"shift"`
+	// Start and Stop restrict the rotation to a time of day, of the form HH:MM.
+	// If Stop is before Start the range wraps past midnight.
+	Start string `json:"start,omitempty" yaml:"start"`
+	Stop  string `json:"stop,omitempty" yaml:"stop"`
+}
+
+type OnCallOverride struct {
+	Member string    `json:"member" yaml:"member"`
+	Start  time.Time `json:"start" yaml:"start"`
+	Stop   time.Time `json:"stop" yaml:"stop"`
+}
+
+type OnCallScheduleOptions struct {
+	Members []OnCallMember `json:"members" yaml:"members"`
+	// Rotations are evaluated in order, the first rotation covering the time of day decides who is on call.
+	Rotations []OnCallRotation `json:"rotations" yaml:"rotations"`
+	// Overrides take precedence over the rotations.
+	Overrides []OnCallOverride `json:"overrides,omitempty" yaml:"overrides"`
+	// Timezone of the handoffs and times of day, defaults to UTC.
+	Timezone string `json:"timezone,omitempty" yaml:"timezone"`
+}
+
+// OnCallSchedule retrieves an on-call schedule.
+// Errors if no on-call schedule exists.
+func (c *Client) OnCallSchedule(link Link) (OnCallSchedule, error) {
+	o := OnCallSchedule{}
+	if link.Href == "" {
+		return o, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return o, err
+	}
+
+	_, err = c.Do(req, &o, http.StatusOK)
+	return o, err
+}
+
+// ListOnCallSchedules returns all on-call schedules.
+func (c *Client) ListOnCallSchedules() (OnCallSchedules, error) {
+	schedules := OnCallSchedules{}
+
+	u := *c.url
+	u.Path = onCallPath
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return schedules, err
+	}
+
+	_, err = c.Do(req, &schedules, http.StatusOK)
+	return schedules, err
+}
+
+// ReplaceOnCallSchedule creates or replaces an on-call schedule.
+func (c *Client) ReplaceOnCallSchedule(link Link, opt OnCallScheduleOptions) (OnCallSchedule, error) {
+	o := OnCallSchedule{}
+	if link.Href == "" {
+		return o, fmt.Errorf("invalid link %v", link)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return o, err
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("PUT", u.String(), &buf)
+	if err != nil {
+		return o, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &o, http.StatusOK)
+	return o, err
+}
+
+// DeleteOnCallSchedule deletes an on-call schedule.
+func (c *Client) DeleteOnCallSchedule(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
 type StorageList struct {
 	Link    Link      `json:"link"`
 	Storage []Storage `json:"storage"`
@@ -2709,6 +2709,269 @@ func Test_ListTopicEvents(t *testing.T) {
 		t.Errorf("unexpected  topic events result:\ngot:\n%v\nexp:\n%v", topicEvents, exp)
 	}
 }
+func Test_ListTopicEscalations(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/escalations" &&
+			r.Method == "GET" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link": {"rel":"self","href":"/kapacitor/v1preview/alerts/topics/system/escalations"},
+	"topic": "system",
+	"escalations": [
+		{
+			"handler": "escalate",
+			"event-id": "mem",
+			"state": {
+				"level": "CRITICAL",
+				"message": "mem is CRITICAL",
+				"time": "2016-12-01T00:10:00Z",
+				"duration": "1m"
+			},
+			"step": 1,
+			"next-topic": "pagerduty",
+			"start": "2016-12-01T00:10:00Z",
+			"next": "2016-12-01T00:25:00Z",
+			"published": ["slack"]
+		}
+	]
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	topicEscalations, err := c.ListTopicEscalations(c.TopicEscalationsLink("system"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.TopicEscalations{
+		Link:  client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/system/escalations"},
+		Topic: "system",
+		Escalations: []client.TopicEscalation{
+			{
+				Handler: "escalate",
+				EventID: "mem",
+				State: client.EventState{
+					Message:  "mem is CRITICAL",
+					Time:     time.Date(2016, 12, 1, 0, 10, 0, 0, time.UTC),
+					Duration: client.Duration(1 * time.Minute),
+					Level:    "CRITICAL",
+				},
+				Step:      1,
+				NextTopic: "pagerduty",
+				Start:     time.Date(2016, 12, 1, 0, 10, 0, 0, time.UTC),
+				Next:      time.Date(2016, 12, 1, 0, 25, 0, 0, time.UTC),
+				Published: []string{"slack"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, topicEscalations) {
+		t.Errorf("unexpected topic escalations result:\ngot:\n%v\nexp:\n%v", topicEscalations, exp)
+	}
+}
+func Test_TopicRouting(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/routing" &&
+			r.Method == "GET" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link": {"rel":"self","href":"/kapacitor/v1preview/alerts/topics/system/routing"},
+	"topic": "system",
+	"routes": [
+		{
+			"levels": ["CRITICAL"],
+			"tags": {"dc": "east"},
+			"routes": [
+				{"start": "09:00", "stop": "17:00", "handlers": ["slack"]}
+			],
+			"handlers": ["pagerduty"],
+			"continue": true
+		}
+	],
+	"default": ["log"],
+	"timezone": "UTC"
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	routing, err := c.TopicRouting(c.TopicRoutingLink("system"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.TopicRouting{
+		Link:  client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/system/routing"},
+		Topic: "system",
+		Routes: []client.TopicRoute{{
+			Levels: []string{"CRITICAL"},
+			Tags:   map[string]string{"dc": "east"},
+			Routes: []client.TopicRoute{{
+				Start:    "09:00",
+				Stop:     "17:00",
+				Handlers: []string{"slack"},
+			}},
+			Handlers: []string{"pagerduty"},
+			Continue: true,
+		}},
+		Default:  []string{"log"},
+		Timezone: "UTC",
+	}
+	if !reflect.DeepEqual(exp, routing) {
+		t.Errorf("unexpected topic routing result:\ngot:\n%v\nexp:\n%v", routing, exp)
+	}
+}
+
+func Test_ReplaceTopicRouting(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		options := client.TopicRoutingOptions{}
+		json.NewDecoder(r.Body).Decode(&options)
+		expOptions := client.TopicRoutingOptions{
+			Routes: []client.TopicRoute{{
+				TaskName: "cpu_*",
+				Handlers: []string{"slack"},
+			}},
+			Default: []string{"log"},
+		}
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/routing" &&
+			r.Method == "PUT" &&
+			reflect.DeepEqual(expOptions, options) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link": {"rel":"self","href":"/kapacitor/v1preview/alerts/topics/system/routing"},
+	"topic": "system",
+	"routes": [{"task-name": "cpu_*", "handlers": ["slack"]}],
+	"default": ["log"],
+	"timezone": "UTC"
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	routing, err := c.ReplaceTopicRouting(c.TopicRoutingLink("system"), client.TopicRoutingOptions{
+		Routes: []client.TopicRoute{{
+			TaskName: "cpu_*",
+			Handlers: []string{"slack"},
+		}},
+		Default: []string{"log"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.TopicRouting{
+		Link:  client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/system/routing"},
+		Topic: "system",
+		Routes: []client.TopicRoute{{
+			TaskName: "cpu_*",
+			Handlers: []string{"slack"},
+		}},
+		Default:  []string{"log"},
+		Timezone: "UTC",
+	}
+	if !reflect.DeepEqual(exp, routing) {
+		t.Errorf("unexpected replace routing result:\ngot:\n%v\nexp:\n%v", routing, exp)
+	}
+}
+
+func Test_DeleteTopicRouting(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/routing" &&
+			r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = c.DeleteTopicRouting(c.TopicRoutingLink("system"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_TopicEventHistory(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/events/cpu/history?start=2016-12-01T00%3A00%3A00Z" &&
+			r.Method == "GET" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link": {"rel":"self","href":"/kapacitor/v1preview/alerts/topics/system/events/cpu/history"},
+	"topic": "system",
+	"id": "cpu",
+	"history": [
+		{
+			"level": "CRITICAL",
+			"message": "cpu is CRITICAL",
+			"time": "2016-12-01T00:00:00Z",
+			"duration": "0s"
+		},
+		{
+			"level": "OK",
+			"message": "cpu is OK",
+			"time": "2016-12-01T00:10:00Z",
+			"duration": "10m"
+		}
+	]
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	history, err := c.TopicEventHistory(c.TopicEventHistoryLink("system", "cpu"), &client.TopicEventHistoryOptions{
+		Start: time.Date(2016, 12, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.TopicEventHistory{
+		Link:  client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/system/events/cpu/history"},
+		Topic: "system",
+		ID:    "cpu",
+		History: []client.EventState{
+			{
+				Message: "cpu is CRITICAL",
+				Time:    time.Date(2016, 12, 1, 0, 0, 0, 0, time.UTC),
+				Level:   "CRITICAL",
+			},
+			{
+				Message:  "cpu is OK",
+				Time:     time.Date(2016, 12, 1, 0, 10, 0, 0, time.UTC),
+				Duration: client.Duration(10 * time.Minute),
+				Level:    "OK",
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, history) {
+		t.Errorf("unexpected topic event history result:\ngot:\n%v\nexp:\n%v", history, exp)
+	}
+}
 func Test_ListTopicHandlers(t *testing.T) {
 	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/handlers?pattern=" &&
@@ -3006,6 +3269,792 @@ func Test_DeleteTopicHandler(t *testing.T) {
 	}
 }
 
+func Test_AckTopicEvent(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		options := client.AckEventOptions{}
+		json.NewDecoder(r.Body).Decode(&options)
+		expOptions := client.AckEventOptions{
+			By:      "bob",
+			Comment: "investigating",
+		}
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/events/cpu/ack" &&
+			r.Method == "POST" &&
+			reflect.DeepEqual(expOptions, options) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/topics/system/events/cpu"},
+	"id": "cpu",
+	"state": {
+		"level": "WARNING",
+		"message": "cpu is WARNING",
+		"time": "2016-12-01T00:00:00Z",
+		"duration": "5m",
+		"ack": {
+			"by": "bob",
+			"time": "2016-12-01T00:01:00Z",
+			"comment": "investigating"
+		}
+	}
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	topicEvent, err := c.AckTopicEvent(c.TopicEventLink("system", "cpu"), client.AckEventOptions{
+		By:      "bob",
+		Comment: "investigating",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.TopicEvent{
+		ID:   "cpu",
+		Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/system/events/cpu"},
+		State: client.EventState{
+			Message:  "cpu is WARNING",
+			Time:     time.Date(2016, 12, 1, 0, 0, 0, 0, time.UTC),
+			Duration: client.Duration(5 * time.Minute),
+			Level:    "WARNING",
+			Ack: &client.Acknowledgement{
+				By:      "bob",
+				Time:    time.Date(2016, 12, 1, 0, 1, 0, 0, time.UTC),
+				Comment: "investigating",
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, topicEvent) {
+		t.Errorf("unexpected ack topic event result:\ngot:\n%v\nexp:\n%v", topicEvent, exp)
+	}
+}
+
+func Test_CreateSilence(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		options := client.SilenceOptions{}
+		json.NewDecoder(r.Body).Decode(&options)
+		expOptions := client.SilenceOptions{
+			Topic:     "cpu*",
+			Tags:      map[string]string{"host": "serverA"},
+			Duration:  client.Duration(2 * time.Hour),
+			CreatedBy: "bob",
+		}
+		if r.URL.String() == "/kapacitor/v1preview/alerts/silences" &&
+			r.Method == "POST" &&
+			reflect.DeepEqual(expOptions, options) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/silences/maintenance"},
+	"id": "maintenance",
+	"topic": "cpu*",
+	"event": "",
+	"tags": {"host": "serverA"},
+	"start": "2016-12-01T00:00:00Z",
+	"stop": "2016-12-01T02:00:00Z",
+	"created-by": "bob",
+	"comment": ""
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	silence, err := c.CreateSilence(client.SilenceOptions{
+		Topic:     "cpu*",
+		Tags:      map[string]string{"host": "serverA"},
+		Duration:  client.Duration(2 * time.Hour),
+		CreatedBy: "bob",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.Silence{
+		Link:      client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/silences/maintenance"},
+		ID:        "maintenance",
+		Topic:     "cpu*",
+		Tags:      map[string]string{"host": "serverA"},
+		Start:     time.Date(2016, 12, 1, 0, 0, 0, 0, time.UTC),
+		Stop:      time.Date(2016, 12, 1, 2, 0, 0, 0, time.UTC),
+		CreatedBy: "bob",
+	}
+	if !reflect.DeepEqual(exp, silence) {
+		t.Errorf("unexpected create silence result:\ngot:\n%v\nexp:\n%v", silence, exp)
+	}
+}
+
+func Test_ListSilences(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/silences" &&
+			r.Method == "GET" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/silences"},
+	"silences": [
+		{
+			"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/silences/maintenance"},
+			"id": "maintenance",
+			"topic": "",
+			"event": "*:serverA",
+			"tags": null,
+			"start": "2016-12-01T00:00:00Z",
+			"stop": "2016-12-01T02:00:00Z",
+			"created-by": "bob",
+			"comment": "upgrade"
+		}
+	]
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	silences, err := c.ListSilences()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.Silences{
+		Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/silences"},
+		Silences: []client.Silence{
+			{
+				Link:      client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/silences/maintenance"},
+				ID:        "maintenance",
+				Event:     "*:serverA",
+				Start:     time.Date(2016, 12, 1, 0, 0, 0, 0, time.UTC),
+				Stop:      time.Date(2016, 12, 1, 2, 0, 0, 0, time.UTC),
+				CreatedBy: "bob",
+				Comment:   "upgrade",
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, silences) {
+		t.Errorf("unexpected list silences result:\ngot:\n%v\nexp:\n%v", silences, exp)
+	}
+}
+
+func Test_DeleteSilence(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/silences/maintenance" &&
+			r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = c.DeleteSilence(c.SilenceLink("maintenance"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CreateInhibition(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		options := client.InhibitionOptions{}
+		json.NewDecoder(r.Body).Decode(&options)
+		expOptions := client.InhibitionOptions{
+			SourceTopic: "dc",
+			TargetTopic: "host_*",
+			Tags:        map[string]string{"dc": "us_west"},
+		}
+		if r.URL.String() == "/kapacitor/v1preview/alerts/inhibitions" &&
+			r.Method == "POST" &&
+			reflect.DeepEqual(expOptions, options) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/inhibitions/dc_outage"},
+	"id": "dc_outage",
+	"source-topic": "dc",
+	"level": "CRITICAL",
+	"target-topic": "host_*",
+	"tags": {"dc": "us_west"},
+	"comment": ""
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	inhibition, err := c.CreateInhibition(client.InhibitionOptions{
+		SourceTopic: "dc",
+		TargetTopic: "host_*",
+		Tags:        map[string]string{"dc": "us_west"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.Inhibition{
+		Link:        client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/inhibitions/dc_outage"},
+		ID:          "dc_outage",
+		SourceTopic: "dc",
+		Level:       "CRITICAL",
+		TargetTopic: "host_*",
+		Tags:        map[string]string{"dc": "us_west"},
+	}
+	if !reflect.DeepEqual(exp, inhibition) {
+		t.Errorf("unexpected create inhibition result:\ngot:\n%v\nexp:\n%v", inhibition, exp)
+	}
+}
+
+func Test_ListInhibitions(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/inhibitions" &&
+			r.Method == "GET" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/inhibitions"},
+	"inhibitions": [
+		{
+			"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/inhibitions/dc_outage"},
+			"id": "dc_outage",
+			"source-topic": "dc",
+			"level": "WARNING",
+			"target-topic": "",
+			"tags": null,
+			"comment": "outage"
+		}
+	]
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	inhibitions, err := c.ListInhibitions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.Inhibitions{
+		Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/inhibitions"},
+		Inhibitions: []client.Inhibition{{
+			Link:        client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/inhibitions/dc_outage"},
+			ID:          "dc_outage",
+			SourceTopic: "dc",
+			Level:       "WARNING",
+			Comment:     "outage",
+		}},
+	}
+	if !reflect.DeepEqual(exp, inhibitions) {
+		t.Errorf("unexpected list inhibitions result:\ngot:\n%v\nexp:\n%v", inhibitions, exp)
+	}
+}
+
+func Test_DeleteInhibition(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/inhibitions/dc_outage" &&
+			r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = c.DeleteInhibition(c.InhibitionLink("dc_outage"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CreateMaintenanceWindow(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		options := client.MaintenanceWindowOptions{}
+		json.NewDecoder(r.Body).Decode(&options)
+		expOptions := client.MaintenanceWindowOptions{
+			ID:       "db_backup",
+			Tags:     map[string]string{"host": "db-*"},
+			Cron:     "0 2 * * SUN",
+			Duration: client.Duration(2 * time.Hour),
+			Timezone: "America/New_York",
+		}
+		if r.URL.String() == "/kapacitor/v1preview/alerts/maintenance-windows" &&
+			r.Method == "POST" &&
+			reflect.DeepEqual(expOptions, options) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/maintenance-windows/db_backup"},
+	"id": "db_backup",
+	"topic": "",
+	"event": "",
+	"tags": {"host": "db-*"},
+	"cron": "0 2 * * SUN",
+	"duration": "2h0m0s",
+	"timezone": "America/New_York",
+	"start": "0001-01-01T00:00:00Z",
+	"stop": "0001-01-01T00:00:00Z",
+	"comment": ""
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	window, err := c.CreateMaintenanceWindow(client.MaintenanceWindowOptions{
+		ID:       "db_backup",
+		Tags:     map[string]string{"host": "db-*"},
+		Cron:     "0 2 * * SUN",
+		Duration: client.Duration(2 * time.Hour),
+		Timezone: "America/New_York",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.MaintenanceWindow{
+		Link:     client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/maintenance-windows/db_backup"},
+		ID:       "db_backup",
+		Tags:     map[string]string{"host": "db-*"},
+		Cron:     "0 2 * * SUN",
+		Duration: client.Duration(2 * time.Hour),
+		Timezone: "America/New_York",
+	}
+	if !reflect.DeepEqual(exp, window) {
+		t.Errorf("unexpected create maintenance window result:\ngot:\n%v\nexp:\n%v", window, exp)
+	}
+}
+
+func Test_ListMaintenanceWindows(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/maintenance-windows" &&
+			r.Method == "GET" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/maintenance-windows"},
+	"maintenance-windows": [
+		{
+			"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/maintenance-windows/upgrade"},
+			"id": "upgrade",
+			"topic": "cpu",
+			"event": "",
+			"tags": null,
+			"cron": "",
+			"duration": "0s",
+			"timezone": "UTC",
+			"start": "2017-06-01T02:00:00Z",
+			"stop": "2017-06-01T04:00:00Z",
+			"comment": "upgrade"
+		}
+	]
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	windows, err := c.ListMaintenanceWindows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.MaintenanceWindows{
+		Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/maintenance-windows"},
+		MaintenanceWindows: []client.MaintenanceWindow{{
+			Link:     client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/maintenance-windows/upgrade"},
+			ID:       "upgrade",
+			Topic:    "cpu",
+			Timezone: "UTC",
+			Start:    time.Date(2017, 6, 1, 2, 0, 0, 0, time.UTC),
+			Stop:     time.Date(2017, 6, 1, 4, 0, 0, 0, time.UTC),
+			Comment:  "upgrade",
+		}},
+	}
+	if !reflect.DeepEqual(exp, windows) {
+		t.Errorf("unexpected list maintenance windows result:\ngot:\n%v\nexp:\n%v", windows, exp)
+	}
+}
+
+func Test_DeleteMaintenanceWindow(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/maintenance-windows/upgrade" &&
+			r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = c.DeleteMaintenanceWindow(c.MaintenanceWindowLink("upgrade"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ListOnCallSchedules(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/oncall-schedules" &&
+			r.Method == "GET" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/oncall-schedules"},
+	"schedules": [
+		{
+			"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/oncall-schedules/dba"},
+			"id": "dba",
+			"members": [{"name": "alice", "contacts": {"smtp": "alice@example.com"}}],
+			"rotations": [{"members": ["alice"], "handoff": "2017-06-05T09:00:00Z", "shift": "168h0m0s"}],
+			"overrides": [],
+			"timezone": "UTC",
+			"oncall": "alice"
+		}
+	]
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	schedules, err := c.ListOnCallSchedules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.OnCallSchedules{
+		Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/oncall-schedules"},
+		Schedules: []client.OnCallSchedule{{
+			Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/oncall-schedules/dba"},
+			ID:   "dba",
+			Members: []client.OnCallMember{{
+				Name:     "alice",
+				Contacts: map[string]string{"smtp": "alice@example.com"},
+			}},
+			Rotations: []client.OnCallRotation{{
+				Members: []string{"alice"},
+				Handoff: time.Date(2017, 6, 5, 9, 0, 0, 0, time.UTC),
+				Shift:   client.Duration(168 * time.Hour),
+			}},
+			Overrides: []client.OnCallOverride{},
+			Timezone:  "UTC",
+			OnCall:    "alice",
+		}},
+	}
+	if !reflect.DeepEqual(exp, schedules) {
+		t.Errorf("unexpected list on-call schedules result:\ngot:\n%v\nexp:\n%v", schedules, exp)
+	}
+}
+
+func Test_ReplaceOnCallSchedule(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		options := client.OnCallScheduleOptions{}
+		json.NewDecoder(r.Body).Decode(&options)
+		expOptions := client.OnCallScheduleOptions{
+			Members: []client.OnCallMember{{Name: "alice"}, {Name: "bob"}},
+			Rotations: []client.OnCallRotation{{
+				Members: []string{"alice", "bob"},
+				Handoff: time.Date(2017, 6, 5, 9, 0, 0, 0, time.UTC),
+				Shift:   client.Duration(24 * time.Hour),
+				Start:   "09:00",
+				Stop:    "17:00",
+			}},
+		}
+		if r.URL.String() == "/kapacitor/v1preview/alerts/oncall-schedules/dba" &&
+			r.Method == "PUT" &&
+			reflect.DeepEqual(expOptions, options) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1preview/alerts/oncall-schedules/dba"},
+	"id": "dba",
+	"members": [{"name": "alice"}, {"name": "bob"}],
+	"rotations": [{"members": ["alice", "bob"], "handoff": "2017-06-05T09:00:00Z", "shift": "24h0m0s", "start": "09:00", "stop": "17:00"}],
+	"overrides": [],
+	"timezone": "UTC",
+	"oncall": ""
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	schedule, err := c.ReplaceOnCallSchedule(c.OnCallScheduleLink("dba"), client.OnCallScheduleOptions{
+		Members: []client.OnCallMember{{Name: "alice"}, {Name: "bob"}},
+		Rotations: []client.OnCallRotation{{
+			Members: []string{"alice", "bob"},
+			Handoff: time.Date(2017, 6, 5, 9, 0, 0, 0, time.UTC),
+			Shift:   client.Duration(24 * time.Hour),
+			Start:   "09:00",
+			Stop:    "17:00",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.OnCallSchedule{
+		Link:    client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/oncall-schedules/dba"},
+		ID:      "dba",
+		Members: []client.OnCallMember{{Name: "alice"}, {Name: "bob"}},
+		Rotations: []client.OnCallRotation{{
+			Members: []string{"alice", "bob"},
+			Handoff: time.Date(2017, 6, 5, 9, 0, 0, 0, time.UTC),
+			Shift:   client.Duration(24 * time.Hour),
+			Start:   "09:00",
+			Stop:    "17:00",
+		}},
+		Overrides: []client.OnCallOverride{},
+		Timezone:  "UTC",
+	}
+	if !reflect.DeepEqual(exp, schedule) {
+		t.Errorf("unexpected replace on-call schedule result:\ngot:\n%v\nexp:\n%v", schedule, exp)
+	}
+}
+
+func Test_DeleteOnCallSchedule(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/oncall-schedules/dba" &&
+			r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = c.DeleteOnCallSchedule(c.OnCallScheduleLink("dba"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_HandlerOutbox(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox" &&
+			r.Method == "GET" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link": {"rel":"self","href":"/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox"},
+	"topic": "system",
+	"handler": "slack",
+	"queue-depth": 0,
+	"dead-letters": 1,
+	"entries": [
+		{
+			"link": {"rel":"self","href":"/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox/01480550600000000000"},
+			"id": "01480550600000000000",
+			"event-id": "cpu",
+			"state": {
+				"level": "CRITICAL",
+				"message": "cpu is CRITICAL",
+				"time": "2016-12-01T00:00:00Z",
+				"duration": "0s"
+			},
+			"queued": "2016-12-01T00:03:20Z",
+			"attempts": 12,
+			"next": "2016-12-02T00:03:20Z",
+			"last-error": "connection refused",
+			"dead": true
+		}
+	]
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	outbox, err := c.HandlerOutbox(c.HandlerOutboxLink("system", "slack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.HandlerOutbox{
+		Link:        client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox"},
+		Topic:       "system",
+		Handler:     "slack",
+		QueueDepth:  0,
+		DeadLetters: 1,
+		Entries: []client.OutboxEntry{
+			{
+				Link:    client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox/01480550600000000000"},
+				ID:      "01480550600000000000",
+				EventID: "cpu",
+				State: client.EventState{
+					Message: "cpu is CRITICAL",
+					Time:    time.Date(2016, 12, 1, 0, 0, 0, 0, time.UTC),
+					Level:   "CRITICAL",
+				},
+				Queued:    time.Date(2016, 12, 1, 0, 3, 20, 0, time.UTC),
+				Attempts:  12,
+				Next:      time.Date(2016, 12, 2, 0, 3, 20, 0, time.UTC),
+				LastError: "connection refused",
+				Dead:      true,
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, outbox) {
+		t.Errorf("unexpected handler outbox result:\ngot:\n%v\nexp:\n%v", outbox, exp)
+	}
+}
+
+func Test_ReplayDeadLetter(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox/01480550600000000000/replay" &&
+			r.Method == "POST" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link": {"rel":"self","href":"/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox/01480550600000000000"},
+	"id": "01480550600000000000",
+	"event-id": "cpu",
+	"state": {
+		"level": "CRITICAL",
+		"message": "cpu is CRITICAL",
+		"time": "2016-12-01T00:00:00Z",
+		"duration": "0s"
+	},
+	"queued": "2016-12-03T00:00:00Z",
+	"attempts": 0,
+	"next": "2016-12-03T00:00:00Z",
+	"last-error": "",
+	"dead": false
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	entry, err := c.ReplayDeadLetter(c.OutboxEntryLink("system", "slack", "01480550600000000000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.OutboxEntry{
+		Link:    client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox/01480550600000000000"},
+		ID:      "01480550600000000000",
+		EventID: "cpu",
+		State: client.EventState{
+			Message: "cpu is CRITICAL",
+			Time:    time.Date(2016, 12, 1, 0, 0, 0, 0, time.UTC),
+			Level:   "CRITICAL",
+		},
+		Queued: time.Date(2016, 12, 3, 0, 0, 0, 0, time.UTC),
+		Next:   time.Date(2016, 12, 3, 0, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(exp, entry) {
+		t.Errorf("unexpected outbox entry result:\ngot:\n%v\nexp:\n%v", entry, exp)
+	}
+}
+
+func Test_ReplayDeadLetters(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox/replay" &&
+			r.Method == "POST" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link": {"rel":"self","href":"/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox"},
+	"topic": "system",
+	"handler": "slack",
+	"queue-depth": 0,
+	"dead-letters": 0,
+	"entries": []
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	outbox, err := c.ReplayDeadLetters(c.HandlerOutboxLink("system", "slack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.HandlerOutbox{
+		Link:    client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox"},
+		Topic:   "system",
+		Handler: "slack",
+		Entries: []client.OutboxEntry{},
+	}
+	if !reflect.DeepEqual(exp, outbox) {
+		t.Errorf("unexpected handler outbox result:\ngot:\n%v\nexp:\n%v", outbox, exp)
+	}
+}
+
+func Test_DeleteDeadLetter(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/kapacitor/v1preview/alerts/topics/system/handlers/slack/outbox/01480550600000000000" &&
+			r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = c.DeleteDeadLetter(c.OutboxEntryLink("system", "slack", "01480550600000000000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func Test_LogLevel(t *testing.T) {
 	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var opts client.LogLevelOptions
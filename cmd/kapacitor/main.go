@@ -50,18 +50,27 @@ Commands:
 	define                Create/update a task.
 	define-template       Create/update a template.
 	define-topic-handler  Create/update an alert handler for a topic.
+	define-topic-routing  Create/update the routing of events to the alert handlers of a topic.
+	define-oncall         Create/update an on-call schedule for alert handler recipients.
 	replay                Replay a recording to a task.
 	replay-live           Replay data against a task without recording it.
 	enable                Enable and start running a task with live data.
 	disable               Stop running a task.
 	reload                Reload a running task with an updated task definition.
 	push                  Publish a task definition to another Kapacitor instance. Not implemented yet.
-	delete                Delete tasks, templates, recordings, replays, topics or topic-handlers.
-	list                  List information about tasks, templates, recordings, replays, topics, topic-handlers or service-tests.
+	delete                Delete tasks, templates, recordings, replays, topics, topic-handlers, topic-routings, silences, inhibitions, maintenance-windows or oncall-schedules.
+	list                  List information about tasks, templates, recordings, replays, topics, topic-handlers, silences, inhibitions, maintenance-windows, oncall-schedules or service-tests.
 	show                  Display detailed information about a task.
 	show-template         Display detailed information about a template.
-	show-topic-handler    Display detailed information about an alert handler for a topic.
+	show-topic-handler    Display detailed information about an alert handler for a topic and its outbox.
 	show-topic            Display detailed information about an alert topic.
+	show-topic-routing    Display the routing of events to the alert handlers of a topic.
+	show-oncall           Display an on-call schedule and who is currently on call.
+	ack                   Acknowledge alert events.
+	silence               Silence alert events for a period of time.
+	inhibit               Inhibit alert events while another topic is alerting.
+	maintenance           Suppress external alert handlers during maintenance windows.
+	replay-dead-letters   Retry the delivery of alert events an alert handler gave up on.
 	backup                Backup the Kapacitor database.
 	level                 Sets the logging level on the kapacitord server.
 	stats                 Display various stats about Kapacitor.
@@ -142,6 +151,12 @@ func main() {
 	case "define-topic-handler":
 		commandArgs = args
 		commandF = doDefineTopicHandler
+	case "define-topic-routing":
+		commandArgs = args
+		commandF = doDefineTopicRouting
+	case "define-oncall":
+		commandArgs = args
+		commandF = doDefineOnCallSchedule
 	case "replay":
 		replayFlags.Parse(args)
 		commandArgs = replayFlags.Args()
@@ -179,8 +194,34 @@ func main() {
 		commandArgs = args
 		commandF = doShowTopicHandler
 	case "show-topic":
-		commandArgs = args
+		showTopicFlags.Parse(args)
+		commandArgs = showTopicFlags.Args()
 		commandF = doShowTopic
+	case "show-topic-routing":
+		commandArgs = args
+		commandF = doShowTopicRouting
+	case "show-oncall":
+		commandArgs = args
+		commandF = doShowOnCallSchedule
+	case "replay-dead-letters":
+		commandArgs = args
+		commandF = doReplayDeadLetters
+	case "ack":
+		ackFlags.Parse(args)
+		commandArgs = ackFlags.Args()
+		commandF = doAck
+	case "silence":
+		silenceFlags.Parse(args)
+		commandArgs = silenceFlags.Args()
+		commandF = doSilence
+	case "inhibit":
+		inhibitFlags.Parse(args)
+		commandArgs = inhibitFlags.Args()
+		commandF = doInhibit
+	case "maintenance":
+		maintenanceFlags.Parse(args)
+		commandArgs = maintenanceFlags.Args()
+		commandF = doMaintenance
 	case "backup":
 		commandArgs = args
 		commandF = doBackup
@@ -217,6 +258,11 @@ func init() {
 	defineFlags.Usage = defineUsage
 	defineTemplateFlags.Usage = defineTemplateUsage
 	showFlags.Usage = showUsage
+	showTopicFlags.Usage = showTopicUsage
+	ackFlags.Usage = ackUsage
+	silenceFlags.Usage = silenceUsage
+	inhibitFlags.Usage = inhibitUsage
+	maintenanceFlags.Usage = maintenanceUsage
 
 	recordStreamFlags.Usage = recordStreamUsage
 	recordBatchFlags.Usage = recordBatchUsage
@@ -262,6 +308,10 @@ func doHelp(args []string) error {
 			defineTemplateFlags.Usage()
 		case "define-topic-handler":
 			defineTopicHandlerUsage()
+		case "define-topic-routing":
+			defineTopicRoutingUsage()
+		case "define-oncall":
+			defineOnCallScheduleUsage()
 		case "replay":
 			replayFlags.Usage()
 		case "enable":
@@ -282,6 +332,20 @@ func doHelp(args []string) error {
 			showTopicHandlerUsage()
 		case "show-topic":
 			showTopicUsage()
+		case "show-topic-routing":
+			showTopicRoutingUsage()
+		case "show-oncall":
+			showOnCallScheduleUsage()
+		case "replay-dead-letters":
+			replayDeadLettersUsage()
+		case "ack":
+			ackFlags.Usage()
+		case "silence":
+			silenceFlags.Usage()
+		case "inhibit":
+			inhibitFlags.Usage()
+		case "maintenance":
+			maintenanceFlags.Usage()
 		case "backup":
 			backupUsage()
 		case "level":
@@ -882,6 +946,179 @@ func doDefineTopicHandler(args []string) error {
 	return err
 }
 
+func defineTopicRoutingUsage() {
+	var u = `Usage: kapacitor define-topic-routing <topic id> <path to routing file>
+
+	Create or update the routing of a topic.
+
+	The routing is an ordered tree of routes, defined via a JSON or YAML file.
+	Routes match events by levels, tags, task name and time of day.
+	Evaluation stops at the first matching route unless it sets continue.
+	A matching route sends the event to its handlers if none of its child routes match.
+	Events matching no route are sent to the default handlers.
+	Handlers of a topic with a routing only receive the events routed to them.
+	The recovery of an event is sent to the handlers the event was routed to since it was last OK.
+
+For example:
+
+	Define the routing of the system topic using the routing.yaml file:
+
+		$ kapacitor define-topic-routing system routing.yaml
+
+	Where routing.yaml contains:
+
+		timezone: America/New_York
+		routes:
+		  - levels: [CRITICAL]
+		    continue: true
+		    handlers: [pagerduty]
+		  - tags:
+		      team: db
+		    routes:
+		      - start: "09:00"
+		        stop: "17:00"
+		        handlers: [slack_db]
+		    handlers: [email_db]
+		default: [log]
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+}
+
+func doDefineTopicRouting(args []string) error {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Must provide a topic ID and a path to a routing file.")
+		defineTopicRoutingUsage()
+		os.Exit(2)
+	}
+	topic := args[0]
+	p := args[1]
+	f, err := os.Open(p)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open routing file %q", p)
+	}
+
+	// Decode file into TopicRoutingOptions
+	var ro client.TopicRoutingOptions
+	ext := path.Ext(p)
+	switch ext {
+	case ".yaml", ".yml":
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read routing file %q", p)
+		}
+		if err := yaml.Unmarshal(data, &ro); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal yaml routing file %q", p)
+		}
+	case ".json":
+		if err := json.NewDecoder(f).Decode(&ro); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal json routing file %q", p)
+		}
+	default:
+		return fmt.Errorf("routing file %q must be a .yaml, .yml or .json file", p)
+	}
+
+	_, err = cli.ReplaceTopicRouting(cli.TopicRoutingLink(topic), ro)
+	return err
+}
+
+func defineOnCallScheduleUsage() {
+	var u = `Usage: kapacitor define-oncall <schedule id> <path to schedule file>
+
+	Create or update an on-call schedule.
+
+	The schedule is defined via a JSON or YAML file.
+	Members take turns being on call in the order of a rotation, each for a shift starting at the handoff time.
+	Rotations are evaluated in order and may be restricted to a time of day, the first rotation covering the time decides.
+	Overrides put a member on call for a period of time regardless of the rotations.
+
+	Handlers reference the schedule with a recipient of the form 'oncall:<schedule id>',
+	which is replaced by the contact of the member on call for the kind of the handler
+	each time an event is handled. The recipient options that may reference a schedule are:
+
+		hipchat:  room
+		opsgenie: recipients-list
+		pushover: device
+		slack:    channel
+		smtp:     to
+		telegram: chat-id
+
+For example:
+
+	Define the dba schedule using the dba.yaml file:
+
+		$ kapacitor define-oncall dba dba.yaml
+
+	Where dba.yaml contains:
+
+		timezone: Europe/Paris
+		members:
+		  - name: alice
+		    contacts:
+		      smtp: alice@example.com
+		      slack: "@alice"
+		  - name: bob
+		    contacts:
+		      smtp: bob@example.com
+		      slack: "@bob"
+		rotations:
+		  - members: [alice, bob]
+		    handoff: 2017-06-05T09:00:00+02:00
+		    shift: 168h
+		overrides:
+		  - member: bob
+		    start: 2017-06-10T00:00:00+02:00
+		    stop: 2017-06-11T00:00:00+02:00
+
+	And an smtp handler sends to the member on call:
+
+		kind: smtp
+		options:
+		  to: ["oncall:dba"]
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+}
+
+func doDefineOnCallSchedule(args []string) error {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Must provide a schedule ID and a path to a schedule file.")
+		defineOnCallScheduleUsage()
+		os.Exit(2)
+	}
+	id := args[0]
+	p := args[1]
+	f, err := os.Open(p)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open schedule file %q", p)
+	}
+
+	// Decode file into OnCallScheduleOptions
+	var so client.OnCallScheduleOptions
+	ext := path.Ext(p)
+	switch ext {
+	case ".yaml", ".yml":
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read schedule file %q", p)
+		}
+		if err := yaml.Unmarshal(data, &so); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal yaml schedule file %q", p)
+		}
+	case ".json":
+		if err := json.NewDecoder(f).Decode(&so); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal json schedule file %q", p)
+		}
+	default:
+		return fmt.Errorf("schedule file %q must be a .yaml, .yml or .json file", p)
+	}
+
+	_, err = cli.ReplaceOnCallSchedule(cli.OnCallScheduleLink(id), so)
+	return err
+}
+
 // Replay
 var (
 	replayFlags = flag.NewFlagSet("replay", flag.ExitOnError)
@@ -1468,17 +1705,149 @@ func doShowTopicHandler(args []string) error {
 	fmt.Println("Kind:", h.Kind)
 	fmt.Println("Match:", h.Match)
 	fmt.Println("Options:", string(options))
+
+	o, err := cli.HandlerOutbox(cli.HandlerOutboxLink(topic, handler))
+	if err != nil {
+		return err
+	}
+	fmt.Println("Queue Depth:", o.QueueDepth)
+	fmt.Println("Dead Letters:", o.DeadLetters)
+	if len(o.Entries) > 0 {
+		maxID := 2     // len("ID")
+		maxEvent := 5  // len("Event")
+		maxStatus := 6 // len("Status")
+		for _, e := range o.Entries {
+			if l := len(e.ID); l > maxID {
+				maxID = l
+			}
+			if l := len(e.EventID); l > maxEvent {
+				maxEvent = l
+			}
+		}
+		headerFmt := fmt.Sprintf("%%-%ds%%-%ds%%-%ds%%-9s%%-20s%%s\n", maxID+1, maxEvent+1, maxStatus+1)
+		entryFmt := fmt.Sprintf("%%-%ds%%-%ds%%-%ds%%-9d%%-20s%%s\n", maxID+1, maxEvent+1, maxStatus+1)
+		fmt.Println("Outbox:")
+		fmt.Printf(headerFmt, "ID", "Event", "Status", "Attempts", "Queued", "Last Error")
+		for _, e := range o.Entries {
+			status := "queued"
+			if e.Dead {
+				status = "dead"
+			}
+			fmt.Printf(entryFmt, e.ID, e.EventID, status, e.Attempts, e.Queued.Local().Format(time.RFC822), e.LastError)
+		}
+	}
+	return nil
+}
+
+// Show Topic Routing
+
+func showTopicRoutingUsage() {
+	var u = `Usage: kapacitor show-topic-routing [topic ID]
+
+	Show the routing of a topic.
+`
+	fmt.Fprintln(os.Stderr, u)
+}
+
+func doShowTopicRouting(args []string) error {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Must specify one topic ID")
+		showTopicRoutingUsage()
+		os.Exit(2)
+	}
+
+	r, err := cli.TopicRouting(cli.TopicRoutingLink(args[0]))
+	if err != nil {
+		return err
+	}
+	routes, err := yaml.Marshal(r.Routes)
+	if err != nil {
+		return errors.Wrap(err, "failed to format routes")
+	}
+	fmt.Println("Topic:", r.Topic)
+	fmt.Println("Timezone:", r.Timezone)
+	fmt.Printf("Default: [%s]\n", strings.Join(r.Default, ", "))
+	fmt.Println("Routes:")
+	fmt.Print(string(routes))
+	return nil
+}
+
+// Show On-Call Schedule
+
+func showOnCallScheduleUsage() {
+	var u = `Usage: kapacitor show-oncall [schedule ID]
+
+	Show an on-call schedule and who is currently on call.
+`
+	fmt.Fprintln(os.Stderr, u)
+}
+
+func doShowOnCallSchedule(args []string) error {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Must specify one schedule ID")
+		showOnCallScheduleUsage()
+		os.Exit(2)
+	}
+
+	o, err := cli.OnCallSchedule(cli.OnCallScheduleLink(args[0]))
+	if err != nil {
+		return err
+	}
+	members, err := yaml.Marshal(o.Members)
+	if err != nil {
+		return errors.Wrap(err, "failed to format members")
+	}
+	rotations, err := yaml.Marshal(o.Rotations)
+	if err != nil {
+		return errors.Wrap(err, "failed to format rotations")
+	}
+	overrides, err := yaml.Marshal(o.Overrides)
+	if err != nil {
+		return errors.Wrap(err, "failed to format overrides")
+	}
+	fmt.Println("ID:", o.ID)
+	fmt.Println("Timezone:", o.Timezone)
+	fmt.Println("On Call:", o.OnCall)
+	fmt.Println("Members:")
+	fmt.Print(string(members))
+	fmt.Println("Rotations:")
+	fmt.Print(string(rotations))
+	fmt.Println("Overrides:")
+	fmt.Print(string(overrides))
 	return nil
 }
 
 // Show Topic
 
+var (
+	showTopicFlags = flag.NewFlagSet("show-topic", flag.ExitOnError)
+	stHistory      = showTopicFlags.Bool("history", false, "Show the history of level changes of the events of the topic.")
+	stStart        = showTopicFlags.String("start", "", "Only show history since the start time.")
+	stStop         = showTopicFlags.String("stop", "", "Only show history before the stop time.")
+)
+
 func showTopicUsage() {
-	var u = `Usage: kapacitor show-topic [topic ID]
+	var u = `Usage: kapacitor show-topic [options] [topic ID] [event ID or pattern]...
 
 	Show details about a specific topic.
+
+	With -history the history of level changes of the events is shown instead,
+	optionally limited to the events matching the given IDs or patterns.
+
+For example:
+
+	You can show the history of all events of a topic over a day:
+
+		$ kapacitor show-topic -history -start 2017-06-01T00:00:00Z -stop 2017-06-02T00:00:00Z system
+
+	Or show the history of some events:
+
+		$ kapacitor show-topic -history system 'cpu:*'
+
+Options:
 `
 	fmt.Fprintln(os.Stderr, u)
+	showTopicFlags.PrintDefaults()
 }
 
 type topicEvents []client.TopicEvent
@@ -1488,6 +1857,9 @@ func (t topicEvents) Less(i int, j int) bool { return t[i].State.Time.Before(t[j
 func (t topicEvents) Swap(i int, j int)      { t[i], t[j] = t[j], t[i] }
 
 func doShowTopic(args []string) error {
+	if *stHistory {
+		return doShowTopicHistory(args)
+	}
 	if len(args) != 1 {
 		fmt.Fprintln(os.Stderr, "Must specify one topic ID")
 		showTopicUsage()
@@ -1524,25 +1896,524 @@ func doShowTopic(args []string) error {
 		handlerIDs[i] = h.ID
 	}
 
-	outFmt := fmt.Sprintf("%%-%ds%%-9s%%-%ds%%-23s\n", maxEvent+1, maxMessage+1)
+	outFmt := fmt.Sprintf("%%-%ds%%-9s%%-%ds%%-23s%%s\n", maxEvent+1, maxMessage+1)
 	fmt.Println("ID:", topic.ID)
 	fmt.Println("Level:", topic.Level)
 	fmt.Println("Collected:", topic.Collected)
 	fmt.Printf("Handlers: [%s]\n", strings.Join(handlerIDs, ", "))
 	fmt.Println("Events:")
-	fmt.Printf(outFmt, "Event", "Level", "Message", "Date")
+	fmt.Printf(outFmt, "Event", "Level", "Message", "Date", "Acked By")
 	for _, e := range te.Events {
-		fmt.Printf(outFmt, e.ID, e.State.Level, e.State.Message, e.State.Time.Local().Format(time.RFC822))
+		ackedBy := ""
+		if e.State.Ack != nil {
+			ackedBy = e.State.Ack.By
+		}
+		fmt.Printf(outFmt, e.ID, e.State.Level, e.State.Message, e.State.Time.Local().Format(time.RFC822), ackedBy)
+	}
+
+	tes, err := cli.ListTopicEscalations(cli.TopicEscalationsLink(topic.ID))
+	if err != nil {
+		return err
+	}
+	if len(tes.Escalations) > 0 {
+		maxEvent := 5      // len("Event")
+		maxHandler := 7    // len("Handler")
+		maxNextTopic := 10 // len("Next Topic")
+		for _, e := range tes.Escalations {
+			if l := len(e.EventID); l > maxEvent {
+				maxEvent = l
+			}
+			if l := len(e.Handler); l > maxHandler {
+				maxHandler = l
+			}
+			if l := len(e.NextTopic); l > maxNextTopic {
+				maxNextTopic = l
+			}
+		}
+		headerFmt := fmt.Sprintf("%%-%ds%%-%ds%%-5s%%-%ds%%-20s%%s\n", maxEvent+1, maxHandler+1, maxNextTopic+1)
+		escFmt := fmt.Sprintf("%%-%ds%%-%ds%%-5d%%-%ds%%-20s%%s\n", maxEvent+1, maxHandler+1, maxNextTopic+1)
+		fmt.Println("Escalations:")
+		fmt.Printf(headerFmt, "Event", "Handler", "Step", "Next Topic", "Next Date", "Escalated To")
+		for _, e := range tes.Escalations {
+			next := ""
+			if !e.Next.IsZero() {
+				next = e.Next.Local().Format(time.RFC822)
+			}
+			fmt.Printf(escFmt, e.EventID, e.Handler, e.Step, e.NextTopic, next, strings.Join(e.Published, ","))
+		}
+	}
+	return nil
+}
+
+func doShowTopicHistory(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Must specify a topic ID")
+		showTopicUsage()
+		os.Exit(2)
+	}
+	topic := args[0]
+	patterns := args[1:]
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+	opt := &client.TopicEventHistoryOptions{}
+	var err error
+	if *stStart != "" {
+		opt.Start, err = time.Parse(time.RFC3339Nano, *stStart)
+		if err != nil {
+			return err
+		}
+	}
+	if *stStop != "" {
+		opt.Stop, err = time.Parse(time.RFC3339Nano, *stStop)
+		if err != nil {
+			return err
+		}
+	}
+
+	te, err := cli.ListTopicEvents(cli.TopicEventsLink(topic), nil)
+	if err != nil {
+		return err
+	}
+	sort.Sort(topicEvents(te.Events))
+
+	var histories []client.TopicEventHistory
+	maxEvent := 5   // len("Event")
+	maxMessage := 7 // len("Message")
+	for _, e := range te.Events {
+		matched := false
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, e.ID); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		h, err := cli.TopicEventHistory(cli.TopicEventHistoryLink(topic, e.ID), opt)
+		if err != nil {
+			return err
+		}
+		if len(h.History) == 0 {
+			continue
+		}
+		histories = append(histories, h)
+		if l := len(e.ID); l > maxEvent {
+			maxEvent = l
+		}
+		for _, state := range h.History {
+			if l := len(state.Message); l > maxMessage {
+				maxMessage = l
+			}
+		}
+	}
+
+	outFmt := fmt.Sprintf("%%-%ds%%-9s%%-%ds%%s\n", maxEvent+1, maxMessage+1)
+	fmt.Printf(outFmt, "Event", "Level", "Message", "Date")
+	for _, h := range histories {
+		for _, state := range h.History {
+			fmt.Printf(outFmt, h.ID, state.Level, state.Message, state.Time.Local().Format(time.RFC822))
+		}
+	}
+	return nil
+}
+
+// Replay Dead Letters
+
+func replayDeadLettersUsage() {
+	var u = `Usage: kapacitor replay-dead-letters [topic ID] [handler ID] [entry ID]...
+
+	Retry the delivery of alert events the handler gave up on.
+
+	Failed deliveries of the post, slack, teams, discord, pagerduty,
+	pagerduty2, alertmanager, amqp and syslog handlers are retried until
+	they exceed the configured max age, then they are kept as dead letters.
+	Replayed dead letters are retried for another max age.
+	If no entry IDs are given all dead letters of the handler are replayed.
+	Use 'kapacitor show-topic-handler' to list the dead letters.
+
+For example:
+
+	$ kapacitor replay-dead-letters system ops-slack
+`
+	fmt.Fprintln(os.Stderr, u)
+}
+
+func doReplayDeadLetters(args []string) error {
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "Must specify both topic and handler IDs")
+		replayDeadLettersUsage()
+		os.Exit(2)
+	}
+	topic := args[0]
+	handler := args[1]
+	if len(args) == 2 {
+		o, err := cli.ReplayDeadLetters(cli.HandlerOutboxLink(topic, handler))
+		if err != nil {
+			return err
+		}
+		fmt.Println("Queue Depth:", o.QueueDepth)
+		return nil
+	}
+	for _, id := range args[2:] {
+		if _, err := cli.ReplayDeadLetter(cli.OutboxEntryLink(topic, handler, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ack
+
+var (
+	ackFlags = flag.NewFlagSet("ack", flag.ExitOnError)
+	aBy      = ackFlags.String("by", os.Getenv("USER"), "Who is acknowledging the events.")
+	aComment = ackFlags.String("comment", "", "A comment describing the acknowledgement.")
+)
+
+func ackUsage() {
+	var u = `Usage: kapacitor ack [options] [topic ID] [event ID or pattern]...
+
+	Acknowledge the current state of alert events.
+
+	Handlers of the topic skip acknowledged events until their level changes.
+
+For example:
+
+	You can acknowledge a single event:
+
+		$ kapacitor ack -comment "investigating" system cpu:nil
+
+	Or acknowledge events by glob:
+
+		$ kapacitor ack system cpu:*
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	ackFlags.PrintDefaults()
+}
+
+func doAck(args []string) error {
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "Must specify a topic ID and at least one event ID")
+		ackFlags.Usage()
+		os.Exit(2)
+	}
+	topic := args[0]
+	te, err := cli.ListTopicEvents(cli.TopicEventsLink(topic), nil)
+	if err != nil {
+		return err
+	}
+	opt := client.AckEventOptions{
+		By:      *aBy,
+		Comment: *aComment,
+	}
+	for _, pattern := range args[1:] {
+		for _, e := range te.Events {
+			if matched, _ := path.Match(pattern, e.ID); !matched {
+				continue
+			}
+			if _, err := cli.AckTopicEvent(e.Link, opt); err != nil {
+				return errors.Wrapf(err, "acknowledging event %s", e.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// Silence
+
+var (
+	silenceFlags = flag.NewFlagSet("silence", flag.ExitOnError)
+	sID          = silenceFlags.String("id", "", "The ID to give to the silence. If not set a random ID is chosen.")
+	sTopic       = silenceFlags.String("topic", "", "A glob pattern of the topics to silence. Defaults to all topics.")
+	sEvent       = silenceFlags.String("event", "", "A glob pattern of the event IDs to silence. Defaults to all events.")
+	sStart       = silenceFlags.String("start", "", "The start time of the silence (default now).")
+	sStop        = silenceFlags.String("stop", "", "The stop time of the silence.")
+	sDuration    = silenceFlags.String("duration", "", "How long the silence lasts, set instead of the stop time.")
+	sBy          = silenceFlags.String("by", os.Getenv("USER"), "Who is creating the silence.")
+	sComment     = silenceFlags.String("comment", "", "A comment describing the silence.")
+	sTags        = make(tagFlags)
+)
+
+func init() {
+	silenceFlags.Var(sTags, "tag", `A tag of the form key=value that events must have to be silenced. The flag can be specified multiple times.`)
+}
+
+type tagFlags map[string]string
+
+func (t tagFlags) String() string {
+	return fmt.Sprint(map[string]string(t))
+}
+
+func (t tagFlags) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errors.New("tag must be of the form key=value")
 	}
+	t[parts[0]] = parts[1]
+	return nil
+}
+
+func silenceUsage() {
+	var u = `Usage: kapacitor silence [options]
+
+	Silence alert events for a period of time.
+
+	Events matching the silence are still collected but no handlers are called for them.
+	Use 'kapacitor list silences' and 'kapacitor delete silences' to manage existing silences.
+
+For example:
+
+	You can silence all events of a host for two hours:
+
+		$ kapacitor silence -tag host=serverA -duration 2h -comment "maintenance"
+
+	Or silence the events of some topics until a specific time:
+
+		$ kapacitor silence -topic 'cpu*' -event '*:serverA' -stop 2017-06-01T08:00:00Z
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	silenceFlags.PrintDefaults()
+}
+
+func doSilence(args []string) error {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Unexpected arguments", args)
+		silenceFlags.Usage()
+		os.Exit(2)
+	}
+	if *sStop == "" && *sDuration == "" {
+		silenceFlags.Usage()
+		return errors.New("must set one of stop or duration flags.")
+	}
+	if *sStop != "" && *sDuration != "" {
+		silenceFlags.Usage()
+		return errors.New("cannot set both stop and duration flags.")
+	}
+	opt := client.SilenceOptions{
+		ID:        *sID,
+		Topic:     *sTopic,
+		Event:     *sEvent,
+		Tags:      sTags,
+		CreatedBy: *sBy,
+		Comment:   *sComment,
+	}
+	var err error
+	if *sStart != "" {
+		opt.Start, err = time.Parse(time.RFC3339Nano, *sStart)
+		if err != nil {
+			return err
+		}
+	}
+	if *sStop != "" {
+		opt.Stop, err = time.Parse(time.RFC3339Nano, *sStop)
+		if err != nil {
+			return err
+		}
+	}
+	if *sDuration != "" {
+		d, err := influxql.ParseDuration(*sDuration)
+		if err != nil {
+			return err
+		}
+		opt.Duration = client.Duration(d)
+	}
+	silence, err := cli.CreateSilence(opt)
+	if err != nil {
+		return err
+	}
+	fmt.Println(silence.ID)
+	return nil
+}
+
+// Inhibit
+
+var (
+	inhibitFlags = flag.NewFlagSet("inhibit", flag.ExitOnError)
+	iID          = inhibitFlags.String("id", "", "The ID to give to the inhibition. If not set a random ID is chosen.")
+	iSource      = inhibitFlags.String("source", "", "The topic whose level inhibits events.")
+	iLevel       = inhibitFlags.String("level", "CRITICAL", "The minimum level of the source topic to inhibit events.")
+	iTarget      = inhibitFlags.String("target", "", "A glob pattern of the topics to inhibit. Defaults to all topics.")
+	iComment     = inhibitFlags.String("comment", "", "A comment describing the inhibition.")
+	iTags        = make(tagFlags)
+	iEqual       stringFlags
+)
+
+func init() {
+	inhibitFlags.Var(iTags, "tag", `A tag of the form key=value that events must have to be inhibited. The flag can be specified multiple times.`)
+	inhibitFlags.Var(&iEqual, "equal", `A tag key whose value must be the same on the event and an alerting event of the source topic. The flag can be specified multiple times.`)
+}
+
+type stringFlags []string
+
+func (s *stringFlags) String() string {
+	return fmt.Sprint(*s)
+}
+
+func (s *stringFlags) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func inhibitUsage() {
+	var u = `Usage: kapacitor inhibit [options]
+
+	Inhibit alert events while another topic is alerting.
+
+	Events matching the inhibition are still collected but no handlers are called for them
+	while the source topic is at or above the level. The reason is recorded on the event state.
+	Use 'kapacitor list inhibitions' and 'kapacitor delete inhibitions' to manage existing inhibitions.
+
+For example:
+
+	You can inhibit the host topics of a datacenter while the datacenter topic is CRITICAL:
+
+		$ kapacitor inhibit -source dc_us_west -target 'host_*' -tag dc=us_west
+
+	You can inhibit the host events of a datacenter while the outage topic has a CRITICAL event of the same datacenter:
+
+		$ kapacitor inhibit -source outage -target 'host_*' -equal dc
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	inhibitFlags.PrintDefaults()
+}
+
+func doInhibit(args []string) error {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Unexpected arguments", args)
+		inhibitFlags.Usage()
+		os.Exit(2)
+	}
+	if *iSource == "" {
+		inhibitFlags.Usage()
+		return errors.New("must set the source flag.")
+	}
+	inhibition, err := cli.CreateInhibition(client.InhibitionOptions{
+		ID:          *iID,
+		SourceTopic: *iSource,
+		Level:       *iLevel,
+		TargetTopic: *iTarget,
+		Tags:        iTags,
+		Equal:       iEqual,
+		Comment:     *iComment,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(inhibition.ID)
+	return nil
+}
+
+// Maintenance
+
+var (
+	maintenanceFlags = flag.NewFlagSet("maintenance", flag.ExitOnError)
+	mID              = maintenanceFlags.String("id", "", "The ID to give to the maintenance window. If not set a random ID is chosen.")
+	mTopic           = maintenanceFlags.String("topic", "", "A glob pattern of the topics in maintenance. Defaults to all topics.")
+	mEvent           = maintenanceFlags.String("event", "", "A glob pattern of the events in maintenance. Defaults to all events.")
+	mCron            = maintenanceFlags.String("cron", "", "A cron expression at which recurring windows start.")
+	mDuration        = maintenanceFlags.String("duration", "", "How long each recurring window lasts, or how long a one-off window lasts instead of the stop time.")
+	mTimezone        = maintenanceFlags.String("timezone", "", "The timezone in which the cron expression is evaluated (default UTC).")
+	mStart           = maintenanceFlags.String("start", "", "The time from which the window applies (default now for one-off windows).")
+	mStop            = maintenanceFlags.String("stop", "", "The time at which the window stops applying.")
+	mComment         = maintenanceFlags.String("comment", "", "A comment describing the maintenance.")
+	mTags            = make(tagFlags)
+)
+
+func init() {
+	maintenanceFlags.Var(mTags, "tag", `A tag of the form key=pattern that events must have to be in maintenance. The flag can be specified multiple times.`)
+}
+
+func maintenanceUsage() {
+	var u = `Usage: kapacitor maintenance [options]
+
+	Suppress external alert handlers during maintenance windows.
+
+	Events matching an active maintenance window are still collected and handled by internal handlers,
+	but they are not sent to external services. The window is recorded on the event state.
+	Windows either recur on a cron schedule or are active once between a start and stop time.
+	Use 'kapacitor list maintenance-windows' and 'kapacitor delete maintenance-windows' to manage existing windows.
+
+For example:
+
+	You can suppress the alerts of database hosts every Sunday from 02:00 to 04:00 New York time:
+
+		$ kapacitor maintenance -tag 'host=db-*' -cron '0 2 * * SUN' -duration 2h -timezone America/New_York
+
+	Or suppress the alerts of a topic once:
+
+		$ kapacitor maintenance -topic cpu -start 2017-06-01T02:00:00Z -stop 2017-06-01T04:00:00Z
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	maintenanceFlags.PrintDefaults()
+}
+
+func doMaintenance(args []string) error {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Unexpected arguments", args)
+		maintenanceFlags.Usage()
+		os.Exit(2)
+	}
+	if *mCron != "" && *mDuration == "" {
+		maintenanceFlags.Usage()
+		return errors.New("must set the duration flag with the cron flag.")
+	}
+	if *mCron == "" && *mStop == "" && *mDuration == "" {
+		maintenanceFlags.Usage()
+		return errors.New("must set one of cron, stop or duration flags.")
+	}
+	opt := client.MaintenanceWindowOptions{
+		ID:       *mID,
+		Topic:    *mTopic,
+		Event:    *mEvent,
+		Tags:     mTags,
+		Cron:     *mCron,
+		Timezone: *mTimezone,
+		Comment:  *mComment,
+	}
+	var err error
+	if *mStart != "" {
+		opt.Start, err = time.Parse(time.RFC3339Nano, *mStart)
+		if err != nil {
+			return err
+		}
+	}
+	if *mStop != "" {
+		opt.Stop, err = time.Parse(time.RFC3339Nano, *mStop)
+		if err != nil {
+			return err
+		}
+	}
+	if *mDuration != "" {
+		d, err := influxql.ParseDuration(*mDuration)
+		if err != nil {
+			return err
+		}
+		opt.Duration = client.Duration(d)
+	}
+	window, err := cli.CreateMaintenanceWindow(opt)
+	if err != nil {
+		return err
+	}
+	fmt.Println(window.ID)
 	return nil
 }
 
 // List
 
 func listUsage() {
-	var u = `Usage: kapacitor list (tasks|templates|recordings|replays|topics|topic-handlers|service-tests) [ID or pattern]...
+	var u = `Usage: kapacitor list (tasks|templates|recordings|replays|topics|topic-handlers|silences|inhibitions|maintenance-windows|oncall-schedules|service-tests) [ID or pattern]...
 
-	List tasks, templates, recordings, replays, topics or handlers and their current state.
+	List tasks, templates, recordings, replays, topics, handlers, silences, inhibitions, maintenance windows or on-call schedules and their current state.
 
 	If no ID or pattern is given then all items will be listed.
 
@@ -1836,8 +2707,146 @@ func doList(args []string) error {
 		for _, t := range allTopics {
 			fmt.Fprintf(os.Stdout, outFmt, t.ID, t.Level, t.Collected)
 		}
+	case "silences":
+		silences, err := cli.ListSilences()
+		if err != nil {
+			return err
+		}
+		maxID := 2    // len("ID")
+		maxTopic := 5 // len("Topic")
+		maxEvent := 5 // len("Event")
+		var matched []client.Silence
+		for _, s := range silences.Silences {
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, s.ID); ok || pattern == "" {
+					matched = append(matched, s)
+					break
+				}
+			}
+		}
+		for _, s := range matched {
+			if l := len(s.ID); l > maxID {
+				maxID = l
+			}
+			if l := len(s.Topic); l > maxTopic {
+				maxTopic = l
+			}
+			if l := len(s.Event); l > maxEvent {
+				maxEvent = l
+			}
+		}
+		outFmt := fmt.Sprintf("%%-%dv%%-%dv%%-%dv%%-23v%%-23v%%v\n", maxID+1, maxTopic+1, maxEvent+1)
+		fmt.Fprintf(os.Stdout, outFmt, "ID", "Topic", "Event", "Start", "Stop", "Tags")
+		for _, s := range matched {
+			fmt.Fprintf(os.Stdout, outFmt, s.ID, s.Topic, s.Event, s.Start.Local().Format(time.RFC822), s.Stop.Local().Format(time.RFC822), s.Tags)
+		}
+	case "inhibitions":
+		inhibitions, err := cli.ListInhibitions()
+		if err != nil {
+			return err
+		}
+		maxID := 2     // len("ID")
+		maxSource := 6 // len("Source")
+		maxTarget := 6 // len("Target")
+		maxTags := 4   // len("Tags")
+		var matched []client.Inhibition
+		for _, i := range inhibitions.Inhibitions {
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, i.ID); ok || pattern == "" {
+					matched = append(matched, i)
+					break
+				}
+			}
+		}
+		for _, i := range matched {
+			if l := len(i.ID); l > maxID {
+				maxID = l
+			}
+			if l := len(i.SourceTopic); l > maxSource {
+				maxSource = l
+			}
+			if l := len(i.TargetTopic); l > maxTarget {
+				maxTarget = l
+			}
+			if l := len(fmt.Sprint(i.Tags)); l > maxTags {
+				maxTags = l
+			}
+		}
+		outFmt := fmt.Sprintf("%%-%dv%%-%dv%%-9v%%-%dv%%-%dv%%v\n", maxID+1, maxSource+1, maxTarget+1, maxTags+1)
+		fmt.Fprintf(os.Stdout, outFmt, "ID", "Source", "Level", "Target", "Tags", "Equal")
+		for _, i := range matched {
+			fmt.Fprintf(os.Stdout, outFmt, i.ID, i.SourceTopic, i.Level, i.TargetTopic, i.Tags, i.Equal)
+		}
+	case "maintenance-windows":
+		windows, err := cli.ListMaintenanceWindows()
+		if err != nil {
+			return err
+		}
+		maxID := 2       // len("ID")
+		maxTopic := 5    // len("Topic")
+		maxSchedule := 8 // len("Schedule")
+		var matched []client.MaintenanceWindow
+		for _, w := range windows.MaintenanceWindows {
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, w.ID); ok || pattern == "" {
+					matched = append(matched, w)
+					break
+				}
+			}
+		}
+		schedules := make([]string, len(matched))
+		for i, w := range matched {
+			if w.Cron != "" {
+				schedules[i] = fmt.Sprintf("%s for %v (%s)", w.Cron, w.Duration, w.Timezone)
+			} else {
+				schedules[i] = fmt.Sprintf("%s - %s", w.Start.Local().Format(time.RFC822), w.Stop.Local().Format(time.RFC822))
+			}
+			if l := len(w.ID); l > maxID {
+				maxID = l
+			}
+			if l := len(w.Topic); l > maxTopic {
+				maxTopic = l
+			}
+			if l := len(schedules[i]); l > maxSchedule {
+				maxSchedule = l
+			}
+		}
+		outFmt := fmt.Sprintf("%%-%dv%%-%dv%%-%dv%%v\n", maxID+1, maxTopic+1, maxSchedule+1)
+		fmt.Fprintf(os.Stdout, outFmt, "ID", "Topic", "Schedule", "Tags")
+		for i, w := range matched {
+			fmt.Fprintf(os.Stdout, outFmt, w.ID, w.Topic, schedules[i], w.Tags)
+		}
+	case "oncall-schedules":
+		schedules, err := cli.ListOnCallSchedules()
+		if err != nil {
+			return err
+		}
+		maxID := 2       // len("ID")
+		maxTimezone := 8 // len("Timezone")
+		var matched []client.OnCallSchedule
+		for _, o := range schedules.Schedules {
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, o.ID); ok || pattern == "" {
+					matched = append(matched, o)
+					break
+				}
+			}
+		}
+		for _, o := range matched {
+			if l := len(o.ID); l > maxID {
+				maxID = l
+			}
+			if l := len(o.Timezone); l > maxTimezone {
+				maxTimezone = l
+			}
+		}
+		outFmt := fmt.Sprintf("%%-%dv%%-%dv%%-10v%%v\n", maxID+1, maxTimezone+1)
+		fmt.Fprintf(os.Stdout, outFmt, "ID", "Timezone", "Members", "On Call")
+		for _, o := range matched {
+			fmt.Fprintf(os.Stdout, outFmt, o.ID, o.Timezone, len(o.Members), o.OnCall)
+		}
 	default:
-		return fmt.Errorf("cannot list '%s' did you mean 'tasks', 'recordings', 'replays', 'topics', 'topic-handlers' or 'service-tests'?", kind)
+		return fmt.Errorf("cannot list '%s' did you mean 'tasks', 'recordings', 'replays', 'topics', 'topic-handlers', 'silences', 'inhibitions', 'maintenance-windows', 'oncall-schedules' or 'service-tests'?", kind)
 	}
 	return nil
 
@@ -1845,9 +2854,9 @@ func doList(args []string) error {
 
 // Delete
 func deleteUsage() {
-	var u = `Usage: kapacitor delete (tasks|templates|recordings|replays|topics|topic-handlers) [ID or pattern]...
+	var u = `Usage: kapacitor delete (tasks|templates|recordings|replays|topics|topic-handlers|topic-routings|silences|inhibitions|maintenance-windows|oncall-schedules) [ID or pattern]...
 
-	Delete a tasks, templates, recordings, replays, topics or handlers.
+	Delete a tasks, templates, recordings, replays, topics, handlers, routings, silences, inhibitions, maintenance windows or on-call schedules.
 
 	If a task is enabled it will be disabled and then deleted.
 
@@ -1873,6 +2882,10 @@ For example:
 	You can delete a handler in the topic 'system':
 
 		$ kapacitor delete topic-handlers system slack
+
+	You can delete the routing of the topic 'system', so that all its handlers receive all events again:
+
+		$ kapacitor delete topic-routings system
 `
 	fmt.Fprintln(os.Stderr, u)
 }
@@ -2005,8 +3018,74 @@ func doDelete(args []string) error {
 				}
 			}
 		}
+	case "topic-routings":
+		for _, topic := range args[1:] {
+			if err := cli.DeleteTopicRouting(cli.TopicRoutingLink(topic)); err != nil {
+				return err
+			}
+		}
+	case "silences":
+		silences, err := cli.ListSilences()
+		if err != nil {
+			return err
+		}
+		for _, pattern := range args[1:] {
+			for _, s := range silences.Silences {
+				if matched, _ := path.Match(pattern, s.ID); !matched {
+					continue
+				}
+				if err := cli.DeleteSilence(s.Link); err != nil {
+					return err
+				}
+			}
+		}
+	case "inhibitions":
+		inhibitions, err := cli.ListInhibitions()
+		if err != nil {
+			return err
+		}
+		for _, pattern := range args[1:] {
+			for _, i := range inhibitions.Inhibitions {
+				if matched, _ := path.Match(pattern, i.ID); !matched {
+					continue
+				}
+				if err := cli.DeleteInhibition(i.Link); err != nil {
+					return err
+				}
+			}
+		}
+	case "maintenance-windows":
+		windows, err := cli.ListMaintenanceWindows()
+		if err != nil {
+			return err
+		}
+		for _, pattern := range args[1:] {
+			for _, w := range windows.MaintenanceWindows {
+				if matched, _ := path.Match(pattern, w.ID); !matched {
+					continue
+				}
+				if err := cli.DeleteMaintenanceWindow(w.Link); err != nil {
+					return err
+				}
+			}
+		}
+	case "oncall-schedules":
+		schedules, err := cli.ListOnCallSchedules()
+		if err != nil {
+			return err
+		}
+		for _, pattern := range args[1:] {
+			for _, o := range schedules.Schedules {
+				if matched, _ := path.Match(pattern, o.ID); !matched {
+					continue
+				}
+				if err := cli.DeleteOnCallSchedule(o.Link); err != nil {
+					return err
+				}
+			}
+		}
 	default:
-		return fmt.Errorf("cannot delete '%s' did you mean 'tasks', 'templates', 'recordings', 'replays', 'topics' or 'topic-handlers'?", kind)
+		return fmt.Errorf("cannot delete '%s' did you mean 'tasks', 'templates', 'recordings', 'replays', 'topics', 'topic-handlers', 'topic-routings', 'silences', 'inhibitions', 'maintenance-windows' or 'oncall-schedules'?", kind)
 	}
 	return nil
 }
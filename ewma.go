@@ -0,0 +1,181 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/pkg/errors"
+)
+
+type EWMANode struct {
+	node
+	e *pipeline.EWMANode
+
+	// mu protects the state of all groups, since snapshots are taken concurrently.
+	mu sync.Mutex
+	// groups contains the current average of each group.
+	groups map[models.GroupID]*ewmaGroup
+	// restored contains the averages of groups from a snapshot, which have not yet received data.
+	restored map[models.GroupID]ewmaState
+	// expiry drops the restored states of groups that do not receive data again.
+	expiry restoredGroups
+}
+
+// ewmaState is the persisted state of a single group.
+type ewmaState struct {
+	Average float64 `json:"average"`
+}
+
+// Create a new ewma node.
+func newEWMANode(et *ExecutingTask, n *pipeline.EWMANode, l *log.Logger) (*EWMANode, error) {
+	en := &EWMANode{
+		node:   node{Node: n, et: et, logger: l},
+		e:      n,
+		groups: make(map[models.GroupID]*ewmaGroup),
+	}
+	en.node.runF = en.runEWMA
+	return en, nil
+}
+
+func (n *EWMANode) runEWMA(snapshot []byte) error {
+	if err := n.restore(snapshot); err != nil {
+		return err
+	}
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *EWMANode) snapshot() ([]byte, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.expiry.snapshotted() {
+		n.restored = nil
+	}
+	states := make(map[models.GroupID]ewmaState, len(n.groups)+len(n.restored))
+	for id, s := range n.restored {
+		states[id] = s
+	}
+	for id, g := range n.groups {
+		if g.initialized {
+			states[id] = ewmaState{Average: g.average}
+		}
+	}
+	return json.Marshal(states)
+}
+
+func (n *EWMANode) restore(snapshot []byte) error {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := json.Unmarshal(snapshot, &n.restored); err != nil {
+		return errors.Wrap(err, "failed to restore ewma snapshot")
+	}
+	return nil
+}
+
+func (n *EWMANode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup(group.ID)),
+	), nil
+}
+
+func (n *EWMANode) newGroup(id models.GroupID) *ewmaGroup {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.expiry.received()
+	g := &ewmaGroup{
+		n:  n,
+		id: id,
+	}
+	if s, ok := n.restored[id]; ok {
+		g.average = s.Average
+		g.initialized = true
+		delete(n.restored, id)
+	}
+	n.groups[id] = g
+	return g
+}
+
+type ewmaGroup struct {
+	n  *EWMANode
+	id models.GroupID
+
+	average     float64
+	initialized bool
+}
+
+func (g *ewmaGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.n.mu.Lock()
+	g.initialized = false
+	g.n.mu.Unlock()
+	return begin, nil
+}
+
+func (g *ewmaGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if err := g.update(bp); err != nil {
+		g.n.incrementErrorCount()
+		g.n.logger.Println("E! failed to compute ewma:", err)
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *ewmaGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *ewmaGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if err := g.update(p); err != nil {
+		g.n.incrementErrorCount()
+		g.n.logger.Println("E! failed to compute ewma:", err)
+		return nil, nil
+	}
+	return p, nil
+}
+
+// update the average with the value of p and set the average on p.
+func (g *ewmaGroup) update(p edge.FieldsTagsTimeSetter) error {
+	value, ok := numToFloat(p.Fields()[g.n.e.Field])
+	if !ok {
+		return fmt.Errorf("field %q is missing or not numeric", g.n.e.Field)
+	}
+
+	g.n.mu.Lock()
+	if g.initialized {
+		g.average = g.n.e.Alpha*value + (1-g.n.e.Alpha)*g.average
+	} else {
+		g.average = value
+		g.initialized = true
+	}
+	average := g.average
+	g.n.mu.Unlock()
+
+	fields := p.Fields().Copy()
+	fields[g.n.e.As] = average
+	p.SetFields(fields)
+	return nil
+}
+
+func (g *ewmaGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *ewmaGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.n.mu.Lock()
+	delete(g.n.groups, g.id)
+	g.n.mu.Unlock()
+	return d, nil
+}
@@ -1,7 +1,6 @@
 package kapacitor
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -44,6 +43,9 @@ func newHTTPPostNode(et *ExecutingTask, n *pipeline.HTTPPostNode, l *log.Logger)
 		if !ok {
 			return nil, fmt.Errorf("endpoint '%s' does not exist", endpointName)
 		}
+		if n.Template != "" && !e.HasTemplate(n.Template) {
+			return nil, fmt.Errorf("endpoint '%s' has no template '%s'", endpointName, n.Template)
+		}
 		hn.endpoint = e
 	}
 
@@ -110,15 +112,12 @@ func (g *httpPostGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, er
 }
 
 func (n *HTTPPostNode) postRow(row *models.Row) {
-	result := new(models.Result)
-	result.Series = []*models.Row{row}
-
 	body := n.bp.Get()
 	defer n.bp.Put(body)
-	err := json.NewEncoder(body).Encode(result)
+	contentType, err := n.endpoint.RowBody(body, row, n.c.Template, n.c.ContentType)
 	if err != nil {
 		n.incrementErrorCount()
-		n.logger.Printf("E! failed to marshal row data json: %v", err)
+		n.logger.Printf("E! failed to create row data body: %v", err)
 		return
 	}
 	req, err := n.endpoint.NewHTTPRequest(body)
@@ -128,7 +127,7 @@ func (n *HTTPPostNode) postRow(row *models.Row) {
 		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	for k, v := range n.c.Headers {
 		req.Header.Set(k, v)
 	}
@@ -69,6 +69,9 @@ func (n *InfluxQLNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edg
 }
 
 func (n *InfluxQLNode) newGroup(first edge.PointMeta) edge.ForwardReceiver {
+	if n.n.Period != 0 {
+		return newInfluxQLIncrementalGroup(n, first)
+	}
 	bc := baseReduceContext{
 		as:         n.n.As,
 		field:      n.n.Field,
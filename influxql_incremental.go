@@ -0,0 +1,380 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+)
+
+// incrementalReducer keeps a constant size partial aggregate of points.
+// Partial aggregates of the same method and kind can be merged,
+// so that a window is the merge of the panes it covers.
+type incrementalReducer interface {
+	// add a value from the point p.
+	add(value interface{}, p edge.FieldsTagsTimeGetter)
+	// merge the partial aggregate o into the reducer.
+	merge(o incrementalReducer)
+	// emit the aggregate value, if the reducer selected a point it is also returned.
+	// If ok is false nothing should be emitted.
+	emit() (value interface{}, selected edge.FieldsTagsTimeGetter, ok bool)
+}
+
+func newIncrementalReducer(method string, kind reflect.Kind) (incrementalReducer, error) {
+	switch method {
+	case "count":
+		return new(countIncrementalReducer), nil
+	case "sum":
+		switch kind {
+		case reflect.Float64:
+			return new(floatSumIncrementalReducer), nil
+		case reflect.Int64:
+			return new(integerSumIncrementalReducer), nil
+		}
+	case "mean":
+		switch kind {
+		case reflect.Float64:
+			return new(floatMeanIncrementalReducer), nil
+		case reflect.Int64:
+			return new(integerMeanIncrementalReducer), nil
+		}
+	case "min":
+		switch kind {
+		case reflect.Float64:
+			return &floatSelectorIncrementalReducer{less: func(a, b float64) bool { return a < b }}, nil
+		case reflect.Int64:
+			return &integerSelectorIncrementalReducer{less: func(a, b int64) bool { return a < b }}, nil
+		}
+	case "max":
+		switch kind {
+		case reflect.Float64:
+			return &floatSelectorIncrementalReducer{less: func(a, b float64) bool { return a > b }}, nil
+		case reflect.Int64:
+			return &integerSelectorIncrementalReducer{less: func(a, b int64) bool { return a > b }}, nil
+		}
+	default:
+		return nil, fmt.Errorf("cannot aggregate %s incrementally", method)
+	}
+	return nil, fmt.Errorf("cannot apply %s to %v field", method, kind)
+}
+
+type countIncrementalReducer struct {
+	count int64
+}
+
+func (r *countIncrementalReducer) add(interface{}, edge.FieldsTagsTimeGetter) {
+	r.count++
+}
+func (r *countIncrementalReducer) merge(o incrementalReducer) {
+	r.count += o.(*countIncrementalReducer).count
+}
+func (r *countIncrementalReducer) emit() (interface{}, edge.FieldsTagsTimeGetter, bool) {
+	return r.count, nil, true
+}
+
+type floatSumIncrementalReducer struct {
+	sum float64
+}
+
+func (r *floatSumIncrementalReducer) add(v interface{}, _ edge.FieldsTagsTimeGetter) {
+	r.sum += v.(float64)
+}
+func (r *floatSumIncrementalReducer) merge(o incrementalReducer) {
+	r.sum += o.(*floatSumIncrementalReducer).sum
+}
+func (r *floatSumIncrementalReducer) emit() (interface{}, edge.FieldsTagsTimeGetter, bool) {
+	return r.sum, nil, true
+}
+
+type integerSumIncrementalReducer struct {
+	sum int64
+}
+
+func (r *integerSumIncrementalReducer) add(v interface{}, _ edge.FieldsTagsTimeGetter) {
+	r.sum += v.(int64)
+}
+func (r *integerSumIncrementalReducer) merge(o incrementalReducer) {
+	r.sum += o.(*integerSumIncrementalReducer).sum
+}
+func (r *integerSumIncrementalReducer) emit() (interface{}, edge.FieldsTagsTimeGetter, bool) {
+	return r.sum, nil, true
+}
+
+type floatMeanIncrementalReducer struct {
+	sum   float64
+	count int64
+}
+
+func (r *floatMeanIncrementalReducer) add(v interface{}, _ edge.FieldsTagsTimeGetter) {
+	r.sum += v.(float64)
+	r.count++
+}
+func (r *floatMeanIncrementalReducer) merge(o incrementalReducer) {
+	m := o.(*floatMeanIncrementalReducer)
+	r.sum += m.sum
+	r.count += m.count
+}
+func (r *floatMeanIncrementalReducer) emit() (interface{}, edge.FieldsTagsTimeGetter, bool) {
+	if r.count == 0 {
+		return nil, nil, false
+	}
+	return r.sum / float64(r.count), nil, true
+}
+
+type integerMeanIncrementalReducer struct {
+	sum   int64
+	count int64
+}
+
+func (r *integerMeanIncrementalReducer) add(v interface{}, _ edge.FieldsTagsTimeGetter) {
+	r.sum += v.(int64)
+	r.count++
+}
+func (r *integerMeanIncrementalReducer) merge(o incrementalReducer) {
+	m := o.(*integerMeanIncrementalReducer)
+	r.sum += m.sum
+	r.count += m.count
+}
+func (r *integerMeanIncrementalReducer) emit() (interface{}, edge.FieldsTagsTimeGetter, bool) {
+	if r.count == 0 {
+		return nil, nil, false
+	}
+	return float64(r.sum) / float64(r.count), nil, true
+}
+
+// floatSelectorIncrementalReducer selects the point whose value is less than all others,
+// ties are broken by selecting the earliest point, the same as the influxql selectors.
+type floatSelectorIncrementalReducer struct {
+	less     func(a, b float64) bool
+	value    float64
+	selected edge.FieldsTagsTimeGetter
+}
+
+func (r *floatSelectorIncrementalReducer) add(v interface{}, p edge.FieldsTagsTimeGetter) {
+	f := v.(float64)
+	if r.selected == nil || r.less(f, r.value) || (f == r.value && p.Time().Before(r.selected.Time())) {
+		r.value = f
+		r.selected = p
+	}
+}
+func (r *floatSelectorIncrementalReducer) merge(o incrementalReducer) {
+	if s := o.(*floatSelectorIncrementalReducer); s.selected != nil {
+		r.add(s.value, s.selected)
+	}
+}
+func (r *floatSelectorIncrementalReducer) emit() (interface{}, edge.FieldsTagsTimeGetter, bool) {
+	return r.value, r.selected, r.selected != nil
+}
+
+type integerSelectorIncrementalReducer struct {
+	less     func(a, b int64) bool
+	value    int64
+	selected edge.FieldsTagsTimeGetter
+}
+
+func (r *integerSelectorIncrementalReducer) add(v interface{}, p edge.FieldsTagsTimeGetter) {
+	i := v.(int64)
+	if r.selected == nil || r.less(i, r.value) || (i == r.value && p.Time().Before(r.selected.Time())) {
+		r.value = i
+		r.selected = p
+	}
+}
+func (r *integerSelectorIncrementalReducer) merge(o incrementalReducer) {
+	if s := o.(*integerSelectorIncrementalReducer); s.selected != nil {
+		r.add(s.value, s.selected)
+	}
+}
+func (r *integerSelectorIncrementalReducer) emit() (interface{}, edge.FieldsTagsTimeGetter, bool) {
+	return r.value, r.selected, r.selected != nil
+}
+
+// incrementalPane is the partial aggregate of all points within [start, start+paneSize).
+type incrementalPane struct {
+	start time.Time
+	r     incrementalReducer
+}
+
+// influxqlIncrementalGroup windows a stream and aggregates each window incrementally.
+//
+// The window is split into panes the size of the greatest common divisor of the period and every,
+// so that each emitted window is exactly the merge of the panes it covers.
+// Only a partial aggregate per pane is kept instead of every point.
+type influxqlIncrementalGroup struct {
+	n *InfluxQLNode
+
+	name      string
+	groupInfo edge.GroupInfo
+	kind      reflect.Kind
+
+	period   time.Duration
+	every    time.Duration
+	paneSize time.Duration
+	nextEmit time.Time
+
+	// panes ordered by start time
+	panes []incrementalPane
+}
+
+func newInfluxQLIncrementalGroup(n *InfluxQLNode, first edge.PointMeta) *influxqlIncrementalGroup {
+	period := n.n.Period
+	every := n.n.Every
+	if every == 0 {
+		every = period
+	}
+	return &influxqlIncrementalGroup{
+		n:         n,
+		name:      first.Name(),
+		groupInfo: first.GroupInfo(),
+		period:    period,
+		every:     every,
+		paneSize:  gcdDuration(period, every),
+		nextEmit:  first.Time().Add(every).Truncate(every),
+	}
+}
+
+func gcdDuration(a, b time.Duration) time.Duration {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func (g *influxqlIncrementalGroup) BeginBatch(edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, errors.New("incremental aggregation does not support batch data")
+}
+func (g *influxqlIncrementalGroup) BatchPoint(edge.BatchPointMessage) (edge.Message, error) {
+	return nil, errors.New("incremental aggregation does not support batch data")
+}
+func (g *influxqlIncrementalGroup) EndBatch(edge.EndBatchMessage) (edge.Message, error) {
+	return nil, errors.New("incremental aggregation does not support batch data")
+}
+func (g *influxqlIncrementalGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *influxqlIncrementalGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (g *influxqlIncrementalGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	var msg edge.Message
+	// Windows are left aligned [nextEmit - period, nextEmit), the same as the window node.
+	if !p.Time().Before(g.nextEmit) {
+		m, err := g.emit()
+		if err != nil {
+			g.n.incrementErrorCount()
+			g.n.logger.Println("E! failed to emit window:", err)
+		} else if m != nil {
+			msg = m
+		}
+		g.nextEmit = p.Time().Add(g.every).Truncate(g.every)
+		g.purge(g.nextEmit.Add(-g.period))
+	}
+	if err := g.aggregatePoint(p); err != nil {
+		g.n.incrementErrorCount()
+		g.n.logger.Println("E! failed to aggregate point:", err)
+	}
+	return msg, nil
+}
+
+func (g *influxqlIncrementalGroup) aggregatePoint(p edge.PointMessage) error {
+	value, ok := p.Fields()[g.n.n.Field]
+	if !ok {
+		return fmt.Errorf("field %q missing from point", g.n.n.Field)
+	}
+	kind := reflect.TypeOf(value).Kind()
+	if len(g.panes) == 0 {
+		// The kind is only allowed to change once all previous points have expired.
+		g.kind = kind
+	} else if kind != g.kind {
+		return fmt.Errorf("field %q has wrong type: got %v exp %v", g.n.n.Field, kind, g.kind)
+	}
+
+	start := p.Time().Truncate(g.paneSize)
+	if start.Before(g.nextEmit.Add(-g.period)) {
+		// The point is too old to be part of any future window.
+		return nil
+	}
+	// Points mostly arrive in order, so search from the newest pane.
+	i := len(g.panes)
+	for i > 0 && g.panes[i-1].start.After(start) {
+		i--
+	}
+	if i == 0 || !g.panes[i-1].start.Equal(start) {
+		r, err := newIncrementalReducer(g.n.n.Method, kind)
+		if err != nil {
+			return err
+		}
+		g.panes = append(g.panes, incrementalPane{})
+		copy(g.panes[i+1:], g.panes[i:])
+		g.panes[i] = incrementalPane{start: start, r: r}
+		i++
+	}
+	g.panes[i-1].r.add(value, p)
+	return nil
+}
+
+// purge removes all panes that start before oldest.
+func (g *influxqlIncrementalGroup) purge(oldest time.Time) {
+	i := 0
+	for i < len(g.panes) && g.panes[i].start.Before(oldest) {
+		g.panes[i] = incrementalPane{}
+		i++
+	}
+	g.panes = g.panes[i:]
+}
+
+// emit merges the panes of the current window and returns the aggregated point.
+func (g *influxqlIncrementalGroup) emit() (edge.Message, error) {
+	kind := g.kind
+	if len(g.panes) == 0 {
+		if !g.n.n.ReduceCreater.IsEmptyOK {
+			return nil, nil
+		}
+		// Assume float64 type since we do not have any data.
+		kind = reflect.Float64
+	}
+	r, err := newIncrementalReducer(g.n.n.Method, kind)
+	if err != nil {
+		return nil, err
+	}
+	oldest := g.nextEmit.Add(-g.period)
+	for _, pane := range g.panes {
+		if pane.start.Before(oldest) || !pane.start.Before(g.nextEmit) {
+			continue
+		}
+		r.merge(pane.r)
+	}
+	value, selected, ok := r.emit()
+	if !ok {
+		return nil, nil
+	}
+
+	t := g.nextEmit
+	var fields models.Fields
+	var tags models.Tags
+	if selected != nil {
+		if g.n.n.PointTimes {
+			t = selected.Time()
+		}
+		tags = selected.Tags()
+		fields = selected.Fields()
+		if g.n.n.As != g.n.n.Field {
+			fields = fields.Copy()
+			fields[g.n.n.As] = fields[g.n.n.Field]
+			delete(fields, g.n.n.Field)
+		}
+	} else {
+		tags = g.groupInfo.Tags
+		fields = models.Fields{g.n.n.As: value}
+	}
+	return edge.NewPointMessage(
+		g.name, "", "",
+		g.groupInfo.Dimensions,
+		fields,
+		tags,
+		t,
+	), nil
+}
@@ -1627,6 +1627,120 @@ batch
 	testBatcherWithOutput(t, "TestBatch_Flatten", script, 40*time.Second, er, true)
 }
 
+func TestBatch_Pivot(t *testing.T) {
+	var script = `
+batch
+	|query('SELECT value FROM "telegraf"."default"."request_latency"')
+		.period(10s)
+		.every(10s)
+		.groupBy('dc','service')
+	|groupBy('dc')
+	|pivot()
+		.rowKey('dc')
+		.columnKey('service')
+		.tolerance(5s)
+	|httpOut('TestBatch_Pivot')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "request_latency",
+				Tags:    map[string]string{"dc": "A"},
+				Columns: []string{"time", "auth", "cart", "log"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						4.0,
+						8.0,
+						7.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 15, 0, time.UTC),
+						2.0,
+						3.0,
+						1.0,
+					},
+				},
+			},
+			{
+				Name:    "request_latency",
+				Tags:    map[string]string{"dc": "B"},
+				Columns: []string{"time", "auth", "cart", "log"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						9.0,
+						3.0,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 15, 0, time.UTC),
+						6.0,
+						7.0,
+						4.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Pivot", script, 40*time.Second, er, true)
+}
+
+func TestBatch_Unpivot_DimensionColumnKey(t *testing.T) {
+	var script = `
+batch
+	|query('SELECT value FROM "telegraf"."default"."request_latency"')
+		.period(10s)
+		.every(10s)
+		.groupBy('dc','service')
+	|groupBy('dc')
+	|pivot()
+		.rowKey('dc')
+		.columnKey('service')
+		.tolerance(5s)
+	|where(lambda: "dc" == 'A')
+	|unpivot()
+		.columnKey('dc')
+	|httpOut('TestBatch_Unpivot_DimensionColumnKey')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "request_latency",
+				Tags:    map[string]string{"dc": "auth"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 4.0},
+					{time.Date(1971, 1, 1, 0, 0, 15, 0, time.UTC), 2.0},
+				},
+			},
+			{
+				Name:    "request_latency",
+				Tags:    map[string]string{"dc": "cart"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 8.0},
+					{time.Date(1971, 1, 1, 0, 0, 15, 0, time.UTC), 3.0},
+				},
+			},
+			{
+				Name:    "request_latency",
+				Tags:    map[string]string{"dc": "log"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 7.0},
+					{time.Date(1971, 1, 1, 0, 0, 15, 0, time.UTC), 1.0},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Unpivot_DimensionColumnKey", script, 40*time.Second, er, true)
+}
+
 func TestBatch_Combine_All(t *testing.T) {
 	var script = `
 batch
@@ -2981,7 +3095,7 @@ func testBatcher(t *testing.T, name, script string) (clock.Setter, *kapacitor.Ex
 	tm.TaskStore = taskStore{}
 	tm.DeadmanService = deadman{}
 	tm.HTTPPostService = httppost.NewService(nil, logService.NewLogger("[httppost] ", log.LstdFlags))
-	as := alertservice.NewService(logService.NewLogger("[alert] ", log.LstdFlags))
+	as := alertservice.NewService(alertservice.NewConfig(), logService.NewLogger("[alert] ", log.LstdFlags))
 	as.StorageService = storagetest.New()
 	as.HTTPDService = httpdService
 	if err := as.Open(); err != nil {
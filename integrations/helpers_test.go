@@ -160,6 +160,20 @@ func (ts taskStore) LoadSnapshot(name string) (*kapacitor.TaskSnapshot, error) {
 	return nil, errors.New("not implemented")
 }
 
+// snapshotTaskStore keeps the last saved snapshot and loads it for any task.
+type snapshotTaskStore struct {
+	snapshot *kapacitor.TaskSnapshot
+}
+
+func (ts *snapshotTaskStore) SaveSnapshot(name string, snapshot *kapacitor.TaskSnapshot) error {
+	ts.snapshot = snapshot
+	return nil
+}
+func (ts *snapshotTaskStore) HasSnapshot(name string) bool { return ts.snapshot != nil }
+func (ts *snapshotTaskStore) LoadSnapshot(name string) (*kapacitor.TaskSnapshot, error) {
+	return ts.snapshot, nil
+}
+
 type deadman struct {
 	interval  time.Duration
 	threshold float64
@@ -2,10 +2,14 @@ package integrations
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/mail"
@@ -13,6 +17,8 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"text/template"
@@ -31,6 +37,12 @@ import (
 	"github.com/influxdata/kapacitor/services/alert/alerttest"
 	"github.com/influxdata/kapacitor/services/alerta"
 	"github.com/influxdata/kapacitor/services/alerta/alertatest"
+	"github.com/influxdata/kapacitor/services/alertmanager"
+	"github.com/influxdata/kapacitor/services/alertmanager/alertmanagertest"
+	"github.com/influxdata/kapacitor/services/amqp"
+	"github.com/influxdata/kapacitor/services/amqp/amqptest"
+	"github.com/influxdata/kapacitor/services/discord"
+	"github.com/influxdata/kapacitor/services/discord/discordtest"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/hipchat/hipchattest"
 	"github.com/influxdata/kapacitor/services/httppost"
@@ -41,6 +53,8 @@ import (
 	"github.com/influxdata/kapacitor/services/opsgenie/opsgenietest"
 	"github.com/influxdata/kapacitor/services/pagerduty"
 	"github.com/influxdata/kapacitor/services/pagerduty/pagerdutytest"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
+	"github.com/influxdata/kapacitor/services/pagerduty2/pagerduty2test"
 	"github.com/influxdata/kapacitor/services/pushover"
 	"github.com/influxdata/kapacitor/services/pushover/pushovertest"
 	"github.com/influxdata/kapacitor/services/sensu"
@@ -54,6 +68,8 @@ import (
 	"github.com/influxdata/kapacitor/services/storage/storagetest"
 	"github.com/influxdata/kapacitor/services/talk"
 	"github.com/influxdata/kapacitor/services/talk/talktest"
+	"github.com/influxdata/kapacitor/services/teams"
+	"github.com/influxdata/kapacitor/services/teams/teamstest"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/telegram/telegramtest"
 	"github.com/influxdata/kapacitor/services/victorops"
@@ -150,6 +166,343 @@ stream
 	testStreamerWithOutput(t, "TestStream_Derivative", script, 15*time.Second, er, false, nil)
 }
 
+func TestStream_RateEWMA(t *testing.T) {
+
+	var script = `
+stream
+	|from().measurement('packets')
+	|rate('value')
+		.nonNegative()
+		.as('rate')
+	|ewma('value')
+		.alpha(0.5)
+		.as('ewma')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Rate')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    nil,
+				Columns: []string{"time", "ewma", "rate", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						110.0,
+						10.0,
+						110.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						120.0,
+						20.0,
+						130.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						62.5,
+						// Counter reset
+						5.0,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						43.75,
+						20.0,
+						25.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						44.375,
+						20.0,
+						45.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Rate", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_RateEWMA_Snapshot(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('packets')
+		.groupBy('host')
+	|ewma('value')
+		.alpha(0.5)
+		.as('ewma')
+	|rate('value')
+		.nonNegative()
+		.as('rate')
+	|httpOut('TestStream_RateEWMA_Snapshot')
+`
+	store := new(snapshotTaskStore)
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.TaskStore = store
+	}
+
+	// Snapshot the task after the first points.
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_RateEWMA_Snapshot", script, tmInit)
+	if err := fastForwardTask(clock, et, replayErr, tm, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := et.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Close()
+	store.SaveSnapshot("TestStream_RateEWMA_Snapshot", snapshot)
+
+	// Restore the snapshot into a new task and continue the stream.
+	// Without the snapshot the first point of each group would be dropped by rate.
+	// The replay starts at the same time as the first one,
+	// the data begins with a point of another measurement at the time of the first point.
+	clock, et, replayErr, tm = testStreamer(t, "TestStream_RateEWMA_Restore", script, tmInit)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	output, err := et.GetOutput("TestStream_RateEWMA_Snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    models.Tags{"host": "a"},
+				Columns: []string{"time", "ewma", "rate", "value"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					67.5,
+					// Counter reset
+					20.0,
+					20.0,
+				}},
+			},
+			{
+				Name:    "packets",
+				Tags:    models.Tags{"host": "b"},
+				Columns: []string{"time", "ewma", "rate", "value"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					65.0,
+					15.0,
+					80.0,
+				}},
+			},
+		},
+	}
+	if eq, msg := compareResultsIgnoreSeriesOrder(er, result); !eq {
+		t.Error(msg)
+	}
+
+	// Group c never receives data again,
+	// its state is kept for one snapshot interval after data resumed and dropped afterwards.
+	hasGroupC := func() bool {
+		snapshot, err := et.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, data := range snapshot.NodeSnapshots {
+			if strings.Contains(string(data), "host=c") {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasGroupC() {
+		t.Error("expected the first snapshot to keep the restored state of group c")
+	}
+	if hasGroupC() {
+		t.Error("expected the second snapshot to drop the restored state of group c")
+	}
+}
+
+func TestStream_Baseline(t *testing.T) {
+
+	var script = `
+stream
+	|from().measurement('requests')
+	|baseline('value')
+		.season(8s)
+		.bucket(4s)
+		.history(1)
+		.sigma(2.0)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Baseline')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "baseline", "lower", "service", "upper", "value", "zscore"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						10.0,
+						6.0,
+						"api",
+						14.0,
+						14.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						10.0,
+						6.0,
+						"api",
+						14.0,
+						4.0,
+						-3.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Baseline", script, 20*time.Second, er, false, nil)
+}
+
+func TestStream_Baseline_Median(t *testing.T) {
+
+	var script = `
+stream
+	|from().measurement('requests')
+	|baseline('value')
+		.season(4s)
+		.bucket(4s)
+		.history(2)
+		.median()
+		.minSpread(1.0)
+	|window()
+		.period(4s)
+		.every(4s)
+	|httpOut('TestStream_Baseline_Median')
+`
+	// The outlier in the history does not move the median,
+	// and the flat history is scored using the minimum spread.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "baseline", "lower", "service", "upper", "value", "zscore"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						10.0,
+						7.0,
+						"api",
+						13.0,
+						10.0,
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						10.0,
+						7.0,
+						"api",
+						13.0,
+						12.0,
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Baseline_Median", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_Baseline_Snapshot(t *testing.T) {
+	var script = `
+stream
+	|from().measurement('requests')
+	|baseline('value')
+		.season(4s)
+		.bucket(4s)
+		.history(2)
+	|httpOut('TestStream_Baseline_Snapshot')
+`
+	store := new(snapshotTaskStore)
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.TaskStore = store
+	}
+
+	// Learn two seasons and snapshot the task.
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_Baseline_Snapshot", script, tmInit)
+	if err := fastForwardTask(clock, et, replayErr, tm, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := et.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Close()
+	store.SaveSnapshot("TestStream_Baseline_Snapshot", snapshot)
+
+	// Restore the snapshot into a new task, the baseline is compared against the learned history.
+	// Without the snapshot the point would be dropped for lack of history.
+	// The data begins with a point of another measurement so that the replay starts at the same time as the first one.
+	clock, et, replayErr, tm = testStreamer(t, "TestStream_Baseline_Restore", script, tmInit)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	output, err := et.GetOutput("TestStream_Baseline_Snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    models.Tags{"service": "api"},
+				Columns: []string{"time", "baseline", "lower", "upper", "value", "zscore"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+					10.0,
+					4.0,
+					16.0,
+					14.0,
+					2.0,
+				}},
+			},
+		},
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+}
+
 func TestStream_DerivativeAs(t *testing.T) {
 
 	var script = `
@@ -2563,6 +2916,81 @@ stream
 	}
 }
 
+func TestStream_HttpPostTemplate(t *testing.T) {
+	var mu sync.Mutex
+	var bodies, contentTypes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|httpPost()
+	  .endpoint('test')
+	  .contentType('text/plain')
+	|httpOut('TestStream_HttpPost')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "type": "idle"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					95.8,
+				}},
+			},
+		},
+	}
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := httppost.Config{}
+		c.URL = ts.URL
+		c.Endpoint = "test"
+		c.Templates = map[string]string{
+			"line": `{{.Name}},host={{index .Tags "host"}} value={{index (index .Values 0) 1}}`,
+		}
+		c.RowTemplate = "line"
+		sl := httppost.NewService(httppost.Configs{c}, logService.NewLogger("[test_httppost_template] ", log.LstdFlags))
+		tm.HTTPPostService = sl
+	}
+
+	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, tmInit)
+
+	expBodies := []string{
+		"cpu,host=serverA value=97.1",
+		"cpu,host=serverA value=92.6",
+		"cpu,host=serverA value=95.6",
+		"cpu,host=serverA value=93.1",
+		"cpu,host=serverA value=92.6",
+		"cpu,host=serverA value=95.8",
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(bodies, expBodies) {
+		t.Errorf("unexpected bodies:\ngot %v\nexp %v", bodies, expBodies)
+	}
+	for _, ct := range contentTypes {
+		if ct != "text/plain" {
+			t.Errorf("unexpected content type: got %q exp %q", ct, "text/plain")
+		}
+	}
+}
+
 func TestStream_HttpOutPassThrough(t *testing.T) {
 
 	var script = `
@@ -3112,13 +3540,117 @@ stream
 	testStreamerWithOutput(t, "TestStream_Flatten", script, 13*time.Second, er, true, nil)
 }
 
-func TestStream_FlattenDropOriginalFieldName(t *testing.T) {
+func TestStream_Pivot(t *testing.T) {
 	var script = `
 stream
 	|from()
 		.measurement('request_latency')
 		.groupBy('dc')
-	|flatten()
+	|pivot()
+		.rowKey('host')
+		.columnKey('service')
+		.valueField('value')
+		.tolerance(1s)
+	|groupBy('dc', 'host')
+	|httpOut('TestStream_Pivot')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "request_latency",
+				Tags:    map[string]string{"dc": "A", "host": "server01"},
+				Columns: []string{"time", "auth", "cart", "log"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					700.0,
+					800.0,
+					600.0,
+				}},
+			},
+			{
+				Name:    "request_latency",
+				Tags:    map[string]string{"dc": "A", "host": "server02"},
+				Columns: []string{"time", "auth", "cart", "log"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					702.0,
+					802.0,
+					602.0,
+				}},
+			},
+			{
+				Name:    "request_latency",
+				Tags:    map[string]string{"dc": "B", "host": "server01"},
+				Columns: []string{"time", "auth", "cart", "log"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					750.0,
+					850.0,
+					650.0,
+				}},
+			},
+			{
+				Name:    "request_latency",
+				Tags:    map[string]string{"dc": "B", "host": "server02"},
+				Columns: []string{"time", "auth", "cart", "log"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					752.0,
+					852.0,
+					652.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Pivot", script, 13*time.Second, er, true, nil)
+}
+
+func TestStream_Unpivot(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|unpivot()
+		.columnKey('state')
+	|groupBy('host', 'state')
+	|httpOut('TestStream_Unpivot')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "state": "system"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					5.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "state": "user"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					10.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Unpivot", script, 13*time.Second, er, true, nil)
+}
+
+func TestStream_FlattenDropOriginalFieldName(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('request_latency')
+		.groupBy('dc')
+	|flatten()
 		.on('service', 'host')
 		.tolerance(1s)
 		.dropOriginalFieldName()
@@ -4966,6 +5498,39 @@ stream
 				},
 			},
 		},
+		testCase{
+			Method: "approxPercentile",
+			Args:   "'value', 50.0",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "approxPercentile"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							94.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "approxCountDistinct",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "approxCountDistinct"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							6.0,
+						}},
+					},
+				},
+			},
+		},
 		testCase{
 			Method:        "top",
 			UsePointTimes: true,
@@ -5106,12 +5671,45 @@ stream
 	}
 }
 
-func TestStream_InfluxQL_Integer(t *testing.T) {
+func TestStream_ApproxPercentileFromSketch(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(5s)
+		.every(5s)
+	|approxPercentile('value', 50.0)
+		.sketch()
+		.as('value')
+	|approxPercentile('value', 50.0)
+		.fromSketch()
+	|httpOut('TestStream_ApproxPercentileFromSketch')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    models.Tags{"host": "serverA"},
+				Columns: []string{"time", "approxPercentile"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					93.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_ApproxPercentileFromSketch", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_InfluxQL_Incremental(t *testing.T) {
+
 	type testCase struct {
-		Method        string
-		Args          string
-		ER            models.Result
-		UsePointTimes bool
+		Method string
+		ER     models.Result
 	}
 
 	var scriptTmpl = `
@@ -5120,12 +5718,10 @@ stream
 		.measurement('cpu')
 		.where(lambda: "host" == 'serverA')
 		.groupBy('host')
-	|window()
+	|{{ .Method }}('value')
 		.period(10s)
-		.every(10s)
-	|{{ .Method }}({{ .Args }})
-		{{ if .UsePointTimes }}.usePointTimes(){{ end }}
-	|httpOut('TestStream_InfluxQL_Integer')
+		.every(5s)
+	|httpOut('TestStream_InfluxQL_Float')
 `
 	endTime := time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)
 	testCases := []testCase{
@@ -5161,44 +5757,6 @@ stream
 				},
 			},
 		},
-		testCase{
-			Method: "distinct",
-			ER: models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    models.Tags{"host": "serverA"},
-						Columns: []string{"time", "distinct"},
-						Values: [][]interface{}{
-							{
-								endTime,
-								98.0,
-							},
-							{
-								endTime,
-								91.0,
-							},
-							{
-								endTime,
-								95.0,
-							},
-							{
-								endTime,
-								93.0,
-							},
-							{
-								endTime,
-								92.0,
-							},
-							{
-								endTime,
-								96.0,
-							},
-						},
-					},
-				},
-			},
-		},
 		testCase{
 			Method: "mean",
 			ER: models.Result{
@@ -5215,62 +5773,13 @@ stream
 				},
 			},
 		},
-		testCase{
-			Method: "median",
-			ER: models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    models.Tags{"host": "serverA"},
-						Columns: []string{"time", "median"},
-						Values: [][]interface{}{[]interface{}{
-							endTime,
-							94.0,
-						}},
-					},
-				},
-			},
-		},
-		testCase{
-			Method: "mode",
-			ER: models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    models.Tags{"host": "serverA"},
-						Columns: []string{"time", "mode"},
-						Values: [][]interface{}{[]interface{}{
-							endTime,
-							95.0,
-						}},
-					},
-				},
-			},
-		},
-		testCase{
-			Method:        "min",
-			UsePointTimes: true,
-			ER: models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
-						Columns: []string{"time", "min"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
-							91.0,
-						}},
-					},
-				},
-			},
-		},
 		testCase{
 			Method: "min",
 			ER: models.Result{
 				Series: models.Rows{
 					{
 						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Tags:    models.Tags{"host": "serverA", "type": "idle"},
 						Columns: []string{"time", "min"},
 						Values: [][]interface{}{[]interface{}{
 							endTime,
@@ -5280,30 +5789,13 @@ stream
 				},
 			},
 		},
-		testCase{
-			Method:        "max",
-			UsePointTimes: true,
-			ER: models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
-						Columns: []string{"time", "max"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-							98.0,
-						}},
-					},
-				},
-			},
-		},
 		testCase{
 			Method: "max",
 			ER: models.Result{
 				Series: models.Rows{
 					{
 						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Tags:    models.Tags{"host": "serverA", "type": "idle"},
 						Columns: []string{"time", "max"},
 						Values: [][]interface{}{[]interface{}{
 							endTime,
@@ -5313,8 +5805,315 @@ stream
 				},
 			},
 		},
-		testCase{
-			Method: "spread",
+	}
+
+	tmpl, err := template.New("script").Parse(scriptTmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range testCases {
+		t.Log("Method:", tc.Method)
+		var script bytes.Buffer
+		tmpl.Execute(&script, tc)
+		testStreamerWithOutput(
+			t,
+			"TestStream_InfluxQL_Float",
+			script.String(),
+			13*time.Second,
+			tc.ER,
+			false,
+			nil,
+		)
+	}
+}
+
+func TestStream_InfluxQL_Incremental_Equivalence(t *testing.T) {
+	// collect returns the results posted by a task, in order.
+	collect := func(script string) []models.Result {
+		var mu sync.Mutex
+		var results []models.Result
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result := models.Result{}
+			if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+				t.Error(err)
+			}
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}))
+		defer ts.Close()
+
+		name := "TestStream_InfluxQL_Incremental_Equivalence"
+		clock, et, replayErr, tm := testStreamer(t, name, strings.Replace(script, "URL", ts.URL, 1), nil)
+		defer tm.Close()
+		if err := fastForwardTask(clock, et, replayErr, tm, 62*time.Second); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return results
+	}
+
+	windows := []struct {
+		period, every string
+	}{
+		{period: "10s", every: "5s"},
+		{period: "7s", every: "3s"},
+		{period: "4s", every: "6s"},
+		{period: "9s", every: "9s"},
+	}
+	methods := []string{"mean", "sum", "count", "min", "max"}
+	for _, w := range windows {
+		for _, method := range methods {
+			incremental := collect(fmt.Sprintf(`
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|%s('value')
+		.period(%s)
+		.every(%s)
+	|httpPost('URL')
+`, method, w.period, w.every))
+			buffered := collect(fmt.Sprintf(`
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.period(%s)
+		.every(%s)
+		.align()
+	|%s('value')
+	|httpPost('URL')
+`, w.period, w.every, method))
+
+			if len(buffered) == 0 {
+				t.Fatalf("%s period %s every %s: no windows were emitted", method, w.period, w.every)
+			}
+			if got, exp := len(incremental), len(buffered); got != exp {
+				t.Errorf("%s period %s every %s: unexpected number of windows: got %d exp %d", method, w.period, w.every, got, exp)
+				continue
+			}
+			for i := range buffered {
+				if eq, msg := compareResultsIgnoreSeriesOrder(buffered[i], incremental[i]); !eq {
+					t.Errorf("%s period %s every %s: window %d: %s", method, w.period, w.every, i, msg)
+				}
+			}
+		}
+	}
+}
+
+func TestStream_InfluxQL_Integer(t *testing.T) {
+	type testCase struct {
+		Method        string
+		Args          string
+		ER            models.Result
+		UsePointTimes bool
+	}
+
+	var scriptTmpl = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|{{ .Method }}({{ .Args }})
+		{{ if .UsePointTimes }}.usePointTimes(){{ end }}
+	|httpOut('TestStream_InfluxQL_Integer')
+`
+	endTime := time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC)
+	testCases := []testCase{
+		testCase{
+			Method: "sum",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "sum"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							940.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "count",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "count"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							10.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "distinct",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "distinct"},
+						Values: [][]interface{}{
+							{
+								endTime,
+								98.0,
+							},
+							{
+								endTime,
+								91.0,
+							},
+							{
+								endTime,
+								95.0,
+							},
+							{
+								endTime,
+								93.0,
+							},
+							{
+								endTime,
+								92.0,
+							},
+							{
+								endTime,
+								96.0,
+							},
+						},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "mean",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "mean"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							94.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "median",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "median"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							94.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "mode",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    models.Tags{"host": "serverA"},
+						Columns: []string{"time", "mode"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							95.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method:        "min",
+			UsePointTimes: true,
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "min"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+							91.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "min",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "min"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							91.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method:        "max",
+			UsePointTimes: true,
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "max"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+							98.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "max",
+			ER: models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "max"},
+						Values: [][]interface{}{[]interface{}{
+							endTime,
+							98.0,
+						}},
+					},
+				},
+			},
+		},
+		testCase{
+			Method: "spread",
 			ER: models.Result{
 				Series: models.Rows{
 					{
@@ -7331,7 +8130,7 @@ stream
 		c.Enabled = true
 		c.URL = ts.URL + "/test/slack/url"
 		c.Channel = "#channel"
-		sl, err := slack.NewService(c, logService.NewLogger("[test_slack] ", log.LstdFlags))
+		sl, err := slack.NewService(slack.Configs{c}, logService.NewLogger("[test_slack] ", log.LstdFlags))
 		if err != nil {
 			t.Error(err)
 		}
@@ -7385,8 +8184,273 @@ stream
 	}
 }
 
-func TestStream_AlertTelegram(t *testing.T) {
-	ts := telegramtest.NewServer()
+func TestStream_AlertSlackWorkspaces(t *testing.T) {
+	ts := slacktest.NewServer()
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.slack()
+		.slack()
+		.workspace('ops')
+`
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		dev := slack.NewConfig()
+		dev.Enabled = true
+		dev.Workspace = "dev"
+		dev.Default = true
+		dev.URL = ts.URL + "/test/slack/dev"
+		dev.Channel = "#dev"
+		ops := slack.NewConfig()
+		ops.Enabled = true
+		ops.Workspace = "ops"
+		ops.URL = ts.URL + "/test/slack/ops"
+		ops.Channel = "#ops"
+		ops.Username = "ops-bot"
+		sl, err := slack.NewService(slack.Configs{dev, ops}, logService.NewLogger("[test_slack] ", log.LstdFlags))
+		if err != nil {
+			t.Error(err)
+		}
+		tm.SlackService = sl
+	}
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+	exp := []interface{}{
+		slacktest.Request{
+			URL: "/test/slack/dev",
+			PostData: slacktest.PostData{
+				Channel:  "#dev",
+				Username: "kapacitor",
+				Text:     "",
+				Attachments: []slacktest.Attachment{
+					{
+						Fallback:  "kapacitor/cpu/serverA is CRITICAL",
+						Color:     "danger",
+						Text:      "kapacitor/cpu/serverA is CRITICAL",
+						Mrkdwn_in: []string{"text"},
+					},
+				},
+			},
+		},
+		slacktest.Request{
+			URL: "/test/slack/ops",
+			PostData: slacktest.PostData{
+				Channel:  "#ops",
+				Username: "ops-bot",
+				Text:     "",
+				Attachments: []slacktest.Attachment{
+					{
+						Fallback:  "kapacitor/cpu/serverA is CRITICAL",
+						Color:     "danger",
+						Text:      "kapacitor/cpu/serverA is CRITICAL",
+						Mrkdwn_in: []string{"text"},
+					},
+				},
+			},
+		},
+	}
+
+	ts.Close()
+	var got []interface{}
+	for _, g := range ts.Requests() {
+		got = append(got, g)
+	}
+
+	if err := compareListIgnoreOrder(got, exp, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStream_AlertTeams(t *testing.T) {
+	ts := teamstest.NewServer()
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.teams()
+		.teams()
+			.channelURL('` + ts.URL + `/test/teams/other')
+			.cardType('AdaptiveCard')
+`
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := teams.NewConfig()
+		c.Enabled = true
+		c.ChannelURL = ts.URL + "/test/teams/url"
+		tm.TeamsService = teams.NewService(teams.Configs{c}, logService.NewLogger("[test_teams] ", log.LstdFlags))
+	}
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+	exp := []interface{}{
+		teamstest.Request{
+			URL: "/test/teams/url",
+			PostData: teamstest.PostData{
+				CardType:   "MessageCard",
+				Context:    "http://schema.org/extensions",
+				ThemeColor: "CC4A31",
+				Summary:    "kapacitor/cpu/serverA is CRITICAL",
+				Title:      "kapacitor/cpu/serverA",
+				Text:       "kapacitor/cpu/serverA is CRITICAL",
+			},
+		},
+		teamstest.Request{
+			URL: "/test/teams/other",
+			PostData: teamstest.PostData{
+				Type: "message",
+				Attachments: []teamstest.Attachment{{
+					ContentType: "application/vnd.microsoft.card.adaptive",
+					Content: teamstest.AdaptiveCard{
+						Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+						Type:    "AdaptiveCard",
+						Version: "1.2",
+						Body: []teamstest.Element{{
+							Type:  "Container",
+							Style: "attention",
+							Items: []teamstest.Element{
+								{
+									Type:   "TextBlock",
+									Text:   "kapacitor/cpu/serverA",
+									Weight: "Bolder",
+									Size:   "Medium",
+									Color:  "attention",
+									Wrap:   true,
+								},
+								{
+									Type: "TextBlock",
+									Text: "kapacitor/cpu/serverA is CRITICAL",
+									Wrap: true,
+								},
+							},
+						}},
+					},
+				}},
+			},
+		},
+	}
+
+	ts.Close()
+	var got []interface{}
+	for _, g := range ts.Requests() {
+		got = append(got, g)
+	}
+
+	if err := compareListIgnoreOrder(got, exp, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStream_AlertDiscord(t *testing.T) {
+	ts := discordtest.NewServer()
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.discord()
+		.discord()
+			.workspace('community')
+			.username('kapacitor')
+			.embedTitle('CPU')
+`
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		cs := discord.Configs{
+			{
+				Enabled:   true,
+				Workspace: "ops",
+				Default:   true,
+				URL:       ts.URL + "/test/discord/ops",
+				AvatarURL: "http://example.com/avatar.png",
+			},
+			{
+				Enabled:   true,
+				Workspace: "community",
+				URL:       ts.URL + "/test/discord/community",
+			},
+		}
+		tm.DiscordService = discord.NewService(cs, logService.NewLogger("[test_discord] ", log.LstdFlags))
+	}
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+	exp := []interface{}{
+		discordtest.Request{
+			URL: "/test/discord/ops",
+			PostData: discordtest.PostData{
+				AvatarURL: "http://example.com/avatar.png",
+				Embeds: []discordtest.Embed{{
+					Title:       "kapacitor/cpu/serverA",
+					Description: "kapacitor/cpu/serverA is CRITICAL",
+					Color:       0xF95F53,
+					Timestamp:   "1971-01-01T00:00:10Z",
+				}},
+			},
+		},
+		discordtest.Request{
+			URL: "/test/discord/community",
+			PostData: discordtest.PostData{
+				Username: "kapacitor",
+				Embeds: []discordtest.Embed{{
+					Title:       "CPU",
+					Description: "kapacitor/cpu/serverA is CRITICAL",
+					Color:       0xF95F53,
+					Timestamp:   "1971-01-01T00:00:10Z",
+				}},
+			},
+		},
+	}
+
+	ts.Close()
+	var got []interface{}
+	for _, g := range ts.Requests() {
+		got = append(got, g)
+	}
+
+	if err := compareListIgnoreOrder(got, exp, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStream_AlertTelegram(t *testing.T) {
+	ts := telegramtest.NewServer()
 	defer ts.Close()
 
 	var script = `
@@ -7419,7 +8483,7 @@ stream
 		c.ChatId = "123456789"
 		c.DisableWebPagePreview = true
 		c.DisableNotification = false
-		tl := telegram.NewService(c, logService.NewLogger("[test_telegram] ", log.LstdFlags))
+		tl := telegram.NewService(telegram.Configs{c}, logService.NewLogger("[test_telegram] ", log.LstdFlags))
 		tm.TelegramService = tl
 	}
 	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
@@ -7518,6 +8582,46 @@ stream
 	}
 }
 
+func TestStream_AlertSyslog(t *testing.T) {
+	ts, err := alerttest.NewSyslogServer("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.syslog('` + ts.Addr + `')
+			.network('tcp')
+			.facility('local0')
+			.hostname('kapacitor-test')
+`
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, nil)
+
+	exp := []string{
+		`<130>1 1971-01-01T00:00:10Z kapacitor-test kapacitor - - [kapacitor@32473 host="serverA"] kapacitor.cpu.serverA is CRITICAL`,
+	}
+
+	ts.Close()
+	got := ts.Messages()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected syslog messages:\ngot\n%q\nexp\n%q\n", got, exp)
+	}
+}
+
 func TestStream_AlertHipChat(t *testing.T) {
 	ts := hipchattest.NewServer()
 	defer ts.Close()
@@ -7551,7 +8655,7 @@ stream
 		c.URL = ts.URL
 		c.Room = "1231234"
 		c.Token = "testtoken1231234"
-		sl := hipchat.NewService(c, logService.NewLogger("[test_hipchat] ", log.LstdFlags))
+		sl := hipchat.NewService(hipchat.Configs{c}, logService.NewLogger("[test_hipchat] ", log.LstdFlags))
 		tm.HipChatService = sl
 	}
 	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
@@ -7708,7 +8812,7 @@ stream
 		c.URL = ts.URL
 		c.UserKey = "user"
 		c.Token = "KzGDORePKggMaC0QOYAMyEEuzJnyUi"
-		sl := pushover.NewService(c, logService.NewLogger("[test_pushover] ", log.LstdFlags))
+		sl := pushover.NewService(pushover.Configs{c}, logService.NewLogger("[test_pushover] ", log.LstdFlags))
 		tm.PushoverService = sl
 	}
 	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
@@ -7780,7 +8884,7 @@ stream
 		c.Enabled = true
 		c.URL = ts.URL
 		c.APIKey = "api_key"
-		og := opsgenie.NewService(c, logService.NewLogger("[test_og] ", log.LstdFlags))
+		og := opsgenie.NewService(opsgenie.Configs{c}, logService.NewLogger("[test_og] ", log.LstdFlags))
 		tm.OpsGenieService = og
 	}
 	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
@@ -7865,7 +8969,7 @@ stream
 		c.Enabled = true
 		c.URL = ts.URL
 		c.ServiceKey = "service_key"
-		pd := pagerduty.NewService(c, logService.NewLogger("[test_pd] ", log.LstdFlags))
+		pd := pagerduty.NewService(pagerduty.Configs{c}, logService.NewLogger("[test_pd] ", log.LstdFlags))
 		pd.HTTPDService = tm.HTTPDService
 		tm.PagerDutyService = pd
 
@@ -7909,6 +9013,257 @@ stream
 	}
 }
 
+func TestStream_AlertPagerDuty2(t *testing.T) {
+	ts := pagerduty2test.NewServer()
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|eval(lambda: sigma("value"))
+		.as('sigma')
+		.keep()
+	|alert()
+		.id('cpu:{{ index .Tags "host" }}')
+		.info(lambda: "sigma" > 2.0)
+		.warn(lambda: "sigma" > 3.0)
+		.crit(lambda: "sigma" > 3.5)
+		.pagerDuty2()
+			.routingKey('test_override_key')
+			.link('http://example.com/cpu', 'CPU')
+			.image('http://example.com/cpu.png', '', 'CPU usage')
+`
+
+	var kapacitorURL string
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := pagerduty2.NewConfig()
+		c.Enabled = true
+		c.URL = ts.URL
+		c.RoutingKey = "routing_key"
+		pd := pagerduty2.NewService(pagerduty2.Configs{c}, logService.NewLogger("[test_pd2] ", log.LstdFlags))
+		pd.HTTPDService = tm.HTTPDService
+		tm.PagerDuty2Service = pd
+
+		kapacitorURL = tm.HTTPDService.URL()
+	}
+	testStreamerNoOutput(t, "TestStream_AlertSigma", script, 13*time.Second, tmInit)
+
+	links := []pagerduty2test.Link{{Href: "http://example.com/cpu", Text: "CPU"}}
+	images := []pagerduty2test.Image{{Src: "http://example.com/cpu.png", Alt: "CPU usage"}}
+	exp := []pagerduty2test.Request{
+		{
+			URL: "/",
+			PostData: pagerduty2test.PostData{
+				RoutingKey:  "test_override_key",
+				EventAction: "trigger",
+				DedupKey:    "cpu:serverA",
+				Payload: &pagerduty2test.Payload{
+					Summary:   "cpu:serverA is INFO",
+					Source:    "serverA",
+					Severity:  "info",
+					Timestamp: "1971-01-01T00:00:07Z",
+					CustomDetails: map[string]interface{}{
+						"sigma": 2.469916402324427,
+						"value": 16.0,
+					},
+				},
+				Client:    "kapacitor",
+				ClientURL: kapacitorURL,
+				Links:     links,
+				Images:    images,
+			},
+		},
+		{
+			URL: "/",
+			PostData: pagerduty2test.PostData{
+				RoutingKey:  "test_override_key",
+				EventAction: "resolve",
+				DedupKey:    "cpu:serverA",
+				Client:      "kapacitor",
+				ClientURL:   kapacitorURL,
+				Links:       links,
+				Images:      images,
+			},
+		},
+	}
+
+	ts.Close()
+	got := ts.Requests()
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("unexpected pagerduty2 requests:\nexp\n%+v\ngot\n%+v\n", exp, got)
+	}
+}
+
+func TestStream_AlertAlertManager(t *testing.T) {
+	ts := alertmanagertest.NewServer()
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|eval(lambda: sigma("value"))
+		.as('sigma')
+		.keep()
+	|alert()
+		.id('cpu:{{ index .Tags "host" }}')
+		.info(lambda: "sigma" > 2.0)
+		.warn(lambda: "sigma" > 3.0)
+		.crit(lambda: "sigma" > 3.5)
+		.details('')
+		.alertManager()
+			.label('team', 'ops')
+			.label('level', 'ignored')
+`
+
+	var kapacitorURL string
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := alertmanager.NewConfig()
+		c.Enabled = true
+		c.URL = ts.URL + "/api/v1/alerts"
+		am := alertmanager.NewService(alertmanager.Configs{c}, logService.NewLogger("[test_alertmanager] ", log.LstdFlags))
+		am.HTTPDService = tm.HTTPDService
+		tm.AlertManagerService = am
+
+		kapacitorURL = tm.HTTPDService.URL()
+	}
+	testStreamerNoOutput(t, "TestStream_AlertSigma", script, 13*time.Second, tmInit)
+
+	ts.Close()
+	got := ts.Requests()
+	// Active alerts end in the future relative to when they were sent.
+	now := time.Now()
+	for _, r := range got {
+		for i := range r.PostData {
+			if r.PostData[i].EndsAt.After(now) {
+				r.PostData[i].EndsAt = time.Time{}
+			}
+		}
+	}
+
+	labels := map[string]string{
+		"alertname": "cpu:serverA",
+		"level":     "INFO",
+		"task":      "TestStream_AlertSigma",
+		"topic":     "testStreamer:TestStream_AlertSigma:alert3",
+		"host":      "serverA",
+		"type":      "idle",
+		"team":      "ops",
+	}
+	generatorURL := kapacitorURL + "/tasks/TestStream_AlertSigma"
+	exp := []alertmanagertest.Request{
+		{
+			URL: "/api/v1/alerts",
+			PostData: []alertmanagertest.Alert{{
+				Labels: labels,
+				Annotations: map[string]string{
+					"message": "cpu:serverA is INFO",
+				},
+				StartsAt:     time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+				GeneratorURL: generatorURL,
+			}},
+		},
+		{
+			URL: "/api/v1/alerts",
+			PostData: []alertmanagertest.Alert{{
+				Labels: labels,
+				Annotations: map[string]string{
+					"message": "cpu:serverA is OK",
+				},
+				StartsAt:     time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+				EndsAt:       time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+				GeneratorURL: generatorURL,
+			}},
+		},
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("unexpected alertmanager requests:\nexp\n%+v\ngot\n%+v\n", exp, got)
+	}
+}
+
+func TestStream_AlertAMQP(t *testing.T) {
+	ts, err := amqptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|eval(lambda: sigma("value"))
+		.as('sigma')
+		.keep()
+	|alert()
+		.id('cpu:{{ index .Tags "host" }}')
+		.info(lambda: "sigma" > 2.0)
+		.warn(lambda: "sigma" > 3.0)
+		.crit(lambda: "sigma" > 3.5)
+		.amqp()
+			.brokerName('bus')
+			.exchange('alerts')
+			.routingKey('{{ .TaskName }}.{{ index .Tags "host" }}.{{ .Level }}')
+`
+
+	c := amqp.NewConfig()
+	c.Enabled = true
+	c.Name = "bus"
+	c.URL = ts.URL
+	as := amqp.NewService(amqp.Configs{c}, logService.NewLogger("[test_amqp] ", log.LstdFlags))
+	if err := as.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer as.Close()
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.AMQPService = as
+	}
+	testStreamerNoOutput(t, "TestStream_AlertSigma", script, 13*time.Second, tmInit)
+
+	as.Close()
+	ts.Close()
+	msgs := ts.Messages()
+	exp := []struct {
+		routingKey string
+		message    string
+	}{
+		{
+			routingKey: "TestStream_AlertSigma.serverA.INFO",
+			message:    "cpu:serverA is INFO",
+		},
+		{
+			routingKey: "TestStream_AlertSigma.serverA.OK",
+			message:    "cpu:serverA is OK",
+		},
+	}
+	if got, exp := len(msgs), len(exp); got != exp {
+		t.Fatalf("unexpected number of messages: got %d exp %d", got, exp)
+	}
+	for i, e := range exp {
+		msg := msgs[i]
+		if got, exp := msg.Exchange, "alerts"; got != exp {
+			t.Errorf("%d unexpected exchange: got %q exp %q", i, got, exp)
+		}
+		if got, exp := msg.RoutingKey, e.routingKey; got != exp {
+			t.Errorf("%d unexpected routing key: got %q exp %q", i, got, exp)
+		}
+		if got, exp := msg.DeliveryMode, uint8(2); got != exp {
+			t.Errorf("%d unexpected delivery mode: got %d exp %d", i, got, exp)
+		}
+		var data alert.Data
+		if err := json.Unmarshal(msg.Body, &data); err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := data.Message, e.message; got != exp {
+			t.Errorf("%d unexpected alert message: got %q exp %q", i, got, exp)
+		}
+	}
+}
+
 func TestStream_AlertHTTPPost(t *testing.T) {
 	ts := httpposttest.NewAlertServer(nil)
 	defer ts.Close()
@@ -8040,6 +9395,67 @@ stream
 	}
 }
 
+
+func TestStream_AlertHTTPPostTemplate(t *testing.T) {
+	var mu sync.Mutex
+	var bodies, contentTypes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.details('')
+		.post()
+		 .endpoint('test')
+		 .template('short')
+`
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := httppost.Config{}
+		c.URL = ts.URL
+		c.Endpoint = "test"
+		c.ContentType = "text/plain"
+		c.Templates = map[string]string{
+			"short": `{{.ID}} {{.Level}} {{json .Message}}`,
+		}
+		sl := httppost.NewService(httppost.Configs{c}, logService.NewLogger("[test_httppost_template] ", log.LstdFlags))
+		tm.HTTPPostService = sl
+	}
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+	ts.Close()
+	expBodies := []string{`kapacitor.cpu.serverA CRITICAL "kapacitor.cpu.serverA is CRITICAL"`}
+	expContentTypes := []string{"text/plain"}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(bodies, expBodies) {
+		t.Errorf("unexpected bodies:\ngot %v\nexp %v", bodies, expBodies)
+	}
+	if !reflect.DeepEqual(contentTypes, expContentTypes) {
+		t.Errorf("unexpected content types:\ngot %v\nexp %v", contentTypes, expContentTypes)
+	}
+}
 func TestStream_AlertVictorOps(t *testing.T) {
 	ts := victoropstest.NewServer()
 	defer ts.Close()
@@ -8071,7 +9487,7 @@ stream
 		c.URL = ts.URL
 		c.APIKey = "api_key"
 		c.RoutingKey = "routing_key"
-		vo := victorops.NewService(c, logService.NewLogger("[test_vo] ", log.LstdFlags))
+		vo := victorops.NewService(victorops.Configs{c}, logService.NewLogger("[test_vo] ", log.LstdFlags))
 		tm.VictorOpsService = vo
 	}
 	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
@@ -8412,7 +9828,7 @@ Value: 10
 		Port:    smtpServer.Port,
 		From:    "test@example.com",
 	}
-	smtpService := smtp.NewService(sc, logService.NewLogger("[test-smtp] ", log.LstdFlags))
+	smtpService := smtp.NewService(smtp.Configs{sc}, logService.NewLogger("[test-smtp] ", log.LstdFlags))
 	if err := smtpService.Open(); err != nil {
 		t.Fatal(err)
 	}
@@ -8445,6 +9861,140 @@ Value: 10
 	}
 }
 
+func TestStream_AlertEmailTemplate(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.details('count is {{ index .Fields "count" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.email('user1@example.com')
+			.template('alert')
+			.subject('{{ .Level }}: {{ .ID }}')
+			.attachCSV()
+`
+
+	smtpServer, err := smtptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer smtpServer.Close()
+	sc := smtp.Config{
+		Enabled: true,
+		Host:    smtpServer.Host,
+		Port:    smtpServer.Port,
+		From:    "test@example.com",
+		Templates: map[string]smtp.Template{
+			"alert": {
+				Subject: "ignored",
+				Text:    "{{ .Message }}: {{ .Details }}",
+				HTML:    "<b>{{ .Message }}</b>: {{ .Details }}",
+			},
+		},
+	}
+	smtpService := smtp.NewService(smtp.Configs{sc}, logService.NewLogger("[test-smtp] ", log.LstdFlags))
+	if err := smtpService.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer smtpService.Close()
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.SMTPService = smtpService
+	}
+
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+	// Close both client and server to ensure all message are processed
+	smtpService.Close()
+	smtpServer.Close()
+
+	errors := smtpServer.Errors()
+	if got, exp := len(errors), 0; got != exp {
+		t.Errorf("unexpected smtp server errors: %v", errors)
+	}
+
+	msgs := smtpServer.SentMessages()
+	if got, exp := len(msgs), 1; got != exp {
+		t.Fatalf("unexpected number of messages sent: got %d exp %d", got, exp)
+	}
+	msg := msgs[0]
+	if got, exp := msg.Header.Get("Subject"), "CRITICAL: kapacitor.cpu.serverA"; got != exp {
+		t.Errorf("unexpected subject: got %q exp %q", got, exp)
+	}
+	parts := multipartParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	if got, exp := len(parts), 2; got != exp {
+		t.Fatalf("unexpected number of parts: got %d exp %d", got, exp)
+	}
+	alternatives := multipartParts(t, parts[0].Header.Get("Content-Type"), parts[0].Body)
+	expAlternatives := []*smtptest.Message{
+		{
+			Header: mail.Header{"Content-Type": []string{"text/plain; charset=UTF-8"}},
+			Body:   "kapacitor.cpu.serverA is CRITICAL: count is 10",
+		},
+		{
+			Header: mail.Header{"Content-Type": []string{"text/html; charset=UTF-8"}},
+			Body:   "<b>kapacitor.cpu.serverA is CRITICAL</b>: count is 10",
+		},
+	}
+	if got, exp := len(alternatives), len(expAlternatives); got != exp {
+		t.Fatalf("unexpected number of alternatives: got %d exp %d", got, exp)
+	}
+	for i, exp := range expAlternatives {
+		if err := exp.Compare(alternatives[i]); err != nil {
+			t.Errorf("alternative %d: %s", i, err)
+		}
+	}
+
+	attachment := parts[1]
+	if got, exp := attachment.Header.Get("Content-Disposition"), `attachment; filename="points.csv"`; got != exp {
+		t.Errorf("unexpected attachment disposition: got %q exp %q", got, exp)
+	}
+	csv, err := base64.StdEncoding.DecodeString(strings.Replace(attachment.Body, "\r\n", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := string(csv), "name,host,time,count\ncpu,serverA,1971-01-01T00:00:10Z,10\n"; got != exp {
+		t.Errorf("unexpected CSV attachment:\ngot\n%s\nexp\n%s", got, exp)
+	}
+}
+
+// multipartParts returns the parts of a multipart body.
+func multipartParts(t *testing.T, contentType, body string) []*smtptest.Message {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	var parts []*smtptest.Message
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, &smtptest.Message{
+			Header: mail.Header(p.Header),
+			Body:   string(b),
+		})
+	}
+}
+
 func TestStream_AlertSNMPTrap(t *testing.T) {
 
 	var script = `
@@ -10770,7 +12320,7 @@ func createTaskMaster() (*kapacitor.TaskMaster, error) {
 	tm.TaskStore = taskStore{}
 	tm.DeadmanService = deadman{}
 	tm.HTTPPostService = httppost.NewService(nil, logService.NewLogger("[httppost] ", log.LstdFlags))
-	as := alertservice.NewService(logService.NewLogger("[alert] ", log.LstdFlags))
+	as := alertservice.NewService(alertservice.NewConfig(), logService.NewLogger("[alert] ", log.LstdFlags))
 	as.StorageService = storagetest.New()
 	as.HTTPDService = httpdService
 	if err := as.Open(); err != nil {
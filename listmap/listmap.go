@@ -9,6 +9,13 @@ import (
 	"github.com/pkg/errors"
 )
 
+// Initer sets defaults on the receiving object.
+// If the elements of the slice are Initers, Init is called on each new element
+// before it is decoded, so that options absent from the TOML data keep their defaults.
+type Initer interface {
+	Init()
+}
+
 // DoUnmarshalTOML unmarshals either a list of maps or just a single map into dst.
 // The argument dst must be a pointer to a slice.
 func DoUnmarshalTOML(dst, src interface{}) error {
@@ -48,6 +55,9 @@ func DoUnmarshalTOML(dst, src interface{}) error {
 			return errors.Wrap(err, "failed to reencode toml data")
 		}
 		newValue := reflect.New(dstV.Type().Elem())
+		if initer, ok := newValue.Interface().(Initer); ok {
+			initer.Init()
+		}
 		if _, err := toml.Decode(buf.String(), newValue.Interface()); err != nil {
 			return err
 		}
@@ -56,3 +66,48 @@ func DoUnmarshalTOML(dst, src interface{}) error {
 	}
 	return nil
 }
+
+// NameFunc returns the name of the element i of a list of configurations
+// and whether the element is marked as the default.
+type NameFunc func(i int) (name string, isDefault bool)
+
+// ValidateNames checks that the names of the n elements of a list of configurations are unique
+// and that a single element is marked as the default when there is more than one element.
+// The kind of the names is used in the errors, e.g. "workspace".
+func ValidateNames(kind string, n int, name NameFunc) error {
+	defaultCount := 0
+	names := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		nm, isDefault := name(i)
+		if names[nm] {
+			return fmt.Errorf("duplicate %s %q", kind, nm)
+		}
+		names[nm] = true
+		if isDefault {
+			defaultCount++
+		}
+	}
+	if defaultCount > 1 {
+		return errors.New("more than one configuration is marked as the default")
+	}
+	if defaultCount == 0 && n > 1 {
+		return errors.New("no configuration is marked as the default")
+	}
+	return nil
+}
+
+// DefaultName returns the name of the element of a list of n configurations marked as the default,
+// a single element is the default even when it is not marked.
+// An empty name is returned if there is no default.
+func DefaultName(n int, name NameFunc) string {
+	if n == 1 {
+		nm, _ := name(0)
+		return nm
+	}
+	for i := 0; i < n; i++ {
+		if nm, isDefault := name(i); isDefault {
+			return nm
+		}
+	}
+	return ""
+}
@@ -390,3 +390,28 @@ func (v *MaxDuration) Set(next int64) {
 		}
 	}
 }
+
+// restoredGroups tracks the states of groups restored from a snapshot
+// that have not received any data since.
+// They are carried into new snapshots until a full snapshot interval has passed
+// since data resumed, afterwards they are dropped,
+// so that groups that never receive data again do not stay in every snapshot forever.
+type restoredGroups struct {
+	receiving bool
+	snapshots int
+}
+
+// received records that a group received data.
+func (r *restoredGroups) received() {
+	r.receiving = true
+}
+
+// snapshotted records that a snapshot was taken
+// and reports whether the remaining restored states should be dropped.
+func (r *restoredGroups) snapshotted() bool {
+	if !r.receiving {
+		return false
+	}
+	r.snapshots++
+	return r.snapshots >= 2
+}
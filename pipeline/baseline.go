@@ -0,0 +1,173 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// BaselineMean computes the baseline as the mean and the spread as the standard deviation.
+	BaselineMean = "mean"
+	// BaselineMedian computes the baseline as the median and the spread as the scaled median absolute deviation.
+	BaselineMedian = "median"
+)
+
+// Compare each point against a learned seasonal baseline of a field.
+//
+// The season is split into buckets, by default one bucket for each hour of the week.
+// For each group and bucket the node learns the distribution of the field from
+// the previous `history` seasons and adds fields to each point describing how the
+// point compares to that baseline:
+//
+//     * baseline - The mean or median of the bucket.
+//     * zscore   - The number of standard deviations the value is from the baseline.
+//     * upper    - The upper band, baseline + sigma * spread.
+//     * lower    - The lower band, baseline - sigma * spread.
+//
+// The spread is the standard deviation when using the mean,
+// or the median absolute deviation scaled by 1.4826 when using the median,
+// so that z-scores are comparable between both methods.
+//
+// Only complete seasons are used for the baseline, so points are dropped until a
+// bucket has at least `minCount` points of history.
+//
+// When the history of a bucket is constant the spread is zero and the z-score is always 0,
+// which is common for the median of flat data.
+// Set `minSpread` to the smallest deviation that should count as one spread,
+// so that deviations from a constant baseline are still scored.
+//
+// The learned state is kept in the task snapshot, so it survives restarts
+// as long as the task has a snapshot interval.
+//
+// Example:
+//     stream
+//         |from()
+//             .measurement('requests')
+//             .groupBy('service')
+//         |baseline('count')
+//             .season(1w)
+//             .bucket(1h)
+//             .history(4)
+//             .median()
+//         |alert()
+//             .warn(lambda: abs("zscore") > 3.0)
+//             .crit(lambda: abs("zscore") > 5.0)
+//
+// Seasons and buckets are aligned with the Unix epoch in the wall clock time of the `location`,
+// UTC by default.
+// The epoch was a Thursday, so a season of 1w starts on Thursday at midnight.
+type BaselineNode struct {
+	chainnode
+
+	// The field to compare against the baseline.
+	// tick:ignore
+	Field string
+
+	// The length of a season.
+	// Default: 1w
+	Season time.Duration
+
+	// The length of each bucket within a season.
+	// Must evenly divide the season.
+	// Default: 1h
+	Bucket time.Duration
+
+	// The number of previous seasons used to compute the baseline.
+	// Default: 4
+	History int64
+
+	// The minimum number of points in the history of a bucket
+	// before points in that bucket are emitted.
+	// Default: 2
+	MinCount int64
+
+	// The number of spreads between the baseline and the upper and lower bands.
+	// Default: 3.0
+	Sigma float64
+
+	// The minimum spread used for the z-score and the bands.
+	// Default: 0, a constant history results in a z-score of 0.
+	MinSpread float64
+
+	// The name of the time zone whose wall clock time aligns the seasons and buckets,
+	// for example 'America/New_York'.
+	// Default: UTC
+	Location string
+
+	// The method used to compute the baseline, either 'mean' or 'median'.
+	// tick:ignore
+	Method string `tick:"Median"`
+
+	// The name of the baseline field.
+	// Default: 'baseline'
+	BaselineAs string
+
+	// The name of the z-score field.
+	// Default: 'zscore'
+	ZScoreAs string
+
+	// The name of the upper band field.
+	// Default: 'upper'
+	UpperAs string
+
+	// The name of the lower band field.
+	// Default: 'lower'
+	LowerAs string
+}
+
+func newBaselineNode(wants EdgeType, field string) *BaselineNode {
+	return &BaselineNode{
+		chainnode:  newBasicChainNode("baseline", wants, wants),
+		Field:      field,
+		Season:     7 * 24 * time.Hour,
+		Bucket:     time.Hour,
+		History:    4,
+		MinCount:   2,
+		Sigma:      3,
+		Method:     BaselineMean,
+		BaselineAs: "baseline",
+		ZScoreAs:   "zscore",
+		UpperAs:    "upper",
+		LowerAs:    "lower",
+	}
+}
+
+// Use the median and median absolute deviation instead of the mean and standard deviation.
+// The median is more robust to past anomalies in the history.
+// tick:property
+func (n *BaselineNode) Median() *BaselineNode {
+	n.Method = BaselineMedian
+	return n
+}
+
+func (n *BaselineNode) validate() error {
+	if n.Bucket <= 0 {
+		return fmt.Errorf("bucket must be greater than zero, got %v", n.Bucket)
+	}
+	if n.Season < n.Bucket || n.Season%n.Bucket != 0 {
+		return fmt.Errorf("season %v must be a multiple of bucket %v", n.Season, n.Bucket)
+	}
+	if n.History <= 0 {
+		return fmt.Errorf("history must be greater than zero, got %d", n.History)
+	}
+	if n.MinCount <= 0 {
+		return fmt.Errorf("minCount must be greater than zero, got %d", n.MinCount)
+	}
+	if n.Sigma <= 0 {
+		return fmt.Errorf("sigma must be greater than zero, got %v", n.Sigma)
+	}
+	if n.MinSpread < 0 {
+		return fmt.Errorf("minSpread must not be negative, got %v", n.MinSpread)
+	}
+	if _, err := time.LoadLocation(n.Location); err != nil {
+		return fmt.Errorf("invalid location %q: %v", n.Location, err)
+	}
+	if n.Method != BaselineMean && n.Method != BaselineMedian {
+		return fmt.Errorf("unknown baseline method %q", n.Method)
+	}
+	if n.BaselineAs == "" || n.ZScoreAs == "" || n.UpperAs == "" || n.LowerAs == "" {
+		return errors.New("baseline field names must not be empty")
+	}
+	return nil
+}
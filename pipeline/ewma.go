@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"fmt"
+)
+
+// Compute the exponentially weighted moving average of a field.
+// Unlike the movingAverage function no window of points is kept,
+// the average is updated for each point that is received.
+// The average is computed per group.
+//
+// Example:
+//     stream
+//         |from()
+//             .measurement('cpu')
+//             .groupBy('host')
+//         |ewma('usage_idle')
+//             .alpha(0.3)
+//             .as('usage_idle_ewma')
+//
+// Computes the average via:
+//    alpha * current + (1 - alpha) * previous_average
+//
+// The first point initializes the average to its value.
+// For batch edges the average is reset at the start of each batch.
+type EWMANode struct {
+	chainnode
+
+	// The field to use when calculating the average
+	// tick:ignore
+	Field string
+
+	// The new name of the average field.
+	// Default is the name of the field used
+	// when calculating the average.
+	As string
+
+	// The smoothing factor, between 0 and 1.
+	// Larger values discount older points faster.
+	// Default: 0.5
+	Alpha float64
+}
+
+func newEWMANode(wants EdgeType, field string) *EWMANode {
+	return &EWMANode{
+		chainnode: newBasicChainNode("ewma", wants, wants),
+		Field:     field,
+		As:        field,
+		Alpha:     0.5,
+	}
+}
+
+func (n *EWMANode) validate() error {
+	if n.Alpha <= 0 || n.Alpha > 1 {
+		return fmt.Errorf("alpha must be greater than 0 and less than or equal to 1, got %v", n.Alpha)
+	}
+	return nil
+}
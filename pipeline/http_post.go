@@ -40,6 +40,26 @@ type HTTPPostNode struct {
 
 	// tick:ignore
 	URLs []string
+
+	// Name of the endpoint template used to render the body, as is defined in the configuration file.
+	// Templates can only be used with an endpoint.
+	//
+	// Example:
+	//    stream
+	//         |httpPost()
+	//            .endpoint('example')
+	//            .template('line')
+	Template string
+
+	// Content type of the request, overrides the content type of the endpoint.
+	//
+	// Example:
+	//    stream
+	//         |httpPost()
+	//            .endpoint('example')
+	//            .template('line')
+	//            .contentType('text/plain')
+	ContentType string
 }
 
 func newHTTPPostNode(wants EdgeType, urls ...string) *HTTPPostNode {
@@ -73,6 +93,10 @@ func (p *HTTPPostNode) validate() error {
 		}
 	}
 
+	if p.Template != "" && len(p.Endpoints) == 0 {
+		return errors.New("template can only be used with an endpoint")
+	}
+
 	return nil
 }
 
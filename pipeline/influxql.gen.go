@@ -46,4 +46,5 @@ type ReduceCreater struct {
 	IsSimpleSelector       bool
 	IsStreamTransformation bool
 	IsEmptyOK              bool
+	IsIncremental          bool
 }
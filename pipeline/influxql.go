@@ -1,9 +1,11 @@
 package pipeline
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/kapacitor/sketch"
 )
 
 // tmpl -- go get github.com/benbjohnson/tmpl
@@ -25,6 +27,21 @@ import (
 //        |sum('value')
 //
 //
+// The mean, sum, count, min and max functions can also window a stream edge themselves
+// by setting the `period` property.
+// Instead of buffering every point of the window only a partial aggregate is kept per group,
+// so memory use does not grow with the size of the window.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        // Compute the mean over the last 24h every minute.
+//        |mean('usage_idle')
+//            .period(24h)
+//            .every(1m)
+//
 // Note: Derivative has its own implementation as a DerivativeNode instead of as part of the
 // InfluxQL functions.
 type InfluxQLNode struct {
@@ -44,6 +61,31 @@ type InfluxQLNode struct {
 
 	// tick:ignore
 	PointTimes bool `tick:"UsePointTimes"`
+
+	// The period, or length in time, of the window to aggregate incrementally.
+	// If zero, the node aggregates the points with the same time for a stream edge,
+	// or the points of each batch for a batch edge.
+	//
+	// Windows are aligned with the `every` property, like a window node using `.align()`.
+	// Only applies to the mean, sum, count, min and max functions on a stream edge.
+	Period time.Duration
+
+	// How often the incrementally aggregated window is emitted.
+	// Defaults to the period.
+	Every time.Duration
+
+	// tick:ignore
+	EmitSketch bool `tick:"Sketch"`
+
+	// tick:ignore
+	MergeSketches bool `tick:"FromSketch"`
+
+	// sketchReduceCreater creates the ReduceCreater for approximate functions,
+	// it is nil for all other functions.
+	sketchReduceCreater func(emitSketch, mergeSketches bool) ReduceCreater
+
+	// percentile of the approxPercentile function.
+	percentile float64
 }
 
 func newInfluxQLNode(method, field string, wants, provides EdgeType, reducer ReduceCreater) *InfluxQLNode {
@@ -66,6 +108,75 @@ func (n *InfluxQLNode) UsePointTimes() *InfluxQLNode {
 	return n
 }
 
+// Emit the serialized sketch instead of the estimated value.
+// The sketch is a base64 encoded string which can be merged downstream
+// by the same function using the `.fromSketch()` property.
+//
+// Only applies to approximate functions like approxPercentile and approxCountDistinct.
+//
+// Example:
+//    stream
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//        |approxPercentile('value', 99.0)
+//            .sketch()
+//            .as('value')
+//        |window()
+//            .period(1h)
+//            .every(1h)
+//        // Estimate the hourly 99th percentile from the minutely sketches.
+//        |approxPercentile('value', 99.0)
+//            .fromSketch()
+//
+// tick:property
+func (n *InfluxQLNode) Sketch() *InfluxQLNode {
+	n.EmitSketch = true
+	n.updateSketchReduceCreater()
+	return n
+}
+
+// The field contains serialized sketches produced by the `.sketch()` property,
+// instead of raw values. The sketches are merged before estimating the result.
+//
+// Only applies to approximate functions like approxPercentile and approxCountDistinct.
+// tick:property
+func (n *InfluxQLNode) FromSketch() *InfluxQLNode {
+	n.MergeSketches = true
+	n.updateSketchReduceCreater()
+	return n
+}
+
+func (n *InfluxQLNode) updateSketchReduceCreater() {
+	if n.sketchReduceCreater != nil {
+		n.ReduceCreater = n.sketchReduceCreater(n.EmitSketch, n.MergeSketches)
+	}
+}
+
+func (n *InfluxQLNode) validate() error {
+	if (n.EmitSketch || n.MergeSketches) && n.sketchReduceCreater == nil {
+		return fmt.Errorf("cannot use sketch properties with %s, only approximate functions support sketches", n.Method)
+	}
+	if n.Method == "approxPercentile" && (n.percentile < 0 || n.percentile > 100) {
+		return fmt.Errorf("percentile must be between 0 and 100, got %v", n.percentile)
+	}
+	if n.Period != 0 || n.Every != 0 {
+		if !n.ReduceCreater.IsIncremental {
+			return fmt.Errorf("cannot set period or every on %s, only mean, sum, count, min and max can be aggregated incrementally", n.Method)
+		}
+		if n.Wants() != StreamEdge {
+			return fmt.Errorf("cannot set period or every on %s for a %v edge, only stream edges can be aggregated incrementally", n.Method, n.Wants())
+		}
+		if n.Period <= 0 {
+			return fmt.Errorf("period must be greater than zero, got %v", n.Period)
+		}
+		if n.Every < 0 {
+			return fmt.Errorf("every must not be negative, got %v", n.Every)
+		}
+	}
+	return nil
+}
+
 //------------------------------------
 // Aggregation Functions
 //
@@ -89,7 +200,8 @@ func (n *chainnode) Count(field string) *InfluxQLNode {
 			fn := influxql.NewBooleanFuncIntegerReducer(influxql.BooleanCountReduce, &influxql.IntegerPoint{Value: 0})
 			return fn, fn
 		},
-		IsEmptyOK: true,
+		IsEmptyOK:     true,
+		IsIncremental: true,
 	})
 	n.linkChild(i)
 	return i
@@ -130,6 +242,7 @@ func (n *chainnode) Mean(field string) *InfluxQLNode {
 			fn := influxql.NewIntegerMeanReducer()
 			return fn, fn
 		},
+		IsIncremental: true,
 	})
 	n.linkChild(i)
 	return i
@@ -195,7 +308,8 @@ func (n *chainnode) Sum(field string) *InfluxQLNode {
 			fn := influxql.NewIntegerFuncReducer(influxql.IntegerSumReduce, &influxql.IntegerPoint{Value: 0})
 			return fn, fn
 		},
-		IsEmptyOK: true,
+		IsEmptyOK:     true,
+		IsIncremental: true,
 	})
 	n.linkChild(i)
 	return i
@@ -267,6 +381,7 @@ func (n *chainnode) Min(field string) *InfluxQLNode {
 			return fn, fn
 		},
 		IsSimpleSelector: true,
+		IsIncremental:    true,
 	})
 	n.linkChild(i)
 	return i
@@ -284,6 +399,7 @@ func (n *chainnode) Max(field string) *InfluxQLNode {
 			return fn, fn
 		},
 		IsSimpleSelector: true,
+		IsIncremental:    true,
 	})
 	n.linkChild(i)
 	return i
@@ -306,6 +422,127 @@ func (n *chainnode) Percentile(field string, percentile float64) *InfluxQLNode {
 	return i
 }
 
+// Estimate the value at the given percentile using a t-digest sketch.
+// Unlike percentile, the points are not buffered, so memory use is bounded by the size
+// of the sketch instead of the number of points.
+// The estimate is interpolated between points and so is not a selector function.
+func (n *chainnode) ApproxPercentile(field string, percentile float64) *InfluxQLNode {
+	i := newInfluxQLNode("approxPercentile", field, n.Provides(), StreamEdge, ReduceCreater{})
+	i.percentile = percentile
+	q := percentile / 100
+	i.sketchReduceCreater = func(emitSketch, mergeSketches bool) ReduceCreater {
+		switch {
+		case mergeSketches && emitSketch:
+			return ReduceCreater{
+				CreateStringReducer: func() (influxql.StringPointAggregator, influxql.StringPointEmitter) {
+					fn := sketch.NewTDigestSketchReducer()
+					return fn, fn
+				},
+			}
+		case mergeSketches:
+			return ReduceCreater{
+				CreateStringFloatReducer: func() (influxql.StringPointAggregator, influxql.FloatPointEmitter) {
+					fn := sketch.NewTDigestQuantileReducer(q)
+					return fn, fn
+				},
+			}
+		case emitSketch:
+			return ReduceCreater{
+				CreateFloatStringReducer: func() (influxql.FloatPointAggregator, influxql.StringPointEmitter) {
+					fn := sketch.NewTDigestSketchReducer()
+					return fn, fn
+				},
+				CreateIntegerStringReducer: func() (influxql.IntegerPointAggregator, influxql.StringPointEmitter) {
+					fn := sketch.NewTDigestSketchReducer()
+					return fn, fn
+				},
+			}
+		default:
+			return ReduceCreater{
+				CreateFloatReducer: func() (influxql.FloatPointAggregator, influxql.FloatPointEmitter) {
+					fn := sketch.NewTDigestQuantileReducer(q)
+					return fn, fn
+				},
+				CreateIntegerFloatReducer: func() (influxql.IntegerPointAggregator, influxql.FloatPointEmitter) {
+					fn := sketch.NewTDigestQuantileReducer(q)
+					return fn, fn
+				},
+			}
+		}
+	}
+	i.updateSketchReduceCreater()
+	n.linkChild(i)
+	return i
+}
+
+// Estimate the number of distinct values using a HyperLogLog sketch.
+// Unlike distinct, the points are not buffered, so memory use is bounded by the size
+// of the sketch instead of the number of distinct values.
+func (n *chainnode) ApproxCountDistinct(field string) *InfluxQLNode {
+	i := newInfluxQLNode("approxCountDistinct", field, n.Provides(), StreamEdge, ReduceCreater{})
+	i.sketchReduceCreater = func(emitSketch, mergeSketches bool) ReduceCreater {
+		switch {
+		case mergeSketches && emitSketch:
+			return ReduceCreater{
+				CreateStringReducer: func() (influxql.StringPointAggregator, influxql.StringPointEmitter) {
+					fn := sketch.NewHyperLogLogSketchReducer(true)
+					return fn, fn
+				},
+			}
+		case mergeSketches:
+			return ReduceCreater{
+				CreateStringIntegerReducer: func() (influxql.StringPointAggregator, influxql.IntegerPointEmitter) {
+					fn := sketch.NewHyperLogLogCountReducer(true)
+					return fn, fn
+				},
+			}
+		case emitSketch:
+			return ReduceCreater{
+				CreateFloatStringReducer: func() (influxql.FloatPointAggregator, influxql.StringPointEmitter) {
+					fn := sketch.NewHyperLogLogSketchReducer(false)
+					return fn, fn
+				},
+				CreateIntegerStringReducer: func() (influxql.IntegerPointAggregator, influxql.StringPointEmitter) {
+					fn := sketch.NewHyperLogLogSketchReducer(false)
+					return fn, fn
+				},
+				CreateStringReducer: func() (influxql.StringPointAggregator, influxql.StringPointEmitter) {
+					fn := sketch.NewHyperLogLogSketchReducer(false)
+					return fn, fn
+				},
+				CreateBooleanStringReducer: func() (influxql.BooleanPointAggregator, influxql.StringPointEmitter) {
+					fn := sketch.NewHyperLogLogSketchReducer(false)
+					return fn, fn
+				},
+				IsEmptyOK: true,
+			}
+		default:
+			return ReduceCreater{
+				CreateFloatIntegerReducer: func() (influxql.FloatPointAggregator, influxql.IntegerPointEmitter) {
+					fn := sketch.NewHyperLogLogCountReducer(false)
+					return fn, fn
+				},
+				CreateIntegerReducer: func() (influxql.IntegerPointAggregator, influxql.IntegerPointEmitter) {
+					fn := sketch.NewHyperLogLogCountReducer(false)
+					return fn, fn
+				},
+				CreateStringIntegerReducer: func() (influxql.StringPointAggregator, influxql.IntegerPointEmitter) {
+					fn := sketch.NewHyperLogLogCountReducer(false)
+					return fn, fn
+				},
+				CreateBooleanIntegerReducer: func() (influxql.BooleanPointAggregator, influxql.IntegerPointEmitter) {
+					fn := sketch.NewHyperLogLogCountReducer(false)
+					return fn, fn
+				},
+				IsEmptyOK: true,
+			}
+		}
+	}
+	i.updateSketchReduceCreater()
+	n.linkChild(i)
+	return i
+}
+
 //tick:ignore
 type TopBottomCallInfo struct {
 	FieldsAndTags []string
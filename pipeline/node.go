@@ -384,6 +384,20 @@ func (n *chainnode) Flatten() *FlattenNode {
 	return f
 }
 
+// Pivot points with similar times into a single point per row.
+func (n *chainnode) Pivot() *PivotNode {
+	p := newPivotNode(n.provides)
+	n.linkChild(p)
+	return p
+}
+
+// Unpivot each point into a point per field.
+func (n *chainnode) Unpivot() *UnpivotNode {
+	u := newUnpivotNode(n.provides)
+	n.linkChild(u)
+	return u
+}
+
 // Create an eval node that will evaluate the given transformation function to each data point.
 // A list of expressions may be provided and will be evaluated in the order they are given.
 // The results are available to later expressions.
@@ -433,6 +447,27 @@ func (n *chainnode) Derivative(field string) *DerivativeNode {
 	return s
 }
 
+// Create a new node that computes the exponentially weighted moving average of a field.
+func (n *chainnode) Ewma(field string) *EWMANode {
+	e := newEWMANode(n.Provides(), field)
+	n.linkChild(e)
+	return e
+}
+
+// Create a new node that computes the rate of change of a field, with counter reset detection.
+func (n *chainnode) Rate(field string) *RateNode {
+	r := newRateNode(n.Provides(), field)
+	n.linkChild(r)
+	return r
+}
+
+// Create a new node that compares a field against a learned seasonal baseline.
+func (n *chainnode) Baseline(field string) *BaselineNode {
+	b := newBaselineNode(n.Provides(), field)
+	n.linkChild(b)
+	return b
+}
+
 // Create a new node that shifts the incoming points or batches in time.
 func (n *chainnode) Shift(shift time.Duration) *ShiftNode {
 	s := newShiftNode(n.Provides(), shift)
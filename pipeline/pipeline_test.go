@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -96,3 +97,29 @@ func TestPipelineSort(t *testing.T) {
 
 	assert.Equal(sorted, p.sorted)
 }
+
+func TestApproxPercentile_Validate(t *testing.T) {
+	testCases := []struct {
+		percentile float64
+		err        bool
+	}{
+		{percentile: 0.0},
+		{percentile: 99.9},
+		{percentile: 100.0},
+		{percentile: -1, err: true},
+		{percentile: 100.1, err: true},
+	}
+	for _, tc := range testCases {
+		var tickScript = fmt.Sprintf(`
+stream
+	|from()
+	|approxPercentile('value', %.1f)
+`, tc.percentile)
+		_, err := CreatePipeline(tickScript, StreamEdge, stateful.NewScope(), deadman{}, nil)
+		if tc.err && err == nil {
+			t.Errorf("%v: expected error, got nil", tc.percentile)
+		} else if !tc.err && err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.percentile, err)
+		}
+	}
+}
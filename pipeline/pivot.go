@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"errors"
+	"time"
+)
+
+// Pivot a set of points with the same time into one point per row.
+// The value of the column key tag becomes the field name
+// and the value field becomes the field value.
+// For example given the points:
+//
+// cpu,host=A,cpu=cpu0 usage_idle=90
+// cpu,host=A,cpu=cpu1 usage_idle=80
+// cpu,host=B,cpu=cpu0 usage_idle=70
+//
+// Pivoting with row key `host`, column key `cpu` and value field `usage_idle` would result in the points:
+//
+// cpu,host=A cpu0=90,cpu1=80
+// cpu,host=B cpu0=70
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |pivot()
+//            .rowKey('host')
+//            .columnKey('cpu')
+//            .valueField('usage_idle')
+//            .tolerance(1s)
+//        |groupBy('host')
+//
+// Only points within the same group are pivoted together,
+// so group by the row key after the pivot node if you need the rows as separate groups.
+// The resulting points keep the group tags and the row key tags, all other tags are dropped.
+//
+// Points missing any of the row key tags, the column key tag or the value field are dropped and an error is logged.
+type PivotNode struct {
+	chainnode
+
+	// The tags that identify a row.
+	// tick:ignore
+	RowKeys []string `tick:"RowKey"`
+
+	// The tag whose values become the field names.
+	ColumnKey string
+
+	// The field whose values become the field values.
+	// Default: value
+	ValueField string
+
+	// The maximum duration of time that two incoming points
+	// can be apart and still be considered to be equal in time.
+	// The pivoted data point's time will be rounded to the nearest
+	// multiple of the tolerance duration.
+	Tolerance time.Duration
+}
+
+func newPivotNode(e EdgeType) *PivotNode {
+	return &PivotNode{
+		chainnode:  newBasicChainNode("pivot", e, e),
+		ValueField: "value",
+	}
+}
+
+// Specify the tags that identify a row.
+// tick:property
+func (n *PivotNode) RowKey(tags ...string) *PivotNode {
+	n.RowKeys = tags
+	return n
+}
+
+func (n *PivotNode) validate() error {
+	if n.ColumnKey == "" {
+		return errors.New("must provide a column key for pivot")
+	}
+	if n.ValueField == "" {
+		return errors.New("must provide a value field for pivot")
+	}
+	for _, tag := range n.RowKeys {
+		if tag == n.ColumnKey {
+			return errors.New("column key cannot also be a row key")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+)
+
+// Compute the rate of change of a field between adjacent points.
+// The rate is computed per group.
+//
+// Example:
+//     stream
+//         |from()
+//             .measurement('net')
+//             .groupBy('host', 'interface')
+//         |rate('bytes_recv')
+//             .unit(1s) // default
+//             .nonNegative()
+//
+// Computes the rate via:
+//    (current - previous) / (time_difference / unit)
+//
+// When the field is a monotonic counter use the `nonNegative` property,
+// a decrease is then treated as a counter reset instead of a negative rate.
+//
+// The first point of each group is dropped since it has no previous point.
+// For batch edges the previous point is reset at the start of each batch.
+type RateNode struct {
+	chainnode
+
+	// The field to use when calculating the rate
+	// tick:ignore
+	Field string
+
+	// The new name of the rate field.
+	// Default is the name of the field used
+	// when calculating the rate.
+	As string
+
+	// The time unit of the resulting rate value.
+	// Default: 1s
+	Unit time.Duration
+
+	// Whether the field is a counter that may reset.
+	// tick:ignore
+	NonNegativeFlag bool `tick:"NonNegative"`
+}
+
+func newRateNode(wants EdgeType, field string) *RateNode {
+	return &RateNode{
+		chainnode: newBasicChainNode("rate", wants, wants),
+		Unit:      time.Second,
+		Field:     field,
+		As:        field,
+	}
+}
+
+// Treat the field as a monotonic counter.
+// When the value decreases the counter is assumed to have reset to zero,
+// and the rate is computed from the current value alone.
+// As a result the rate is never negative.
+// tick:property
+func (n *RateNode) NonNegative() *RateNode {
+	n.NonNegativeFlag = true
+	return n
+}
+
+func (n *RateNode) validate() error {
+	if n.Unit <= 0 {
+		return fmt.Errorf("unit must be greater than zero, got %v", n.Unit)
+	}
+	return nil
+}
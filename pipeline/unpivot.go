@@ -0,0 +1,58 @@
+package pipeline
+
+import "errors"
+
+// Unpivot each point into one point per field.
+// The field name becomes the value of the column key tag
+// and the field value is stored in the value field.
+// This is the reverse of the pivot node.
+// For example given the point:
+//
+// cpu,host=A cpu0=90,cpu1=80
+//
+// Unpivoting with column key `cpu` and value field `usage_idle` would result in the points:
+//
+// cpu,host=A,cpu=cpu0 usage_idle=90
+// cpu,host=A,cpu=cpu1 usage_idle=80
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |unpivot()
+//            .columnKey('cpu')
+//            .valueField('usage_idle')
+//        |groupBy('host', 'cpu')
+//
+// The new tag is not added to the group by dimensions,
+// so group by the column key after the unpivot node if you need each field as a separate group.
+// If the column key is already a group by dimension, each field is moved into its own group.
+type UnpivotNode struct {
+	chainnode
+
+	// The tag that contains the original field name.
+	// Default: field
+	ColumnKey string
+
+	// The field that contains the original field value.
+	// Default: value
+	ValueField string
+}
+
+func newUnpivotNode(e EdgeType) *UnpivotNode {
+	return &UnpivotNode{
+		chainnode:  newBasicChainNode("unpivot", e, e),
+		ColumnKey:  "field",
+		ValueField: "value",
+	}
+}
+
+func (n *UnpivotNode) validate() error {
+	if n.ColumnKey == "" {
+		return errors.New("must provide a column key for unpivot")
+	}
+	if n.ValueField == "" {
+		return errors.New("must provide a value field for unpivot")
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package kapacitor
+
+import (
+	"bytes"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type PivotNode struct {
+	node
+	p *pipeline.PivotNode
+}
+
+// Create a new PivotNode, which pivots points with the same time into a point per row.
+func newPivotNode(et *ExecutingTask, n *pipeline.PivotNode, l *log.Logger) (*PivotNode, error) {
+	pn := &PivotNode{
+		p:    n,
+		node: node{Node: n, et: et, logger: l},
+	}
+	pn.node.runF = pn.runPivot
+	return pn, nil
+}
+
+func (n *PivotNode) runPivot([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *PivotNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	t := first.Time().Round(n.p.Tolerance)
+	return &pivotBuffer{
+		n:         n,
+		time:      t,
+		name:      first.Name(),
+		groupInfo: group,
+		rows:      make(map[string]*pivotRow),
+	}, nil
+}
+
+// pivotRow is a single row of pivoted fields.
+type pivotRow struct {
+	tags   models.Tags
+	fields models.Fields
+}
+
+type pivotBuffer struct {
+	n         *PivotNode
+	time      time.Time
+	name      string
+	groupInfo edge.GroupInfo
+
+	// rows maps the row key to its row, order contains the rows in the order they were first seen.
+	rows  map[string]*pivotRow
+	order []*pivotRow
+}
+
+func (b *pivotBuffer) BeginBatch(begin edge.BeginBatchMessage) error {
+	b.n.timer.Start()
+	defer b.n.timer.Stop()
+
+	b.name = begin.Name()
+	b.time = time.Time{}
+
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	b.n.timer.Pause()
+	err := edge.Forward(b.n.outs, begin)
+	b.n.timer.Resume()
+	return err
+}
+
+func (b *pivotBuffer) BatchPoint(bp edge.BatchPointMessage) error {
+	b.n.timer.Start()
+	defer b.n.timer.Stop()
+
+	t := bp.Time().Round(b.n.p.Tolerance)
+	if !t.Equal(b.time) {
+		if err := b.emitBatchPoints(); err != nil {
+			return err
+		}
+		b.time = t
+	}
+	b.addPoint(bp)
+	return nil
+}
+
+func (b *pivotBuffer) emitBatchPoints() error {
+	for _, row := range b.order {
+		p := edge.NewBatchPointMessage(
+			row.fields,
+			row.tags,
+			b.time,
+		)
+		b.n.timer.Pause()
+		err := edge.Forward(b.n.outs, p)
+		b.n.timer.Resume()
+		if err != nil {
+			return err
+		}
+	}
+	b.reset()
+	return nil
+}
+
+func (b *pivotBuffer) EndBatch(end edge.EndBatchMessage) error {
+	b.n.timer.Start()
+	defer b.n.timer.Stop()
+
+	if err := b.emitBatchPoints(); err != nil {
+		return err
+	}
+
+	b.n.timer.Pause()
+	err := edge.Forward(b.n.outs, end)
+	b.n.timer.Resume()
+	return err
+}
+
+func (b *pivotBuffer) Point(p edge.PointMessage) error {
+	b.n.timer.Start()
+	defer b.n.timer.Stop()
+
+	t := p.Time().Round(b.n.p.Tolerance)
+	if !t.Equal(b.time) {
+		if err := b.emitPoints(); err != nil {
+			return err
+		}
+		b.time = t
+	}
+	b.addPoint(p)
+	return nil
+}
+
+func (b *pivotBuffer) emitPoints() error {
+	for _, row := range b.order {
+		p := edge.NewPointMessage(
+			b.name, "", "",
+			b.groupInfo.Dimensions,
+			row.fields,
+			row.tags,
+			b.time,
+		)
+		b.n.timer.Pause()
+		err := edge.Forward(b.n.outs, p)
+		b.n.timer.Resume()
+		if err != nil {
+			return err
+		}
+	}
+	b.reset()
+	return nil
+}
+
+func (b *pivotBuffer) reset() {
+	b.rows = make(map[string]*pivotRow)
+	b.order = b.order[0:0]
+}
+
+// addPoint adds the value of the point to its row.
+func (b *pivotBuffer) addPoint(p edge.FieldsTagsTimeGetter) {
+	tags := p.Tags()
+	column, ok := tags[b.n.p.ColumnKey]
+	if !ok {
+		b.n.incrementErrorCount()
+		b.n.logger.Printf("E! point missing tag %q for pivot operation", b.n.p.ColumnKey)
+		return
+	}
+	value, ok := p.Fields()[b.n.p.ValueField]
+	if !ok {
+		b.n.incrementErrorCount()
+		b.n.logger.Printf("E! point missing field %q for pivot operation", b.n.p.ValueField)
+		return
+	}
+	values := make([]string, len(b.n.p.RowKeys))
+	var key bytes.Buffer
+	for i, tag := range b.n.p.RowKeys {
+		v, ok := tags[tag]
+		if !ok {
+			b.n.incrementErrorCount()
+			b.n.logger.Printf("E! point missing tag %q for pivot operation", tag)
+			return
+		}
+		values[i] = v
+		// Prefix each value with its length so that values containing any separator cannot collide.
+		key.WriteString(strconv.Itoa(len(v)))
+		key.WriteByte(':')
+		key.WriteString(v)
+	}
+	row, ok := b.rows[key.String()]
+	if !ok {
+		rowTags := b.groupInfo.Tags.Copy()
+		for i, tag := range b.n.p.RowKeys {
+			rowTags[tag] = values[i]
+		}
+		row = &pivotRow{
+			tags:   rowTags,
+			fields: make(models.Fields),
+		}
+		b.rows[key.String()] = row
+		b.order = append(b.order, row)
+	}
+	row.fields[column] = value
+}
+
+func (b *pivotBuffer) Barrier(barrier edge.BarrierMessage) error {
+	return edge.Forward(b.n.outs, barrier)
+}
+func (b *pivotBuffer) DeleteGroup(d edge.DeleteGroupMessage) error {
+	return edge.Forward(b.n.outs, d)
+}
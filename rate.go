@@ -0,0 +1,207 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/pkg/errors"
+)
+
+type RateNode struct {
+	node
+	r *pipeline.RateNode
+
+	// mu protects the state of all groups, since snapshots are taken concurrently.
+	mu sync.Mutex
+	// groups contains the previous value of each group.
+	groups map[models.GroupID]*rateGroup
+	// restored contains the previous values of groups from a snapshot, which have not yet received data.
+	restored map[models.GroupID]rateState
+	// expiry drops the restored states of groups that do not receive data again.
+	expiry restoredGroups
+}
+
+// rateState is the persisted state of a single group.
+type rateState struct {
+	Value float64   `json:"value"`
+	Time  time.Time `json:"time"`
+}
+
+// Create a new rate node.
+func newRateNode(et *ExecutingTask, n *pipeline.RateNode, l *log.Logger) (*RateNode, error) {
+	rn := &RateNode{
+		node:   node{Node: n, et: et, logger: l},
+		r:      n,
+		groups: make(map[models.GroupID]*rateGroup),
+	}
+	rn.node.runF = rn.runRate
+	return rn, nil
+}
+
+func (n *RateNode) runRate(snapshot []byte) error {
+	if err := n.restore(snapshot); err != nil {
+		return err
+	}
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *RateNode) snapshot() ([]byte, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.expiry.snapshotted() {
+		n.restored = nil
+	}
+	states := make(map[models.GroupID]rateState, len(n.groups)+len(n.restored))
+	for id, s := range n.restored {
+		states[id] = s
+	}
+	for id, g := range n.groups {
+		if g.hasPrevious {
+			states[id] = g.previous
+		}
+	}
+	return json.Marshal(states)
+}
+
+func (n *RateNode) restore(snapshot []byte) error {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := json.Unmarshal(snapshot, &n.restored); err != nil {
+		return errors.Wrap(err, "failed to restore rate snapshot")
+	}
+	return nil
+}
+
+func (n *RateNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup(group.ID)),
+	), nil
+}
+
+func (n *RateNode) newGroup(id models.GroupID) *rateGroup {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.expiry.received()
+	g := &rateGroup{
+		n:  n,
+		id: id,
+	}
+	if s, ok := n.restored[id]; ok {
+		g.previous = s
+		g.hasPrevious = true
+		delete(n.restored, id)
+	}
+	n.groups[id] = g
+	return g
+}
+
+type rateGroup struct {
+	n  *RateNode
+	id models.GroupID
+
+	previous    rateState
+	hasPrevious bool
+}
+
+func (g *rateGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if s := begin.SizeHint(); s > 0 {
+		begin = begin.ShallowCopy()
+		begin.SetSizeHint(s - 1)
+	}
+	g.n.mu.Lock()
+	g.hasPrevious = false
+	g.n.mu.Unlock()
+	return begin, nil
+}
+
+func (g *rateGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if g.doRate(bp) {
+		return bp, nil
+	}
+	return nil, nil
+}
+
+func (g *rateGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *rateGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if g.doRate(p) {
+		return p, nil
+	}
+	return nil, nil
+}
+
+// doRate computes the rate between the previous value and p.
+// The resulting rate is set on p and whether p should be emitted is returned.
+func (g *rateGroup) doRate(p edge.FieldsTagsTimeSetter) bool {
+	value, ok := numToFloat(p.Fields()[g.n.r.Field])
+	if !ok {
+		g.n.incrementErrorCount()
+		g.n.logger.Printf("E! field %q is missing or not numeric", g.n.r.Field)
+		return false
+	}
+	current := rateState{Value: value, Time: p.Time()}
+
+	g.n.mu.Lock()
+	previous, hasPrevious := g.previous, g.hasPrevious
+	g.previous, g.hasPrevious = current, true
+	g.n.mu.Unlock()
+
+	if !hasPrevious {
+		return false
+	}
+	rate, err := g.n.rate(previous, current)
+	if err != nil {
+		g.n.incrementErrorCount()
+		g.n.logger.Println("E! cannot compute rate:", err)
+		return false
+	}
+
+	fields := p.Fields().Copy()
+	fields[g.n.r.As] = rate
+	p.SetFields(fields)
+	return true
+}
+
+// rate calculates the rate of change from prev to curr.
+// If the field is a counter, a decrease is treated as a reset to zero.
+func (n *RateNode) rate(prev, curr rateState) (float64, error) {
+	elapsed := float64(curr.Time.Sub(prev.Time))
+	if elapsed <= 0 {
+		return 0, fmt.Errorf("elapsed time was %v", curr.Time.Sub(prev.Time))
+	}
+	diff := curr.Value - prev.Value
+	if n.r.NonNegativeFlag && diff < 0 {
+		// The counter was reset, so it has increased from zero.
+		diff = curr.Value
+	}
+	return diff / (elapsed / float64(n.r.Unit)), nil
+}
+
+func (g *rateGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *rateGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.n.mu.Lock()
+	delete(g.n.groups, g.id)
+	g.n.mu.Unlock()
+	return d, nil
+}
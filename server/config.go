@@ -12,11 +12,15 @@ import (
 	"time"
 
 	"github.com/influxdata/kapacitor/command"
+	"github.com/influxdata/kapacitor/services/alert"
 	"github.com/influxdata/kapacitor/services/alerta"
+	"github.com/influxdata/kapacitor/services/alertmanager"
+	"github.com/influxdata/kapacitor/services/amqp"
 	"github.com/influxdata/kapacitor/services/azure"
 	"github.com/influxdata/kapacitor/services/config"
 	"github.com/influxdata/kapacitor/services/consul"
 	"github.com/influxdata/kapacitor/services/deadman"
+	"github.com/influxdata/kapacitor/services/discord"
 	"github.com/influxdata/kapacitor/services/dns"
 	"github.com/influxdata/kapacitor/services/ec2"
 	"github.com/influxdata/kapacitor/services/file_discovery"
@@ -32,6 +36,7 @@ import (
 	"github.com/influxdata/kapacitor/services/nerve"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/pagerduty"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
 	"github.com/influxdata/kapacitor/services/pushover"
 	"github.com/influxdata/kapacitor/services/replay"
 	"github.com/influxdata/kapacitor/services/reporting"
@@ -46,6 +51,7 @@ import (
 	"github.com/influxdata/kapacitor/services/storage"
 	"github.com/influxdata/kapacitor/services/talk"
 	"github.com/influxdata/kapacitor/services/task_store"
+	"github.com/influxdata/kapacitor/services/teams"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/triton"
 	"github.com/influxdata/kapacitor/services/udf"
@@ -64,6 +70,7 @@ type Config struct {
 	Replay         replay.Config     `toml:"replay"`
 	Storage        storage.Config    `toml:"storage"`
 	Task           task_store.Config `toml:"task"`
+	Alert          alert.Config      `toml:"alert"`
 	InfluxDB       []influxdb.Config `toml:"influxdb" override:"influxdb,element-key=name"`
 	Logging        logging.Config    `toml:"logging"`
 	ConfigOverride config.Config     `toml:"config-override"`
@@ -75,20 +82,25 @@ type Config struct {
 	UDP      []udp.Config      `toml:"udp"`
 
 	// Alert handlers
-	Alerta    alerta.Config    `toml:"alerta" override:"alerta"`
-	HipChat   hipchat.Config   `toml:"hipchat" override:"hipchat"`
-	MQTT      mqtt.Configs     `toml:"mqtt" override:"mqtt,element-key=name"`
-	OpsGenie  opsgenie.Config  `toml:"opsgenie" override:"opsgenie"`
-	PagerDuty pagerduty.Config `toml:"pagerduty" override:"pagerduty"`
-	Pushover  pushover.Config  `toml:"pushover" override:"pushover"`
-	HTTPPost  httppost.Configs `toml:"httppost" override:"httppost,element-key=endpoint"`
-	SMTP      smtp.Config      `toml:"smtp" override:"smtp"`
-	SNMPTrap  snmptrap.Config  `toml:"snmptrap" override:"snmptrap"`
-	Sensu     sensu.Config     `toml:"sensu" override:"sensu"`
-	Slack     slack.Config     `toml:"slack" override:"slack"`
-	Talk      talk.Config      `toml:"talk" override:"talk"`
-	Telegram  telegram.Config  `toml:"telegram" override:"telegram"`
-	VictorOps victorops.Config `toml:"victorops" override:"victorops"`
+	Alerta       alerta.Config        `toml:"alerta" override:"alerta"`
+	AlertManager alertmanager.Configs `toml:"alertmanager" override:"alertmanager,element-key=workspace" env-config:"implicit-index"`
+	AMQP         amqp.Configs         `toml:"amqp" override:"amqp,element-key=name"`
+	Discord      discord.Configs      `toml:"discord" override:"discord,element-key=workspace"`
+	HipChat      hipchat.Configs      `toml:"hipchat" override:"hipchat,element-key=workspace" env-config:"implicit-index"`
+	MQTT         mqtt.Configs         `toml:"mqtt" override:"mqtt,element-key=name"`
+	OpsGenie     opsgenie.Configs     `toml:"opsgenie" override:"opsgenie,element-key=workspace" env-config:"implicit-index"`
+	PagerDuty    pagerduty.Configs    `toml:"pagerduty" override:"pagerduty,element-key=workspace" env-config:"implicit-index"`
+	PagerDuty2   pagerduty2.Configs   `toml:"pagerduty2" override:"pagerduty2,element-key=workspace" env-config:"implicit-index"`
+	Pushover     pushover.Configs     `toml:"pushover" override:"pushover,element-key=workspace" env-config:"implicit-index"`
+	HTTPPost     httppost.Configs     `toml:"httppost" override:"httppost,element-key=endpoint"`
+	SMTP         smtp.Configs         `toml:"smtp" override:"smtp,element-key=workspace" env-config:"implicit-index"`
+	SNMPTrap     snmptrap.Config      `toml:"snmptrap" override:"snmptrap"`
+	Sensu        sensu.Config         `toml:"sensu" override:"sensu"`
+	Slack        slack.Configs        `toml:"slack" override:"slack,element-key=workspace" env-config:"implicit-index"`
+	Talk         talk.Config          `toml:"talk" override:"talk"`
+	Teams        teams.Configs        `toml:"teams" override:"teams,element-key=workspace" env-config:"implicit-index"`
+	Telegram     telegram.Configs     `toml:"telegram" override:"telegram,element-key=workspace" env-config:"implicit-index"`
+	VictorOps    victorops.Configs    `toml:"victorops" override:"victorops,element-key=workspace" env-config:"implicit-index"`
 
 	// Discovery for scraping
 	Scraper         []scraper.Config          `toml:"scraper" override:"scraper,element-key=name"`
@@ -131,6 +143,7 @@ func NewConfig() *Config {
 	c.Storage = storage.NewConfig()
 	c.Replay = replay.NewConfig()
 	c.Task = task_store.NewConfig()
+	c.Alert = alert.NewConfig()
 	c.InfluxDB = []influxdb.Config{influxdb.NewConfig()}
 	c.Logging = logging.NewConfig()
 	c.ConfigOverride = config.NewConfig()
@@ -139,19 +152,24 @@ func NewConfig() *Config {
 	c.OpenTSDB = opentsdb.NewConfig()
 
 	c.Alerta = alerta.NewConfig()
-	c.HipChat = hipchat.NewConfig()
+	c.AlertManager = alertmanager.Configs{alertmanager.NewConfig()}
+	c.AMQP = amqp.Configs{}
+	c.Discord = discord.Configs{}
+	c.HipChat = hipchat.Configs{hipchat.NewConfig()}
 	c.MQTT = mqtt.Configs{}
-	c.OpsGenie = opsgenie.NewConfig()
-	c.PagerDuty = pagerduty.NewConfig()
-	c.Pushover = pushover.NewConfig()
+	c.OpsGenie = opsgenie.Configs{opsgenie.NewConfig()}
+	c.PagerDuty = pagerduty.Configs{pagerduty.NewConfig()}
+	c.PagerDuty2 = pagerduty2.Configs{pagerduty2.NewConfig()}
+	c.Pushover = pushover.Configs{pushover.NewConfig()}
 	c.HTTPPost = httppost.Configs{}
-	c.SMTP = smtp.NewConfig()
+	c.SMTP = smtp.Configs{smtp.NewConfig()}
 	c.Sensu = sensu.NewConfig()
-	c.Slack = slack.NewConfig()
+	c.Slack = slack.Configs{slack.NewConfig()}
 	c.Talk = talk.NewConfig()
+	c.Teams = teams.Configs{teams.NewConfig()}
 	c.SNMPTrap = snmptrap.NewConfig()
-	c.Telegram = telegram.NewConfig()
-	c.VictorOps = victorops.NewConfig()
+	c.Telegram = telegram.Configs{telegram.NewConfig()}
+	c.VictorOps = victorops.Configs{victorops.NewConfig()}
 
 	c.Reporting = reporting.NewConfig()
 	c.Stats = stats.NewConfig()
@@ -204,6 +222,9 @@ func (c *Config) Validate() error {
 	if err := c.Task.Validate(); err != nil {
 		return err
 	}
+	if err := c.Alert.Validate(); err != nil {
+		return err
+	}
 	// Validate the set of InfluxDB configs.
 	// All names should be unique.
 	names := make(map[string]bool, len(c.InfluxDB))
@@ -245,6 +266,15 @@ func (c *Config) Validate() error {
 	if err := c.Alerta.Validate(); err != nil {
 		return err
 	}
+	if err := c.AlertManager.Validate(); err != nil {
+		return err
+	}
+	if err := c.AMQP.Validate(); err != nil {
+		return err
+	}
+	if err := c.Discord.Validate(); err != nil {
+		return err
+	}
 	if err := c.HipChat.Validate(); err != nil {
 		return err
 	}
@@ -257,6 +287,9 @@ func (c *Config) Validate() error {
 	if err := c.PagerDuty.Validate(); err != nil {
 		return err
 	}
+	if err := c.PagerDuty2.Validate(); err != nil {
+		return err
+	}
 	if err := c.Pushover.Validate(); err != nil {
 		return err
 	}
@@ -278,6 +311,9 @@ func (c *Config) Validate() error {
 	if err := c.Talk.Validate(); err != nil {
 		return err
 	}
+	if err := c.Teams.Validate(); err != nil {
+		return err
+	}
 	if err := c.Telegram.Validate(); err != nil {
 		return err
 	}
@@ -81,3 +81,45 @@ headers = { Authorization = "your-key" }
 		t.Fatalf("unexpected header Authorization: %s", c.InfluxDB[0].URLs[0])
 	}
 }
+
+// Ensure alert handler sections can be parsed either as a single table or as a list of named workspaces.
+func TestConfig_Parse_Workspaces(t *testing.T) {
+	c := server.NewConfig()
+	if _, err := toml.Decode(`
+[smtp]
+enabled = true
+from = "kapacitor@example.com"
+
+[[slack]]
+enabled = true
+workspace = "dev"
+default = true
+url = "https://hooks.slack.com/services/dev"
+
+[[slack]]
+enabled = true
+workspace = "ops"
+url = "https://hooks.slack.com/services/ops"
+`, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SMTP.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Slack.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, exp := len(c.SMTP), 1; got != exp {
+		t.Fatalf("unexpected number of smtp configs: got %d exp %d", got, exp)
+	} else if c.SMTP[0].Host != "localhost" || c.SMTP[0].Port != 25 {
+		t.Fatalf("unexpected smtp defaults: %s:%d", c.SMTP[0].Host, c.SMTP[0].Port)
+	}
+	if got, exp := len(c.Slack), 2; got != exp {
+		t.Fatalf("unexpected number of slack configs: got %d exp %d", got, exp)
+	} else if c.Slack[1].Workspace != "ops" {
+		t.Fatalf("unexpected slack workspace: %s", c.Slack[1].Workspace)
+	} else if c.Slack[1].Username != "kapacitor" {
+		t.Fatalf("unexpected slack username: %s", c.Slack[1].Username)
+	}
+}
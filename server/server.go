@@ -23,10 +23,13 @@ import (
 	"github.com/influxdata/kapacitor/server/vars"
 	"github.com/influxdata/kapacitor/services/alert"
 	"github.com/influxdata/kapacitor/services/alerta"
+	"github.com/influxdata/kapacitor/services/alertmanager"
+	"github.com/influxdata/kapacitor/services/amqp"
 	"github.com/influxdata/kapacitor/services/azure"
 	"github.com/influxdata/kapacitor/services/config"
 	"github.com/influxdata/kapacitor/services/consul"
 	"github.com/influxdata/kapacitor/services/deadman"
+	"github.com/influxdata/kapacitor/services/discord"
 	"github.com/influxdata/kapacitor/services/dns"
 	"github.com/influxdata/kapacitor/services/ec2"
 	"github.com/influxdata/kapacitor/services/file_discovery"
@@ -43,6 +46,7 @@ import (
 	"github.com/influxdata/kapacitor/services/noauth"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/pagerduty"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
 	"github.com/influxdata/kapacitor/services/pushover"
 	"github.com/influxdata/kapacitor/services/replay"
 	"github.com/influxdata/kapacitor/services/reporting"
@@ -58,6 +62,7 @@ import (
 	"github.com/influxdata/kapacitor/services/storage"
 	"github.com/influxdata/kapacitor/services/talk"
 	"github.com/influxdata/kapacitor/services/task_store"
+	"github.com/influxdata/kapacitor/services/teams"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/triton"
 	"github.com/influxdata/kapacitor/services/udf"
@@ -205,12 +210,16 @@ func New(c *Config, buildInfo BuildInfo, logService logging.Interface) (*Server,
 
 	// Append Alert integration services
 	s.appendAlertaService()
+	s.appendAlertManagerService()
+	s.appendAMQPService()
+	s.appendDiscordService()
 	s.appendHipChatService()
 	if err := s.appendMQTTService(); err != nil {
 		return nil, errors.Wrap(err, "mqtt service")
 	}
 	s.appendOpsGenieService()
 	s.appendPagerDutyService()
+	s.appendPagerDuty2Service()
 	s.appendPushoverService()
 	s.appendHTTPPostService()
 	s.appendSMTPService()
@@ -221,6 +230,7 @@ func New(c *Config, buildInfo BuildInfo, logService logging.Interface) (*Server,
 	s.appendSNMPTrapService()
 	s.appendSensuService()
 	s.appendTalkService()
+	s.appendTeamsService()
 	s.appendVictorOpsService()
 
 	// Append alert service
@@ -314,7 +324,7 @@ func (s *Server) appendConfigOverrideService() {
 
 func (s *Server) initAlertService() {
 	l := s.LogService.NewLogger("[alert] ", log.LstdFlags)
-	srv := alert.NewService(l)
+	srv := alert.NewService(s.config.Alert, l)
 
 	srv.Commander = s.Commander
 	srv.HTTPDService = s.HTTPDService
@@ -466,6 +476,7 @@ func (s *Server) appendMQTTService() error {
 	if err != nil {
 		return err
 	}
+	srv.PointsWriter = s.TaskMaster
 
 	s.TaskMaster.MQTTService = srv
 	s.AlertService.MQTTService = srv
@@ -512,6 +523,44 @@ func (s *Server) appendPagerDutyService() {
 	s.AppendService("pagerduty", srv)
 }
 
+func (s *Server) appendAlertManagerService() {
+	c := s.config.AlertManager
+	l := s.LogService.NewLogger("[alertmanager] ", log.LstdFlags)
+	srv := alertmanager.NewService(c, l)
+	srv.HTTPDService = s.HTTPDService
+
+	s.TaskMaster.AlertManagerService = srv
+	s.AlertService.AlertManagerService = srv
+
+	s.SetDynamicService("alertmanager", srv)
+	s.AppendService("alertmanager", srv)
+}
+
+func (s *Server) appendAMQPService() {
+	c := s.config.AMQP
+	l := s.LogService.NewLogger("[amqp] ", log.LstdFlags)
+	srv := amqp.NewService(c, l)
+
+	s.TaskMaster.AMQPService = srv
+	s.AlertService.AMQPService = srv
+
+	s.SetDynamicService("amqp", srv)
+	s.AppendService("amqp", srv)
+}
+
+func (s *Server) appendPagerDuty2Service() {
+	c := s.config.PagerDuty2
+	l := s.LogService.NewLogger("[pagerduty2] ", log.LstdFlags)
+	srv := pagerduty2.NewService(c, l)
+	srv.HTTPDService = s.HTTPDService
+
+	s.TaskMaster.PagerDuty2Service = srv
+	s.AlertService.PagerDuty2Service = srv
+
+	s.SetDynamicService("pagerduty2", srv)
+	s.AppendService("pagerduty2", srv)
+}
+
 func (s *Server) appendPushoverService() {
 	c := s.config.Pushover
 	l := s.LogService.NewLogger("[pushover] ", log.LstdFlags)
@@ -612,6 +661,18 @@ func (s *Server) appendAlertaService() {
 	s.AppendService("alerta", srv)
 }
 
+func (s *Server) appendDiscordService() {
+	cs := s.config.Discord
+	l := s.LogService.NewLogger("[discord] ", log.LstdFlags)
+	srv := discord.NewService(cs, l)
+
+	s.TaskMaster.DiscordService = srv
+	s.AlertService.DiscordService = srv
+
+	s.SetDynamicService("discord", srv)
+	s.AppendService("discord", srv)
+}
+
 func (s *Server) appendTalkService() {
 	c := s.config.Talk
 	l := s.LogService.NewLogger("[talk] ", log.LstdFlags)
@@ -624,6 +685,18 @@ func (s *Server) appendTalkService() {
 	s.AppendService("talk", srv)
 }
 
+func (s *Server) appendTeamsService() {
+	c := s.config.Teams
+	l := s.LogService.NewLogger("[teams] ", log.LstdFlags)
+	srv := teams.NewService(c, l)
+
+	s.TaskMaster.TeamsService = srv
+	s.AlertService.TeamsService = srv
+
+	s.SetDynamicService("teams", srv)
+	s.AppendService("teams", srv)
+}
+
 func (s *Server) appendCollectdService() {
 	c := s.config.Collectd
 	if !c.Enabled {
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"net/mail"
 	"net/url"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,6 +35,12 @@ import (
 	"github.com/influxdata/kapacitor/server"
 	"github.com/influxdata/kapacitor/services/alert/alerttest"
 	"github.com/influxdata/kapacitor/services/alerta/alertatest"
+	"github.com/influxdata/kapacitor/services/alertmanager"
+	"github.com/influxdata/kapacitor/services/alertmanager/alertmanagertest"
+	"github.com/influxdata/kapacitor/services/amqp"
+	"github.com/influxdata/kapacitor/services/amqp/amqptest"
+	"github.com/influxdata/kapacitor/services/discord"
+	"github.com/influxdata/kapacitor/services/discord/discordtest"
 	"github.com/influxdata/kapacitor/services/hipchat/hipchattest"
 	"github.com/influxdata/kapacitor/services/httppost"
 	"github.com/influxdata/kapacitor/services/k8s"
@@ -42,12 +50,17 @@ import (
 	"github.com/influxdata/kapacitor/services/opsgenie/opsgenietest"
 	"github.com/influxdata/kapacitor/services/pagerduty"
 	"github.com/influxdata/kapacitor/services/pagerduty/pagerdutytest"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
+	"github.com/influxdata/kapacitor/services/pagerduty2/pagerduty2test"
 	"github.com/influxdata/kapacitor/services/pushover/pushovertest"
 	"github.com/influxdata/kapacitor/services/sensu/sensutest"
+	"github.com/influxdata/kapacitor/services/slack"
 	"github.com/influxdata/kapacitor/services/slack/slacktest"
 	"github.com/influxdata/kapacitor/services/smtp/smtptest"
 	"github.com/influxdata/kapacitor/services/snmptrap/snmptraptest"
 	"github.com/influxdata/kapacitor/services/talk/talktest"
+	"github.com/influxdata/kapacitor/services/teams"
+	"github.com/influxdata/kapacitor/services/teams/teamstest"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/telegram/telegramtest"
 	"github.com/influxdata/kapacitor/services/udf"
@@ -5982,6 +5995,272 @@ func TestServer_UpdateConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			section: "alertmanager",
+			setDefaults: func(c *server.Config) {
+				c.AlertManager[0].ResendInterval = toml.Duration(30 * time.Second)
+			},
+			expDefaultSection: client.ConfigSection{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/alertmanager"},
+				Elements: []client.ConfigElement{{
+					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/alertmanager/"},
+					Options: map[string]interface{}{
+						"enabled":         false,
+						"workspace":       "",
+						"default":         false,
+						"global":          false,
+						"url":             alertmanager.DefaultURL,
+						"resend-interval": "30s",
+					},
+					Redacted: nil,
+				}},
+			},
+			expDefaultElement: client.ConfigElement{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/alertmanager/"},
+				Options: map[string]interface{}{
+					"enabled":         false,
+					"workspace":       "",
+					"default":         false,
+					"global":          false,
+					"url":             alertmanager.DefaultURL,
+					"resend-interval": "30s",
+				},
+				Redacted: nil,
+			},
+			updates: []updateAction{
+				{
+					updateAction: client.ConfigUpdateAction{
+						Set: map[string]interface{}{
+							"enabled":         true,
+							"url":             "http://alertmanager.example.com:9093/api/v1/alerts",
+							"resend-interval": "5m",
+						},
+					},
+					expSection: client.ConfigSection{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/alertmanager"},
+						Elements: []client.ConfigElement{{
+							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/alertmanager/"},
+							Options: map[string]interface{}{
+								"enabled":         true,
+								"workspace":       "",
+								"default":         false,
+								"global":          false,
+								"url":             "http://alertmanager.example.com:9093/api/v1/alerts",
+								"resend-interval": "5m0s",
+							},
+							Redacted: nil,
+						}},
+					},
+					expElement: client.ConfigElement{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/alertmanager/"},
+						Options: map[string]interface{}{
+							"enabled":         true,
+							"workspace":       "",
+							"default":         false,
+							"global":          false,
+							"url":             "http://alertmanager.example.com:9093/api/v1/alerts",
+							"resend-interval": "5m0s",
+						},
+						Redacted: nil,
+					},
+				},
+			},
+		},
+		{
+			section: "amqp",
+			setDefaults: func(c *server.Config) {
+				c.AMQP = amqp.Configs{amqp.NewConfig()}
+				c.AMQP[0].Name = "default"
+			},
+			element: "default",
+			expDefaultSection: client.ConfigSection{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/amqp"},
+				Elements: []client.ConfigElement{{
+					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/amqp/default"},
+					Options: map[string]interface{}{
+						"enabled":              false,
+						"name":                 "default",
+						"default":              false,
+						"url":                  true,
+						"ssl-ca":               "",
+						"ssl-cert":             "",
+						"ssl-key":              "",
+						"insecure-skip-verify": false,
+						"exchange":             "amq.topic",
+						"routing-key":          "kapacitor.{{ .Level }}",
+						"persistent":           true,
+						"confirm-timeout":      "10s",
+						"reconnect-interval":   "5s",
+					},
+					Redacted: []string{
+						"url",
+					},
+				}},
+			},
+			expDefaultElement: client.ConfigElement{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/amqp/default"},
+				Options: map[string]interface{}{
+					"enabled":              false,
+					"name":                 "default",
+					"default":              false,
+					"url":                  true,
+					"ssl-ca":               "",
+					"ssl-cert":             "",
+					"ssl-key":              "",
+					"insecure-skip-verify": false,
+					"exchange":             "amq.topic",
+					"routing-key":          "kapacitor.{{ .Level }}",
+					"persistent":           true,
+					"confirm-timeout":      "10s",
+					"reconnect-interval":   "5s",
+				},
+				Redacted: []string{
+					"url",
+				},
+			},
+			updates: []updateAction{
+				{
+					updateAction: client.ConfigUpdateAction{
+						Set: map[string]interface{}{
+							"routing-key": "alerts.{{ .TaskName }}",
+						},
+					},
+					element: "default",
+					expSection: client.ConfigSection{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/amqp"},
+						Elements: []client.ConfigElement{{
+							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/amqp/default"},
+							Options: map[string]interface{}{
+								"enabled":              false,
+								"name":                 "default",
+								"default":              false,
+								"url":                  true,
+								"ssl-ca":               "",
+								"ssl-cert":             "",
+								"ssl-key":              "",
+								"insecure-skip-verify": false,
+								"exchange":             "amq.topic",
+								"routing-key":          "alerts.{{ .TaskName }}",
+								"persistent":           true,
+								"confirm-timeout":      "10s",
+								"reconnect-interval":   "5s",
+							},
+							Redacted: []string{
+								"url",
+							},
+						}},
+					},
+					expElement: client.ConfigElement{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/amqp/default"},
+						Options: map[string]interface{}{
+							"enabled":              false,
+							"name":                 "default",
+							"default":              false,
+							"url":                  true,
+							"ssl-ca":               "",
+							"ssl-cert":             "",
+							"ssl-key":              "",
+							"insecure-skip-verify": false,
+							"exchange":             "amq.topic",
+							"routing-key":          "alerts.{{ .TaskName }}",
+							"persistent":           true,
+							"confirm-timeout":      "10s",
+							"reconnect-interval":   "5s",
+						},
+						Redacted: []string{
+							"url",
+						},
+					},
+				},
+			},
+		},
+		{
+			section: "discord",
+			element: "test",
+			setDefaults: func(c *server.Config) {
+				c.Discord = discord.Configs{{
+					Workspace: "test",
+					URL:       "http://discord.example.com/secret-token",
+				}}
+			},
+			expDefaultSection: client.ConfigSection{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/discord"},
+				Elements: []client.ConfigElement{{
+					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/discord/test"},
+					Options: map[string]interface{}{
+						"enabled":     false,
+						"workspace":   "test",
+						"default":     false,
+						"url":         true,
+						"username":    "",
+						"avatar-url":  "",
+						"embed-title": "",
+					},
+					Redacted: []string{
+						"url",
+					},
+				}},
+			},
+			expDefaultElement: client.ConfigElement{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/discord/test"},
+				Options: map[string]interface{}{
+					"enabled":     false,
+					"workspace":   "test",
+					"default":     false,
+					"url":         true,
+					"username":    "",
+					"avatar-url":  "",
+					"embed-title": "",
+				},
+				Redacted: []string{
+					"url",
+				},
+			},
+			updates: []updateAction{
+				{
+					element: "test",
+					updateAction: client.ConfigUpdateAction{
+						Set: map[string]interface{}{
+							"enabled":     true,
+							"embed-title": "On-call",
+						},
+					},
+					expSection: client.ConfigSection{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/discord"},
+						Elements: []client.ConfigElement{{
+							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/discord/test"},
+							Options: map[string]interface{}{
+								"enabled":     true,
+								"workspace":   "test",
+								"default":     false,
+								"url":         true,
+								"username":    "",
+								"avatar-url":  "",
+								"embed-title": "On-call",
+							},
+							Redacted: []string{
+								"url",
+							},
+						}},
+					},
+					expElement: client.ConfigElement{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/discord/test"},
+						Options: map[string]interface{}{
+							"enabled":     true,
+							"workspace":   "test",
+							"default":     false,
+							"url":         true,
+							"username":    "",
+							"avatar-url":  "",
+							"embed-title": "On-call",
+						},
+						Redacted: []string{
+							"url",
+						},
+					},
+				},
+			},
+		},
 		{
 			section: "httppost",
 			element: "test",
@@ -6006,7 +6285,11 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"headers": map[string]interface{}{
 								"testing": "works",
 							},
-							"basic-auth": false,
+							"basic-auth":     false,
+							"content-type":   "",
+							"templates":      nil,
+							"alert-template": "",
+							"row-template":   "",
 						},
 						Redacted: []string{
 							"basic-auth",
@@ -6021,7 +6304,11 @@ func TestServer_UpdateConfig(t *testing.T) {
 					"headers": map[string]interface{}{
 						"testing": "works",
 					},
-					"basic-auth": false,
+					"basic-auth":     false,
+					"content-type":   "",
+					"templates":      nil,
+					"alert-template": "",
+					"row-template":   "",
 				},
 				Redacted: []string{
 					"basic-auth",
@@ -6051,7 +6338,11 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"headers": map[string]interface{}{
 									"testing": "more",
 								},
-								"basic-auth": true,
+								"basic-auth":     true,
+								"content-type":   "",
+								"templates":      nil,
+								"alert-template": "",
+								"row-template":   "",
 							},
 							Redacted: []string{
 								"basic-auth",
@@ -6066,7 +6357,11 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"headers": map[string]interface{}{
 								"testing": "more",
 							},
-							"basic-auth": true,
+							"basic-auth":     true,
+							"content-type":   "",
+							"templates":      nil,
+							"alert-template": "",
+							"row-template":   "",
 						},
 						Redacted: []string{
 							"basic-auth",
@@ -6078,17 +6373,19 @@ func TestServer_UpdateConfig(t *testing.T) {
 		{
 			section: "pushover",
 			setDefaults: func(c *server.Config) {
-				c.Pushover.URL = "http://pushover.example.com"
+				c.Pushover[0].URL = "http://pushover.example.com"
 			},
 			expDefaultSection: client.ConfigSection{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pushover"},
 				Elements: []client.ConfigElement{{
 					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pushover/"},
 					Options: map[string]interface{}{
-						"enabled":  false,
-						"token":    false,
-						"user-key": false,
-						"url":      "http://pushover.example.com",
+						"enabled":   false,
+						"workspace": "",
+						"default":   false,
+						"token":     false,
+						"user-key":  false,
+						"url":       "http://pushover.example.com",
 					},
 					Redacted: []string{
 						"token",
@@ -6099,10 +6396,12 @@ func TestServer_UpdateConfig(t *testing.T) {
 			expDefaultElement: client.ConfigElement{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pushover/"},
 				Options: map[string]interface{}{
-					"enabled":  false,
-					"token":    false,
-					"user-key": false,
-					"url":      "http://pushover.example.com",
+					"enabled":   false,
+					"workspace": "",
+					"default":   false,
+					"token":     false,
+					"user-key":  false,
+					"url":       "http://pushover.example.com",
 				},
 				Redacted: []string{
 					"token",
@@ -6122,10 +6421,12 @@ func TestServer_UpdateConfig(t *testing.T) {
 						Elements: []client.ConfigElement{{
 							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pushover/"},
 							Options: map[string]interface{}{
-								"enabled":  false,
-								"user-key": true,
-								"token":    true,
-								"url":      "http://pushover.example.com",
+								"enabled":   false,
+								"workspace": "",
+								"default":   false,
+								"user-key":  true,
+								"token":     true,
+								"url":       "http://pushover.example.com",
 							},
 							Redacted: []string{
 								"token",
@@ -6136,10 +6437,12 @@ func TestServer_UpdateConfig(t *testing.T) {
 					expElement: client.ConfigElement{
 						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pushover/"},
 						Options: map[string]interface{}{
-							"enabled":  false,
-							"user-key": true,
-							"token":    true,
-							"url":      "http://pushover.example.com",
+							"enabled":   false,
+							"workspace": "",
+							"default":   false,
+							"user-key":  true,
+							"token":     true,
+							"url":       "http://pushover.example.com",
 						},
 						Redacted: []string{
 							"token",
@@ -6238,7 +6541,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 		{
 			section: "hipchat",
 			setDefaults: func(c *server.Config) {
-				c.HipChat.URL = "http://hipchat.example.com"
+				c.HipChat[0].URL = "http://hipchat.example.com"
 			},
 			expDefaultSection: client.ConfigSection{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/hipchat"},
@@ -6246,6 +6549,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/hipchat/"},
 					Options: map[string]interface{}{
 						"enabled":            false,
+						"workspace":          "",
+						"default":            false,
 						"global":             false,
 						"room":               "",
 						"state-changes-only": false,
@@ -6261,6 +6566,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/hipchat/"},
 				Options: map[string]interface{}{
 					"enabled":            false,
+					"workspace":          "",
+					"default":            false,
 					"global":             false,
 					"room":               "",
 					"state-changes-only": false,
@@ -6285,6 +6592,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/hipchat/"},
 							Options: map[string]interface{}{
 								"enabled":            false,
+								"workspace":          "",
+								"default":            false,
 								"global":             false,
 								"room":               "kapacitor",
 								"state-changes-only": false,
@@ -6300,6 +6609,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/hipchat/"},
 						Options: map[string]interface{}{
 							"enabled":            false,
+							"workspace":          "",
+							"default":            false,
 							"global":             false,
 							"room":               "kapacitor",
 							"state-changes-only": false,
@@ -6339,6 +6650,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						"client-id":            "",
 						"username":             "",
 						"password":             false,
+						"subscriptions":        nil,
 					},
 					Redacted: []string{
 						"password",
@@ -6359,6 +6671,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 					"client-id":            "",
 					"username":             "",
 					"password":             false,
+					"subscriptions":        nil,
 				},
 				Redacted: []string{
 					"password",
@@ -6389,6 +6702,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"client-id":            "kapacitor-default",
 								"username":             "",
 								"password":             true,
+								"subscriptions":        nil,
 							},
 							Redacted: []string{
 								"password",
@@ -6409,6 +6723,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"client-id":            "kapacitor-default",
 							"username":             "",
 							"password":             true,
+							"subscriptions":        nil,
 						},
 						Redacted: []string{
 							"password",
@@ -6420,7 +6735,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 		{
 			section: "opsgenie",
 			setDefaults: func(c *server.Config) {
-				c.OpsGenie.URL = "http://opsgenie.example.com"
+				c.OpsGenie[0].URL = "http://opsgenie.example.com"
 			},
 			expDefaultSection: client.ConfigSection{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opsgenie"},
@@ -6429,6 +6744,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 					Options: map[string]interface{}{
 						"api-key":      false,
 						"enabled":      false,
+						"workspace":    "",
+						"default":      false,
 						"global":       false,
 						"recipients":   nil,
 						"recovery_url": opsgenie.DefaultOpsGenieRecoveryURL,
@@ -6445,6 +6762,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 				Options: map[string]interface{}{
 					"api-key":      false,
 					"enabled":      false,
+					"workspace":    "",
+					"default":      false,
 					"global":       false,
 					"recipients":   nil,
 					"recovery_url": opsgenie.DefaultOpsGenieRecoveryURL,
@@ -6471,6 +6790,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 							Options: map[string]interface{}{
 								"api-key":      true,
 								"enabled":      false,
+								"workspace":    "",
+								"default":      false,
 								"global":       true,
 								"recipients":   nil,
 								"recovery_url": opsgenie.DefaultOpsGenieRecoveryURL,
@@ -6487,6 +6808,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 						Options: map[string]interface{}{
 							"api-key":      true,
 							"enabled":      false,
+							"workspace":    "",
+							"default":      false,
 							"global":       true,
 							"recipients":   nil,
 							"recovery_url": opsgenie.DefaultOpsGenieRecoveryURL,
@@ -6503,7 +6826,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 		{
 			section: "pagerduty",
 			setDefaults: func(c *server.Config) {
-				c.PagerDuty.ServiceKey = "secret"
+				c.PagerDuty[0].ServiceKey = "secret"
 			},
 			expDefaultSection: client.ConfigSection{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty"},
@@ -6511,6 +6834,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty/"},
 					Options: map[string]interface{}{
 						"enabled":     false,
+						"workspace":   "",
+						"default":     false,
 						"global":      false,
 						"service-key": true,
 						"url":         pagerduty.DefaultPagerDutyAPIURL,
@@ -6524,6 +6849,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty/"},
 				Options: map[string]interface{}{
 					"enabled":     false,
+					"workspace":   "",
+					"default":     false,
 					"global":      false,
 					"service-key": true,
 					"url":         pagerduty.DefaultPagerDutyAPIURL,
@@ -6546,6 +6873,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty/"},
 							Options: map[string]interface{}{
 								"enabled":     true,
+								"workspace":   "",
+								"default":     false,
 								"global":      false,
 								"service-key": false,
 								"url":         pagerduty.DefaultPagerDutyAPIURL,
@@ -6559,6 +6888,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty/"},
 						Options: map[string]interface{}{
 							"enabled":     true,
+							"workspace":   "",
+							"default":     false,
 							"global":      false,
 							"service-key": false,
 							"url":         pagerduty.DefaultPagerDutyAPIURL,
@@ -6570,10 +6901,88 @@ func TestServer_UpdateConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			section: "pagerduty2",
+			setDefaults: func(c *server.Config) {
+				c.PagerDuty2[0].RoutingKey = "secret"
+			},
+			expDefaultSection: client.ConfigSection{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2"},
+				Elements: []client.ConfigElement{{
+					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2/"},
+					Options: map[string]interface{}{
+						"enabled":     false,
+						"workspace":   "",
+						"default":     false,
+						"global":      false,
+						"routing-key": true,
+						"url":         pagerduty2.DefaultPagerDuty2APIURL,
+					},
+					Redacted: []string{
+						"routing-key",
+					},
+				}},
+			},
+			expDefaultElement: client.ConfigElement{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2/"},
+				Options: map[string]interface{}{
+					"enabled":     false,
+					"workspace":   "",
+					"default":     false,
+					"global":      false,
+					"routing-key": true,
+					"url":         pagerduty2.DefaultPagerDuty2APIURL,
+				},
+				Redacted: []string{
+					"routing-key",
+				},
+			},
+			updates: []updateAction{
+				{
+					updateAction: client.ConfigUpdateAction{
+						Set: map[string]interface{}{
+							"routing-key": "",
+							"enabled":     true,
+						},
+					},
+					expSection: client.ConfigSection{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2"},
+						Elements: []client.ConfigElement{{
+							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2/"},
+							Options: map[string]interface{}{
+								"enabled":     true,
+								"workspace":   "",
+								"default":     false,
+								"global":      false,
+								"routing-key": false,
+								"url":         pagerduty2.DefaultPagerDuty2APIURL,
+							},
+							Redacted: []string{
+								"routing-key",
+							},
+						}},
+					},
+					expElement: client.ConfigElement{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2/"},
+						Options: map[string]interface{}{
+							"enabled":     true,
+							"workspace":   "",
+							"default":     false,
+							"global":      false,
+							"routing-key": false,
+							"url":         pagerduty2.DefaultPagerDuty2APIURL,
+						},
+						Redacted: []string{
+							"routing-key",
+						},
+					},
+				},
+			},
+		},
 		{
 			section: "smtp",
 			setDefaults: func(c *server.Config) {
-				c.SMTP.Host = "smtp.example.com"
+				c.SMTP[0].Host = "smtp.example.com"
 			},
 			expDefaultSection: client.ConfigSection{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/smtp"},
@@ -6581,6 +6990,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/smtp/"},
 					Options: map[string]interface{}{
 						"enabled":            false,
+						"workspace":          "",
+						"default":            false,
 						"from":               "",
 						"global":             false,
 						"host":               "smtp.example.com",
@@ -6589,6 +7000,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 						"password":           false,
 						"port":               float64(25),
 						"state-changes-only": false,
+						"template":           "",
+						"templates":          nil,
 						"to":                 nil,
 						"username":           "",
 					},
@@ -6601,6 +7014,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/smtp/"},
 				Options: map[string]interface{}{
 					"enabled":            false,
+					"workspace":          "",
+					"default":            false,
 					"from":               "",
 					"global":             false,
 					"host":               "smtp.example.com",
@@ -6609,6 +7024,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 					"password":           false,
 					"port":               float64(25),
 					"state-changes-only": false,
+					"template":           "",
+					"templates":          nil,
 					"to":                 nil,
 					"username":           "",
 				},
@@ -6623,6 +7040,13 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"idle-timeout": "1m0s",
 							"global":       true,
 							"password":     "secret",
+							"template":     "alert",
+							"templates": map[string]interface{}{
+								"alert": map[string]interface{}{
+									"subject": "{{ .Level }}: {{ .ID }}",
+									"html":    "<b>{{ .Message }}</b>",
+								},
+							},
 						},
 					},
 					expSection: client.ConfigSection{
@@ -6631,6 +7055,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/smtp/"},
 							Options: map[string]interface{}{
 								"enabled":            false,
+								"workspace":          "",
+								"default":            false,
 								"from":               "",
 								"global":             true,
 								"host":               "smtp.example.com",
@@ -6639,8 +7065,17 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"password":           true,
 								"port":               float64(25),
 								"state-changes-only": false,
-								"to":                 nil,
-								"username":           "",
+								"template":           "alert",
+								"templates": map[string]interface{}{
+									"alert": map[string]interface{}{
+										"subject":    "{{ .Level }}: {{ .ID }}",
+										"text":       "",
+										"html":       "<b>{{ .Message }}</b>",
+										"attach-csv": false,
+									},
+								},
+								"to":       nil,
+								"username": "",
 							},
 							Redacted: []string{
 								"password",
@@ -6651,6 +7086,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/smtp/"},
 						Options: map[string]interface{}{
 							"enabled":            false,
+							"workspace":          "",
+							"default":            false,
 							"from":               "",
 							"global":             true,
 							"host":               "smtp.example.com",
@@ -6659,8 +7096,17 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"password":           true,
 							"port":               float64(25),
 							"state-changes-only": false,
-							"to":                 nil,
-							"username":           "",
+							"template":           "alert",
+							"templates": map[string]interface{}{
+								"alert": map[string]interface{}{
+									"subject":    "{{ .Level }}: {{ .ID }}",
+									"text":       "",
+									"html":       "<b>{{ .Message }}</b>",
+									"attach-csv": false,
+								},
+							},
+							"to":       nil,
+							"username": "",
 						},
 						Redacted: []string{
 							"password",
@@ -6735,7 +7181,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 		{
 			section: "slack",
 			setDefaults: func(c *server.Config) {
-				c.Slack.Global = true
+				c.Slack[0].Global = true
 			},
 			expDefaultSection: client.ConfigSection{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/slack"},
@@ -6744,6 +7190,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 					Options: map[string]interface{}{
 						"channel":              "",
 						"enabled":              false,
+						"workspace":            "",
+						"default":              false,
 						"global":               true,
 						"icon-emoji":           "",
 						"state-changes-only":   false,
@@ -6764,6 +7212,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 				Options: map[string]interface{}{
 					"channel":              "",
 					"enabled":              false,
+					"workspace":            "",
+					"default":              false,
 					"global":               true,
 					"icon-emoji":           "",
 					"state-changes-only":   false,
@@ -6795,6 +7245,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 							Options: map[string]interface{}{
 								"channel":              "#general",
 								"enabled":              true,
+								"workspace":            "",
+								"default":              false,
 								"global":               false,
 								"icon-emoji":           "",
 								"state-changes-only":   false,
@@ -6815,6 +7267,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 						Options: map[string]interface{}{
 							"channel":              "#general",
 							"enabled":              true,
+							"workspace":            "",
+							"default":              false,
 							"global":               false,
 							"icon-emoji":           "",
 							"state-changes-only":   false,
@@ -6972,44 +7426,130 @@ func TestServer_UpdateConfig(t *testing.T) {
 			},
 		},
 		{
-			section: "telegram",
+			section: "teams",
 			setDefaults: func(c *server.Config) {
-				c.Telegram.ChatId = "kapacitor"
+				c.Teams[0].ChannelURL = "http://teams.example.com/secret-token"
 			},
 			expDefaultSection: client.ConfigSection{
-				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/telegram"},
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/teams"},
 				Elements: []client.ConfigElement{{
-					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/telegram/"},
+					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/teams/"},
 					Options: map[string]interface{}{
-						"chat-id":                  "kapacitor",
-						"disable-notification":     false,
-						"disable-web-page-preview": false,
-						"enabled":                  false,
-						"global":                   false,
-						"parse-mode":               "",
-						"state-changes-only":       false,
-						"token":                    false,
-						"url":                      telegram.DefaultTelegramURL,
+						"enabled":            false,
+						"workspace":          "",
+						"default":            false,
+						"channel-url":        true,
+						"card-type":          teams.MessageCard,
+						"global":             false,
+						"state-changes-only": false,
 					},
 					Redacted: []string{
-						"token",
+						"channel-url",
 					},
 				}},
 			},
 			expDefaultElement: client.ConfigElement{
-				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/telegram/"},
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/teams/"},
 				Options: map[string]interface{}{
-					"chat-id":                  "kapacitor",
-					"disable-notification":     false,
-					"disable-web-page-preview": false,
-					"enabled":                  false,
-					"global":                   false,
-					"parse-mode":               "",
-					"state-changes-only":       false,
-					"token":                    false,
-					"url":                      telegram.DefaultTelegramURL,
-				},
-				Redacted: []string{
+					"enabled":            false,
+					"workspace":          "",
+					"default":            false,
+					"channel-url":        true,
+					"card-type":          teams.MessageCard,
+					"global":             false,
+					"state-changes-only": false,
+				},
+				Redacted: []string{
+					"channel-url",
+				},
+			},
+			updates: []updateAction{
+				{
+					updateAction: client.ConfigUpdateAction{
+						Set: map[string]interface{}{
+							"enabled":   true,
+							"card-type": teams.AdaptiveCard,
+						},
+					},
+					expSection: client.ConfigSection{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/teams"},
+						Elements: []client.ConfigElement{{
+							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/teams/"},
+							Options: map[string]interface{}{
+								"enabled":            true,
+								"workspace":          "",
+								"default":            false,
+								"channel-url":        true,
+								"card-type":          teams.AdaptiveCard,
+								"global":             false,
+								"state-changes-only": false,
+							},
+							Redacted: []string{
+								"channel-url",
+							},
+						}},
+					},
+					expElement: client.ConfigElement{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/teams/"},
+						Options: map[string]interface{}{
+							"enabled":            true,
+							"workspace":          "",
+							"default":            false,
+							"channel-url":        true,
+							"card-type":          teams.AdaptiveCard,
+							"global":             false,
+							"state-changes-only": false,
+						},
+						Redacted: []string{
+							"channel-url",
+						},
+					},
+				},
+			},
+		},
+		{
+			section: "telegram",
+			setDefaults: func(c *server.Config) {
+				c.Telegram[0].ChatId = "kapacitor"
+			},
+			expDefaultSection: client.ConfigSection{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/telegram"},
+				Elements: []client.ConfigElement{{
+					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/telegram/"},
+					Options: map[string]interface{}{
+						"chat-id":                  "kapacitor",
+						"disable-notification":     false,
+						"disable-web-page-preview": false,
+						"enabled":                  false,
+						"workspace":                "",
+						"default":                  false,
+						"global":                   false,
+						"parse-mode":               "",
+						"state-changes-only":       false,
+						"token":                    false,
+						"url":                      telegram.DefaultTelegramURL,
+					},
+					Redacted: []string{
+						"token",
+					},
+				}},
+			},
+			expDefaultElement: client.ConfigElement{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/telegram/"},
+				Options: map[string]interface{}{
+					"chat-id":                  "kapacitor",
+					"disable-notification":     false,
+					"disable-web-page-preview": false,
+					"enabled":                  false,
+					"workspace":                "",
+					"default":                  false,
+					"global":                   false,
+					"parse-mode":               "",
+					"state-changes-only":       false,
+					"token":                    false,
+					"url":                      telegram.DefaultTelegramURL,
+				},
+				Redacted: []string{
 					"token",
 				},
 			},
@@ -7030,6 +7570,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"disable-notification":     false,
 								"disable-web-page-preview": false,
 								"enabled":                  true,
+								"workspace":                "",
+								"default":                  false,
 								"global":                   false,
 								"parse-mode":               "",
 								"state-changes-only":       false,
@@ -7048,6 +7590,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"disable-notification":     false,
 							"disable-web-page-preview": false,
 							"enabled":                  true,
+							"workspace":                "",
+							"default":                  false,
 							"global":                   false,
 							"parse-mode":               "",
 							"state-changes-only":       false,
@@ -7064,8 +7608,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 		{
 			section: "victorops",
 			setDefaults: func(c *server.Config) {
-				c.VictorOps.RoutingKey = "test"
-				c.VictorOps.APIKey = "secret"
+				c.VictorOps[0].RoutingKey = "test"
+				c.VictorOps[0].APIKey = "secret"
 			},
 			expDefaultSection: client.ConfigSection{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/victorops"},
@@ -7074,6 +7618,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 					Options: map[string]interface{}{
 						"api-key":     true,
 						"enabled":     false,
+						"workspace":   "",
+						"default":     false,
 						"global":      false,
 						"routing-key": "test",
 						"url":         victorops.DefaultVictorOpsAPIURL,
@@ -7088,6 +7634,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 				Options: map[string]interface{}{
 					"api-key":     true,
 					"enabled":     false,
+					"workspace":   "",
+					"default":     false,
 					"global":      false,
 					"routing-key": "test",
 					"url":         victorops.DefaultVictorOpsAPIURL,
@@ -7111,6 +7659,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 							Options: map[string]interface{}{
 								"api-key":     false,
 								"enabled":     false,
+								"workspace":   "",
+								"default":     false,
 								"global":      true,
 								"routing-key": "test",
 								"url":         victorops.DefaultVictorOpsAPIURL,
@@ -7125,6 +7675,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 						Options: map[string]interface{}{
 							"api-key":     false,
 							"enabled":     false,
+							"workspace":   "",
+							"default":     false,
 							"global":      true,
 							"routing-key": "test",
 							"url":         victorops.DefaultVictorOpsAPIURL,
@@ -7278,6 +7830,29 @@ func TestServer_ListServiceTests(t *testing.T) {
 					},
 				},
 			},
+			{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/alertmanager"},
+				Name: "alertmanager",
+				Options: client.ServiceTestOptions{
+					"workspace": "",
+					"alert-id":  "testAlertID",
+					"message":   "test alertmanager message",
+					"level":     "CRITICAL",
+					"labels":    nil,
+				},
+			},
+			{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/amqp"},
+				Name: "amqp",
+				Options: client.ServiceTestOptions{
+					"broker-name": "",
+					"exchange":    "",
+					"routing-key": "",
+					"id":          "testAMQP",
+					"message":     "test AMQP message",
+					"level":       "CRITICAL",
+				},
+			},
 			{
 				Link: client.Link{Relation: "self", Href: "/kapacitor/v1/service-tests/azure"},
 				Name: "azure",
@@ -7292,6 +7867,16 @@ func TestServer_ListServiceTests(t *testing.T) {
 					"id": "",
 				},
 			},
+			{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/discord"},
+				Name: "discord",
+				Options: client.ServiceTestOptions{
+					"workspace": "",
+					"alert-id":  "testAlertID",
+					"message":   "test discord message",
+					"level":     "CRITICAL",
+				},
+			},
 			{
 				Link: client.Link{Relation: "self", Href: "/kapacitor/v1/service-tests/dns"},
 				Name: "dns",
@@ -7323,18 +7908,21 @@ func TestServer_ListServiceTests(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/hipchat"},
 				Name: "hipchat",
 				Options: client.ServiceTestOptions{
-					"room":    "",
-					"message": "test hipchat message",
-					"level":   "CRITICAL",
+					"workspace": "",
+					"room":      "",
+					"message":   "test hipchat message",
+					"level":     "CRITICAL",
 				},
 			},
 			{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/httppost"},
 				Name: "httppost",
 				Options: client.ServiceTestOptions{
-					"endpoint": "example",
-					"url":      "http://localhost:3000/",
-					"headers":  map[string]interface{}{"Auth": "secret"},
+					"endpoint":     "example",
+					"url":          "http://localhost:3000/",
+					"headers":      map[string]interface{}{"Auth": "secret"},
+					"content-type": "",
+					"template":     "",
 				},
 			},
 			{
@@ -7380,6 +7968,7 @@ func TestServer_ListServiceTests(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/opsgenie"},
 				Name: "opsgenie",
 				Options: client.ServiceTestOptions{
+					"workspace":    "",
 					"teams":        nil,
 					"recipients":   nil,
 					"message-type": "CRITICAL",
@@ -7391,15 +7980,27 @@ func TestServer_ListServiceTests(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/pagerduty"},
 				Name: "pagerduty",
 				Options: client.ServiceTestOptions{
+					"workspace":    "",
 					"incident-key": "testIncidentKey",
 					"description":  "test pagerduty message",
 					"level":        "CRITICAL",
 				},
 			},
+			{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/pagerduty2"},
+				Name: "pagerduty2",
+				Options: client.ServiceTestOptions{
+					"workspace":   "",
+					"alert-id":    "testAlertID",
+					"description": "test pagerduty2 message",
+					"level":       "CRITICAL",
+				},
+			},
 			{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/pushover"},
 				Name: "pushover",
 				Options: client.ServiceTestOptions{
+					"workspace": "",
 					"user-key":  "", //gohere
 					"message":   "test pushover message",
 					"device":    "",
@@ -7439,6 +8040,7 @@ func TestServer_ListServiceTests(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/slack"},
 				Name: "slack",
 				Options: client.ServiceTestOptions{
+					"workspace":  "",
 					"channel":    "",
 					"icon-emoji": "",
 					"level":      "CRITICAL",
@@ -7450,9 +8052,12 @@ func TestServer_ListServiceTests(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/smtp"},
 				Name: "smtp",
 				Options: client.ServiceTestOptions{
-					"to":      nil,
-					"subject": "test subject",
-					"body":    "test body",
+					"workspace": "",
+					"to":        nil,
+					"subject":   "test subject",
+					"body":      "test body",
+					"template":  "",
+					"level":     "CRITICAL",
 				},
 			},
 			{
@@ -7484,10 +8089,23 @@ func TestServer_ListServiceTests(t *testing.T) {
 					"text":  "test talk text",
 				},
 			},
+			{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/teams"},
+				Name: "teams",
+				Options: client.ServiceTestOptions{
+					"workspace":   "",
+					"channel-url": "",
+					"card-type":   "MessageCard",
+					"title":       "testAlertID",
+					"message":     "test teams message",
+					"level":       "CRITICAL",
+				},
+			},
 			{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/telegram"},
 				Name: "telegram",
 				Options: client.ServiceTestOptions{
+					"workspace":                "",
 					"chat-id":                  "",
 					"parse-mode":               "",
 					"message":                  "test telegram message",
@@ -7506,6 +8124,7 @@ func TestServer_ListServiceTests(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/victorops"},
 				Name: "victorops",
 				Options: client.ServiceTestOptions{
+					"workspace":   "",
 					"routingKey":  "",
 					"messageType": "CRITICAL",
 					"message":     "test victorops message",
@@ -7570,6 +8189,7 @@ func TestServer_ListServiceTests_WithPattern(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/slack"},
 				Name: "slack",
 				Options: client.ServiceTestOptions{
+					"workspace":  "",
 					"channel":    "",
 					"icon-emoji": "",
 					"level":      "CRITICAL",
@@ -7581,9 +8201,12 @@ func TestServer_ListServiceTests_WithPattern(t *testing.T) {
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/smtp"},
 				Name: "smtp",
 				Options: client.ServiceTestOptions{
-					"to":      nil,
-					"subject": "test subject",
-					"body":    "test body",
+					"workspace": "",
+					"to":        nil,
+					"subject":   "test subject",
+					"body":      "test body",
+					"template":  "",
+					"level":     "CRITICAL",
 				},
 			},
 			{
@@ -7643,6 +8266,32 @@ func TestServer_DoServiceTest(t *testing.T) {
 				Message: "service is not enabled",
 			},
 		},
+		{
+			service: "alertmanager",
+			options: client.ServiceTestOptions{},
+			exp: client.ServiceTestResult{
+				Success: false,
+				Message: "service is not enabled",
+			},
+		},
+		{
+			service: "amqp",
+			options: client.ServiceTestOptions{
+				"broker-name": "default",
+			},
+			exp: client.ServiceTestResult{
+				Success: false,
+				Message: "unknown AMQP broker \"default\"",
+			},
+		},
+		{
+			service: "discord",
+			options: client.ServiceTestOptions{},
+			exp: client.ServiceTestResult{
+				Success: false,
+				Message: "unknown Discord workspace \"\"",
+			},
+		},
 		{
 			service: "hipchat",
 			options: client.ServiceTestOptions{},
@@ -7713,6 +8362,14 @@ func TestServer_DoServiceTest(t *testing.T) {
 				Message: "service is not enabled",
 			},
 		},
+		{
+			service: "pagerduty2",
+			options: client.ServiceTestOptions{},
+			exp: client.ServiceTestResult{
+				Success: false,
+				Message: "service is not enabled",
+			},
+		},
 		{
 			service: "pushover",
 			options: client.ServiceTestOptions{},
@@ -7761,6 +8418,14 @@ func TestServer_DoServiceTest(t *testing.T) {
 				Message: "service is not enabled",
 			},
 		},
+		{
+			service: "teams",
+			options: client.ServiceTestOptions{},
+			exp: client.ServiceTestResult{
+				Success: false,
+				Message: "service is not enabled",
+			},
+		},
 		{
 			service: "telegram",
 			options: client.ServiceTestOptions{},
@@ -8000,6 +8665,147 @@ func TestServer_AlertHandlers(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			handler: client.TopicHandler{
+				Kind: "alertmanager",
+				Options: map[string]interface{}{
+					"labels": map[string]interface{}{
+						"team": "ops",
+					},
+				},
+			},
+			setup: func(c *server.Config, ha *client.TopicHandler) (context.Context, error) {
+				ts := alertmanagertest.NewServer()
+				ctxt := context.WithValue(nil, "server", ts)
+
+				c.AlertManager[0].Enabled = true
+				c.AlertManager[0].URL = ts.URL + "/api/v1/alerts"
+				return ctxt, nil
+			},
+			result: func(ctxt context.Context) error {
+				ts := ctxt.Value("server").(*alertmanagertest.Server)
+				kapacitorURL := ctxt.Value("kapacitorURL").(string)
+				ts.Close()
+				got := ts.Requests()
+				// The end time depends on when the alert was sent.
+				for _, r := range got {
+					for i := range r.PostData {
+						r.PostData[i].EndsAt = time.Time{}
+					}
+				}
+				exp := []alertmanagertest.Request{{
+					URL: "/api/v1/alerts",
+					PostData: []alertmanagertest.Alert{{
+						Labels: map[string]string{
+							"alertname": "id",
+							"level":     "CRITICAL",
+							"task":      "testAlertHandlers",
+							"topic":     "test",
+							"team":      "ops",
+						},
+						Annotations: map[string]string{
+							"message": "message",
+							"details": "details",
+						},
+						StartsAt:     time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+						GeneratorURL: kapacitorURL + "/tasks/testAlertHandlers",
+					}},
+				}}
+				if !reflect.DeepEqual(exp, got) {
+					return fmt.Errorf("unexpected alertmanager request:\nexp\n%+v\ngot\n%+v\n", exp, got)
+				}
+				return nil
+			},
+		},
+		{
+			handler: client.TopicHandler{
+				Kind: "amqp",
+				Options: map[string]interface{}{
+					"exchange":    "alerts",
+					"routing-key": "{{ .TaskName }}.{{ .Level }}",
+				},
+			},
+			setup: func(c *server.Config, ha *client.TopicHandler) (context.Context, error) {
+				ts, err := amqptest.NewServer()
+				if err != nil {
+					return nil, err
+				}
+				ctxt := context.WithValue(nil, "server", ts)
+
+				c.AMQP = amqp.Configs{amqp.NewConfig()}
+				c.AMQP[0].Enabled = true
+				c.AMQP[0].Name = "test"
+				c.AMQP[0].URL = ts.URL
+				return ctxt, nil
+			},
+			result: func(ctxt context.Context) error {
+				ts := ctxt.Value("server").(*amqptest.Server)
+				ts.Close()
+				got := ts.Messages()
+				if len(got) != 1 {
+					return fmt.Errorf("unexpected number of AMQP messages: got %d exp 1", len(got))
+				}
+				if got, exp := got[0].Exchange, "alerts"; got != exp {
+					return fmt.Errorf("unexpected exchange: got %q exp %q", got, exp)
+				}
+				if got, exp := got[0].RoutingKey, "testAlertHandlers.CRITICAL"; got != exp {
+					return fmt.Errorf("unexpected routing key: got %q exp %q", got, exp)
+				}
+				if got, exp := got[0].DeliveryMode, uint8(2); got != exp {
+					return fmt.Errorf("unexpected delivery mode: got %d exp %d", got, exp)
+				}
+				return nil
+			},
+		},
+		{
+			handler: client.TopicHandler{
+				Kind: "discord",
+				Options: map[string]interface{}{
+					"workspace": "ops",
+					"username":  "kapacitor",
+				},
+			},
+			setup: func(c *server.Config, ha *client.TopicHandler) (context.Context, error) {
+				ts := discordtest.NewServer()
+				ctxt := context.WithValue(nil, "server", ts)
+
+				c.Discord = discord.Configs{
+					{
+						Enabled:   true,
+						Workspace: "community",
+						Default:   true,
+						URL:       ts.URL + "/community",
+					},
+					{
+						Enabled:   true,
+						Workspace: "ops",
+						URL:       ts.URL + "/ops",
+					},
+				}
+				return ctxt, nil
+			},
+			result: func(ctxt context.Context) error {
+				ts := ctxt.Value("server").(*discordtest.Server)
+				ts.Close()
+				got := ts.Requests()
+				exp := []discordtest.Request{{
+					URL: "/ops",
+					PostData: discordtest.PostData{
+						Username: "kapacitor",
+						Embeds: []discordtest.Embed{{
+							Title:       "id",
+							Description: "message",
+							Color:       0xF95F53,
+							Timestamp:   "1970-01-01T00:00:00Z",
+						}},
+					},
+				}}
+				if !reflect.DeepEqual(exp, got) {
+					return fmt.Errorf("unexpected discord request:\nexp\n%+v\ngot\n%+v\n", exp, got)
+				}
+				return nil
+			},
+		},
 		{
 			handler: client.TopicHandler{
 				Kind: "exec",
@@ -8050,8 +8856,8 @@ func TestServer_AlertHandlers(t *testing.T) {
 				ts := hipchattest.NewServer()
 				ctxt := context.WithValue(nil, "server", ts)
 
-				c.HipChat.Enabled = true
-				c.HipChat.URL = ts.URL
+				c.HipChat[0].Enabled = true
+				c.HipChat[0].URL = ts.URL
 				return ctxt, nil
 			},
 			result: func(ctxt context.Context) error {
@@ -8175,9 +8981,9 @@ func TestServer_AlertHandlers(t *testing.T) {
 				ts := opsgenietest.NewServer()
 				ctxt := context.WithValue(nil, "server", ts)
 
-				c.OpsGenie.Enabled = true
-				c.OpsGenie.URL = ts.URL
-				c.OpsGenie.APIKey = "api_key"
+				c.OpsGenie[0].Enabled = true
+				c.OpsGenie[0].URL = ts.URL
+				c.OpsGenie[0].APIKey = "api_key"
 				return ctxt, nil
 			},
 			result: func(ctxt context.Context) error {
@@ -8217,10 +9023,10 @@ func TestServer_AlertHandlers(t *testing.T) {
 				ts := pushovertest.NewServer()
 				ctxt := context.WithValue(nil, "server", ts)
 
-				c.Pushover.Enabled = true
-				c.Pushover.URL = ts.URL
-				c.Pushover.Token = "api_key"
-				c.Pushover.UserKey = "user"
+				c.Pushover[0].Enabled = true
+				c.Pushover[0].URL = ts.URL
+				c.Pushover[0].Token = "api_key"
+				c.Pushover[0].UserKey = "user"
 				return ctxt, nil
 			},
 			result: func(ctxt context.Context) error {
@@ -8253,8 +9059,8 @@ func TestServer_AlertHandlers(t *testing.T) {
 				ts := pagerdutytest.NewServer()
 				ctxt := context.WithValue(nil, "server", ts)
 
-				c.PagerDuty.Enabled = true
-				c.PagerDuty.URL = ts.URL
+				c.PagerDuty[0].Enabled = true
+				c.PagerDuty[0].URL = ts.URL
 				return ctxt, nil
 			},
 			result: func(ctxt context.Context) error {
@@ -8279,6 +9085,61 @@ func TestServer_AlertHandlers(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			handler: client.TopicHandler{
+				Kind: "pagerduty2",
+				Options: map[string]interface{}{
+					"routing-key": "routing_key",
+					"links": []interface{}{
+						map[string]interface{}{
+							"href": "http://example.com",
+							"text": "example",
+						},
+					},
+				},
+			},
+			setup: func(c *server.Config, ha *client.TopicHandler) (context.Context, error) {
+				ts := pagerduty2test.NewServer()
+				ctxt := context.WithValue(nil, "server", ts)
+
+				c.PagerDuty2[0].Enabled = true
+				c.PagerDuty2[0].URL = ts.URL
+				return ctxt, nil
+			},
+			result: func(ctxt context.Context) error {
+				ts := ctxt.Value("server").(*pagerduty2test.Server)
+				kapacitorURL := ctxt.Value("kapacitorURL").(string)
+				ts.Close()
+				got := ts.Requests()
+				exp := []pagerduty2test.Request{{
+					URL: "/",
+					PostData: pagerduty2test.PostData{
+						RoutingKey:  "routing_key",
+						EventAction: "trigger",
+						DedupKey:    "id",
+						Payload: &pagerduty2test.Payload{
+							Summary:   "message",
+							Source:    "kapacitor",
+							Severity:  "critical",
+							Timestamp: "1970-01-01T00:00:00Z",
+							CustomDetails: map[string]interface{}{
+								"value": 1.0,
+							},
+						},
+						Client:    "kapacitor",
+						ClientURL: kapacitorURL,
+						Links: []pagerduty2test.Link{{
+							Href: "http://example.com",
+							Text: "example",
+						}},
+					},
+				}}
+				if !reflect.DeepEqual(exp, got) {
+					return fmt.Errorf("unexpected pagerduty2 request:\nexp\n%+v\ngot\n%+v\n", exp, got)
+				}
+				return nil
+			},
+		},
 		{
 			handler: client.TopicHandler{
 				Kind: "post",
@@ -8348,8 +9209,8 @@ func TestServer_AlertHandlers(t *testing.T) {
 				ts := slacktest.NewServer()
 				ctxt := context.WithValue(nil, "server", ts)
 
-				c.Slack.Enabled = true
-				c.Slack.URL = ts.URL + "/test/slack/url"
+				c.Slack[0].Enabled = true
+				c.Slack[0].URL = ts.URL + "/test/slack/url"
 				return ctxt, nil
 			},
 			result: func(ctxt context.Context) error {
@@ -8380,36 +9241,85 @@ func TestServer_AlertHandlers(t *testing.T) {
 		},
 		{
 			handler: client.TopicHandler{
-				Kind: "smtp",
+				Kind: "slack",
 				Options: map[string]interface{}{
-					"to": []string{"oncall@example.com", "backup@example.com"},
+					"workspace": "ops",
+					"channel":   "#test",
 				},
 			},
 			setup: func(c *server.Config, ha *client.TopicHandler) (context.Context, error) {
-				ts, err := smtptest.NewServer()
-				if err != nil {
-					return nil, err
-				}
+				ts := slacktest.NewServer()
 				ctxt := context.WithValue(nil, "server", ts)
 
-				c.SMTP.Enabled = true
-				c.SMTP.Host = ts.Host
-				c.SMTP.Port = ts.Port
-				c.SMTP.From = "test@example.com"
+				c.Slack[0].Enabled = true
+				c.Slack[0].Workspace = "dev"
+				c.Slack[0].Default = true
+				c.Slack[0].URL = ts.URL + "/test/slack/dev"
+				ops := slack.NewConfig()
+				ops.Enabled = true
+				ops.Workspace = "ops"
+				ops.URL = ts.URL + "/test/slack/ops"
+				c.Slack = append(c.Slack, ops)
 				return ctxt, nil
 			},
 			result: func(ctxt context.Context) error {
-				ts := ctxt.Value("server").(*smtptest.Server)
+				ts := ctxt.Value("server").(*slacktest.Server)
 				ts.Close()
-
-				errors := ts.Errors()
-				if len(errors) != 0 {
-					return fmt.Errorf("multiple errors %d: %v", len(errors), errors)
-				}
-
-				expMail := []*smtptest.Message{{
-					Header: mail.Header{
-						"Mime-Version":              []string{"1.0"},
+				got := ts.Requests()
+				exp := []slacktest.Request{{
+					URL: "/test/slack/ops",
+					PostData: slacktest.PostData{
+						Channel:  "#test",
+						Username: "kapacitor",
+						Text:     "",
+						Attachments: []slacktest.Attachment{
+							{
+								Fallback:  "message",
+								Color:     "danger",
+								Text:      "message",
+								Mrkdwn_in: []string{"text"},
+							},
+						},
+					},
+				}}
+				if !reflect.DeepEqual(exp, got) {
+					return fmt.Errorf("unexpected slack request:\nexp\n%+v\ngot\n%+v\n", exp, got)
+				}
+				return nil
+			},
+		},
+		{
+			handler: client.TopicHandler{
+				Kind: "smtp",
+				Options: map[string]interface{}{
+					"to": []string{"oncall@example.com", "backup@example.com"},
+				},
+			},
+			setup: func(c *server.Config, ha *client.TopicHandler) (context.Context, error) {
+				ts, err := smtptest.NewServer()
+				if err != nil {
+					return nil, err
+				}
+				ctxt := context.WithValue(nil, "server", ts)
+
+				c.SMTP[0].Enabled = true
+				c.SMTP[0].Host = ts.Host
+				c.SMTP[0].Port = ts.Port
+				c.SMTP[0].From = "test@example.com"
+				return ctxt, nil
+			},
+			result: func(ctxt context.Context) error {
+				ts := ctxt.Value("server").(*smtptest.Server)
+				ts.Close()
+
+				errors := ts.Errors()
+				if len(errors) != 0 {
+					return fmt.Errorf("multiple errors %d: %v", len(errors), errors)
+				}
+
+				expMail := []*smtptest.Message{{
+					Header: mail.Header{
+						"Mime-Version":              []string{"1.0"},
 						"Content-Type":              []string{"text/html; charset=UTF-8"},
 						"Content-Transfer-Encoding": []string{"quoted-printable"},
 						"To":      []string{"oncall@example.com, backup@example.com"},
@@ -8496,6 +9406,35 @@ func TestServer_AlertHandlers(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			handler: client.TopicHandler{
+				Kind: "syslog",
+			},
+			setup: func(c *server.Config, ha *client.TopicHandler) (context.Context, error) {
+				ts, err := alerttest.NewSyslogServer("udp")
+				if err != nil {
+					return nil, err
+				}
+
+				ha.Options = map[string]interface{}{
+					"address":  ts.Addr,
+					"hostname": "test-host",
+				}
+
+				ctxt := context.WithValue(nil, "server", ts)
+				return ctxt, nil
+			},
+			result: func(ctxt context.Context) error {
+				ts := ctxt.Value("server").(*alerttest.SyslogServer)
+				ts.Close()
+				exp := []string{"<10>1 1970-01-01T00:00:00Z test-host kapacitor - - - message"}
+				got := ts.Messages()
+				if !reflect.DeepEqual(exp, got) {
+					return fmt.Errorf("unexpected syslog messages:\nexp\n%q\ngot\n%q\n", exp, got)
+				}
+				return nil
+			},
+		},
 		{
 			handler: client.TopicHandler{
 				Kind: "talk",
@@ -8527,6 +9466,39 @@ func TestServer_AlertHandlers(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			handler: client.TopicHandler{
+				Kind: "teams",
+			},
+			setup: func(c *server.Config, ha *client.TopicHandler) (context.Context, error) {
+				ts := teamstest.NewServer()
+				ctxt := context.WithValue(nil, "server", ts)
+
+				c.Teams[0].Enabled = true
+				c.Teams[0].ChannelURL = ts.URL + "/test/teams/url"
+				return ctxt, nil
+			},
+			result: func(ctxt context.Context) error {
+				ts := ctxt.Value("server").(*teamstest.Server)
+				ts.Close()
+				got := ts.Requests()
+				exp := []teamstest.Request{{
+					URL: "/test/teams/url",
+					PostData: teamstest.PostData{
+						CardType:   "MessageCard",
+						Context:    "http://schema.org/extensions",
+						ThemeColor: "CC4A31",
+						Summary:    "message",
+						Title:      "id",
+						Text:       "message",
+					},
+				}}
+				if !reflect.DeepEqual(exp, got) {
+					return fmt.Errorf("unexpected teams request:\nexp\n%+v\ngot\n%+v\n", exp, got)
+				}
+				return nil
+			},
+		},
 		{
 			handler: client.TopicHandler{
 				Kind: "tcp",
@@ -8565,9 +9537,9 @@ func TestServer_AlertHandlers(t *testing.T) {
 				ts := telegramtest.NewServer()
 				ctxt := context.WithValue(nil, "server", ts)
 
-				c.Telegram.Enabled = true
-				c.Telegram.URL = ts.URL + "/bot"
-				c.Telegram.Token = "TOKEN:AUTH"
+				c.Telegram[0].Enabled = true
+				c.Telegram[0].URL = ts.URL + "/bot"
+				c.Telegram[0].Token = "TOKEN:AUTH"
 				return ctxt, nil
 			},
 			result: func(ctxt context.Context) error {
@@ -8601,9 +9573,9 @@ func TestServer_AlertHandlers(t *testing.T) {
 				ts := victoropstest.NewServer()
 				ctxt := context.WithValue(nil, "server", ts)
 
-				c.VictorOps.Enabled = true
-				c.VictorOps.URL = ts.URL
-				c.VictorOps.APIKey = "api_key"
+				c.VictorOps[0].Enabled = true
+				c.VictorOps[0].URL = ts.URL
+				c.VictorOps[0].APIKey = "api_key"
 				return ctxt, nil
 			},
 			result: func(ctxt context.Context) error {
@@ -8964,6 +9936,774 @@ alert value=2 0000000000002
 	}
 }
 
+func TestServer_Alert_Throttle(t *testing.T) {
+	// Setup test TCP server
+	ts, err := alerttest.NewTCPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	// Create default config
+	c := NewConfig()
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	throttleTopic := "throttle"
+
+	// Create task for alert
+	tick := `
+stream
+	|from()
+		.measurement('alert')
+	|alert()
+		.id('id')
+		.message('message')
+		.details('details')
+		.crit(lambda: "value" > 1.0)
+		.topic('` + throttleTopic + `')
+`
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "throttle_task",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create tpc handler on tcp topic
+	tcpTopic := "tcp"
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(tcpTopic), client.TopicHandlerOptions{
+		ID:   "tcp_handler",
+		Kind: "tcp",
+		Options: map[string]interface{}{
+			"address": ts.Addr,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create throttle handler on throttle topic
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(throttleTopic), client.TopicHandlerOptions{
+		ID:   "throttle_handler",
+		Kind: "throttle",
+		Options: map[string]interface{}{
+			"rate":            1,
+			"interval":        time.Hour,
+			"digest-interval": 100 * time.Millisecond,
+			"samples":         1,
+			"topic":           "tcp",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write points
+	point := `alert value=3 0000000000000
+alert value=4 0000000000001
+alert value=2 0000000000002
+alert value=5 0000000000003
+`
+	v := url.Values{}
+	v.Add("precision", "ms")
+	s.MustWrite("mydb", "myrp", point, v)
+
+	time.Sleep(110 * time.Millisecond)
+
+	// Check TCP handler got the level change, the repeat that took the only token
+	// and the digest of the dropped events
+	ts.Close()
+	got := ts.Data()
+	if len(got) != 3 {
+		t.Fatalf("unexpected number of tcp requests: got %d exp 3", len(got))
+	}
+	for i := 0; i < 2; i++ {
+		if exp := "id"; got[i].ID != exp {
+			t.Errorf("unexpected event ID %d: got %q exp %q", i, got[i].ID, exp)
+		}
+	}
+	digest := got[2]
+	if exp := "throttle-throttle-digest"; digest.ID != exp {
+		t.Errorf("unexpected digest ID: got %q exp %q", digest.ID, exp)
+	}
+	if exp := "Throttled 2 events in the last 100ms."; digest.Message != exp {
+		t.Errorf("unexpected digest message: got %q exp %q", digest.Message, exp)
+	}
+	if exp := alert.Critical; digest.Level != exp {
+		t.Errorf("unexpected digest level: got %v exp %v", digest.Level, exp)
+	}
+	if exp := time.Date(1970, 1, 1, 0, 0, 0, 3000000, time.UTC); !digest.Time.Equal(exp) {
+		t.Errorf("unexpected digest time: got %v exp %v", digest.Time, exp)
+	}
+	// Only the sampled event is included in the digest
+	if got, exp := len(digest.Data.Series), 1; got != exp {
+		t.Errorf("unexpected number of digest series: got %d exp %d", got, exp)
+	}
+}
+
+func TestServer_Alert_Escalate(t *testing.T) {
+	// Setup test TCP server
+	ts, err := alerttest.NewTCPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	// Create default config
+	c := NewConfig()
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	escalateTopic := "escalate"
+
+	// Create task for alert
+	tick := `
+stream
+	|from()
+		.measurement('alert')
+	|alert()
+		.id('id')
+		.message('message')
+		.details('details')
+		.crit(lambda: "value" > 1.0)
+		.topic('` + escalateTopic + `')
+`
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "escalate_task",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create tpc handler on tcp topic
+	tcpTopic := "tcp"
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(tcpTopic), client.TopicHandlerOptions{
+		ID:   "tcp_handler",
+		Kind: "tcp",
+		Options: map[string]interface{}{
+			"address": ts.Addr,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create escalate handler on escalate topic
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(escalateTopic), client.TopicHandlerOptions{
+		ID:   "escalate_handler",
+		Kind: "escalate",
+		Options: map[string]interface{}{
+			"steps": []map[string]interface{}{
+				{"after": 0, "topic": tcpTopic},
+				{"after": time.Hour, "topic": "phone"},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write points
+	point := `alert value=3 0000000000000`
+	v := url.Values{}
+	v.Add("precision", "ms")
+	s.MustWrite("mydb", "myrp", point, v)
+
+	time.Sleep(50 * time.Millisecond)
+
+	// Check TCP handler got the event of the first step
+	ts.Close()
+	got := ts.Data()
+	if len(got) != 1 {
+		t.Fatalf("unexpected number of tcp requests: got %d exp 1", len(got))
+	}
+	if exp := "id"; got[0].ID != exp {
+		t.Errorf("unexpected event ID: got %q exp %q", got[0].ID, exp)
+	}
+
+	// Check the second step is pending
+	te, err := cli.ListTopicEscalations(cli.TopicEscalationsLink(escalateTopic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(te.Escalations) != 1 {
+		t.Fatalf("unexpected number of escalations: got %d exp 1", len(te.Escalations))
+	}
+	e := te.Escalations[0]
+	if e.Handler != "escalate_handler" || e.EventID != "id" || e.Step != 1 || e.NextTopic != "phone" {
+		t.Errorf("unexpected escalation: %+v", e)
+	}
+	if exp := []string{tcpTopic}; !reflect.DeepEqual(e.Published, exp) {
+		t.Errorf("unexpected escalated topics: got %v exp %v", e.Published, exp)
+	}
+	if exp := e.Start.Add(time.Hour); !e.Next.Equal(exp) {
+		t.Errorf("unexpected next escalation time: got %v exp %v", e.Next, exp)
+	}
+
+	// Check deleting the handler deletes its escalations
+	if err := cli.DeleteTopicHandler(cli.TopicHandlerLink(escalateTopic, "escalate_handler")); err != nil {
+		t.Fatal(err)
+	}
+	te, err = cli.ListTopicEscalations(cli.TopicEscalationsLink(escalateTopic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(te.Escalations) != 0 {
+		t.Errorf("unexpected escalations after deleting handler: %+v", te.Escalations)
+	}
+}
+
+func TestServer_Alert_Correlate(t *testing.T) {
+	// Setup test TCP server
+	ts, err := alerttest.NewTCPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	// Create default config
+	c := NewConfig()
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	correlateTopic := "correlate"
+
+	// Create task for alert
+	tick := `
+stream
+	|from()
+		.measurement('alert')
+		.groupBy('host', 'dc')
+	|alert()
+		.id('{{ index .Tags "host" }}')
+		.message('message')
+		.crit(lambda: "value" > 1.0)
+		.topic('` + correlateTopic + `')
+`
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "correlate_task",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create tpc handler on tcp topic
+	tcpTopic := "tcp"
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(tcpTopic), client.TopicHandlerOptions{
+		ID:   "tcp_handler",
+		Kind: "tcp",
+		Options: map[string]interface{}{
+			"address": ts.Addr,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create correlate handler on correlate topic
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(correlateTopic), client.TopicHandlerOptions{
+		ID:   "correlate_handler",
+		Kind: "correlate",
+		Options: map[string]interface{}{
+			"id":     `correlated-{{ index .Tags "dc" }}`,
+			"by":     []string{"dc"},
+			"window": 100 * time.Millisecond,
+			"topic":  "tcp",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write points
+	point := `alert,host=serverA,dc=east value=3 0000000000000
+alert,host=serverB,dc=east value=4 0000000000001
+`
+	v := url.Values{}
+	v.Add("precision", "ms")
+	s.MustWrite("mydb", "myrp", point, v)
+
+	time.Sleep(110 * time.Millisecond)
+
+	// Check TCP handler got a single parent event for both hosts
+	ts.Close()
+	got := ts.Data()
+	if len(got) != 1 {
+		t.Fatalf("unexpected number of tcp requests: got %d exp 1", len(got))
+	}
+	parent := got[0]
+	if exp := "correlated-east"; parent.ID != exp {
+		t.Errorf("unexpected parent ID: got %q exp %q", parent.ID, exp)
+	}
+	if exp := "2 correlated events for dc=east, combined level is CRITICAL."; parent.Message != exp {
+		t.Errorf("unexpected parent message: got %q exp %q", parent.Message, exp)
+	}
+	if exp := "serverA (CRITICAL): message\nserverB (CRITICAL): message"; parent.Details != exp {
+		t.Errorf("unexpected parent details: got %q exp %q", parent.Details, exp)
+	}
+	if exp := alert.Critical; parent.Level != exp {
+		t.Errorf("unexpected parent level: got %v exp %v", parent.Level, exp)
+	}
+	if exp := time.Date(1970, 1, 1, 0, 0, 0, 1000000, time.UTC); !parent.Time.Equal(exp) {
+		t.Errorf("unexpected parent time: got %v exp %v", parent.Time, exp)
+	}
+}
+
+func TestServer_Alert_Routing(t *testing.T) {
+	// Setup test TCP servers
+	tsCrit, err := alerttest.NewTCPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tsCrit.Close()
+	tsDefault, err := alerttest.NewTCPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tsDefault.Close()
+
+	// Create default config
+	c := NewConfig()
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	routingTopic := "routing"
+
+	// Create task for alert
+	tick := `
+stream
+	|from()
+		.measurement('alert')
+		.groupBy('host')
+	|alert()
+		.id('{{ index .Tags "host" }}')
+		.message('message')
+		.warn(lambda: "value" > 0.5)
+		.crit(lambda: "value" > 1.0)
+		.topic('` + routingTopic + `')
+`
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "routing_task",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create tcp handlers on the routing topic
+	for id, addr := range map[string]string{
+		"tcp_crit":    tsCrit.Addr,
+		"tcp_default": tsDefault.Addr,
+	} {
+		if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(routingTopic), client.TopicHandlerOptions{
+			ID:   id,
+			Kind: "tcp",
+			Options: map[string]interface{}{
+				"address": addr,
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Route critical events to one handler and all others to the default handler
+	routing, err := cli.ReplaceTopicRouting(cli.TopicRoutingLink(routingTopic), client.TopicRoutingOptions{
+		Routes: []client.TopicRoute{{
+			Levels:   []string{"CRITICAL"},
+			Handlers: []string{"tcp_crit"},
+		}},
+		Default: []string{"tcp_default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expRouting := client.TopicRouting{
+		Link:  client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/topics/routing/routing"},
+		Topic: routingTopic,
+		Routes: []client.TopicRoute{{
+			Levels:   []string{"CRITICAL"},
+			Handlers: []string{"tcp_crit"},
+		}},
+		Default:  []string{"tcp_default"},
+		Timezone: "UTC",
+	}
+	if !reflect.DeepEqual(routing, expRouting) {
+		t.Errorf("unexpected routing:\ngot\n%+v\nexp\n%+v\n", routing, expRouting)
+	}
+
+	// Write points
+	point := `alert,host=serverA value=3 0000000000000
+alert,host=serverB value=0.8 0000000000001
+`
+	v := url.Values{}
+	v.Add("precision", "ms")
+	s.MustWrite("mydb", "myrp", point, v)
+
+	s.Restart()
+
+	tsCrit.Close()
+	tsDefault.Close()
+	if got := tsCrit.Data(); len(got) != 1 || got[0].ID != "serverA" {
+		t.Errorf("unexpected critical handler events: %+v", got)
+	}
+	if got := tsDefault.Data(); len(got) != 1 || got[0].ID != "serverB" {
+		t.Errorf("unexpected default handler events: %+v", got)
+	}
+}
+
+func TestServer_Alert_Maintenance(t *testing.T) {
+	// Setup test TCP servers
+	tsTopic, err := alerttest.NewTCPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tsTopic.Close()
+	tsAnon, err := alerttest.NewTCPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tsAnon.Close()
+
+	// Create default config
+	c := NewConfig()
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	maintenanceTopic := "maintenance"
+
+	// Create task for alert with both a topic and a direct handler
+	tick := `
+stream
+	|from()
+		.measurement('alert')
+		.groupBy('host')
+	|alert()
+		.id('{{ index .Tags "host" }}')
+		.message('message')
+		.crit(lambda: "value" > 1.0)
+		.topic('` + maintenanceTopic + `')
+		.tcp('` + tsAnon.Addr + `')
+`
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "maintenance_task",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(maintenanceTopic), client.TopicHandlerOptions{
+		ID:   "tcp",
+		Kind: "tcp",
+		Options: map[string]interface{}{
+			"address": tsTopic.Addr,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Put the database hosts in maintenance
+	now := time.Now().UTC().Truncate(time.Second)
+	window, err := cli.CreateMaintenanceWindow(client.MaintenanceWindowOptions{
+		ID:    "db_upgrade",
+		Tags:  map[string]string{"host": "db-*"},
+		Start: now.Add(-time.Hour),
+		Stop:  now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expWindow := client.MaintenanceWindow{
+		Link:     client.Link{Relation: client.Self, Href: "/kapacitor/v1preview/alerts/maintenance-windows/db_upgrade"},
+		ID:       "db_upgrade",
+		Tags:     map[string]string{"host": "db-*"},
+		Timezone: "UTC",
+		Start:    now.Add(-time.Hour),
+		Stop:     now.Add(time.Hour),
+	}
+	if !reflect.DeepEqual(window, expWindow) {
+		t.Errorf("unexpected maintenance window:\ngot\n%+v\nexp\n%+v\n", window, expWindow)
+	}
+
+	// Write points
+	point := `alert,host=db-1 value=3 0000000000000
+alert,host=web-1 value=3 0000000000001
+`
+	v := url.Values{}
+	v.Add("precision", "ms")
+	s.MustWrite("mydb", "myrp", point, v)
+
+	s.Restart()
+
+	tsTopic.Close()
+	tsAnon.Close()
+	for name, ts := range map[string]*alerttest.TCPServer{"topic": tsTopic, "anonymous": tsAnon} {
+		if got := ts.Data(); len(got) != 1 || got[0].ID != "web-1" {
+			t.Errorf("unexpected %s handler events: %+v", name, got)
+		}
+	}
+
+	// The suppressed event is still recorded
+	event, err := cli.TopicEvent(cli.TopicEventLink(maintenanceTopic, "db-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := event.State.Maintenance, "db_upgrade"; got != exp {
+		t.Errorf("unexpected event maintenance window: got %q exp %q", got, exp)
+	}
+}
+
+func TestServer_Alert_Outbox(t *testing.T) {
+	// Setup a test HTTP server that fails until it recovers
+	var mu sync.Mutex
+	failing := true
+	var received []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		ad := alert.Data{}
+		json.NewDecoder(r.Body).Decode(&ad)
+		received = append(received, ad)
+	}))
+	defer ts.Close()
+
+	// Create default config
+	c := NewConfig()
+	c.Alert.OutboxRetryInterval = toml.Duration(10 * time.Millisecond)
+	c.Alert.OutboxMaxRetryInterval = toml.Duration(50 * time.Millisecond)
+	c.Alert.OutboxMaxAge = toml.Duration(200 * time.Millisecond)
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	outboxTopic := "outbox"
+
+	tick := `
+stream
+	|from()
+		.measurement('alert')
+	|alert()
+		.id('id')
+		.message('message')
+		.crit(lambda: "value" > 1.0)
+		.topic('` + outboxTopic + `')
+`
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "outbox_task",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(outboxTopic), client.TopicHandlerOptions{
+		ID:   "post",
+		Kind: "post",
+		Options: map[string]interface{}{
+			"url": ts.URL,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	point := `alert value=3 0000000000000`
+	v := url.Values{}
+	v.Add("precision", "s")
+	s.MustWrite("mydb", "myrp", point, v)
+
+	// waitOutbox waits until the outbox of the handler has the expected number of dead letters and no queued entries.
+	outboxLink := cli.HandlerOutboxLink(outboxTopic, "post")
+	waitOutbox := func(deadLetters int) client.HandlerOutbox {
+		var outbox client.HandlerOutbox
+		for i := 0; i < 100; i++ {
+			var err error
+			outbox, err = cli.HandlerOutbox(outboxLink)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if outbox.QueueDepth == 0 && outbox.DeadLetters == deadLetters {
+				return outbox
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("unexpected outbox, exp %d dead letters: %+v", deadLetters, outbox)
+		return outbox
+	}
+
+	// The delivery is retried until it exceeds the max age
+	outbox := waitOutbox(1)
+	if got := outbox.Entries[0]; got.EventID != "id" || !got.Dead || got.Attempts < 2 || got.LastError == "" {
+		t.Errorf("unexpected dead letter: %+v", got)
+	}
+
+	// Replay the dead letter once the server recovered
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	if _, err := cli.ReplayDeadLetters(outboxLink); err != nil {
+		t.Fatal(err)
+	}
+	waitOutbox(0)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 || received[0].ID != "id" || received[0].Level != alert.Critical {
+		t.Errorf("unexpected received alert data: %+v", received)
+	}
+}
+
+func TestServer_Alert_OnCall(t *testing.T) {
+	// Setup test SMTP server
+	ts, err := smtptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	// Create default config
+	c := NewConfig()
+	c.SMTP[0].Enabled = true
+	c.SMTP[0].Host = ts.Host
+	c.SMTP[0].Port = ts.Port
+	c.SMTP[0].From = "test@example.com"
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	// Alice is on call today, bob tomorrow
+	handoff := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	schedule, err := cli.ReplaceOnCallSchedule(cli.OnCallScheduleLink("dba"), client.OnCallScheduleOptions{
+		Members: []client.OnCallMember{
+			{Name: "alice", Contacts: map[string]string{"smtp": "alice@example.com"}},
+			{Name: "bob", Contacts: map[string]string{"smtp": "bob@example.com"}},
+		},
+		Rotations: []client.OnCallRotation{{
+			Members: []string{"alice", "bob"},
+			Handoff: handoff,
+			Shift:   client.Duration(24 * time.Hour),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := schedule.OnCall, "alice"; got != exp {
+		t.Errorf("unexpected on call member: got %q exp %q", got, exp)
+	}
+
+	onCallTopic := "oncall"
+
+	// Create task for alert
+	tick := `
+stream
+	|from()
+		.measurement('alert')
+	|alert()
+		.id('id')
+		.message('message')
+		.details('details')
+		.crit(lambda: TRUE)
+		.topic('` + onCallTopic + `')
+`
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "oncall_task",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Send emails to whoever is on call
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(onCallTopic), client.TopicHandlerOptions{
+		ID:   "smtp",
+		Kind: "smtp",
+		Options: map[string]interface{}{
+			"to": []string{"oncall:dba"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write points
+	point := `alert value=1 0000000000000
+`
+	v := url.Values{}
+	v.Add("precision", "ms")
+	s.MustWrite("mydb", "myrp", point, v)
+
+	s.Restart()
+
+	ts.Close()
+	if errors := ts.Errors(); len(errors) != 0 {
+		t.Fatalf("multiple errors %d: %v", len(errors), errors)
+	}
+	expMail := []*smtptest.Message{{
+		Header: mail.Header{
+			"To":      []string{"alice@example.com"},
+			"From":    []string{"test@example.com"},
+			"Subject": []string{"message"},
+		},
+		Body: "details\n",
+	}}
+	msgs := ts.SentMessages()
+	if got, exp := len(msgs), len(expMail); got != exp {
+		t.Fatalf("unexpected number of messages sent: got %d exp %d", got, exp)
+	}
+	for i, exp := range expMail {
+		if err := exp.Compare(msgs[i]); err != nil {
+			t.Errorf("unexpected message %d: %v", i, err)
+		}
+	}
+}
+
 func TestServer_Alert_Publish(t *testing.T) {
 	// Setup test TCP server
 	ts, err := alerttest.NewTCPServer()
@@ -9770,14 +11510,14 @@ func TestServer_AlertHandler_MultipleHandlers(t *testing.T) {
 
 	// Configure slack
 	slack := slacktest.NewServer()
-	c.Slack.Enabled = true
-	c.Slack.URL = slack.URL + "/test/slack/url"
+	c.Slack[0].Enabled = true
+	c.Slack[0].URL = slack.URL + "/test/slack/url"
 
 	// Configure victorops
 	vo := victoropstest.NewServer()
-	c.VictorOps.Enabled = true
-	c.VictorOps.URL = vo.URL
-	c.VictorOps.APIKey = "api_key"
+	c.VictorOps[0].Enabled = true
+	c.VictorOps[0].URL = vo.URL
+	c.VictorOps[0].APIKey = "api_key"
 
 	s := OpenServer(c)
 	cli := Client(s)
@@ -1,7 +1,10 @@
 package alerttest
 
 import (
+	"bufio"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -127,6 +130,155 @@ func (s *TCPServer) run() {
 	}
 }
 
+// SyslogServer records the syslog messages it receives.
+// Messages received over tcp are expected to use octet counting framing.
+type SyslogServer struct {
+	Addr string
+
+	l  net.Listener
+	pc net.PacketConn
+
+	mu       sync.Mutex
+	messages []string
+	// conns are the open tcp connections, connections is the number ever accepted.
+	conns       map[net.Conn]bool
+	connections int
+	stopped     bool
+
+	wg     sync.WaitGroup
+	closed bool
+}
+
+func NewSyslogServer(network string) (*SyslogServer, error) {
+	s := &SyslogServer{
+		conns: make(map[net.Conn]bool),
+	}
+	switch network {
+	case "udp":
+		pc, err := net.ListenPacket("udp", "localhost:0")
+		if err != nil {
+			return nil, err
+		}
+		s.pc = pc
+		s.Addr = pc.LocalAddr().String()
+	case "tcp":
+		l, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			return nil, err
+		}
+		s.l = l
+		s.Addr = l.Addr().String()
+	default:
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if s.pc != nil {
+			s.runUDP()
+		} else {
+			s.runTCP()
+		}
+	}()
+	return s, nil
+}
+
+func (s *SyslogServer) Messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages
+}
+
+// Connections returns the number of tcp connections accepted.
+func (s *SyslogServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+// CloseConnections closes the open tcp connections, as a receiver that restarted would.
+func (s *SyslogServer) CloseConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *SyslogServer) Close() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if s.pc != nil {
+		s.pc.Close()
+	} else {
+		s.l.Close()
+		s.mu.Lock()
+		s.stopped = true
+		s.mu.Unlock()
+		s.CloseConnections()
+	}
+	s.wg.Wait()
+}
+
+func (s *SyslogServer) record(m string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, m)
+}
+
+func (s *SyslogServer) runUDP() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := s.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		s.record(string(buf[:n]))
+	}
+}
+
+func (s *SyslogServer) runTCP() {
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.stopped {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = true
+		s.connections++
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			r := bufio.NewReader(conn)
+			for {
+				var n int
+				if _, err := fmt.Fscanf(r, "%d ", &n); err != nil {
+					return
+				}
+				m := make([]byte, n)
+				if _, err := io.ReadFull(r, m); err != nil {
+					return
+				}
+				s.record(string(m))
+			}
+		}()
+	}
+}
+
 type PostServer struct {
 	ts     *httptest.Server
 	URL    string
@@ -10,11 +10,13 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/influxdata/kapacitor/alert"
 	client "github.com/influxdata/kapacitor/client/v1"
 	"github.com/influxdata/kapacitor/services/httpd"
+	"github.com/influxdata/kapacitor/uuid"
 )
 
 const (
@@ -28,11 +30,47 @@ const (
 
 	topicEventsPath   = "events"
 	topicHandlersPath = "handlers"
+	escalationsPath   = "escalations"
+	eventAckPath      = "ack"
+	eventHistoryPath  = "history"
+	topicRoutingPath  = "routing"
+	outboxPath        = "outbox"
+	outboxReplayPath  = "replay"
+
+	silencesPath             = alertsPath + "/silences"
+	silencesPathAnchored     = alertsPath + "/silences/"
+	silencesBasePath         = httpd.BasePreviewPath + silencesPath
+	silencesBasePathAnchored = httpd.BasePreviewPath + silencesPathAnchored
+
+	inhibitionsPath             = alertsPath + "/inhibitions"
+	inhibitionsPathAnchored     = alertsPath + "/inhibitions/"
+	inhibitionsBasePath         = httpd.BasePreviewPath + inhibitionsPath
+	inhibitionsBasePathAnchored = httpd.BasePreviewPath + inhibitionsPathAnchored
+
+	maintenancePath             = alertsPath + "/maintenance-windows"
+	maintenancePathAnchored     = alertsPath + "/maintenance-windows/"
+	maintenanceBasePath         = httpd.BasePreviewPath + maintenancePath
+	maintenanceBasePathAnchored = httpd.BasePreviewPath + maintenancePathAnchored
+
+	onCallPath             = alertsPath + "/oncall-schedules"
+	onCallPathAnchored     = alertsPath + "/oncall-schedules/"
+	onCallBasePath         = httpd.BasePreviewPath + onCallPath
+	onCallBasePathAnchored = httpd.BasePreviewPath + onCallPathAnchored
 
 	eventsPattern   = "*/" + topicEventsPath
 	eventPattern    = "*/" + topicEventsPath + "/*"
+	eventAckPattern = "*/" + topicEventsPath + "/*/" + eventAckPath
+	historyPattern  = "*/" + topicEventsPath + "/*/" + eventHistoryPath
 	handlersPattern = "*/" + topicHandlersPath
 	handlerPattern  = "*/" + topicHandlersPath + "/*"
+	routingPattern  = "*/" + topicRoutingPath
+
+	outboxPattern            = handlerPattern + "/" + outboxPath
+	outboxReplayPattern      = outboxPattern + "/" + outboxReplayPath
+	outboxEntryPattern       = outboxPattern + "/*"
+	outboxEntryReplayPattern = outboxEntryPattern + "/" + outboxReplayPath
+
+	escalationsPattern = "*/" + escalationsPath
 
 	eventsRelation   = "events"
 	handlersRelation = "handlers"
@@ -42,6 +80,15 @@ type apiServer struct {
 	Registrar    HandlerSpecRegistrar
 	Topics       Topics
 	Persister    TopicPersister
+	Acknowledger EventAcknowledger
+	Silencer     Silencer
+	Inhibitor    Inhibitor
+	History      EventHistory
+	Escalations  Escalations
+	Router       TopicRouter
+	Maintenance  MaintenanceScheduler
+	OnCall       OnCallScheduler
+	Outboxes     Outboxes
 	routes       []httpd.Route
 	HTTPDService interface {
 		AddPreviewRoutes([]httpd.Route) error
@@ -89,6 +136,110 @@ func (s *apiServer) Open() error {
 			Pattern:     topicsPathAnchored,
 			HandlerFunc: httpd.ServeOptions,
 		},
+		{
+			Method:      "GET",
+			Pattern:     silencesPath,
+			HandlerFunc: s.handleListSilences,
+		},
+		{
+			Method:      "POST",
+			Pattern:     silencesPath,
+			HandlerFunc: s.handleCreateSilence,
+		},
+		{
+			Method:      "GET",
+			Pattern:     silencesPathAnchored,
+			HandlerFunc: s.handleGetSilence,
+		},
+		{
+			Method:      "DELETE",
+			Pattern:     silencesPathAnchored,
+			HandlerFunc: s.handleDeleteSilence,
+		},
+		{
+			// Satisfy CORS checks.
+			Method:      "OPTIONS",
+			Pattern:     silencesPathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
+		{
+			Method:      "GET",
+			Pattern:     inhibitionsPath,
+			HandlerFunc: s.handleListInhibitions,
+		},
+		{
+			Method:      "POST",
+			Pattern:     inhibitionsPath,
+			HandlerFunc: s.handleCreateInhibition,
+		},
+		{
+			Method:      "GET",
+			Pattern:     inhibitionsPathAnchored,
+			HandlerFunc: s.handleGetInhibition,
+		},
+		{
+			Method:      "DELETE",
+			Pattern:     inhibitionsPathAnchored,
+			HandlerFunc: s.handleDeleteInhibition,
+		},
+		{
+			// Satisfy CORS checks.
+			Method:      "OPTIONS",
+			Pattern:     inhibitionsPathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
+		{
+			Method:      "GET",
+			Pattern:     maintenancePath,
+			HandlerFunc: s.handleListMaintenanceWindows,
+		},
+		{
+			Method:      "POST",
+			Pattern:     maintenancePath,
+			HandlerFunc: s.handleCreateMaintenanceWindow,
+		},
+		{
+			Method:      "GET",
+			Pattern:     maintenancePathAnchored,
+			HandlerFunc: s.handleGetMaintenanceWindow,
+		},
+		{
+			Method:      "DELETE",
+			Pattern:     maintenancePathAnchored,
+			HandlerFunc: s.handleDeleteMaintenanceWindow,
+		},
+		{
+			// Satisfy CORS checks.
+			Method:      "OPTIONS",
+			Pattern:     maintenancePathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
+		{
+			Method:      "GET",
+			Pattern:     onCallPath,
+			HandlerFunc: s.handleListOnCallSchedules,
+		},
+		{
+			Method:      "GET",
+			Pattern:     onCallPathAnchored,
+			HandlerFunc: s.handleGetOnCallSchedule,
+		},
+		{
+			Method:      "PUT",
+			Pattern:     onCallPathAnchored,
+			HandlerFunc: s.handlePutOnCallSchedule,
+		},
+		{
+			Method:      "DELETE",
+			Pattern:     onCallPathAnchored,
+			HandlerFunc: s.handleDeleteOnCallSchedule,
+		},
+		{
+			// Satisfy CORS checks.
+			Method:      "OPTIONS",
+			Pattern:     onCallPathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
 	}
 
 	return s.HTTPDService.AddPreviewRoutes(s.routes)
@@ -179,11 +330,24 @@ func (s *apiServer) handleRouteTopicGet(w http.ResponseWriter, r *http.Request)
 	case pathMatch(eventPattern, p):
 		event := s.eventIDFromPath(p)
 		s.handleGetEvent(id, event, w, r)
+	case pathMatch(historyPattern, p):
+		event := s.eventIDFromPath(path.Dir(p))
+		s.handleGetEventHistory(id, event, w, r)
 	case pathMatch(handlersPattern, p):
 		s.handleListHandlers(id, w, r)
 	case pathMatch(handlerPattern, p):
 		handler := s.handlerIDFromPath(p)
 		s.handleGetHandler(id, handler, w, r)
+	case pathMatch(escalationsPattern, p):
+		s.handleListEscalations(id, w, r)
+	case pathMatch(routingPattern, p):
+		s.handleGetRouting(id, w, r)
+	case pathMatch(outboxPattern, p):
+		handler, _ := outboxIDsFromPath(p)
+		s.handleGetOutbox(id, handler, w, r)
+	case pathMatch(outboxEntryPattern, p):
+		handler, entry := outboxIDsFromPath(p)
+		s.handleGetOutboxEntry(id, handler, entry, w, r)
 	default:
 		s.handleGetTopic(id, w, r)
 	}
@@ -192,12 +356,31 @@ func (s *apiServer) handleRouteTopicGet(w http.ResponseWriter, r *http.Request)
 func (s *apiServer) handleRouteTopicPost(w http.ResponseWriter, r *http.Request) {
 	p := strings.TrimPrefix(r.URL.Path, topicsBasePathAnchored)
 	topic := s.topicIDFromPath(p)
+	if pathMatch(eventAckPattern, p) {
+		event := s.eventIDFromPath(path.Dir(p))
+		s.handleAckEvent(topic, event, w, r)
+		return
+	}
+	if pathMatch(outboxReplayPattern, p) {
+		handler, _ := outboxIDsFromPath(p)
+		s.handleReplayDeadLetters(topic, handler, w, r)
+		return
+	}
+	if pathMatch(outboxEntryReplayPattern, p) {
+		handler, entry := outboxIDsFromPath(p)
+		s.handleReplayDeadLetter(topic, handler, entry, w, r)
+		return
+	}
 	s.handleCreateHandler(topic, w, r)
 }
 
 func (s *apiServer) handleRouteTopicPut(w http.ResponseWriter, r *http.Request) {
 	p := strings.TrimPrefix(r.URL.Path, topicsBasePathAnchored)
 	topic := s.topicIDFromPath(p)
+	if pathMatch(routingPattern, p) {
+		s.handlePutRouting(topic, w, r)
+		return
+	}
 	handler := s.handlerIDFromPath(p)
 	s.handlePutHandler(topic, handler, w, r)
 }
@@ -210,6 +393,15 @@ func (s *apiServer) handleRouteTopicPatch(w http.ResponseWriter, r *http.Request
 func (s *apiServer) handleRouteTopicDelete(w http.ResponseWriter, r *http.Request) {
 	p := strings.TrimPrefix(r.URL.Path, topicsBasePathAnchored)
 	topic := s.topicIDFromPath(p)
+	if pathMatch(routingPattern, p) {
+		s.handleDeleteRouting(topic, w, r)
+		return
+	}
+	if pathMatch(outboxEntryPattern, p) {
+		handler, entry := outboxIDsFromPath(p)
+		s.handleDeleteDeadLetter(topic, handler, entry, w, r)
+		return
+	}
 	handler := s.handlerIDFromPath(p)
 	if topic == handler {
 		s.handleDeleteTopic(topic, w, r)
@@ -262,13 +454,23 @@ func (s *apiServer) handleGetTopic(id string, w http.ResponseWriter, r *http.Req
 }
 
 func (s *apiServer) convertEventStateToClient(state alert.EventState) client.EventState {
-	return client.EventState{
-		Message:  state.Message,
-		Details:  state.Details,
-		Time:     state.Time,
-		Duration: client.Duration(state.Duration),
-		Level:    state.Level.String(),
+	cs := client.EventState{
+		Message:     state.Message,
+		Details:     state.Details,
+		Time:        state.Time,
+		Duration:    client.Duration(state.Duration),
+		Level:       state.Level.String(),
+		Inhibited:   state.Inhibited,
+		Maintenance: state.Maintenance,
 	}
+	if state.Ack.Acked() {
+		cs.Ack = &client.Acknowledgement{
+			By:      state.Ack.By,
+			Time:    state.Ack.Time,
+			Comment: state.Ack.Comment,
+		}
+	}
+	return cs
 }
 
 func (s *apiServer) convertHandlerSpec(spec HandlerSpec) client.TopicHandler {
@@ -328,6 +530,385 @@ func (s *apiServer) handleGetEvent(topic, eventID string, w http.ResponseWriter,
 	w.Write(httpd.MarshalJSON(event, true))
 }
 
+func (s *apiServer) topicEventHistoryLink(topic, event string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(topicsBasePath, topic, topicEventsPath, event, eventHistoryPath)}
+}
+
+func (s *apiServer) handleGetEventHistory(topic, eventID string, w http.ResponseWriter, r *http.Request) {
+	var start, stop time.Time
+	if str := r.URL.Query().Get("start"); str != "" {
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid start time %q: %v", str, err), true, http.StatusBadRequest)
+			return
+		}
+		start = t
+	}
+	if str := r.URL.Query().Get("stop"); str != "" {
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid stop time %q: %v", str, err), true, http.StatusBadRequest)
+			return
+		}
+		stop = t
+	}
+	history, err := s.History.EventHistory(topic, eventID, start, stop)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get event history: %s", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	res := client.TopicEventHistory{
+		Link:    s.topicEventHistoryLink(topic, eventID),
+		Topic:   topic,
+		ID:      eventID,
+		History: make([]client.EventState, len(history)),
+	}
+	for i, state := range history {
+		res.History[i] = s.convertEventStateToClient(state)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(res, true))
+}
+
+func (s *apiServer) handleAckEvent(topic, eventID string, w http.ResponseWriter, r *http.Request) {
+	opts := client.AckEventOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && err != io.EOF {
+		httpd.HttpError(w, fmt.Sprint("invalid acknowledgement json: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	ack := alert.Acknowledgement{
+		By:      opts.By,
+		Time:    time.Now().UTC(),
+		Comment: opts.Comment,
+	}
+	state, ok, err := s.Acknowledger.AcknowledgeEvent(topic, eventID, ack)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to acknowledge event: %s", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown event %q in topic %q", eventID, topic), true, http.StatusNotFound)
+		return
+	}
+	event := client.TopicEvent{
+		Link:  s.topicEventLink(topic, eventID),
+		ID:    eventID,
+		State: s.convertEventStateToClient(state),
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(event, true))
+}
+
+func (s *apiServer) topicEscalationsLink(id string, r client.Relation) client.Link {
+	return client.Link{Relation: r, Href: path.Join(topicsBasePath, id, escalationsPath)}
+}
+
+func (s *apiServer) handleListEscalations(topic string, w http.ResponseWriter, r *http.Request) {
+	escalations, err := s.Escalations.TopicEscalations(topic)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get topic escalations: %s", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	res := client.TopicEscalations{
+		Link:        s.topicEscalationsLink(topic, client.Self),
+		Topic:       topic,
+		Escalations: make([]client.TopicEscalation, len(escalations)),
+	}
+	for i, e := range escalations {
+		res.Escalations[i] = client.TopicEscalation{
+			Handler:   e.Handler,
+			EventID:   e.EventID,
+			State:     s.convertEventStateToClient(convertEventStateToAlert(e.EventID, e.Event)),
+			Step:      e.Step,
+			NextTopic: e.NextTopic,
+			Start:     e.Start,
+			Next:      e.Next,
+			Published: e.Published,
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(res, true))
+}
+
+// outboxIDsFromPath returns the handler and entry IDs of a path below the outbox of a handler.
+func outboxIDsFromPath(p string) (handler, entry string) {
+	parts := strings.Split(p, "/")
+	handler = parts[2]
+	if len(parts) > 4 {
+		entry = parts[4]
+	}
+	return
+}
+
+func (s *apiServer) outboxLink(topic, handler string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(topicsBasePath, topic, topicHandlersPath, handler, outboxPath)}
+}
+
+func (s *apiServer) outboxEntryLink(topic, handler, id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(topicsBasePath, topic, topicHandlersPath, handler, outboxPath, id)}
+}
+
+func (s *apiServer) convertOutboxEntryToClient(e OutboxEntry) client.OutboxEntry {
+	return client.OutboxEntry{
+		Link:      s.outboxEntryLink(e.Topic, e.Handler, e.ID),
+		ID:        e.ID,
+		EventID:   e.EventID,
+		State:     s.convertEventStateToClient(convertEventStateToAlert(e.EventID, e.Event)),
+		Queued:    e.Queued,
+		Attempts:  e.Attempts,
+		Next:      e.Next,
+		LastError: e.LastError,
+		Dead:      e.Dead,
+	}
+}
+
+// handlerExists writes a not found error if the handler does not exist.
+func (s *apiServer) handlerExists(topic, handler string, w http.ResponseWriter) bool {
+	_, ok, err := s.Registrar.HandlerSpec(topic, handler)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get handler %q: %v", handler, err), true, http.StatusInternalServerError)
+		return false
+	}
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown handler: %q", handler), true, http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+func (s *apiServer) writeOutbox(topic, handler string, w http.ResponseWriter) {
+	entries, err := s.Outboxes.HandlerOutbox(topic, handler)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get outbox of handler %q: %v", handler, err), true, http.StatusInternalServerError)
+		return
+	}
+	res := client.HandlerOutbox{
+		Link:    s.outboxLink(topic, handler),
+		Topic:   topic,
+		Handler: handler,
+		Entries: make([]client.OutboxEntry, len(entries)),
+	}
+	for i, e := range entries {
+		if e.Dead {
+			res.DeadLetters++
+		} else {
+			res.QueueDepth++
+		}
+		res.Entries[i] = s.convertOutboxEntryToClient(e)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(res, true))
+}
+
+func (s *apiServer) handleGetOutbox(topic, handler string, w http.ResponseWriter, r *http.Request) {
+	if !s.handlerExists(topic, handler, w) {
+		return
+	}
+	s.writeOutbox(topic, handler, w)
+}
+
+func (s *apiServer) handleGetOutboxEntry(topic, handler, id string, w http.ResponseWriter, r *http.Request) {
+	entries, err := s.Outboxes.HandlerOutbox(topic, handler)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get outbox of handler %q: %v", handler, err), true, http.StatusInternalServerError)
+		return
+	}
+	for _, e := range entries {
+		if e.ID == id {
+			w.WriteHeader(http.StatusOK)
+			w.Write(httpd.MarshalJSON(s.convertOutboxEntryToClient(e), true))
+			return
+		}
+	}
+	httpd.HttpError(w, fmt.Sprintf("unknown outbox entry %q of handler %q", id, handler), true, http.StatusNotFound)
+}
+
+// outboxEntryError writes the error of an operation on an outbox entry.
+func (s *apiServer) outboxEntryError(action, handler, id string, err error, w http.ResponseWriter) {
+	code := http.StatusBadRequest
+	if err == ErrNoOutboxEntryExists {
+		code = http.StatusNotFound
+	}
+	httpd.HttpError(w, fmt.Sprintf("failed to %s outbox entry %q of handler %q: %v", action, id, handler, err), true, code)
+}
+
+func (s *apiServer) handleReplayDeadLetter(topic, handler, id string, w http.ResponseWriter, r *http.Request) {
+	if !s.handlerExists(topic, handler, w) {
+		return
+	}
+	if err := s.Outboxes.ReplayDeadLetter(topic, handler, id); err != nil {
+		s.outboxEntryError("replay", handler, id, err, w)
+		return
+	}
+	s.handleGetOutboxEntry(topic, handler, id, w, r)
+}
+
+func (s *apiServer) handleReplayDeadLetters(topic, handler string, w http.ResponseWriter, r *http.Request) {
+	if !s.handlerExists(topic, handler, w) {
+		return
+	}
+	if _, err := s.Outboxes.ReplayDeadLetters(topic, handler); err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to replay dead letters of handler %q: %v", handler, err), true, http.StatusInternalServerError)
+		return
+	}
+	s.writeOutbox(topic, handler, w)
+}
+
+func (s *apiServer) handleDeleteDeadLetter(topic, handler, id string, w http.ResponseWriter, r *http.Request) {
+	if err := s.Outboxes.DeleteDeadLetter(topic, handler, id); err != nil {
+		s.outboxEntryError("delete", handler, id, err, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *apiServer) topicRoutingLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(topicsBasePath, id, topicRoutingPath)}
+}
+
+// timeOfDayLayout is the layout of the start and stop times of day of routes.
+const timeOfDayLayout = "15:04"
+
+func formatTimeOfDay(d time.Duration) string {
+	return time.Time{}.Add(d).Format(timeOfDayLayout)
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(timeOfDayLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, must be of the form HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (s *apiServer) convertRoutesToClient(routes []alert.Route) []client.TopicRoute {
+	converted := make([]client.TopicRoute, len(routes))
+	for i, r := range routes {
+		cr := client.TopicRoute{
+			Tags:     r.Tags,
+			TaskName: r.TaskName,
+			Routes:   s.convertRoutesToClient(r.Routes),
+			Handlers: r.Handlers,
+			Continue: r.Continue,
+		}
+		for _, l := range r.Levels {
+			cr.Levels = append(cr.Levels, l.String())
+		}
+		if r.Start != r.Stop {
+			cr.Start = formatTimeOfDay(r.Start)
+			cr.Stop = formatTimeOfDay(r.Stop)
+		}
+		converted[i] = cr
+	}
+	return converted
+}
+
+func (s *apiServer) convertRoutesFromClient(routes []client.TopicRoute) ([]alert.Route, error) {
+	if routes == nil {
+		return nil, nil
+	}
+	converted := make([]alert.Route, len(routes))
+	for i, cr := range routes {
+		r := alert.Route{
+			Tags:     cr.Tags,
+			TaskName: cr.TaskName,
+			Handlers: cr.Handlers,
+			Continue: cr.Continue,
+		}
+		for _, level := range cr.Levels {
+			l, err := alert.ParseLevel(level)
+			if err != nil {
+				return nil, err
+			}
+			r.Levels = append(r.Levels, l)
+		}
+		var err error
+		if r.Start, err = parseTimeOfDay(cr.Start); err != nil {
+			return nil, err
+		}
+		if r.Stop, err = parseTimeOfDay(cr.Stop); err != nil {
+			return nil, err
+		}
+		if r.Routes, err = s.convertRoutesFromClient(cr.Routes); err != nil {
+			return nil, err
+		}
+		converted[i] = r
+	}
+	return converted, nil
+}
+
+func (s *apiServer) convertRoutingToClient(routing alert.Routing) client.TopicRouting {
+	r := client.TopicRouting{
+		Link:    s.topicRoutingLink(routing.Topic),
+		Topic:   routing.Topic,
+		Routes:  s.convertRoutesToClient(routing.Routes),
+		Default: routing.Default,
+	}
+	if routing.Location != nil {
+		r.Timezone = routing.Location.String()
+	}
+	return r
+}
+
+func (s *apiServer) handleGetRouting(topic string, w http.ResponseWriter, r *http.Request) {
+	routing, ok, err := s.Router.Routing(topic)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get routing of topic %q: %v", topic, err), true, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("topic %q has no routing", topic), true, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertRoutingToClient(routing), true))
+}
+
+func (s *apiServer) handlePutRouting(topic string, w http.ResponseWriter, r *http.Request) {
+	opts := client.TopicRoutingOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid routing json: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	loc, err := time.LoadLocation(opts.Timezone)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid timezone: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	routes, err := s.convertRoutesFromClient(opts.Routes)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid routing: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	routing := alert.Routing{
+		Topic:    topic,
+		Routes:   routes,
+		Default:  opts.Default,
+		Location: loc,
+	}
+	if err := routing.Validate(); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid routing: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	if err := s.Router.SetRouting(routing); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to set routing: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertRoutingToClient(routing), true))
+}
+
+func (s *apiServer) handleDeleteRouting(topic string, w http.ResponseWriter, r *http.Request) {
+	if err := s.Router.DeleteRouting(topic); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to delete routing: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (s *apiServer) handleListHandlers(topic string, w http.ResponseWriter, r *http.Request) {
 	pattern := r.URL.Query().Get("pattern")
 	if err := validatePattern(pattern); err != nil {
@@ -502,3 +1083,466 @@ func (s *apiServer) handleGetHandler(topic, handler string, w http.ResponseWrite
 	w.WriteHeader(http.StatusOK)
 	w.Write(httpd.MarshalJSON(h, true))
 }
+
+func (s *apiServer) silenceLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(silencesBasePath, id)}
+}
+
+func (s *apiServer) convertSilenceToClient(silence alert.Silence) client.Silence {
+	return client.Silence{
+		Link:      s.silenceLink(silence.ID),
+		ID:        silence.ID,
+		Topic:     silence.Topic,
+		Event:     silence.Event,
+		Tags:      silence.Tags,
+		Start:     silence.Start,
+		Stop:      silence.Stop,
+		CreatedBy: silence.CreatedBy,
+		Comment:   silence.Comment,
+	}
+}
+
+func (s *apiServer) handleListSilences(w http.ResponseWriter, r *http.Request) {
+	silences, err := s.Silencer.Silences()
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to get silences: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	list := client.Silences{
+		Link:     client.Link{Relation: client.Self, Href: r.URL.String()},
+		Silences: make([]client.Silence, len(silences)),
+	}
+	for i, silence := range silences {
+		list.Silences[i] = s.convertSilenceToClient(silence)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(list, true))
+}
+
+func (s *apiServer) handleCreateSilence(w http.ResponseWriter, r *http.Request) {
+	opts := client.SilenceOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid silence json: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	silence := alert.Silence{
+		ID:        opts.ID,
+		Topic:     opts.Topic,
+		Event:     opts.Event,
+		Tags:      opts.Tags,
+		Start:     opts.Start,
+		Stop:      opts.Stop,
+		CreatedBy: opts.CreatedBy,
+		Comment:   opts.Comment,
+	}
+	if silence.ID == "" {
+		silence.ID = uuid.New().String()
+	}
+	if silence.Start.IsZero() {
+		silence.Start = time.Now().UTC()
+	}
+	if silence.Stop.IsZero() && opts.Duration > 0 {
+		silence.Stop = silence.Start.Add(time.Duration(opts.Duration))
+	}
+	if err := silence.Validate(); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid silence: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	if err := s.Silencer.CreateSilence(silence); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to create silence: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertSilenceToClient(silence), true))
+}
+
+func (s *apiServer) handleGetSilence(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, silencesBasePathAnchored)
+	silence, ok, err := s.Silencer.Silence(id)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get silence %q: %v", id, err), true, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown silence: %q", id), true, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertSilenceToClient(silence), true))
+}
+
+func (s *apiServer) handleDeleteSilence(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, silencesBasePathAnchored)
+	if err := s.Silencer.DeleteSilence(id); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to delete silence: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *apiServer) inhibitionLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(inhibitionsBasePath, id)}
+}
+
+func (s *apiServer) convertInhibitionToClient(inhibition alert.Inhibition) client.Inhibition {
+	return client.Inhibition{
+		Link:        s.inhibitionLink(inhibition.ID),
+		ID:          inhibition.ID,
+		SourceTopic: inhibition.SourceTopic,
+		Level:       inhibition.Level.String(),
+		TargetTopic: inhibition.TargetTopic,
+		Tags:        inhibition.Tags,
+		Equal:       inhibition.Equal,
+		Comment:     inhibition.Comment,
+	}
+}
+
+func (s *apiServer) handleListInhibitions(w http.ResponseWriter, r *http.Request) {
+	inhibitions, err := s.Inhibitor.Inhibitions()
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to get inhibitions: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	list := client.Inhibitions{
+		Link:        client.Link{Relation: client.Self, Href: r.URL.String()},
+		Inhibitions: make([]client.Inhibition, len(inhibitions)),
+	}
+	for i, inhibition := range inhibitions {
+		list.Inhibitions[i] = s.convertInhibitionToClient(inhibition)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(list, true))
+}
+
+func (s *apiServer) handleCreateInhibition(w http.ResponseWriter, r *http.Request) {
+	opts := client.InhibitionOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid inhibition json: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	level := alert.Critical
+	if opts.Level != "" {
+		l, err := alert.ParseLevel(opts.Level)
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+			return
+		}
+		level = l
+	}
+	inhibition := alert.Inhibition{
+		ID:          opts.ID,
+		SourceTopic: opts.SourceTopic,
+		Level:       level,
+		TargetTopic: opts.TargetTopic,
+		Tags:        opts.Tags,
+		Equal:       opts.Equal,
+		Comment:     opts.Comment,
+	}
+	if inhibition.ID == "" {
+		inhibition.ID = uuid.New().String()
+	}
+	if err := inhibition.Validate(); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid inhibition: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	if err := s.Inhibitor.CreateInhibition(inhibition); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to create inhibition: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertInhibitionToClient(inhibition), true))
+}
+
+func (s *apiServer) handleGetInhibition(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, inhibitionsBasePathAnchored)
+	inhibition, ok, err := s.Inhibitor.Inhibition(id)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get inhibition %q: %v", id, err), true, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown inhibition: %q", id), true, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertInhibitionToClient(inhibition), true))
+}
+
+func (s *apiServer) handleDeleteInhibition(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, inhibitionsBasePathAnchored)
+	if err := s.Inhibitor.DeleteInhibition(id); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to delete inhibition: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *apiServer) maintenanceWindowLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(maintenanceBasePath, id)}
+}
+
+func (s *apiServer) convertMaintenanceWindowToClient(window alert.MaintenanceWindow) client.MaintenanceWindow {
+	cw := client.MaintenanceWindow{
+		Link:     s.maintenanceWindowLink(window.ID),
+		ID:       window.ID,
+		Topic:    window.Topic,
+		Event:    window.Event,
+		Tags:     window.Tags,
+		Cron:     window.Cron,
+		Duration: client.Duration(window.Duration),
+		Start:    window.Start,
+		Stop:     window.Stop,
+		Comment:  window.Comment,
+	}
+	if window.Location != nil {
+		cw.Timezone = window.Location.String()
+	}
+	return cw
+}
+
+func (s *apiServer) handleListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	windows, err := s.Maintenance.MaintenanceWindows()
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to get maintenance windows: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	list := client.MaintenanceWindows{
+		Link:               client.Link{Relation: client.Self, Href: r.URL.String()},
+		MaintenanceWindows: make([]client.MaintenanceWindow, len(windows)),
+	}
+	for i, window := range windows {
+		list.MaintenanceWindows[i] = s.convertMaintenanceWindowToClient(window)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(list, true))
+}
+
+func (s *apiServer) handleCreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	opts := client.MaintenanceWindowOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid maintenance window json: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	loc, err := time.LoadLocation(opts.Timezone)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid timezone: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	window := alert.MaintenanceWindow{
+		ID:       opts.ID,
+		Topic:    opts.Topic,
+		Event:    opts.Event,
+		Tags:     opts.Tags,
+		Cron:     opts.Cron,
+		Duration: time.Duration(opts.Duration),
+		Location: loc,
+		Start:    opts.Start,
+		Stop:     opts.Stop,
+		Comment:  opts.Comment,
+	}
+	if window.ID == "" {
+		window.ID = uuid.New().String()
+	}
+	if window.Cron == "" && window.Start.IsZero() {
+		window.Start = time.Now().UTC()
+	}
+	if window.Cron == "" && window.Stop.IsZero() && window.Duration > 0 {
+		// A one-off window may be given as a duration from its start.
+		window.Stop = window.Start.Add(window.Duration)
+		window.Duration = 0
+	}
+	if err := window.Validate(); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid maintenance window: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	if err := s.Maintenance.CreateMaintenanceWindow(window); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to create maintenance window: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertMaintenanceWindowToClient(window), true))
+}
+
+func (s *apiServer) handleGetMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, maintenanceBasePathAnchored)
+	window, ok, err := s.Maintenance.MaintenanceWindow(id)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get maintenance window %q: %v", id, err), true, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown maintenance window: %q", id), true, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertMaintenanceWindowToClient(window), true))
+}
+
+func (s *apiServer) handleDeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, maintenanceBasePathAnchored)
+	if err := s.Maintenance.DeleteMaintenanceWindow(id); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to delete maintenance window: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *apiServer) onCallScheduleLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(onCallBasePath, id)}
+}
+
+func (s *apiServer) convertOnCallScheduleToClient(schedule alert.OnCallSchedule) client.OnCallSchedule {
+	cs := client.OnCallSchedule{
+		Link:      s.onCallScheduleLink(schedule.ID),
+		ID:        schedule.ID,
+		Members:   make([]client.OnCallMember, len(schedule.Members)),
+		Rotations: make([]client.OnCallRotation, len(schedule.Rotations)),
+		Overrides: make([]client.OnCallOverride, len(schedule.Overrides)),
+	}
+	for i, m := range schedule.Members {
+		cs.Members[i] = client.OnCallMember{
+			Name:     m.Name,
+			Contacts: m.Contacts,
+		}
+	}
+	for i, r := range schedule.Rotations {
+		cr := client.OnCallRotation{
+			Members: r.Members,
+			Handoff: r.Handoff,
+			Shift:   client.Duration(r.Shift),
+		}
+		if r.Start != r.Stop {
+			cr.Start = formatTimeOfDay(r.Start)
+			cr.Stop = formatTimeOfDay(r.Stop)
+		}
+		cs.Rotations[i] = cr
+	}
+	for i, o := range schedule.Overrides {
+		cs.Overrides[i] = client.OnCallOverride{
+			Member: o.Member,
+			Start:  o.Start,
+			Stop:   o.Stop,
+		}
+	}
+	if schedule.Location != nil {
+		cs.Timezone = schedule.Location.String()
+	}
+	if m, ok := schedule.OnCall(time.Now()); ok {
+		cs.OnCall = m.Name
+	}
+	return cs
+}
+
+func (s *apiServer) convertOnCallScheduleFromClient(id string, opts client.OnCallScheduleOptions) (alert.OnCallSchedule, error) {
+	loc, err := time.LoadLocation(opts.Timezone)
+	if err != nil {
+		return alert.OnCallSchedule{}, fmt.Errorf("invalid timezone: %v", err)
+	}
+	schedule := alert.OnCallSchedule{
+		ID:        id,
+		Members:   make([]alert.OnCallMember, len(opts.Members)),
+		Rotations: make([]alert.Rotation, len(opts.Rotations)),
+		Overrides: make([]alert.OnCallOverride, len(opts.Overrides)),
+		Location:  loc,
+	}
+	for i, m := range opts.Members {
+		schedule.Members[i] = alert.OnCallMember{
+			Name:     m.Name,
+			Contacts: m.Contacts,
+		}
+	}
+	for i, cr := range opts.Rotations {
+		r := alert.Rotation{
+			Members: cr.Members,
+			Handoff: cr.Handoff,
+			Shift:   time.Duration(cr.Shift),
+		}
+		if r.Start, err = parseTimeOfDay(cr.Start); err != nil {
+			return alert.OnCallSchedule{}, err
+		}
+		if r.Stop, err = parseTimeOfDay(cr.Stop); err != nil {
+			return alert.OnCallSchedule{}, err
+		}
+		schedule.Rotations[i] = r
+	}
+	for i, o := range opts.Overrides {
+		schedule.Overrides[i] = alert.OnCallOverride{
+			Member: o.Member,
+			Start:  o.Start,
+			Stop:   o.Stop,
+		}
+	}
+	return schedule, nil
+}
+
+type sortedOnCallSchedules []client.OnCallSchedule
+
+func (s sortedOnCallSchedules) Len() int               { return len(s) }
+func (s sortedOnCallSchedules) Less(i int, j int) bool { return s[i].ID < s[j].ID }
+func (s sortedOnCallSchedules) Swap(i int, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s *apiServer) handleListOnCallSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := s.OnCall.OnCallSchedules()
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to get on-call schedules: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	list := client.OnCallSchedules{
+		Link:      client.Link{Relation: client.Self, Href: r.URL.String()},
+		Schedules: make([]client.OnCallSchedule, len(schedules)),
+	}
+	for i, schedule := range schedules {
+		list.Schedules[i] = s.convertOnCallScheduleToClient(schedule)
+	}
+	sort.Sort(sortedOnCallSchedules(list.Schedules))
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(list, true))
+}
+
+func (s *apiServer) handleGetOnCallSchedule(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, onCallBasePathAnchored)
+	schedule, ok, err := s.OnCall.OnCallSchedule(id)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get on-call schedule %q: %v", id, err), true, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown on-call schedule: %q", id), true, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertOnCallScheduleToClient(schedule), true))
+}
+
+func (s *apiServer) handlePutOnCallSchedule(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, onCallBasePathAnchored)
+	opts := client.OnCallScheduleOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid on-call schedule json: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	schedule, err := s.convertOnCallScheduleFromClient(id, opts)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid on-call schedule: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid on-call schedule: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	if err := s.OnCall.SetOnCallSchedule(schedule); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to set on-call schedule: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertOnCallScheduleToClient(schedule), true))
+}
+
+func (s *apiServer) handleDeleteOnCallSchedule(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, onCallBasePathAnchored)
+	if err := s.OnCall.DeleteOnCallSchedule(id); err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to delete on-call schedule: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,41 @@
+package alert
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+)
+
+// Config of the outboxes of topic handlers.
+// Handlers defined in TICKscript alert nodes have no outbox, their failed deliveries are not retried.
+type Config struct {
+	// OutboxRetryInterval is how long to wait before first retrying a failed delivery,
+	// the wait doubles with each failed attempt.
+	OutboxRetryInterval toml.Duration `toml:"outbox-retry-interval"`
+	// OutboxMaxRetryInterval is the longest wait between two attempts.
+	OutboxMaxRetryInterval toml.Duration `toml:"outbox-max-retry-interval"`
+	// OutboxMaxAge is how long a failed delivery is retried before it becomes a dead letter.
+	OutboxMaxAge toml.Duration `toml:"outbox-max-age"`
+}
+
+func NewConfig() Config {
+	return Config{
+		OutboxRetryInterval:    toml.Duration(10 * time.Second),
+		OutboxMaxRetryInterval: toml.Duration(10 * time.Minute),
+		OutboxMaxAge:           toml.Duration(24 * time.Hour),
+	}
+}
+
+func (c Config) Validate() error {
+	if c.OutboxRetryInterval <= 0 {
+		return errors.New("alert outbox-retry-interval must be positive")
+	}
+	if c.OutboxMaxRetryInterval < c.OutboxRetryInterval {
+		return errors.New("alert outbox-max-retry-interval must not be less than outbox-retry-interval")
+	}
+	if c.OutboxMaxAge <= 0 {
+		return errors.New("alert outbox-max-age must be positive")
+	}
+	return nil
+}
@@ -3,11 +3,13 @@ package alert
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"path"
 	"regexp"
 	"time"
 
 	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/services/storage"
 	"github.com/pkg/errors"
 )
@@ -249,11 +251,20 @@ type TopicState struct {
 }
 
 type EventState struct {
-	Message  string        `json:"message"`
-	Details  string        `json:"details"`
-	Time     time.Time     `json:"time"`
-	Duration time.Duration `json:"duration"`
-	Level    alert.Level   `json:"level"`
+	Message     string           `json:"message"`
+	Details     string           `json:"details"`
+	Time        time.Time        `json:"time"`
+	Duration    time.Duration    `json:"duration"`
+	Level       alert.Level      `json:"level"`
+	Ack         *Acknowledgement `json:"ack,omitempty"`
+	Inhibited   string           `json:"inhibited,omitempty"`
+	Maintenance string           `json:"maintenance,omitempty"`
+}
+
+type Acknowledgement struct {
+	By      string    `json:"by"`
+	Time    time.Time `json:"time"`
+	Comment string    `json:"comment"`
 }
 
 func (t TopicState) ObjectID() string {
@@ -338,3 +349,1068 @@ func (kv *topicStateKV) List(pattern string, offset, limit int) ([]TopicState, e
 func (kv *topicStateKV) Rebuild() error {
 	return kv.store.Rebuild()
 }
+
+var (
+	ErrSilenceExists   = errors.New("silence already exists")
+	ErrNoSilenceExists = errors.New("no silence exists")
+)
+
+// Data access object for Silence data.
+type SilenceDAO interface {
+	// Retrieve a silence
+	Get(id string) (Silence, error)
+
+	// Create a silence.
+	// ErrSilenceExists is returned if a silence already exists with the same ID.
+	Create(s Silence) error
+
+	// Delete a silence.
+	// It is not an error to delete an non-existent silence.
+	Delete(id string) error
+
+	// List silences matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]Silence, error)
+
+	Rebuild() error
+}
+
+const silenceVersion = 1
+
+var validSilenceID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
+
+type Silence struct {
+	ID        string            `json:"id"`
+	Topic     string            `json:"topic"`
+	Event     string            `json:"event"`
+	Tags      map[string]string `json:"tags"`
+	Start     time.Time         `json:"start"`
+	Stop      time.Time         `json:"stop"`
+	CreatedBy string            `json:"created-by"`
+	Comment   string            `json:"comment"`
+}
+
+func (s Silence) ObjectID() string {
+	return s.ID
+}
+
+func (s Silence) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(silenceVersion, s)
+}
+
+func (s *Silence) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		return dec.Decode(&s)
+	})
+}
+
+// Key/Value store based implementation of the SilenceDAO
+type silenceKV struct {
+	store *storage.IndexedStore
+}
+
+func newSilenceKV(store storage.Interface) (*silenceKV, error) {
+	c := storage.DefaultIndexedStoreConfig("silences", func() storage.BinaryObject {
+		return new(Silence)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &silenceKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *silenceKV) error(err error) error {
+	if err == storage.ErrObjectExists {
+		return ErrSilenceExists
+	} else if err == storage.ErrNoObjectExists {
+		return ErrNoSilenceExists
+	}
+	return err
+}
+
+func (kv *silenceKV) Get(id string) (Silence, error) {
+	o, err := kv.store.Get(id)
+	if err != nil {
+		return Silence{}, kv.error(err)
+	}
+	s, ok := o.(*Silence)
+	if !ok {
+		return Silence{}, storage.ImpossibleTypeErr(s, o)
+	}
+	return *s, nil
+}
+
+func (kv *silenceKV) Create(s Silence) error {
+	return kv.error(kv.store.Create(&s))
+}
+
+func (kv *silenceKV) Delete(id string) error {
+	return kv.store.Delete(id)
+}
+
+func (kv *silenceKV) List(pattern string, offset, limit int) ([]Silence, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	silences := make([]Silence, len(objects))
+	for i, o := range objects {
+		s, ok := o.(*Silence)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(s, o)
+		}
+		silences[i] = *s
+	}
+	return silences, nil
+}
+
+func (kv *silenceKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+var (
+	ErrInhibitionExists   = errors.New("inhibition already exists")
+	ErrNoInhibitionExists = errors.New("no inhibition exists")
+)
+
+// Data access object for Inhibition data.
+type InhibitionDAO interface {
+	// Retrieve an inhibition
+	Get(id string) (Inhibition, error)
+
+	// Create an inhibition.
+	// ErrInhibitionExists is returned if an inhibition already exists with the same ID.
+	Create(i Inhibition) error
+
+	// Delete an inhibition.
+	// It is not an error to delete an non-existent inhibition.
+	Delete(id string) error
+
+	// List inhibitions matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]Inhibition, error)
+
+	Rebuild() error
+}
+
+const inhibitionVersion = 1
+
+var validInhibitionID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
+
+type Inhibition struct {
+	ID          string            `json:"id"`
+	SourceTopic string            `json:"source-topic"`
+	Level       alert.Level       `json:"level"`
+	TargetTopic string            `json:"target-topic"`
+	Tags        map[string]string `json:"tags"`
+	Equal       []string          `json:"equal"`
+	Comment     string            `json:"comment"`
+}
+
+func (i Inhibition) ObjectID() string {
+	return i.ID
+}
+
+func (i Inhibition) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(inhibitionVersion, i)
+}
+
+func (i *Inhibition) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		return dec.Decode(&i)
+	})
+}
+
+// Key/Value store based implementation of the InhibitionDAO
+type inhibitionKV struct {
+	store *storage.IndexedStore
+}
+
+func newInhibitionKV(store storage.Interface) (*inhibitionKV, error) {
+	c := storage.DefaultIndexedStoreConfig("inhibitions", func() storage.BinaryObject {
+		return new(Inhibition)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &inhibitionKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *inhibitionKV) error(err error) error {
+	if err == storage.ErrObjectExists {
+		return ErrInhibitionExists
+	} else if err == storage.ErrNoObjectExists {
+		return ErrNoInhibitionExists
+	}
+	return err
+}
+
+func (kv *inhibitionKV) Get(id string) (Inhibition, error) {
+	o, err := kv.store.Get(id)
+	if err != nil {
+		return Inhibition{}, kv.error(err)
+	}
+	i, ok := o.(*Inhibition)
+	if !ok {
+		return Inhibition{}, storage.ImpossibleTypeErr(i, o)
+	}
+	return *i, nil
+}
+
+func (kv *inhibitionKV) Create(i Inhibition) error {
+	return kv.error(kv.store.Create(&i))
+}
+
+func (kv *inhibitionKV) Delete(id string) error {
+	return kv.store.Delete(id)
+}
+
+func (kv *inhibitionKV) List(pattern string, offset, limit int) ([]Inhibition, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	inhibitions := make([]Inhibition, len(objects))
+	for j, o := range objects {
+		i, ok := o.(*Inhibition)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(i, o)
+		}
+		inhibitions[j] = *i
+	}
+	return inhibitions, nil
+}
+
+func (kv *inhibitionKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+var (
+	ErrNoRoutingExists = errors.New("no routing exists")
+)
+
+// Data access object for Routing data.
+type RoutingDAO interface {
+	// Retrieve the routing of a topic
+	Get(topic string) (Routing, error)
+
+	// Put creates or replaces the routing of a topic.
+	Put(r Routing) error
+
+	// Delete the routing of a topic.
+	// It is not an error to delete an non-existent routing.
+	Delete(topic string) error
+
+	// List routings of topics matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]Routing, error)
+
+	Rebuild() error
+}
+
+const routingVersion = 1
+
+type Routing struct {
+	Topic    string   `json:"topic"`
+	Routes   []Route  `json:"routes"`
+	Default  []string `json:"default"`
+	Timezone string   `json:"timezone"`
+}
+
+type Route struct {
+	Levels   []alert.Level     `json:"levels"`
+	Tags     map[string]string `json:"tags"`
+	TaskName string            `json:"task-name"`
+	Start    time.Duration     `json:"start"`
+	Stop     time.Duration     `json:"stop"`
+	Routes   []Route           `json:"routes"`
+	Handlers []string          `json:"handlers"`
+	Continue bool              `json:"continue"`
+}
+
+func (r Routing) ObjectID() string {
+	return r.Topic
+}
+
+func (r Routing) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(routingVersion, r)
+}
+
+func (r *Routing) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		return dec.Decode(&r)
+	})
+}
+
+// Key/Value store based implementation of the RoutingDAO
+type routingKV struct {
+	store *storage.IndexedStore
+}
+
+func newRoutingKV(store storage.Interface) (*routingKV, error) {
+	c := storage.DefaultIndexedStoreConfig("routings", func() storage.BinaryObject {
+		return new(Routing)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &routingKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *routingKV) error(err error) error {
+	if err == storage.ErrNoObjectExists {
+		return ErrNoRoutingExists
+	}
+	return err
+}
+
+func (kv *routingKV) Get(topic string) (Routing, error) {
+	o, err := kv.store.Get(topic)
+	if err != nil {
+		return Routing{}, kv.error(err)
+	}
+	r, ok := o.(*Routing)
+	if !ok {
+		return Routing{}, storage.ImpossibleTypeErr(r, o)
+	}
+	return *r, nil
+}
+
+func (kv *routingKV) Put(r Routing) error {
+	return kv.store.Put(&r)
+}
+
+func (kv *routingKV) Delete(topic string) error {
+	return kv.store.Delete(topic)
+}
+
+func (kv *routingKV) List(pattern string, offset, limit int) ([]Routing, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	routings := make([]Routing, len(objects))
+	for i, o := range objects {
+		r, ok := o.(*Routing)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(r, o)
+		}
+		routings[i] = *r
+	}
+	return routings, nil
+}
+
+func (kv *routingKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+var (
+	ErrEscalationExists   = errors.New("escalation already exists")
+	ErrNoEscalationExists = errors.New("no escalation exists")
+)
+
+// Data access object for Escalation data.
+type EscalationDAO interface {
+	// Retrieve an escalation
+	Get(topic, handler, event string) (Escalation, error)
+
+	// Put creates or replaces an escalation.
+	Put(e Escalation) error
+
+	// Delete an escalation.
+	// It is not an error to delete an non-existent escalation.
+	Delete(topic, handler, event string) error
+
+	// List escalations of a topic for handlers matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(topic, pattern string, offset, limit int) ([]Escalation, error)
+
+	Rebuild() error
+}
+
+const escalationVersion = 1
+
+// Escalation is the pending escalation of an event by an escalate handler.
+type Escalation struct {
+	Topic   string     `json:"topic"`
+	Handler string     `json:"handler"`
+	EventID string     `json:"event-id"`
+	Event   EventState `json:"event"`
+	// Step is the index of the next step of the escalation.
+	// Once all steps have been published the escalation is kept until the event recovers.
+	Step      int       `json:"step"`
+	NextTopic string    `json:"next-topic"`
+	Start     time.Time `json:"start"`
+	Next      time.Time `json:"next"`
+	// Published is the list of step topics the event has been published to,
+	// they are sent the recovery of the event.
+	Published []string `json:"published"`
+}
+
+func escalationID(topic, handler, event string) string {
+	// Event IDs may contain any character, escape them so they form a single path element.
+	return path.Join(topic, handler, url.QueryEscape(event))
+}
+
+func (e Escalation) ObjectID() string {
+	return escalationID(e.Topic, e.Handler, e.EventID)
+}
+
+func (e Escalation) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(escalationVersion, e)
+}
+
+func (e *Escalation) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		return dec.Decode(&e)
+	})
+}
+
+// Key/Value store based implementation of the EscalationDAO
+type escalationKV struct {
+	store *storage.IndexedStore
+}
+
+func newEscalationKV(store storage.Interface) (*escalationKV, error) {
+	c := storage.DefaultIndexedStoreConfig("escalations", func() storage.BinaryObject {
+		return new(Escalation)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &escalationKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *escalationKV) error(err error) error {
+	if err == storage.ErrObjectExists {
+		return ErrEscalationExists
+	} else if err == storage.ErrNoObjectExists {
+		return ErrNoEscalationExists
+	}
+	return err
+}
+
+func (kv *escalationKV) Get(topic, handler, event string) (Escalation, error) {
+	o, err := kv.store.Get(escalationID(topic, handler, event))
+	if err != nil {
+		return Escalation{}, kv.error(err)
+	}
+	e, ok := o.(*Escalation)
+	if !ok {
+		return Escalation{}, storage.ImpossibleTypeErr(e, o)
+	}
+	return *e, nil
+}
+
+func (kv *escalationKV) Put(e Escalation) error {
+	return kv.error(kv.store.Put(&e))
+}
+
+func (kv *escalationKV) Delete(topic, handler, event string) error {
+	return kv.store.Delete(escalationID(topic, handler, event))
+}
+
+func (kv *escalationKV) List(topic, pattern string, offset, limit int) ([]Escalation, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	objects, err := kv.store.List(storage.DefaultIDIndex, path.Join(topic, pattern, "*"), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	escalations := make([]Escalation, len(objects))
+	for i, o := range objects {
+		e, ok := o.(*Escalation)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(e, o)
+		}
+		escalations[i] = *e
+	}
+	return escalations, nil
+}
+
+func (kv *escalationKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+// Data access object for the history of events.
+type EventHistoryDAO interface {
+	// Put records a transition, replacing any transition of the event at the same time,
+	// and deletes the oldest transitions of the event beyond limit.
+	Put(t EventTransition, limit int) error
+
+	// List the transitions of an event in time order.
+	List(topic, event string) ([]EventTransition, error)
+
+	// DeleteTopic deletes the transitions of all events of a topic.
+	DeleteTopic(topic string) error
+
+	Rebuild() error
+}
+
+const eventTransitionVersion = 1
+
+// EventTransition is the state of an event when its level changed.
+type EventTransition struct {
+	Topic   string     `json:"topic"`
+	EventID string     `json:"event-id"`
+	State   EventState `json:"state"`
+}
+
+func (t EventTransition) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(eventTransitionVersion, t)
+}
+
+func (t *EventTransition) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		return dec.Decode(&t)
+	})
+}
+
+// Key/Value store based implementation of the EventHistoryDAO.
+//
+// Transitions are stored by topic, event and time:
+//
+// /event-history/data/<topic>/<event>/<time>
+//
+// so the transitions of an event are read without scanning the history of other events.
+type eventHistoryKV struct {
+	store storage.Interface
+}
+
+const eventHistoryDataPrefix = "/event-history/data/"
+
+func newEventHistoryKV(store storage.Interface) (*eventHistoryKV, error) {
+	return &eventHistoryKV{
+		store: store,
+	}, nil
+}
+
+func eventHistoryTopicPrefix(topic string) string {
+	// Escaped IDs contain no slashes.
+	return eventHistoryDataPrefix + url.QueryEscape(topic) + "/"
+}
+
+func eventHistoryEventPrefix(topic, event string) string {
+	return eventHistoryTopicPrefix(topic) + url.QueryEscape(event) + "/"
+}
+
+func eventTransitionKey(topic, event string, t time.Time) string {
+	// Flip the sign bit so the fixed width keys sort in time order, including times before the epoch.
+	return eventHistoryEventPrefix(topic, event) + fmt.Sprintf("%016x", uint64(t.UnixNano())^(1<<63))
+}
+
+func (kv *eventHistoryKV) Put(t EventTransition, limit int) error {
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return kv.store.Update(func(tx storage.Tx) error {
+		if err := tx.Put(eventTransitionKey(t.Topic, t.EventID, t.State.Time), data); err != nil {
+			return err
+		}
+		kvs, err := tx.List(eventHistoryEventPrefix(t.Topic, t.EventID))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(kvs)-limit; i++ {
+			if err := tx.Delete(kvs[i].Key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (kv *eventHistoryKV) List(topic, event string) (transitions []EventTransition, err error) {
+	err = kv.store.View(func(tx storage.ReadOnlyTx) error {
+		kvs, err := tx.List(eventHistoryEventPrefix(topic, event))
+		if err != nil {
+			return err
+		}
+		transitions = make([]EventTransition, len(kvs))
+		for i, kv := range kvs {
+			if err := transitions[i].UnmarshalBinary(kv.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (kv *eventHistoryKV) DeleteTopic(topic string) error {
+	return kv.store.Update(func(tx storage.Tx) error {
+		kvs, err := tx.List(eventHistoryTopicPrefix(topic))
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			if err := tx.Delete(kv.Key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Rebuild does nothing, the history has no indexes.
+func (kv *eventHistoryKV) Rebuild() error {
+	return nil
+}
+
+var (
+	ErrMaintenanceWindowExists   = errors.New("maintenance window already exists")
+	ErrNoMaintenanceWindowExists = errors.New("no maintenance window exists")
+)
+
+// Data access object for MaintenanceWindow data.
+type MaintenanceWindowDAO interface {
+	// Retrieve a maintenance window
+	Get(id string) (MaintenanceWindow, error)
+
+	// Create a maintenance window.
+	// ErrMaintenanceWindowExists is returned if a maintenance window already exists with the same ID.
+	Create(w MaintenanceWindow) error
+
+	// Delete a maintenance window.
+	// It is not an error to delete an non-existent maintenance window.
+	Delete(id string) error
+
+	// List maintenance windows matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]MaintenanceWindow, error)
+
+	Rebuild() error
+}
+
+const maintenanceWindowVersion = 1
+
+var validMaintenanceWindowID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
+
+type MaintenanceWindow struct {
+	ID       string            `json:"id"`
+	Topic    string            `json:"topic"`
+	Event    string            `json:"event"`
+	Tags     map[string]string `json:"tags"`
+	Cron     string            `json:"cron"`
+	Duration time.Duration     `json:"duration"`
+	Timezone string            `json:"timezone"`
+	Start    time.Time         `json:"start"`
+	Stop     time.Time         `json:"stop"`
+	Comment  string            `json:"comment"`
+}
+
+func (w MaintenanceWindow) ObjectID() string {
+	return w.ID
+}
+
+func (w MaintenanceWindow) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(maintenanceWindowVersion, w)
+}
+
+func (w *MaintenanceWindow) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		return dec.Decode(&w)
+	})
+}
+
+// Key/Value store based implementation of the MaintenanceWindowDAO
+type maintenanceWindowKV struct {
+	store *storage.IndexedStore
+}
+
+func newMaintenanceWindowKV(store storage.Interface) (*maintenanceWindowKV, error) {
+	c := storage.DefaultIndexedStoreConfig("maintenance-windows", func() storage.BinaryObject {
+		return new(MaintenanceWindow)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &maintenanceWindowKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *maintenanceWindowKV) error(err error) error {
+	if err == storage.ErrObjectExists {
+		return ErrMaintenanceWindowExists
+	} else if err == storage.ErrNoObjectExists {
+		return ErrNoMaintenanceWindowExists
+	}
+	return err
+}
+
+func (kv *maintenanceWindowKV) Get(id string) (MaintenanceWindow, error) {
+	o, err := kv.store.Get(id)
+	if err != nil {
+		return MaintenanceWindow{}, kv.error(err)
+	}
+	w, ok := o.(*MaintenanceWindow)
+	if !ok {
+		return MaintenanceWindow{}, storage.ImpossibleTypeErr(w, o)
+	}
+	return *w, nil
+}
+
+func (kv *maintenanceWindowKV) Create(w MaintenanceWindow) error {
+	return kv.error(kv.store.Create(&w))
+}
+
+func (kv *maintenanceWindowKV) Delete(id string) error {
+	return kv.store.Delete(id)
+}
+
+func (kv *maintenanceWindowKV) List(pattern string, offset, limit int) ([]MaintenanceWindow, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	windows := make([]MaintenanceWindow, len(objects))
+	for i, o := range objects {
+		w, ok := o.(*MaintenanceWindow)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(w, o)
+		}
+		windows[i] = *w
+	}
+	return windows, nil
+}
+
+func (kv *maintenanceWindowKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+var (
+	ErrNoOnCallScheduleExists = errors.New("no on-call schedule exists")
+)
+
+// Data access object for OnCallSchedule data.
+type OnCallScheduleDAO interface {
+	// Retrieve an on-call schedule
+	Get(id string) (OnCallSchedule, error)
+
+	// Put creates or replaces an on-call schedule.
+	Put(s OnCallSchedule) error
+
+	// Delete an on-call schedule.
+	// It is not an error to delete an non-existent on-call schedule.
+	Delete(id string) error
+
+	// List on-call schedules matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]OnCallSchedule, error)
+
+	Rebuild() error
+}
+
+const onCallScheduleVersion = 1
+
+var validOnCallScheduleID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
+
+type OnCallSchedule struct {
+	ID        string           `json:"id"`
+	Members   []OnCallMember   `json:"members"`
+	Rotations []Rotation       `json:"rotations"`
+	Overrides []OnCallOverride `json:"overrides"`
+	Timezone  string           `json:"timezone"`
+}
+
+type OnCallMember struct {
+	Name     string            `json:"name"`
+	Contacts map[string]string `json:"contacts"`
+}
+
+type Rotation struct {
+	Members []string      `json:"members"`
+	Handoff time.Time     `json:"handoff"`
+	Shift   time.Duration `json:"shift"`
+	Start   time.Duration `json:"start"`
+	Stop    time.Duration `json:"stop"`
+}
+
+type OnCallOverride struct {
+	Member string    `json:"member"`
+	Start  time.Time `json:"start"`
+	Stop   time.Time `json:"stop"`
+}
+
+func (s OnCallSchedule) ObjectID() string {
+	return s.ID
+}
+
+func (s OnCallSchedule) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(onCallScheduleVersion, s)
+}
+
+func (s *OnCallSchedule) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		return dec.Decode(&s)
+	})
+}
+
+// Key/Value store based implementation of the OnCallScheduleDAO
+type onCallScheduleKV struct {
+	store *storage.IndexedStore
+}
+
+func newOnCallScheduleKV(store storage.Interface) (*onCallScheduleKV, error) {
+	c := storage.DefaultIndexedStoreConfig("oncall-schedules", func() storage.BinaryObject {
+		return new(OnCallSchedule)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &onCallScheduleKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *onCallScheduleKV) error(err error) error {
+	if err == storage.ErrNoObjectExists {
+		return ErrNoOnCallScheduleExists
+	}
+	return err
+}
+
+func (kv *onCallScheduleKV) Get(id string) (OnCallSchedule, error) {
+	o, err := kv.store.Get(id)
+	if err != nil {
+		return OnCallSchedule{}, kv.error(err)
+	}
+	s, ok := o.(*OnCallSchedule)
+	if !ok {
+		return OnCallSchedule{}, storage.ImpossibleTypeErr(s, o)
+	}
+	return *s, nil
+}
+
+func (kv *onCallScheduleKV) Put(s OnCallSchedule) error {
+	return kv.store.Put(&s)
+}
+
+func (kv *onCallScheduleKV) Delete(id string) error {
+	return kv.store.Delete(id)
+}
+
+func (kv *onCallScheduleKV) List(pattern string, offset, limit int) ([]OnCallSchedule, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	schedules := make([]OnCallSchedule, len(objects))
+	for i, o := range objects {
+		s, ok := o.(*OnCallSchedule)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(s, o)
+		}
+		schedules[i] = *s
+	}
+	return schedules, nil
+}
+
+func (kv *onCallScheduleKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+var (
+	ErrNoOutboxEntryExists = errors.New("no outbox entry exists")
+)
+
+// Data access object for the outboxes of handlers.
+type OutboxDAO interface {
+	// Retrieve an outbox entry
+	Get(topic, handler, id string) (OutboxEntry, error)
+
+	// Put creates or replaces an outbox entry.
+	Put(e OutboxEntry) error
+
+	// Delete an outbox entry.
+	// It is not an error to delete an non-existent outbox entry.
+	Delete(topic, handler, id string) error
+
+	// List the entries of the outbox of a handler in the order they were queued.
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(topic, handler string, offset, limit int) ([]OutboxEntry, error)
+
+	// Pending lists the entries of the outbox of a handler that are not dead letters,
+	// in the order they were queued, which is the order they are delivered.
+	// Offset and limit are pagination bounds, as for List.
+	Pending(topic, handler string, offset, limit int) ([]OutboxEntry, error)
+
+	Rebuild() error
+}
+
+const outboxEntryVersion = 1
+
+// OutboxEntry is an event whose delivery by a handler failed and is being retried.
+type OutboxEntry struct {
+	Topic   string `json:"topic"`
+	Handler string `json:"handler"`
+	// ID orders the entries of a handler by the time they were queued.
+	ID      string     `json:"id"`
+	EventID string     `json:"event-id"`
+	Event   EventState `json:"event"`
+	Data    EventData  `json:"data"`
+	// Queued is the time of the first failed attempt, or of the last replay.
+	Queued    time.Time `json:"queued"`
+	Attempts  int       `json:"attempts"`
+	Next      time.Time `json:"next"`
+	LastError string    `json:"last-error"`
+	// Dead is set once the entry is no longer retried.
+	Dead bool `json:"dead"`
+}
+
+// EventData is the data of an event, the result error is not kept.
+type EventData struct {
+	Name     string                 `json:"name"`
+	TaskName string                 `json:"task-name"`
+	Group    string                 `json:"group"`
+	Tags     map[string]string      `json:"tags"`
+	Fields   map[string]interface{} `json:"fields"`
+	Series   models.Rows            `json:"series"`
+}
+
+func outboxEntryID(topic, handler, id string) string {
+	return path.Join(topic, handler, id)
+}
+
+func (e OutboxEntry) ObjectID() string {
+	return outboxEntryID(e.Topic, e.Handler, e.ID)
+}
+
+func (e OutboxEntry) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(outboxEntryVersion, e)
+}
+
+func (e *OutboxEntry) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		return dec.Decode(&e)
+	})
+}
+
+const (
+	// outboxStateIndex indexes entries by whether they are pending or dead letters.
+	outboxStateIndex = "state"
+
+	outboxPending = "pending"
+	outboxDead    = "dead"
+)
+
+// Key/Value store based implementation of the OutboxDAO
+type outboxKV struct {
+	store *storage.IndexedStore
+}
+
+func newOutboxKV(store storage.Interface) (*outboxKV, error) {
+	c := storage.DefaultIndexedStoreConfig("outbox", func() storage.BinaryObject {
+		return new(OutboxEntry)
+	})
+	c.Indexes = append(c.Indexes, storage.Index{
+		Name: outboxStateIndex,
+		ValueFunc: func(o storage.BinaryObject) (string, error) {
+			e, ok := o.(*OutboxEntry)
+			if !ok {
+				return "", storage.ImpossibleTypeErr(e, o)
+			}
+			if e.Dead {
+				return outboxDead, nil
+			}
+			return outboxPending, nil
+		},
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &outboxKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *outboxKV) error(err error) error {
+	if err == storage.ErrNoObjectExists {
+		return ErrNoOutboxEntryExists
+	}
+	return err
+}
+
+func (kv *outboxKV) Get(topic, handler, id string) (OutboxEntry, error) {
+	o, err := kv.store.Get(outboxEntryID(topic, handler, id))
+	if err != nil {
+		return OutboxEntry{}, kv.error(err)
+	}
+	e, ok := o.(*OutboxEntry)
+	if !ok {
+		return OutboxEntry{}, storage.ImpossibleTypeErr(e, o)
+	}
+	return *e, nil
+}
+
+func (kv *outboxKV) Put(e OutboxEntry) error {
+	return kv.store.Put(&e)
+}
+
+func (kv *outboxKV) Delete(topic, handler, id string) error {
+	return kv.store.Delete(outboxEntryID(topic, handler, id))
+}
+
+func (kv *outboxKV) List(topic, handler string, offset, limit int) ([]OutboxEntry, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, path.Join(topic, handler, "*"), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return kv.entries(objects)
+}
+
+func (kv *outboxKV) Pending(topic, handler string, offset, limit int) ([]OutboxEntry, error) {
+	// The index values are the state followed by the ID of the entry.
+	objects, err := kv.store.ListPrefix(outboxStateIndex, path.Join(outboxPending, topic, handler)+"/", offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return kv.entries(objects)
+}
+
+func (kv *outboxKV) entries(objects []storage.BinaryObject) ([]OutboxEntry, error) {
+	entries := make([]OutboxEntry, len(objects))
+	for i, o := range objects {
+		e, ok := o.(*OutboxEntry)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(e, o)
+		}
+		entries[i] = *e
+	}
+	return entries, nil
+}
+
+func (kv *outboxKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
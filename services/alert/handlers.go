@@ -2,12 +2,17 @@ package alert
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	text "text/template"
@@ -16,8 +21,11 @@ import (
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/bufpool"
 	"github.com/influxdata/kapacitor/command"
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/server/vars"
 	"github.com/influxdata/kapacitor/tick/ast"
 	"github.com/influxdata/kapacitor/tick/stateful"
+	"github.com/influxdata/kapacitor/tlsconfig"
 	"github.com/pkg/errors"
 )
 
@@ -172,6 +180,331 @@ func (h *tcpHandler) Handle(event alert.Event) {
 	conn.Write(buf.Bytes())
 }
 
+const (
+	// DefaultSyslogNetwork is the default transport for the syslog handler.
+	DefaultSyslogNetwork = "udp"
+	// DefaultSyslogFacility is the default syslog facility.
+	DefaultSyslogFacility = "user"
+	// DefaultSyslogAppName is the default APP-NAME of syslog messages.
+	DefaultSyslogAppName = "kapacitor"
+	// DefaultSyslogStructuredDataID is the default SD-ID of the element carrying the alert tags.
+	// It uses the example enterprise number from RFC 5612, replace it with your own.
+	DefaultSyslogStructuredDataID = "kapacitor@32473"
+
+	// syslogTimestampLayout is the RFC 5424 TIMESTAMP format, it allows at most microsecond precision.
+	syslogTimestampLayout = "2006-01-02T15:04:05.999999Z07:00"
+	// syslogNilValue is the RFC 5424 NILVALUE.
+	syslogNilValue = "-"
+
+	syslogMaxAppNameLen  = 48
+	syslogMaxHostnameLen = 255
+	syslogMaxSDNameLen   = 32
+)
+
+// syslogFacilities maps the facility names to their numerical codes as defined in RFC 5424.
+var syslogFacilities = map[string]int{
+	"kern":         0,
+	"user":         1,
+	"mail":         2,
+	"daemon":       3,
+	"auth":         4,
+	"syslog":       5,
+	"lpr":          6,
+	"news":         7,
+	"uucp":         8,
+	"cron":         9,
+	"authpriv":     10,
+	"ftp":          11,
+	"ntp":          12,
+	"security":     13,
+	"console":      14,
+	"solaris-cron": 15,
+	"local0":       16,
+	"local1":       17,
+	"local2":       18,
+	"local3":       19,
+	"local4":       20,
+	"local5":       21,
+	"local6":       22,
+	"local7":       23,
+}
+
+// Syslog severities as defined in RFC 5424.
+const (
+	syslogSeverityCritical = 2
+	syslogSeverityWarning  = 4
+	syslogSeverityNotice   = 5
+	syslogSeverityInfo     = 6
+)
+
+// syslogSeverity maps an alert level to a syslog severity.
+func syslogSeverity(l alert.Level) int {
+	switch l {
+	case alert.Critical:
+		return syslogSeverityCritical
+	case alert.Warning:
+		return syslogSeverityWarning
+	case alert.OK:
+		return syslogSeverityNotice
+	default:
+		return syslogSeverityInfo
+	}
+}
+
+type SyslogHandlerConfig struct {
+	// Network is the transport used to send the messages, one of udp, tcp or tls.
+	Network string `mapstructure:"network"`
+	// Address is the host:port of the syslog receiver.
+	Address string `mapstructure:"address"`
+	// Facility is the name of the syslog facility, i.e. user, daemon or local0-local7.
+	Facility string `mapstructure:"facility"`
+	// AppName is the APP-NAME of the messages.
+	AppName string `mapstructure:"app-name"`
+	// Hostname is the HOSTNAME of the messages.
+	// If empty the hostname of the machine is used.
+	Hostname string `mapstructure:"hostname"`
+	// StructuredDataID is the SD-ID of the structured data element that contains the alert tags.
+	StructuredDataID string `mapstructure:"structured-data-id"`
+
+	// Path to CA file, used by the tls network.
+	SSLCA string `mapstructure:"ssl-ca"`
+	// Path to host cert file, used by the tls network.
+	SSLCert string `mapstructure:"ssl-cert"`
+	// Path to cert key file, used by the tls network.
+	SSLKey string `mapstructure:"ssl-key"`
+	// Use SSL but skip chain & host verification, used by the tls network.
+	InsecureSkipVerify bool `mapstructure:"insecure-skip-verify"`
+}
+
+func DefaultSyslogHandlerConfig() SyslogHandlerConfig {
+	return SyslogHandlerConfig{
+		Network:          DefaultSyslogNetwork,
+		Facility:         DefaultSyslogFacility,
+		AppName:          DefaultSyslogAppName,
+		StructuredDataID: DefaultSyslogStructuredDataID,
+	}
+}
+
+func (c SyslogHandlerConfig) Validate() error {
+	switch c.Network {
+	case "udp", "tcp", "tls":
+	default:
+		return fmt.Errorf("invalid syslog network %q, must be one of udp, tcp or tls", c.Network)
+	}
+	if c.Address == "" {
+		return errors.New("syslog address must not be empty")
+	}
+	if _, ok := syslogFacilities[c.Facility]; !ok {
+		return fmt.Errorf("unknown syslog facility %q", c.Facility)
+	}
+	if !isSyslogPrintable(c.AppName) || len(c.AppName) > syslogMaxAppNameLen {
+		return fmt.Errorf("invalid syslog app-name %q, must be 1 to %d printable ASCII characters", c.AppName, syslogMaxAppNameLen)
+	}
+	if c.Hostname != "" && (!isSyslogPrintable(c.Hostname) || len(c.Hostname) > syslogMaxHostnameLen) {
+		return fmt.Errorf("invalid syslog hostname %q, must be 1 to %d printable ASCII characters", c.Hostname, syslogMaxHostnameLen)
+	}
+	if !isSyslogSDName(c.StructuredDataID) {
+		return fmt.Errorf("invalid syslog structured-data-id %q", c.StructuredDataID)
+	}
+	return nil
+}
+
+// isSyslogPrintable reports whether s is a non empty string of the RFC 5424 PRINTUSASCII characters.
+func isSyslogPrintable(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// isSyslogSDName reports whether s is a valid RFC 5424 SD-NAME.
+func isSyslogSDName(s string) bool {
+	if !isSyslogPrintable(s) || len(s) > syslogMaxSDNameLen {
+		return false
+	}
+	return !strings.ContainsAny(s, `= ]"`)
+}
+
+// syslogSDName replaces the characters of s that are not allowed in an SD-NAME
+// and truncates it to the maximum length.
+func syslogSDName(s string) string {
+	name := []byte(s)
+	for i, c := range name {
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			name[i] = '_'
+		}
+	}
+	if len(name) > syslogMaxSDNameLen {
+		name = name[:syslogMaxSDNameLen]
+	}
+	return string(name)
+}
+
+// syslogParamValueReplacer escapes the characters that are not allowed unescaped in a PARAM-VALUE.
+var syslogParamValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+type syslogHandler struct {
+	bp        *bufpool.Pool
+	network   string
+	addr      string
+	facility  int
+	appName   string
+	hostname  string
+	sdID      string
+	tlsConfig *tls.Config
+	logger    *log.Logger
+
+	mu sync.Mutex
+	// conn is the connection reused for all messages, it is dialed on demand.
+	conn net.Conn
+}
+
+func NewSyslogHandler(c SyslogHandlerConfig, l *log.Logger) (alert.Handler, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	hostname := c.Hostname
+	if hostname == "" {
+		if h, err := os.Hostname(); err == nil && isSyslogPrintable(h) && len(h) <= syslogMaxHostnameLen {
+			hostname = h
+		} else {
+			hostname = syslogNilValue
+		}
+	}
+	h := &syslogHandler{
+		bp:       bufpool.New(),
+		network:  c.Network,
+		addr:     c.Address,
+		facility: syslogFacilities[c.Facility],
+		appName:  c.AppName,
+		hostname: hostname,
+		sdID:     c.StructuredDataID,
+		logger:   l,
+	}
+	if c.Network == "tls" {
+		tlsConfig, err := tlsconfig.Create(c.SSLCA, c.SSLCert, c.SSLKey, c.InsecureSkipVerify)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid syslog TLS configuration")
+		}
+		h.tlsConfig = tlsConfig
+	}
+	return h, nil
+}
+
+func (h *syslogHandler) Handle(event alert.Event) {
+	if err := h.TryHandle(event); err != nil {
+		h.logger.Printf("E! syslog handler: %v", err)
+	}
+}
+
+func (h *syslogHandler) TryHandle(event alert.Event) error {
+	buf := h.bp.Get()
+	defer h.bp.Put(buf)
+	h.writeMessage(buf, event)
+
+	msg := buf.Bytes()
+	if h.network != "udp" {
+		// Stream transports use octet counting framing, see RFC 6587.
+		// The frame is written at once so a failed write never leaves half a frame on a reused connection.
+		frame := h.bp.Get()
+		defer h.bp.Put(frame)
+		fmt.Fprintf(frame, "%d ", len(msg))
+		frame.Write(msg)
+		msg = frame.Bytes()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// The connection may have been closed by the receiver since the last message,
+	// so a failed write on a reused connection is retried once on a new connection.
+	reused := h.conn != nil
+	for {
+		if h.conn == nil {
+			conn, err := h.dial()
+			if err != nil {
+				return errors.Wrapf(err, "failed to connect to %s", h.addr)
+			}
+			h.conn = conn
+		}
+		_, err := h.conn.Write(msg)
+		if err == nil {
+			return nil
+		}
+		h.conn.Close()
+		h.conn = nil
+		if !reused {
+			return errors.Wrapf(err, "failed to send message to %s", h.addr)
+		}
+		reused = false
+	}
+}
+
+func (h *syslogHandler) dial() (net.Conn, error) {
+	if h.network == "tls" {
+		return tls.Dial("tcp", h.addr, h.tlsConfig)
+	}
+	return net.Dial(h.network, h.addr)
+}
+
+// Close closes the connection to the syslog receiver.
+func (h *syslogHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn = nil
+	}
+}
+
+// writeMessage writes the RFC 5424 message for the event into buf.
+// The message is of the form `<PRI>1 TIMESTAMP HOSTNAME APP-NAME - - [SD-ID tag="value"...] MSG`.
+// The tags of the event are sent as the parameters of a single structured data element.
+func (h *syslogHandler) writeMessage(buf *bytes.Buffer, event alert.Event) {
+	pri := h.facility*8 + syslogSeverity(event.State.Level)
+	fmt.Fprintf(buf, "<%d>1 ", pri)
+	if event.State.Time.IsZero() {
+		buf.WriteString(syslogNilValue)
+	} else {
+		buf.WriteString(event.State.Time.Format(syslogTimestampLayout))
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(h.hostname)
+	buf.WriteByte(' ')
+	buf.WriteString(h.appName)
+	// PROCID and MSGID are not used.
+	buf.WriteString(" - - ")
+
+	if len(event.Data.Tags) == 0 {
+		buf.WriteString(syslogNilValue)
+	} else {
+		keys := make([]string, 0, len(event.Data.Tags))
+		for k := range event.Data.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('[')
+		buf.WriteString(h.sdID)
+		for _, k := range keys {
+			buf.WriteByte(' ')
+			buf.WriteString(syslogSDName(k))
+			buf.WriteString(`="`)
+			syslogParamValueReplacer.WriteString(buf, event.Data.Tags[k])
+			buf.WriteByte('"')
+		}
+		buf.WriteByte(']')
+	}
+
+	if event.State.Message != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(event.State.Message)
+	}
+}
+
 type AggregateHandlerConfig struct {
 	ID       string        `mapstructure:"id"`
 	Interval time.Duration `mapstructure:"interval"`
@@ -305,144 +638,1571 @@ func (h *aggregateHandler) Close() {
 	h.wg.Wait()
 }
 
-type PublishHandlerConfig struct {
-	Topics []string `mapstructure:"topics"`
-	ec     EventCollector
+const (
+	// ThrottleByTopic shares a single token bucket for all events.
+	ThrottleByTopic = "topic"
+	// ThrottleByEvent uses a token bucket per event ID.
+	ThrottleByEvent = "event"
+	// ThrottleByTag uses a token bucket per value of a tag.
+	ThrottleByTag = "tag"
+)
+
+type ThrottleHandlerConfig struct {
+	// ID of the digest event.
+	// Defaults to <topic>-throttle-digest, where topic is the topic of the handler.
+	ID string `mapstructure:"id"`
+	// Topic to which events that are not throttled are published.
+	// Events that change level are never throttled.
+	Topic string `mapstructure:"topic"`
+	// DigestTopic to which the digest of dropped events is published.
+	// Defaults to Topic.
+	DigestTopic string `mapstructure:"digest-topic"`
+	// Rate is the number of events allowed per Interval.
+	Rate int `mapstructure:"rate"`
+	// Burst is the size of the token bucket.
+	// Defaults to Rate.
+	Burst    int           `mapstructure:"burst"`
+	Interval time.Duration `mapstructure:"interval"`
+	// DigestInterval is how often a digest of the dropped events is published.
+	// Defaults to Interval.
+	DigestInterval time.Duration `mapstructure:"digest-interval"`
+	// By is one of topic, event or tag.
+	By string `mapstructure:"by"`
+	// Tag is the tag key used when throttling by tag.
+	Tag     string `mapstructure:"tag"`
+	Message string `mapstructure:"message"`
+	// Samples is the maximum number of dropped events whose messages and data are included in the digest.
+	// Defaults to 10.
+	Samples int `mapstructure:"samples"`
+	ec      EventCollector
 }
-type publishHandler struct {
-	c      PublishHandlerConfig
-	logger *log.Logger
+
+type throttleMessageData struct {
+	Count    int
+	Interval time.Duration
+	// Dropped is the number of dropped events by throttle key.
+	Dropped map[string]int
 }
 
-func NewPublishHandler(c PublishHandlerConfig, l *log.Logger) alert.Handler {
-	return &publishHandler{
-		c:      c,
-		logger: l,
+func newDefaultThrottleHandlerConfig(topic string, ec EventCollector) ThrottleHandlerConfig {
+	return ThrottleHandlerConfig{
+		ID:      topic + "-throttle-digest",
+		By:      ThrottleByEvent,
+		Message: "Throttled {{ .Count }} events in the last {{ .Interval }}.",
+		Samples: 10,
+		ec:      ec,
 	}
 }
 
-func (h *publishHandler) Handle(event alert.Event) {
-	for _, t := range h.c.Topics {
-		event.Topic = t
-		h.c.ec.Collect(event)
+func (c ThrottleHandlerConfig) Validate() error {
+	if c.ID == "" {
+		return errors.New("must provide an id")
+	}
+	if c.Topic == "" {
+		return errors.New("must provide a topic")
+	}
+	if c.Rate <= 0 {
+		return fmt.Errorf("rate must be greater than zero, got %d", c.Rate)
+	}
+	if c.Burst < 0 {
+		return fmt.Errorf("burst must not be negative, got %d", c.Burst)
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than zero, got %v", c.Interval)
 	}
+	if c.DigestInterval < 0 {
+		return fmt.Errorf("digest-interval must not be negative, got %v", c.DigestInterval)
+	}
+	if c.Samples < 0 {
+		return fmt.Errorf("samples must not be negative, got %d", c.Samples)
+	}
+	switch c.By {
+	case ThrottleByTopic, ThrottleByEvent:
+	case ThrottleByTag:
+		if c.Tag == "" {
+			return errors.New("must provide a tag when throttling by tag")
+		}
+	default:
+		return fmt.Errorf("unknown throttle by %q, must be one of %q, %q or %q", c.By, ThrottleByTopic, ThrottleByEvent, ThrottleByTag)
+	}
+	return nil
 }
 
-// ExternalHandler wraps an existing handler that calls out to external services.
-// The events are checked for the NoExternal flag before being passed to the external handler.
-type externalHandler struct {
-	h alert.Handler
+// throttleSummary is a bounded summary of dropped events.
+type throttleSummary struct {
+	count int
+	// counts is the number of dropped events by throttle key.
+	counts   map[string]int
+	level    alert.Level
+	time     time.Time
+	duration time.Duration
+	external bool
+	// samples are the first dropped events, up to the configured number of samples.
+	samples []alert.Event
 }
 
-func newExternalHandler(h alert.Handler) *externalHandler {
-	return &externalHandler{
-		h: h,
-	}
+// tokenBucket holds the tokens available to a single throttle key.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
 }
 
-func (h *externalHandler) Handle(event alert.Event) {
-	if !event.NoExternal {
-		h.h.Handle(event)
-	}
-}
+type throttleHandler struct {
+	c ThrottleHandlerConfig
 
-type matchHandler struct {
-	h alert.Handler
+	messageTmpl *text.Template
 
-	scope *stateful.Scope
-	expr  stateful.Expression
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
 
-	// scope optimization
-	usesChanged,
-	usesLevel,
-	usesName,
-	usesTaskName,
-	usesDuration bool
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	// dropped summarizes the events dropped since the last digest.
+	dropped throttleSummary
 
-	vars []string
+	logger  *log.Logger
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
 
-	logger *log.Logger
+func NewThrottleHandler(c ThrottleHandlerConfig, l *log.Logger) (alert.Handler, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.Burst == 0 {
+		c.Burst = c.Rate
+	}
+	if c.DigestInterval == 0 {
+		c.DigestInterval = c.Interval
+	}
+	if c.DigestTopic == "" {
+		c.DigestTopic = c.Topic
+	}
+	// Parse and validate message template
+	tmpl, err := text.New("message").Parse(c.Message)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	md := throttleMessageData{}
+	err = tmpl.Execute(&buf, md)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to evaluate message template with throttle message data")
+	}
+
+	h := &throttleHandler{
+		c:           c,
+		messageTmpl: tmpl,
+		now:         time.Now,
+		buckets:     make(map[string]*tokenBucket),
+		logger:      l,
+		closing:     make(chan struct{}),
+	}
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		h.run()
+	}()
+	return h, nil
 }
 
-const (
-	changedFunc  = "changed"
-	levelFunc    = "level"
-	nameFunc     = "name"
-	taskNameFunc = "taskName"
-	durationFunc = "duration"
-)
+func (h *throttleHandler) key(event alert.Event) string {
+	switch h.c.By {
+	case ThrottleByEvent:
+		return event.State.ID
+	case ThrottleByTag:
+		return event.Data.Tags[h.c.Tag]
+	default:
+		return event.Topic
+	}
+}
 
-var matchIdentifiers = map[string]interface{}{
-	"OK":       int64(alert.OK),
-	"INFO":     int64(alert.Info),
-	"WARNING":  int64(alert.Warning),
-	"CRITICAL": int64(alert.Critical),
+// allow takes a token from the bucket of the key, reporting whether one was available.
+func (h *throttleHandler) allow(key string) bool {
+	now := h.now()
+	b := h.buckets[key]
+	if b == nil {
+		b = &tokenBucket{
+			tokens: float64(h.c.Burst),
+			last:   now,
+		}
+		h.buckets[key] = b
+	}
+	// Refill the bucket for the elapsed time
+	elapsed := now.Sub(b.last)
+	b.last = now
+	b.tokens += float64(h.c.Rate) * float64(elapsed) / float64(h.c.Interval)
+	if max := float64(h.c.Burst); b.tokens > max {
+		b.tokens = max
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
 }
 
-func newMatchHandler(match string, h alert.Handler, l *log.Logger) (*matchHandler, error) {
-	lambda, err := ast.ParseLambda(match)
-	if err != nil {
-		return nil, errors.Wrap(err, "invalid match expression")
+func (h *throttleHandler) Handle(event alert.Event) {
+	// Level changes, including recoveries, are never throttled,
+	// otherwise the topic could be left at the level of a dropped event.
+	allowed := event.State.Level != event.PreviousState().Level
+	if !allowed {
+		h.mu.Lock()
+		allowed = h.allow(h.key(event))
+		if !allowed {
+			h.drop(event)
+		}
+		h.mu.Unlock()
 	}
 
-	// Replace identifiers with static values
-	_, err = ast.Walk(lambda, func(n ast.Node) (ast.Node, error) {
-		if ident, ok := n.(*ast.IdentifierNode); ok {
-			v, ok := matchIdentifiers[ident.Ident]
-			if !ok {
-				return nil, fmt.Errorf("unknown identifier %q", ident.Ident)
-			}
-			return ast.ValueToLiteralNode(n, v)
+	if allowed {
+		event.Topic = h.c.Topic
+		if err := h.c.ec.Collect(event); err != nil {
+			h.logger.Println("E! failed to publish throttled event:", err)
 		}
-		return n, nil
-	})
-	if err != nil {
-		return nil, err
 	}
+}
 
-	expr, err := stateful.NewExpression(lambda.Expression)
-	if err != nil {
-		return nil, errors.Wrap(err, "invalid match expression")
+// drop adds the event to the summary of dropped events.
+// Must be called with the lock held.
+func (h *throttleHandler) drop(event alert.Event) {
+	d := &h.dropped
+	if d.counts == nil {
+		d.counts = make(map[string]int)
+	}
+	d.count++
+	d.counts[h.key(event)]++
+	if event.State.Level > d.level {
+		d.level = event.State.Level
+	}
+	if event.State.Time.After(d.time) {
+		d.time = event.State.Time
 	}
+	if event.State.Duration > d.duration {
+		d.duration = event.State.Duration
+	}
+	d.external = d.external || !event.NoExternal
+	if len(d.samples) < h.c.Samples {
+		d.samples = append(d.samples, event)
+	}
+}
 
-	mh := &matchHandler{
-		h:      h,
-		expr:   expr,
-		scope:  stateful.NewScope(),
-		vars:   ast.FindReferenceVariables(lambda),
-		logger: l,
+func (h *throttleHandler) run() {
+	ticker := time.NewTicker(h.c.DigestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.closing:
+			return
+		case <-ticker.C:
+			h.digest()
+		}
 	}
+}
 
-	// Determine which functions are called
-	funcs := ast.FindFunctionCalls(lambda)
-	for _, f := range funcs {
-		switch f {
-		case changedFunc:
-			mh.usesChanged = true
-		case levelFunc:
-			mh.usesLevel = true
-		case nameFunc:
-			mh.usesName = true
-		case taskNameFunc:
-			mh.usesTaskName = true
-		case durationFunc:
-			mh.usesDuration = true
-		default:
-			// ignore the function
+// digest publishes a summary of the dropped events, if any.
+func (h *throttleHandler) digest() {
+	h.mu.Lock()
+	dropped := h.dropped
+	h.dropped = throttleSummary{}
+	// Forget idle buckets, which have been full for a whole digest interval.
+	now := h.now()
+	for key, b := range h.buckets {
+		if now.Sub(b.last) > h.c.DigestInterval && now.Sub(b.last) > h.c.Interval {
+			delete(h.buckets, key)
 		}
 	}
+	h.mu.Unlock()
 
-	return mh, nil
+	if dropped.count == 0 {
+		return
+	}
+
+	md := throttleMessageData{
+		Count:    dropped.count,
+		Interval: h.c.DigestInterval,
+		Dropped:  dropped.counts,
+	}
+	digest := alert.Event{
+		Topic: h.c.DigestTopic,
+		State: alert.EventState{
+			ID:       h.c.ID,
+			Level:    dropped.level,
+			Time:     dropped.time,
+			Duration: dropped.duration,
+		},
+		NoExternal: !dropped.external,
+	}
+	details := make([]string, len(dropped.samples))
+	for i, e := range dropped.samples {
+		details[i] = e.State.Message
+		digest.Data.Result.Series = append(digest.Data.Result.Series, e.Data.Result.Series...)
+	}
+	var messageBuf bytes.Buffer
+	// Ignore error since we have validated the template already
+	_ = h.messageTmpl.Execute(&messageBuf, md)
+	digest.State.Message = messageBuf.String()
+	digest.State.Details = strings.Join(details, "\n")
+	if err := h.c.ec.Collect(digest); err != nil {
+		h.logger.Println("E! failed to publish throttle digest:", err)
+	}
 }
 
-func (h *matchHandler) Handle(event alert.Event) {
-	if ok, err := h.match(event); err != nil {
+func (h *throttleHandler) Close() {
+	close(h.closing)
+	h.wg.Wait()
+}
+
+type CorrelateHandlerConfig struct {
+	// ID is a template for the ID of the parent event of each group.
+	ID string `mapstructure:"id"`
+	// Topic to which the parent events are published.
+	Topic string `mapstructure:"topic"`
+	// By is the list of tag keys whose values group events together.
+	By []string `mapstructure:"by"`
+	// Patterns is a list of event ID patterns, events are grouped by the first pattern they match.
+	// Events that match none of the patterns are published to Topic unchanged.
+	Patterns []string `mapstructure:"patterns"`
+	// Window is how long members are gathered before the parent event of a new group is published.
+	Window  time.Duration `mapstructure:"window"`
+	Message string        `mapstructure:"message"`
+	ec      EventCollector
+}
+
+type correlateTemplateData struct {
+	// Group is the key of the group of the form [pattern,][key=value,]*.
+	// If events are not grouped it is equal to literal 'nil'.
+	Group   string
+	Pattern string
+	Tags    map[string]string
+	Count   int
+	Level   alert.Level
+	// Members are the sorted IDs of the events in the group.
+	Members []string
+}
+
+func newDefaultCorrelateHandlerConfig(ec EventCollector) CorrelateHandlerConfig {
+	return CorrelateHandlerConfig{
+		ID:      "{{ .Group }}",
+		Message: "{{ .Count }} correlated events for {{ .Group }}, combined level is {{ .Level }}.",
+		ec:      ec,
+	}
+}
+
+func (c CorrelateHandlerConfig) Validate() error {
+	if c.Topic == "" {
+		return errors.New("must provide a topic")
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("window must be greater than zero, got %v", c.Window)
+	}
+	for _, key := range c.By {
+		if key == "" {
+			return errors.New("by tag keys must not be empty")
+		}
+	}
+	for _, pattern := range c.Patterns {
+		if pattern == "" {
+			return errors.New("patterns must not be empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "invalid pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// correlationGroup is the state of a group of correlated events.
+type correlationGroup struct {
+	key     string
+	pattern string
+	tags    map[string]string
+	// members by event ID, recovered members are kept until the whole group recovers.
+	members map[string]alert.Event
+	timer   *time.Timer
+	// published reports whether the window has passed and parent events are published.
+	published bool
+	// level of the last published parent event.
+	level alert.Level
+}
+
+type correlateHandler struct {
+	c CorrelateHandlerConfig
+
+	idTmpl      *text.Template
+	messageTmpl *text.Template
+
+	mu     sync.Mutex
+	groups map[string]*correlationGroup
+	closed bool
+
+	logger *log.Logger
+}
+
+func NewCorrelateHandler(c CorrelateHandlerConfig, l *log.Logger) (alert.Handler, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	// Parse and validate the ID and message templates
+	idTmpl, err := text.New("id").Parse(c.ID)
+	if err != nil {
+		return nil, err
+	}
+	messageTmpl, err := text.New("message").Parse(c.Message)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	td := correlateTemplateData{}
+	if err := idTmpl.Execute(&buf, td); err != nil {
+		return nil, errors.Wrap(err, "failed to evaluate ID template with correlate template data")
+	}
+	if err := messageTmpl.Execute(&buf, td); err != nil {
+		return nil, errors.Wrap(err, "failed to evaluate message template with correlate template data")
+	}
+	return &correlateHandler{
+		c:           c,
+		idTmpl:      idTmpl,
+		messageTmpl: messageTmpl,
+		groups:      make(map[string]*correlationGroup),
+		logger:      l,
+	}, nil
+}
+
+// match returns the first pattern the event ID matches.
+func (h *correlateHandler) match(id string) (string, bool) {
+	if len(h.c.Patterns) == 0 {
+		return "", true
+	}
+	for _, pattern := range h.c.Patterns {
+		if alert.PatternMatch(pattern, id) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+func (h *correlateHandler) Handle(event alert.Event) {
+	pattern, ok := h.match(event.State.ID)
+	if !ok {
+		event.Topic = h.c.Topic
+		if err := h.c.ec.Collect(event); err != nil {
+			h.logger.Println("E! failed to publish uncorrelated event:", err)
+		}
+		return
+	}
+
+	tags := make(map[string]string, len(h.c.By))
+	parts := make([]string, 0, len(h.c.By)+1)
+	if pattern != "" {
+		parts = append(parts, pattern)
+	}
+	for _, k := range h.c.By {
+		v := event.Data.Tags[k]
+		tags[k] = v
+		parts = append(parts, k+"="+v)
+	}
+	key := strings.Join(parts, ",")
+	if key == "" {
+		key = "nil"
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	g := h.groups[key]
+	if g == nil {
+		if event.State.Level == alert.OK {
+			// There is nothing to recover
+			h.mu.Unlock()
+			return
+		}
+		g = &correlationGroup{
+			key:     key,
+			pattern: pattern,
+			tags:    tags,
+			members: make(map[string]alert.Event),
+		}
+		g.timer = time.AfterFunc(h.c.Window, func() { h.publish(g) })
+		h.groups[key] = g
+	}
+	g.members[event.State.ID] = event
+	var parent alert.Event
+	changed := false
+	if g.published {
+		parent, changed = h.update(g)
+	}
+	h.mu.Unlock()
+
+	if changed {
+		h.collect(parent)
+	}
+}
+
+// publish publishes the first parent event of a group once its window has passed.
+func (h *correlateHandler) publish(g *correlationGroup) {
+	h.mu.Lock()
+	if h.closed || h.groups[g.key] != g {
+		h.mu.Unlock()
+		return
+	}
+	g.published = true
+	g.timer = nil
+	parent, changed := h.update(g)
+	if !changed {
+		// All members recovered within the window.
+		delete(h.groups, g.key)
+	}
+	h.mu.Unlock()
+
+	if changed {
+		h.collect(parent)
+	}
+}
+
+// update returns the parent event of the group if its combined level has changed.
+// Groups are forgotten once all of their members have recovered.
+// Must be called with the lock held.
+func (h *correlateHandler) update(g *correlationGroup) (alert.Event, bool) {
+	level := alert.OK
+	for _, e := range g.members {
+		if e.State.Level > level {
+			level = e.State.Level
+		}
+	}
+	if level == g.level {
+		return alert.Event{}, false
+	}
+	g.level = level
+	if level == alert.OK {
+		delete(h.groups, g.key)
+	}
+	return h.parent(g), true
+}
+
+// parent returns the parent event of the group at its current level.
+// Must be called with the lock held.
+func (h *correlateHandler) parent(g *correlationGroup) alert.Event {
+	td := correlateTemplateData{
+		Group:   g.key,
+		Pattern: g.pattern,
+		Tags:    g.tags,
+		Count:   len(g.members),
+		Level:   g.level,
+		Members: make([]string, 0, len(g.members)),
+	}
+	for id := range g.members {
+		td.Members = append(td.Members, id)
+	}
+	sort.Strings(td.Members)
+
+	parent := alert.Event{
+		Topic: h.c.Topic,
+		State: alert.EventState{
+			Level: g.level,
+		},
+		Data: alert.EventData{
+			Tags: g.tags,
+		},
+		NoExternal: true,
+	}
+	var start time.Time
+	details := make([]string, len(td.Members))
+	for i, id := range td.Members {
+		e := g.members[id]
+		parent.NoExternal = parent.NoExternal && e.NoExternal
+		if e.State.Time.After(parent.State.Time) {
+			parent.State.Time = e.State.Time
+		}
+		if first := e.State.Time.Add(-e.State.Duration); start.IsZero() || first.Before(start) {
+			start = first
+		}
+		details[i] = fmt.Sprintf("%s (%v): %s", id, e.State.Level, e.State.Message)
+		parent.Data.Result.Series = append(parent.Data.Result.Series, e.Data.Result.Series...)
+	}
+	parent.State.Duration = parent.State.Time.Sub(start)
+	parent.State.Details = strings.Join(details, "\n")
+
+	var buf bytes.Buffer
+	// Ignore errors since we have validated the templates already
+	_ = h.idTmpl.Execute(&buf, td)
+	parent.State.ID = buf.String()
+	buf.Reset()
+	_ = h.messageTmpl.Execute(&buf, td)
+	parent.State.Message = buf.String()
+	return parent
+}
+
+func (h *correlateHandler) collect(parent alert.Event) {
+	if err := h.c.ec.Collect(parent); err != nil {
+		h.logger.Println("E! failed to publish correlated event:", err)
+	}
+}
+
+func (h *correlateHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, g := range h.groups {
+		if g.timer != nil {
+			g.timer.Stop()
+		}
+	}
+}
+
+// EscalationStep publishes an event to a topic once the event has been unresolved for a duration.
+type EscalationStep struct {
+	// After is how long since the start of the escalation to wait before escalating to this step.
+	After time.Duration `mapstructure:"after"`
+	// Topic to which the event is published.
+	Topic string `mapstructure:"topic"`
+}
+
+type EscalateHandlerConfig struct {
+	// Level is the minimum level at which events are escalated.
+	// An event below the level has recovered, the recovery is published to each topic the event was escalated to.
+	// Defaults to CRITICAL.
+	Level alert.Level `mapstructure:"level"`
+	// Steps of the escalation, in order of their After durations.
+	Steps []EscalationStep `mapstructure:"steps"`
+
+	topic   string
+	handler string
+	ec      EventCollector
+	topics  Topics
+	dao     EscalationDAO
+}
+
+func newDefaultEscalateHandlerConfig(topic, handler string, ec EventCollector, topics Topics, dao EscalationDAO) EscalateHandlerConfig {
+	return EscalateHandlerConfig{
+		Level:   alert.Critical,
+		topic:   topic,
+		handler: handler,
+		ec:      ec,
+		topics:  topics,
+		dao:     dao,
+	}
+}
+
+func (c EscalateHandlerConfig) Validate() error {
+	if len(c.Steps) == 0 {
+		return errors.New("must provide at least one step")
+	}
+	if c.Level == alert.OK {
+		return errors.New("level must be more severe than OK")
+	}
+	for i, step := range c.Steps {
+		if step.Topic == "" {
+			return fmt.Errorf("step %d must provide a topic", i)
+		}
+		if step.Topic == c.topic {
+			return fmt.Errorf("step %d cannot escalate to its own topic %q", i, step.Topic)
+		}
+		if step.After < 0 {
+			return fmt.Errorf("step %d after must not be negative, got %v", i, step.After)
+		}
+		if i > 0 && step.After < c.Steps[i-1].After {
+			return fmt.Errorf("step %d after must not be less than the after of the previous step", i)
+		}
+	}
+	return nil
+}
+
+// pendingEscalation is an escalation waiting for its next step or for the event to recover.
+type pendingEscalation struct {
+	e Escalation
+	// event is the last event, it is not known for escalations restored from storage.
+	event *alert.Event
+	// timer is nil once there are no more steps.
+	timer *time.Timer
+}
+
+type escalateHandler struct {
+	c EscalateHandlerConfig
+
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*pendingEscalation
+	closed  bool
+
+	logger *log.Logger
+}
+
+func NewEscalateHandler(c EscalateHandlerConfig, l *log.Logger) (alert.Handler, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	h := &escalateHandler{
+		c:       c,
+		now:     time.Now,
+		pending: make(map[string]*pendingEscalation),
+		logger:  l,
+	}
+	// Restore pending escalations
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	offset := 0
+	limit := 100
+	for {
+		escalations, err := c.dao.List(c.topic, c.handler, offset, limit)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load pending escalations")
+		}
+		for _, e := range escalations {
+			p := &pendingEscalation{e: e}
+			if e.Step >= len(c.Steps) {
+				// All steps have been published, or the steps have changed since the escalation was saved.
+				if len(e.Published) == 0 {
+					if err := c.dao.Delete(e.Topic, e.Handler, e.EventID); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				h.complete(p)
+				h.pending[e.EventID] = p
+				continue
+			}
+			p.e.Next = e.Start.Add(c.Steps[e.Step].After)
+			p.e.NextTopic = c.Steps[e.Step].Topic
+			h.pending[e.EventID] = p
+			h.schedule(p)
+		}
+		offset += limit
+		if len(escalations) != limit {
+			break
+		}
+	}
+	return h, nil
+}
+
+// escalates reports whether the event state should be escalated.
+func (h *escalateHandler) escalates(state alert.EventState) bool {
+	return state.Level >= h.c.Level && !state.Ack.Acked()
+}
+
+func (h *escalateHandler) Handle(event alert.Event) {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+
+	id := event.State.ID
+	p := h.pending[id]
+	if !h.escalates(event.State) {
+		var recovered []alert.Event
+		if p != nil {
+			recovered = h.stop(p, event)
+		}
+		h.mu.Unlock()
+		h.publish(recovered)
+		return
+	}
+	defer h.mu.Unlock()
+	if p == nil {
+		start := h.now()
+		p = &pendingEscalation{
+			e: Escalation{
+				Topic:     h.c.topic,
+				Handler:   h.c.handler,
+				EventID:   id,
+				Start:     start,
+				Next:      start.Add(h.c.Steps[0].After),
+				NextTopic: h.c.Steps[0].Topic,
+			},
+		}
+		h.pending[id] = p
+		h.schedule(p)
+	}
+	p.event = &event
+	p.e.Event = convertEventStateFromAlert(event.State)
+	if err := h.c.dao.Put(p.e); err != nil {
+		h.logger.Printf("E! failed to save escalation of event %q: %v", id, err)
+	}
+}
+
+// schedule starts the timer for the next step of the escalation.
+// Caller must have the lock.
+func (h *escalateHandler) schedule(p *pendingEscalation) {
+	id := p.e.EventID
+	p.timer = time.AfterFunc(p.e.Next.Sub(h.now()), func() {
+		h.escalate(id)
+	})
+}
+
+// cancel stops the escalation and forgets it.
+// Caller must have the lock.
+func (h *escalateHandler) cancel(p *pendingEscalation) {
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	delete(h.pending, p.e.EventID)
+	if err := h.c.dao.Delete(p.e.Topic, p.e.Handler, p.e.EventID); err != nil {
+		h.logger.Printf("E! failed to delete escalation of event %q: %v", p.e.EventID, err)
+	}
+}
+
+// complete stops any further steps of the escalation,
+// it is kept so that the topics it was published to are sent the recovery.
+// Caller must have the lock.
+func (h *escalateHandler) complete(p *pendingEscalation) {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.e.Step = len(h.c.Steps)
+	p.e.Next = time.Time{}
+	p.e.NextTopic = ""
+}
+
+// stop ends the escalation of an event that no longer escalates.
+// The escalation of a recovered event is cancelled and the recovery is returned once for each topic the event was published to.
+// The escalation of an acknowledged event that was published is only completed, so its recovery is still sent.
+// Caller must have the lock.
+func (h *escalateHandler) stop(p *pendingEscalation, event alert.Event) []alert.Event {
+	if event.State.Level >= h.c.Level && len(p.e.Published) > 0 {
+		h.complete(p)
+		p.event = &event
+		p.e.Event = convertEventStateFromAlert(event.State)
+		if err := h.c.dao.Put(p.e); err != nil {
+			h.logger.Printf("E! failed to save escalation of event %q: %v", p.e.EventID, err)
+		}
+		return nil
+	}
+	recovered := make([]alert.Event, len(p.e.Published))
+	for i, topic := range p.e.Published {
+		recovered[i] = event
+		recovered[i].Topic = topic
+	}
+	h.cancel(p)
+	return recovered
+}
+
+// publish collects the events, it must be called without the lock since collecting takes the locks of the service.
+func (h *escalateHandler) publish(events []alert.Event) {
+	for _, event := range events {
+		if err := h.c.ec.Collect(event); err != nil {
+			h.logger.Printf("E! failed to publish event %q to topic %q: %v", event.State.ID, event.Topic, err)
+		}
+	}
+}
+
+// escalate publishes the event to the topic of the next step, if the event still escalates.
+func (h *escalateHandler) escalate(id string) {
+	h.mu.Lock()
+	p := h.pending[id]
+	if h.closed || p == nil || h.now().Before(p.e.Next) {
+		h.mu.Unlock()
+		return
+	}
+	// Check the current state, the event may have been acknowledged.
+	state, ok, err := h.c.topics.EventState(h.c.topic, id)
+	if err != nil {
+		h.logger.Printf("E! failed to get state of event %q: %v", id, err)
+	}
+	if err != nil || !ok {
+		h.cancel(p)
+		h.mu.Unlock()
+		return
+	}
+
+	var event alert.Event
+	if p.event != nil {
+		event = *p.event
+	} else {
+		event.State = convertEventStateToAlert(id, p.e.Event)
+	}
+	if !h.escalates(state) {
+		// The event recovered or was acknowledged while the handler was not running.
+		event.State = state
+		recovered := h.stop(p, event)
+		h.mu.Unlock()
+		h.publish(recovered)
+		return
+	}
+	event.Topic = h.c.Steps[p.e.Step].Topic
+
+	if !containsString(p.e.Published, event.Topic) {
+		p.e.Published = append(p.e.Published, event.Topic)
+	}
+	p.e.Step++
+	if p.e.Step < len(h.c.Steps) {
+		p.e.Next = p.e.Start.Add(h.c.Steps[p.e.Step].After)
+		p.e.NextTopic = h.c.Steps[p.e.Step].Topic
+		h.schedule(p)
+	} else {
+		// The escalation is complete, it is kept until the event recovers.
+		h.complete(p)
+	}
+	if err := h.c.dao.Put(p.e); err != nil {
+		h.logger.Printf("E! failed to save escalation of event %q: %v", id, err)
+	}
+	h.mu.Unlock()
+
+	h.publish([]alert.Event{event})
+}
+
+func containsString(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Close stops all pending escalations, they are resumed from storage when the handler is recreated.
+func (h *escalateHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, p := range h.pending {
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+	}
+}
+
+const (
+	statOutboxQueueDepth  = "queue_depth"
+	statOutboxDeadLetters = "dead_letters"
+	statOutboxRetries     = "retries"
+)
+
+// outboxHandler keeps the events a handler failed to deliver in storage and retries them with exponential backoff.
+// Events are delivered in order, while events are queued new events are queued behind them.
+// Events that could not be delivered within the max age become dead letters, they are kept until replayed or deleted.
+type outboxHandler struct {
+	topic   string
+	handler string
+	h       alert.FallibleHandler
+	c       Config
+	dao     OutboxDAO
+
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+
+	mu sync.Mutex
+	// lastID is the last sequence used for an entry ID.
+	lastID int64
+	timer  *time.Timer
+	closed bool
+	// release is called once the handler is closed.
+	release func(*outboxHandler)
+
+	queueDepth  *kexpvar.Int
+	deadLetters *kexpvar.Int
+	statKey     string
+	statMap     *kexpvar.Map
+
+	logger *log.Logger
+}
+
+func newOutboxHandler(topic, handler string, h alert.FallibleHandler, c Config, dao OutboxDAO, l *log.Logger) (*outboxHandler, error) {
+	o := &outboxHandler{
+		topic:       topic,
+		handler:     handler,
+		h:           h,
+		c:           c,
+		dao:         dao,
+		now:         time.Now,
+		queueDepth:  &kexpvar.Int{},
+		deadLetters: &kexpvar.Int{},
+		logger:      l,
+	}
+	o.statKey, o.statMap = vars.NewStatistic("alert_outbox", map[string]string{
+		"topic":   topic,
+		"handler": handler,
+	})
+	o.statMap.Set(statOutboxQueueDepth, o.queueDepth)
+	o.statMap.Set(statOutboxDeadLetters, o.deadLetters)
+	o.statMap.Set(statOutboxRetries, &kexpvar.Int{})
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.load(); err != nil {
+		vars.DeleteStatistic(o.statKey)
+		return nil, errors.Wrap(err, "failed to load outbox")
+	}
+	return o, nil
+}
+
+// load counts the stored entries and schedules the next retry.
+// Caller must have the lock.
+func (o *outboxHandler) load() error {
+	var queued, dead int64
+	offset := 0
+	limit := 100
+	for {
+		entries, err := o.dao.List(o.topic, o.handler, offset, limit)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.Dead {
+				dead++
+			} else {
+				queued++
+			}
+			if seq, err := strconv.ParseInt(e.ID, 10, 64); err == nil && seq > o.lastID {
+				o.lastID = seq
+			}
+		}
+		offset += limit
+		if len(entries) != limit {
+			break
+		}
+	}
+	o.queueDepth.Set(queued)
+	o.deadLetters.Set(dead)
+	if queued > 0 {
+		o.schedule(o.now())
+	}
+	return nil
+}
+
+// reload recounts the stored entries after they were changed outside of the handler.
+func (o *outboxHandler) reload() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	if err := o.load(); err != nil {
+		o.logger.Printf("E! failed to reload outbox of handler %q of topic %q: %v", o.handler, o.topic, err)
+	}
+}
+
+func (o *outboxHandler) Handle(event alert.Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	if o.queueDepth.IntValue() > 0 {
+		// Keep the events in order
+		o.enqueue(event, nil)
+		return
+	}
+	if err := o.h.TryHandle(event); err != nil {
+		o.logger.Printf("E! failed to deliver event %q by handler %q of topic %q, it will be retried: %v", event.State.ID, o.handler, o.topic, err)
+		o.enqueue(event, err)
+	}
+}
+
+// enqueue stores the event so that its delivery is retried.
+// Caller must have the lock.
+func (o *outboxHandler) enqueue(event alert.Event, err error) {
+	now := o.now()
+	seq := now.UnixNano()
+	if seq <= o.lastID {
+		seq = o.lastID + 1
+	}
+	o.lastID = seq
+	e := OutboxEntry{
+		Topic:   o.topic,
+		Handler: o.handler,
+		// Zero pad the sequence so the IDs sort in order.
+		ID:      fmt.Sprintf("%020d", seq),
+		EventID: event.State.ID,
+		Event:   convertEventStateFromAlert(event.State),
+		Data:    convertEventDataFromAlert(event.Data),
+		Queued:  now,
+		Next:    now,
+	}
+	if err != nil {
+		e.Attempts = 1
+		e.LastError = err.Error()
+		e.Next = now.Add(o.backoff(e.Attempts))
+	}
+	if err := o.dao.Put(e); err != nil {
+		o.logger.Printf("E! failed to save event %q to the outbox of handler %q of topic %q, the event is lost: %v", e.EventID, o.handler, o.topic, err)
+		return
+	}
+	o.queueDepth.Add(1)
+	if o.queueDepth.IntValue() == 1 {
+		o.schedule(e.Next)
+	}
+}
+
+// backoff returns how long to wait after the given number of failed attempts.
+func (o *outboxHandler) backoff(attempts int) time.Duration {
+	d := time.Duration(o.c.OutboxRetryInterval)
+	max := time.Duration(o.c.OutboxMaxRetryInterval)
+	for i := 1; i < attempts && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// schedule starts the timer for the next retry.
+// Caller must have the lock.
+func (o *outboxHandler) schedule(next time.Time) {
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	o.timer = time.AfterFunc(next.Sub(o.now()), o.retry)
+}
+
+// head returns the first entry that is not a dead letter.
+// Caller must have the lock.
+func (o *outboxHandler) head() (OutboxEntry, bool, error) {
+	entries, err := o.dao.Pending(o.topic, o.handler, 0, 1)
+	if err != nil || len(entries) == 0 {
+		return OutboxEntry{}, false, err
+	}
+	return entries[0], true, nil
+}
+
+// retry delivers the queued events in order until a delivery fails or is not yet due.
+func (o *outboxHandler) retry() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for !o.closed {
+		e, ok, err := o.head()
+		if err != nil {
+			o.logger.Printf("E! failed to read the outbox of handler %q of topic %q: %v", o.handler, o.topic, err)
+			o.schedule(o.now().Add(time.Duration(o.c.OutboxRetryInterval)))
+			return
+		}
+		if !ok {
+			o.queueDepth.Set(0)
+			return
+		}
+		now := o.now()
+		if now.Sub(e.Queued) >= time.Duration(o.c.OutboxMaxAge) {
+			e.Dead = true
+			if err := o.dao.Put(e); err != nil {
+				o.logger.Printf("E! failed to save dead letter %s of handler %q of topic %q: %v", e.ID, o.handler, o.topic, err)
+				o.schedule(now.Add(time.Duration(o.c.OutboxRetryInterval)))
+				return
+			}
+			o.logger.Printf("E! giving up delivery of event %q by handler %q of topic %q after %d attempts, it is kept as dead letter %s", e.EventID, o.handler, o.topic, e.Attempts, e.ID)
+			o.queueDepth.Add(-1)
+			o.deadLetters.Add(1)
+			continue
+		}
+		if now.Before(e.Next) {
+			o.schedule(e.Next)
+			return
+		}
+		event := alert.Event{
+			Topic: e.Topic,
+			State: convertEventStateToAlert(e.EventID, e.Event),
+			Data:  convertEventDataToAlert(e.Data),
+		}
+		if e.Attempts > 0 {
+			o.statMap.Add(statOutboxRetries, 1)
+		}
+		if err := o.h.TryHandle(event); err != nil {
+			e.Attempts++
+			e.LastError = err.Error()
+			e.Next = now.Add(o.backoff(e.Attempts))
+			if err := o.dao.Put(e); err != nil {
+				o.logger.Printf("E! failed to save outbox entry %s of handler %q of topic %q: %v", e.ID, o.handler, o.topic, err)
+			}
+			o.schedule(e.Next)
+			return
+		}
+		if err := o.dao.Delete(e.Topic, e.Handler, e.ID); err != nil {
+			o.logger.Printf("E! failed to delete outbox entry %s of handler %q of topic %q: %v", e.ID, o.handler, o.topic, err)
+			o.schedule(now.Add(time.Duration(o.c.OutboxRetryInterval)))
+			return
+		}
+		o.queueDepth.Add(-1)
+	}
+}
+
+// Close stops retrying, the queued events are retried from storage when the handler is recreated.
+func (o *outboxHandler) Close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	o.closed = true
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	vars.DeleteStatistic(o.statKey)
+	if c, ok := o.h.(closer); ok {
+		c.Close()
+	}
+	if o.release != nil {
+		o.release(o)
+	}
+}
+
+type PublishHandlerConfig struct {
+	Topics []string `mapstructure:"topics"`
+	ec     EventCollector
+}
+type publishHandler struct {
+	c      PublishHandlerConfig
+	logger *log.Logger
+}
+
+func NewPublishHandler(c PublishHandlerConfig, l *log.Logger) alert.Handler {
+	return &publishHandler{
+		c:      c,
+		logger: l,
+	}
+}
+
+func (h *publishHandler) Handle(event alert.Event) {
+	for _, t := range h.c.Topics {
+		event.Topic = t
+		h.c.ec.Collect(event)
+	}
+}
+
+// ExternalHandler wraps an existing handler that calls out to external services.
+// The events are checked for the NoExternal flag before being passed to the external handler.
+type externalHandler struct {
+	h alert.Handler
+}
+
+// NewExternalHandler wraps h so that it does not handle events marked NoExternal.
+func NewExternalHandler(h alert.Handler) alert.Handler {
+	return &externalHandler{
+		h: h,
+	}
+}
+
+func (h *externalHandler) Handle(event alert.Event) {
+	if !event.NoExternal {
+		h.h.Handle(event)
+	}
+}
+
+// Close closes the wrapped handler if it needs closing.
+func (h *externalHandler) Close() {
+	if c, ok := h.h.(closer); ok {
+		c.Close()
+	}
+}
+
+type matchHandler struct {
+	h alert.Handler
+
+	scope *stateful.Scope
+	expr  stateful.Expression
+
+	// scope optimization
+	usesChanged,
+	usesLevel,
+	usesName,
+	usesTaskName,
+	usesDuration bool
+
+	vars []string
+
+	logger *log.Logger
+}
+
+const (
+	changedFunc  = "changed"
+	levelFunc    = "level"
+	nameFunc     = "name"
+	taskNameFunc = "taskName"
+	durationFunc = "duration"
+)
+
+var matchIdentifiers = map[string]interface{}{
+	"OK":       int64(alert.OK),
+	"INFO":     int64(alert.Info),
+	"WARNING":  int64(alert.Warning),
+	"CRITICAL": int64(alert.Critical),
+}
+
+func newMatchHandler(match string, h alert.Handler, l *log.Logger) (*matchHandler, error) {
+	lambda, err := ast.ParseLambda(match)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid match expression")
+	}
+
+	// Replace identifiers with static values
+	_, err = ast.Walk(lambda, func(n ast.Node) (ast.Node, error) {
+		if ident, ok := n.(*ast.IdentifierNode); ok {
+			v, ok := matchIdentifiers[ident.Ident]
+			if !ok {
+				return nil, fmt.Errorf("unknown identifier %q", ident.Ident)
+			}
+			return ast.ValueToLiteralNode(n, v)
+		}
+		return n, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := stateful.NewExpression(lambda.Expression)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid match expression")
+	}
+
+	mh := &matchHandler{
+		h:      h,
+		expr:   expr,
+		scope:  stateful.NewScope(),
+		vars:   ast.FindReferenceVariables(lambda),
+		logger: l,
+	}
+
+	// Determine which functions are called
+	funcs := ast.FindFunctionCalls(lambda)
+	for _, f := range funcs {
+		switch f {
+		case changedFunc:
+			mh.usesChanged = true
+		case levelFunc:
+			mh.usesLevel = true
+		case nameFunc:
+			mh.usesName = true
+		case taskNameFunc:
+			mh.usesTaskName = true
+		case durationFunc:
+			mh.usesDuration = true
+		default:
+			// ignore the function
+		}
+	}
+
+	return mh, nil
+}
+
+func (h *matchHandler) Handle(event alert.Event) {
+	if ok, err := h.match(event); err != nil {
 		h.logger.Println("E! failed to evaluate match expression:", err)
 	} else if ok {
 		h.h.Handle(event)
 	}
 }
 
+// Close closes the wrapped handler if it needs closing.
+func (h *matchHandler) Close() {
+	if c, ok := h.h.(closer); ok {
+		c.Close()
+	}
+}
+
+// routedHandler only handles the events the routing of its topic sends to it.
+type routedHandler struct {
+	h       alert.Handler
+	handler string
+}
+
+func newRoutedHandler(handler string, h alert.Handler) *routedHandler {
+	return &routedHandler{
+		h:       h,
+		handler: handler,
+	}
+}
+
+func (h *routedHandler) Handle(event alert.Event) {
+	if event.RoutedTo(h.handler) {
+		h.h.Handle(event)
+	}
+}
+
+// Close closes the wrapped handler if it needs closing.
+func (h *routedHandler) Close() {
+	if c, ok := h.h.(closer); ok {
+		c.Close()
+	}
+}
+
+// onCallPrefix marks a recipient as a reference to an on-call schedule, i.e. "oncall:<schedule ID>".
+const onCallPrefix = "oncall:"
+
+// onCallRecipientOptions are the options of each handler kind that name recipients,
+// their values may reference on-call schedules.
+var onCallRecipientOptions = map[string][]string{
+	"hipchat":  {"room"},
+	"opsgenie": {"recipients-list"},
+	"pushover": {"device"},
+	"slack":    {"channel"},
+	"smtp":     {"to"},
+	"telegram": {"chat-id"},
+}
+
+// onCallResolver looks up on-call schedules.
+type onCallResolver interface {
+	OnCallSchedule(id string) (alert.OnCallSchedule, bool, error)
+}
+
+// usesOnCall reports whether any recipient of the spec references an on-call schedule.
+func usesOnCall(spec HandlerSpec) bool {
+	for _, o := range onCallRecipientOptions[spec.Kind] {
+		switch v := spec.Options[o].(type) {
+		case string:
+			if strings.HasPrefix(v, onCallPrefix) {
+				return true
+			}
+		case []string:
+			for _, r := range v {
+				if strings.HasPrefix(r, onCallPrefix) {
+					return true
+				}
+			}
+		case []interface{}:
+			for _, r := range v {
+				if str, ok := r.(string); ok && strings.HasPrefix(str, onCallPrefix) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// resolveOnCall returns a copy of the options where the recipients referencing an on-call schedule
+// are replaced with the contact for the handler kind of the member on call at time now.
+func resolveOnCall(kind string, options map[string]interface{}, r onCallResolver, now time.Time) (map[string]interface{}, error) {
+	resolve := func(recipient string) (string, error) {
+		if !strings.HasPrefix(recipient, onCallPrefix) {
+			return recipient, nil
+		}
+		id := strings.TrimPrefix(recipient, onCallPrefix)
+		schedule, ok, err := r.OnCallSchedule(id)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("unknown on-call schedule %q", id)
+		}
+		member, ok := schedule.OnCall(now)
+		if !ok {
+			return "", fmt.Errorf("nobody is on call in schedule %q", id)
+		}
+		contact, ok := member.Contacts[kind]
+		if !ok {
+			return "", fmt.Errorf("member %q on call in schedule %q has no %s contact", member.Name, id, kind)
+		}
+		return contact, nil
+	}
+
+	resolved := make(map[string]interface{}, len(options))
+	for k, v := range options {
+		resolved[k] = v
+	}
+	for _, o := range onCallRecipientOptions[kind] {
+		switch v := options[o].(type) {
+		case string:
+			c, err := resolve(v)
+			if err != nil {
+				return nil, err
+			}
+			resolved[o] = c
+		case []string:
+			cs := make([]string, len(v))
+			for i, recipient := range v {
+				c, err := resolve(recipient)
+				if err != nil {
+					return nil, err
+				}
+				cs[i] = c
+			}
+			resolved[o] = cs
+		case []interface{}:
+			cs := make([]interface{}, len(v))
+			for i, recipient := range v {
+				str, ok := recipient.(string)
+				if !ok {
+					cs[i] = recipient
+					continue
+				}
+				c, err := resolve(str)
+				if err != nil {
+					return nil, err
+				}
+				cs[i] = c
+			}
+			resolved[o] = cs
+		}
+	}
+	return resolved, nil
+}
+
+// onCallHandler resolves the on-call recipients of its spec when an event is handled
+// and passes the event to a handler created for the resolved recipients.
+type onCallHandler struct {
+	spec   HandlerSpec
+	r      onCallResolver
+	create func(HandlerSpec) (alert.Handler, error)
+
+	mu sync.Mutex
+	// options the current handler was created with
+	options map[string]interface{}
+	h       alert.Handler
+
+	logger *log.Logger
+}
+
+func newOnCallHandler(spec HandlerSpec, r onCallResolver, create func(HandlerSpec) (alert.Handler, error), l *log.Logger) (*onCallHandler, error) {
+	// Create a handler from the unresolved options to validate them
+	h, err := create(spec)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := h.(closer); ok {
+		c.Close()
+	}
+	return &onCallHandler{
+		spec:   spec,
+		r:      r,
+		create: create,
+		logger: l,
+	}, nil
+}
+
+func (h *onCallHandler) Handle(event alert.Event) {
+	options, err := resolveOnCall(h.spec.Kind, h.spec.Options, h.r, time.Now())
+	if err != nil {
+		h.logger.Printf("E! failed to resolve on-call recipients of handler %q: %v", h.spec.ID, err)
+		return
+	}
+	h.mu.Lock()
+	if h.h == nil || !reflect.DeepEqual(options, h.options) {
+		spec := h.spec
+		spec.Options = options
+		handler, err := h.create(spec)
+		if err != nil {
+			h.mu.Unlock()
+			h.logger.Printf("E! failed to create handler %q for on-call recipients: %v", h.spec.ID, err)
+			return
+		}
+		h.closeHandler()
+		h.h = handler
+		h.options = options
+	}
+	handler := h.h
+	h.mu.Unlock()
+	handler.Handle(event)
+}
+
+// Close closes the current handler if it needs closing.
+func (h *onCallHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closeHandler()
+}
+
+// closeHandler closes the current handler, caller must have the lock.
+func (h *onCallHandler) closeHandler() {
+	if c, ok := h.h.(closer); ok {
+		c.Close()
+	}
+	h.h = nil
+}
+
 var changedFuncSignature = map[stateful.Domain]ast.ValueType{}
 var levelFuncSignature = map[stateful.Domain]ast.ValueType{}
 var nameFuncSignature = map[stateful.Domain]ast.ValueType{}
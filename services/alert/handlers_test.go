@@ -0,0 +1,80 @@
+package alert_test
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	alertservice "github.com/influxdata/kapacitor/services/alert"
+	"github.com/influxdata/kapacitor/services/alert/alerttest"
+)
+
+func TestSyslogHandler_Connection(t *testing.T) {
+	ts, err := alerttest.NewSyslogServer("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	c := alertservice.DefaultSyslogHandlerConfig()
+	c.Network = "tcp"
+	c.Address = ts.Addr
+	h, err := alertservice.NewSyslogHandler(c, log.New(os.Stderr, "[syslog] ", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sh := h.(alert.FallibleHandler)
+	event := alert.Event{
+		State: alert.EventState{
+			ID:      "id",
+			Message: "message",
+			Level:   alert.Critical,
+			Time:    time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	// All messages are sent over a single connection.
+	for i := 0; i < 3; i++ {
+		if err := sh.TryHandle(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitForMessages(t, ts, 3)
+	if got, exp := ts.Connections(), 1; got != exp {
+		t.Errorf("unexpected number of connections: got %d exp %d", got, exp)
+	}
+
+	// Once the receiver closes the connection writes fail and the handler redials.
+	// The first writes after the close may still succeed and be lost, as with any tcp syslog sender.
+	ts.CloseConnections()
+	received := len(ts.Messages())
+	deadline := time.Now().Add(5 * time.Second)
+	for ts.Connections() < 2 || len(ts.Messages()) == received {
+		if time.Now().After(deadline) {
+			t.Fatalf("handler did not redial, got %d connections", ts.Connections())
+		}
+		if err := sh.TryHandle(event); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got, exp := ts.Connections(), 2; got != exp {
+		t.Errorf("unexpected number of connections after redial: got %d exp %d", got, exp)
+	}
+
+	h.(interface {
+		Close()
+	}).Close()
+}
+
+func waitForMessages(t *testing.T, ts *alerttest.SyslogServer, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for len(ts.Messages()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d messages, got %d", n, len(ts.Messages()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
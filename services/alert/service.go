@@ -9,22 +9,29 @@ import (
 	"reflect"
 	"regexp"
 	"sync"
+	"time"
 
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/command"
+	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/services/alerta"
+	"github.com/influxdata/kapacitor/services/alertmanager"
+	"github.com/influxdata/kapacitor/services/amqp"
+	"github.com/influxdata/kapacitor/services/discord"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/httppost"
 	"github.com/influxdata/kapacitor/services/mqtt"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/pagerduty"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
 	"github.com/influxdata/kapacitor/services/pushover"
 	"github.com/influxdata/kapacitor/services/sensu"
 	"github.com/influxdata/kapacitor/services/slack"
 	"github.com/influxdata/kapacitor/services/smtp"
 	"github.com/influxdata/kapacitor/services/snmptrap"
 	"github.com/influxdata/kapacitor/services/storage"
+	"github.com/influxdata/kapacitor/services/teams"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/victorops"
 	"github.com/mitchellh/mapstructure"
@@ -34,8 +41,34 @@ import (
 type Service struct {
 	mu sync.RWMutex
 
-	specsDAO  HandlerSpecDAO
-	topicsDAO TopicStateDAO
+	specsDAO    HandlerSpecDAO
+	topicsDAO   TopicStateDAO
+	silencesDAO SilenceDAO
+
+	inhibitionsDAO InhibitionDAO
+
+	historyDAO EventHistoryDAO
+
+	escalationsDAO EscalationDAO
+
+	routingsDAO RoutingDAO
+
+	maintenanceWindowsDAO MaintenanceWindowDAO
+
+	onCallSchedulesDAO OnCallScheduleDAO
+
+	outboxDAO OutboxDAO
+
+	// live outboxes by handler, it has its own lock since outboxes are created and closed by handlers.
+	outboxMu sync.Mutex
+	outboxes map[string]map[*outboxHandler]bool
+
+	config Config
+
+	// on-call schedules by ID, it has its own lock since it is read by handlers.
+	onCallMu        sync.RWMutex
+	onCallSchedules map[string]alert.OnCallSchedule
+
 
 	APIServer *apiServer
 
@@ -65,6 +98,15 @@ type Service struct {
 		DefaultHandlerConfig() alerta.HandlerConfig
 		Handler(alerta.HandlerConfig, *log.Logger) (alert.Handler, error)
 	}
+	AlertManagerService interface {
+		Handler(alertmanager.HandlerConfig, *log.Logger) alert.Handler
+	}
+	AMQPService interface {
+		Handler(amqp.HandlerConfig, *log.Logger) (alert.Handler, error)
+	}
+	DiscordService interface {
+		Handler(discord.HandlerConfig, *log.Logger) alert.Handler
+	}
 	HipChatService interface {
 		Handler(hipchat.HandlerConfig, *log.Logger) alert.Handler
 	}
@@ -77,11 +119,14 @@ type Service struct {
 	PagerDutyService interface {
 		Handler(pagerduty.HandlerConfig, *log.Logger) alert.Handler
 	}
+	PagerDuty2Service interface {
+		Handler(pagerduty2.HandlerConfig, *log.Logger) alert.Handler
+	}
 	PushoverService interface {
 		Handler(pushover.HandlerConfig, *log.Logger) alert.Handler
 	}
 	HTTPPostService interface {
-		Handler(httppost.HandlerConfig, *log.Logger) alert.Handler
+		Handler(httppost.HandlerConfig, *log.Logger) (alert.Handler, error)
 	}
 	SensuService interface {
 		Handler(sensu.HandlerConfig, *log.Logger) (alert.Handler, error)
@@ -90,7 +135,7 @@ type Service struct {
 		Handler(slack.HandlerConfig, *log.Logger) alert.Handler
 	}
 	SMTPService interface {
-		Handler(smtp.HandlerConfig, *log.Logger) alert.Handler
+		Handler(smtp.HandlerConfig, *log.Logger) (alert.Handler, error)
 	}
 	SNMPTrapService interface {
 		Handler(snmptrap.HandlerConfig, *log.Logger) (alert.Handler, error)
@@ -98,6 +143,9 @@ type Service struct {
 	TalkService interface {
 		Handler(*log.Logger) alert.Handler
 	}
+	TeamsService interface {
+		Handler(teams.HandlerConfig, *log.Logger) alert.Handler
+	}
 	TelegramService interface {
 		Handler(telegram.HandlerConfig, *log.Logger) alert.Handler
 	}
@@ -106,18 +154,30 @@ type Service struct {
 	}
 }
 
-func NewService(l *log.Logger) *Service {
+func NewService(c Config, l *log.Logger) *Service {
 	s := &Service{
-		handlers:     make(map[string]map[string]handler),
-		closedTopics: make(map[string]bool),
-		topics:       alert.NewTopics(l),
-		logger:       l,
+		handlers:        make(map[string]map[string]handler),
+		closedTopics:    make(map[string]bool),
+		onCallSchedules: make(map[string]alert.OnCallSchedule),
+		outboxes:        make(map[string]map[*outboxHandler]bool),
+		config:          c,
+		topics:          alert.NewTopics(l),
+		logger:          l,
 	}
 	s.APIServer = &apiServer{
-		Registrar: s,
-		Topics:    s,
-		Persister: s,
-		logger:    l,
+		Registrar:    s,
+		Topics:       s,
+		Persister:    s,
+		Acknowledger: s,
+		Silencer:     s,
+		Inhibitor:    s,
+		History:      s,
+		Escalations:  s,
+		Router:       s,
+		Maintenance:  s,
+		OnCall:       s,
+		Outboxes:     s,
+		logger:       l,
 	}
 	s.EventCollector = s
 	return s
@@ -128,8 +188,27 @@ const (
 	handlerSpecsAPIName = "handler-specs"
 	// Public name of the handler specs store.
 	topicStatesAPIName = "topic-states"
+	// Public name of the silences store.
+	silencesAPIName = "silences"
+	// Public name of the inhibitions store.
+	inhibitionsAPIName = "inhibitions"
+	// Public name of the event history store.
+	eventHistoryAPIName = "event-history"
+	// Public name of the escalations store.
+	escalationsAPIName = "escalations"
+	// Public name of the routings store.
+	routingsAPIName = "routings"
+	// Public name of the maintenance windows store.
+	maintenanceWindowsAPIName = "maintenance-windows"
+	// Public name of the on-call schedules store.
+	onCallSchedulesAPIName = "oncall-schedules"
+	// Public name of the outbox store.
+	outboxAPIName = "outbox"
 	// The storage namespace for all task data.
 	alertNamespace = "alert_store"
+
+	// eventHistoryLimit is the maximum number of transitions kept per event.
+	eventHistoryLimit = 50
 )
 
 func (s *Service) Open() error {
@@ -150,19 +229,93 @@ func (s *Service) Open() error {
 	}
 	s.topicsDAO = topicsDAO
 	s.StorageService.Register(topicStatesAPIName, s.topicsDAO)
+	silencesDAO, err := newSilenceKV(store)
+	if err != nil {
+		return err
+	}
+	s.silencesDAO = silencesDAO
+	s.StorageService.Register(silencesAPIName, s.silencesDAO)
+	inhibitionsDAO, err := newInhibitionKV(store)
+	if err != nil {
+		return err
+	}
+	s.inhibitionsDAO = inhibitionsDAO
+	s.StorageService.Register(inhibitionsAPIName, s.inhibitionsDAO)
+	historyDAO, err := newEventHistoryKV(store)
+	if err != nil {
+		return err
+	}
+	s.historyDAO = historyDAO
+	s.StorageService.Register(eventHistoryAPIName, s.historyDAO)
+	escalationsDAO, err := newEscalationKV(store)
+	if err != nil {
+		return err
+	}
+	s.escalationsDAO = escalationsDAO
+	s.StorageService.Register(escalationsAPIName, s.escalationsDAO)
+	routingsDAO, err := newRoutingKV(store)
+	if err != nil {
+		return err
+	}
+	s.routingsDAO = routingsDAO
+	s.StorageService.Register(routingsAPIName, s.routingsDAO)
+	maintenanceWindowsDAO, err := newMaintenanceWindowKV(store)
+	if err != nil {
+		return err
+	}
+	s.maintenanceWindowsDAO = maintenanceWindowsDAO
+	s.StorageService.Register(maintenanceWindowsAPIName, s.maintenanceWindowsDAO)
+	onCallSchedulesDAO, err := newOnCallScheduleKV(store)
+	if err != nil {
+		return err
+	}
+	s.onCallSchedulesDAO = onCallSchedulesDAO
+	s.StorageService.Register(onCallSchedulesAPIName, s.onCallSchedulesDAO)
+	outboxDAO, err := newOutboxKV(store)
+	if err != nil {
+		return err
+	}
+	s.outboxDAO = outboxDAO
+	s.StorageService.Register(outboxAPIName, s.outboxDAO)
 
 	// Migrate v1.2 handlers
 	if err := s.migrateHandlerSpecs(store); err != nil {
 		return err
 	}
 
+	// Load saved topic state,
+	// before the handlers so that restored escalations see the state of their events.
+	if err := s.loadSavedTopicStates(); err != nil {
+		return err
+	}
+
 	// Load saved handlers
 	if err := s.loadSavedHandlerSpecs(); err != nil {
 		return err
 	}
 
-	// Load saved topic state
-	if err := s.loadSavedTopicStates(); err != nil {
+	// Load saved silences
+	if err := s.loadSavedSilences(); err != nil {
+		return err
+	}
+
+	// Load saved inhibitions
+	if err := s.loadSavedInhibitions(); err != nil {
+		return err
+	}
+
+	// Load saved routings
+	if err := s.loadSavedRoutings(); err != nil {
+		return err
+	}
+
+	// Load saved maintenance windows
+	if err := s.loadSavedMaintenanceWindows(); err != nil {
+		return err
+	}
+
+	// Load saved on-call schedules
+	if err := s.loadSavedOnCallSchedules(); err != nil {
 		return err
 	}
 
@@ -178,6 +331,13 @@ func (s *Service) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.topics.Close()
+	for _, handlers := range s.handlers {
+		for _, h := range handlers {
+			if ha, ok := h.Handler.(closer); ok {
+				ha.Close()
+			}
+		}
+	}
 	return s.APIServer.Close()
 }
 
@@ -332,37 +492,303 @@ func (s *Service) loadSavedHandlerSpecs() error {
 func (s *Service) convertEventStatesToAlert(states map[string]EventState) map[string]alert.EventState {
 	newStates := make(map[string]alert.EventState, len(states))
 	for id, state := range states {
-		newStates[id] = s.convertEventStateToAlert(id, state)
+		newStates[id] = convertEventStateToAlert(id, state)
 	}
 	return newStates
 }
-func (s *Service) convertEventStateToAlert(id string, state EventState) alert.EventState {
-	return alert.EventState{
-		ID:       id,
-		Message:  state.Message,
-		Details:  state.Details,
-		Time:     state.Time,
-		Duration: state.Duration,
-		Level:    state.Level,
+func convertEventStateToAlert(id string, state EventState) alert.EventState {
+	newState := alert.EventState{
+		ID:          id,
+		Message:     state.Message,
+		Details:     state.Details,
+		Time:        state.Time,
+		Duration:    state.Duration,
+		Level:       state.Level,
+		Inhibited:   state.Inhibited,
+		Maintenance: state.Maintenance,
+	}
+	if state.Ack != nil {
+		newState.Ack = alert.Acknowledgement{
+			By:      state.Ack.By,
+			Time:    state.Ack.Time,
+			Comment: state.Ack.Comment,
+		}
 	}
+	return newState
 }
 
 func (s *Service) convertEventStatesFromAlert(states map[string]alert.EventState) map[string]EventState {
 	newStates := make(map[string]EventState, len(states))
 	for id, state := range states {
-		newStates[id] = s.convertEventStateFromAlert(state)
+		newStates[id] = convertEventStateFromAlert(state)
 	}
 	return newStates
 }
 
-func (s *Service) convertEventStateFromAlert(state alert.EventState) EventState {
-	return EventState{
-		Message:  state.Message,
-		Details:  state.Details,
-		Time:     state.Time,
-		Duration: state.Duration,
-		Level:    state.Level,
+func convertEventStateFromAlert(state alert.EventState) EventState {
+	newState := EventState{
+		Message:     state.Message,
+		Details:     state.Details,
+		Time:        state.Time,
+		Duration:    state.Duration,
+		Level:       state.Level,
+		Inhibited:   state.Inhibited,
+		Maintenance: state.Maintenance,
+	}
+	if state.Ack.Acked() {
+		newState.Ack = &Acknowledgement{
+			By:      state.Ack.By,
+			Time:    state.Ack.Time,
+			Comment: state.Ack.Comment,
+		}
+	}
+	return newState
+}
+
+func convertEventDataToAlert(data EventData) alert.EventData {
+	return alert.EventData{
+		Name:     data.Name,
+		TaskName: data.TaskName,
+		Group:    data.Group,
+		Tags:     data.Tags,
+		Fields:   data.Fields,
+		Result:   models.Result{Series: data.Series},
+	}
+}
+
+func convertEventDataFromAlert(data alert.EventData) EventData {
+	return EventData{
+		Name:     data.Name,
+		TaskName: data.TaskName,
+		Group:    data.Group,
+		Tags:     data.Tags,
+		Fields:   data.Fields,
+		Series:   data.Result.Series,
+	}
+}
+
+func (s *Service) convertSilenceToAlert(silence Silence) alert.Silence {
+	return alert.Silence{
+		ID:        silence.ID,
+		Topic:     silence.Topic,
+		Event:     silence.Event,
+		Tags:      silence.Tags,
+		Start:     silence.Start,
+		Stop:      silence.Stop,
+		CreatedBy: silence.CreatedBy,
+		Comment:   silence.Comment,
+	}
+}
+
+func (s *Service) convertSilenceFromAlert(silence alert.Silence) Silence {
+	return Silence{
+		ID:        silence.ID,
+		Topic:     silence.Topic,
+		Event:     silence.Event,
+		Tags:      silence.Tags,
+		Start:     silence.Start,
+		Stop:      silence.Stop,
+		CreatedBy: silence.CreatedBy,
+		Comment:   silence.Comment,
+	}
+}
+
+func (s *Service) convertInhibitionToAlert(inhibition Inhibition) alert.Inhibition {
+	return alert.Inhibition{
+		ID:          inhibition.ID,
+		SourceTopic: inhibition.SourceTopic,
+		Level:       inhibition.Level,
+		TargetTopic: inhibition.TargetTopic,
+		Tags:        inhibition.Tags,
+		Equal:       inhibition.Equal,
+		Comment:     inhibition.Comment,
+	}
+}
+
+func (s *Service) convertInhibitionFromAlert(inhibition alert.Inhibition) Inhibition {
+	return Inhibition{
+		ID:          inhibition.ID,
+		SourceTopic: inhibition.SourceTopic,
+		Level:       inhibition.Level,
+		TargetTopic: inhibition.TargetTopic,
+		Tags:        inhibition.Tags,
+		Equal:       inhibition.Equal,
+		Comment:     inhibition.Comment,
+	}
+}
+
+func convertRoutesToAlert(routes []Route) []alert.Route {
+	if routes == nil {
+		return nil
+	}
+	converted := make([]alert.Route, len(routes))
+	for i, r := range routes {
+		converted[i] = alert.Route{
+			Levels:   r.Levels,
+			Tags:     r.Tags,
+			TaskName: r.TaskName,
+			Start:    r.Start,
+			Stop:     r.Stop,
+			Routes:   convertRoutesToAlert(r.Routes),
+			Handlers: r.Handlers,
+			Continue: r.Continue,
+		}
+	}
+	return converted
+}
+
+func convertRoutesFromAlert(routes []alert.Route) []Route {
+	if routes == nil {
+		return nil
+	}
+	converted := make([]Route, len(routes))
+	for i, r := range routes {
+		converted[i] = Route{
+			Levels:   r.Levels,
+			Tags:     r.Tags,
+			TaskName: r.TaskName,
+			Start:    r.Start,
+			Stop:     r.Stop,
+			Routes:   convertRoutesFromAlert(r.Routes),
+			Handlers: r.Handlers,
+			Continue: r.Continue,
+		}
+	}
+	return converted
+}
+
+func (s *Service) convertRoutingToAlert(routing Routing) (alert.Routing, error) {
+	loc, err := time.LoadLocation(routing.Timezone)
+	if err != nil {
+		return alert.Routing{}, errors.Wrapf(err, "invalid timezone for routing of topic %q", routing.Topic)
+	}
+	return alert.Routing{
+		Topic:    routing.Topic,
+		Routes:   convertRoutesToAlert(routing.Routes),
+		Default:  routing.Default,
+		Location: loc,
+	}, nil
+}
+
+func (s *Service) convertRoutingFromAlert(routing alert.Routing) Routing {
+	r := Routing{
+		Topic:   routing.Topic,
+		Routes:  convertRoutesFromAlert(routing.Routes),
+		Default: routing.Default,
+	}
+	if routing.Location != nil {
+		r.Timezone = routing.Location.String()
+	}
+	return r
+}
+
+func (s *Service) convertMaintenanceWindowToAlert(window MaintenanceWindow) (alert.MaintenanceWindow, error) {
+	loc, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return alert.MaintenanceWindow{}, errors.Wrapf(err, "invalid timezone for maintenance window %q", window.ID)
+	}
+	return alert.MaintenanceWindow{
+		ID:       window.ID,
+		Topic:    window.Topic,
+		Event:    window.Event,
+		Tags:     window.Tags,
+		Cron:     window.Cron,
+		Duration: window.Duration,
+		Location: loc,
+		Start:    window.Start,
+		Stop:     window.Stop,
+		Comment:  window.Comment,
+	}, nil
+}
+
+func (s *Service) convertMaintenanceWindowFromAlert(window alert.MaintenanceWindow) MaintenanceWindow {
+	w := MaintenanceWindow{
+		ID:       window.ID,
+		Topic:    window.Topic,
+		Event:    window.Event,
+		Tags:     window.Tags,
+		Cron:     window.Cron,
+		Duration: window.Duration,
+		Start:    window.Start,
+		Stop:     window.Stop,
+		Comment:  window.Comment,
+	}
+	if window.Location != nil {
+		w.Timezone = window.Location.String()
+	}
+	return w
+}
+
+func (s *Service) convertOnCallScheduleToAlert(schedule OnCallSchedule) (alert.OnCallSchedule, error) {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return alert.OnCallSchedule{}, errors.Wrapf(err, "invalid timezone for on-call schedule %q", schedule.ID)
+	}
+	as := alert.OnCallSchedule{
+		ID:        schedule.ID,
+		Members:   make([]alert.OnCallMember, len(schedule.Members)),
+		Rotations: make([]alert.Rotation, len(schedule.Rotations)),
+		Overrides: make([]alert.OnCallOverride, len(schedule.Overrides)),
+		Location:  loc,
+	}
+	for i, m := range schedule.Members {
+		as.Members[i] = alert.OnCallMember{
+			Name:     m.Name,
+			Contacts: m.Contacts,
+		}
 	}
+	for i, r := range schedule.Rotations {
+		as.Rotations[i] = alert.Rotation{
+			Members: r.Members,
+			Handoff: r.Handoff,
+			Shift:   r.Shift,
+			Start:   r.Start,
+			Stop:    r.Stop,
+		}
+	}
+	for i, o := range schedule.Overrides {
+		as.Overrides[i] = alert.OnCallOverride{
+			Member: o.Member,
+			Start:  o.Start,
+			Stop:   o.Stop,
+		}
+	}
+	return as, nil
+}
+
+func (s *Service) convertOnCallScheduleFromAlert(schedule alert.OnCallSchedule) OnCallSchedule {
+	converted := OnCallSchedule{
+		ID:        schedule.ID,
+		Members:   make([]OnCallMember, len(schedule.Members)),
+		Rotations: make([]Rotation, len(schedule.Rotations)),
+		Overrides: make([]OnCallOverride, len(schedule.Overrides)),
+	}
+	for i, m := range schedule.Members {
+		converted.Members[i] = OnCallMember{
+			Name:     m.Name,
+			Contacts: m.Contacts,
+		}
+	}
+	for i, r := range schedule.Rotations {
+		converted.Rotations[i] = Rotation{
+			Members: r.Members,
+			Handoff: r.Handoff,
+			Shift:   r.Shift,
+			Start:   r.Start,
+			Stop:    r.Stop,
+		}
+	}
+	for i, o := range schedule.Overrides {
+		converted.Overrides[i] = OnCallOverride{
+			Member: o.Member,
+			Start:  o.Start,
+			Stop:   o.Stop,
+		}
+	}
+	if schedule.Location != nil {
+		converted.Timezone = schedule.Location.String()
+	}
+	return converted
 }
 
 func (s *Service) loadSavedTopicStates() error {
@@ -386,6 +812,155 @@ func (s *Service) loadSavedTopicStates() error {
 	return nil
 }
 
+func (s *Service) loadSavedSilences() error {
+	offset := 0
+	limit := 100
+	for {
+		silences, err := s.silencesDAO.List("", offset, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, silence := range silences {
+			s.topics.SetSilence(s.convertSilenceToAlert(silence))
+		}
+
+		offset += limit
+		if len(silences) != limit {
+			break
+		}
+	}
+	// Delete expired silences after listing so the pagination is not disturbed.
+	return s.deleteExpiredSilences()
+}
+
+// deleteExpiredSilences removes the silences that can never apply again.
+// It is called whenever silences are loaded or read, so expired silences are never listed.
+func (s *Service) deleteExpiredSilences() error {
+	for _, id := range s.topics.DeleteExpiredSilences(time.Now()) {
+		if err := s.silencesDAO.Delete(id); err != nil && err != ErrNoSilenceExists {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) loadSavedInhibitions() error {
+	offset := 0
+	limit := 100
+	for {
+		inhibitions, err := s.inhibitionsDAO.List("", offset, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, inhibition := range inhibitions {
+			s.topics.SetInhibition(s.convertInhibitionToAlert(inhibition))
+		}
+
+		offset += limit
+		if len(inhibitions) != limit {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Service) loadSavedRoutings() error {
+	offset := 0
+	limit := 100
+	for {
+		routings, err := s.routingsDAO.List("", offset, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, routing := range routings {
+			r, err := s.convertRoutingToAlert(routing)
+			if err != nil {
+				// The timezone database may differ from the one the routing was saved with.
+				s.logger.Println("E! failed to load routing:", err)
+				continue
+			}
+			s.topics.SetRouting(r)
+		}
+
+		offset += limit
+		if len(routings) != limit {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Service) loadSavedMaintenanceWindows() error {
+	now := time.Now()
+	offset := 0
+	limit := 100
+	var expired []string
+	for {
+		windows, err := s.maintenanceWindowsDAO.List("", offset, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, window := range windows {
+			w, err := s.convertMaintenanceWindowToAlert(window)
+			if err != nil {
+				// The timezone database may differ from the one the window was saved with.
+				s.logger.Println("E! failed to load maintenance window:", err)
+				continue
+			}
+			if w.Expired(now) {
+				expired = append(expired, w.ID)
+				continue
+			}
+			s.topics.SetMaintenanceWindow(w)
+		}
+
+		offset += limit
+		if len(windows) != limit {
+			break
+		}
+	}
+	// Delete expired windows after listing so the pagination is not disturbed.
+	for _, id := range expired {
+		if err := s.maintenanceWindowsDAO.Delete(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) loadSavedOnCallSchedules() error {
+	offset := 0
+	limit := 100
+	for {
+		schedules, err := s.onCallSchedulesDAO.List("", offset, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, schedule := range schedules {
+			as, err := s.convertOnCallScheduleToAlert(schedule)
+			if err != nil {
+				// The timezone database may differ from the one the schedule was saved with.
+				s.logger.Println("E! failed to load on-call schedule:", err)
+				continue
+			}
+			s.onCallMu.Lock()
+			s.onCallSchedules[as.ID] = as
+			s.onCallMu.Unlock()
+		}
+
+		offset += limit
+		if len(schedules) != limit {
+			break
+		}
+	}
+	return nil
+}
+
 func validatePattern(pattern string) error {
 	_, err := path.Match(pattern, "")
 	return err
@@ -410,11 +985,48 @@ func (s *Service) Collect(event alert.Event) error {
 		}
 	}
 
-	err := s.topics.Collect(event)
-	if err != nil {
+	// Topics.Collect returns the previous state together with the new state,
+	// so concurrent collects of an event record each transition once.
+	state, prev, hasPrev, err := s.topics.Collect(event)
+	if !hasPrev || prev.Level != state.Level {
+		if err := s.recordTransition(event.Topic, state); err != nil {
+			return err
+		}
+	}
+	if err := s.persistTopicState(event.Topic); err != nil {
 		return err
 	}
-	return s.persistTopicState(event.Topic)
+	// The state is stored even if a handler failed to receive the event.
+	return err
+}
+
+// recordTransition adds the state to the history of the event,
+// forgetting the oldest states beyond the history limit.
+func (s *Service) recordTransition(topic string, state alert.EventState) error {
+	return s.historyDAO.Put(EventTransition{
+		Topic:   topic,
+		EventID: state.ID,
+		State:   convertEventStateFromAlert(state),
+	}, eventHistoryLimit)
+}
+
+// EventHistory returns the states of the event when its level changed between start and stop, in time order.
+func (s *Service) EventHistory(topic, event string, start, stop time.Time) ([]alert.EventState, error) {
+	transitions, err := s.historyDAO.List(topic, event)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]alert.EventState, 0, len(transitions))
+	for _, t := range transitions {
+		if !start.IsZero() && t.State.Time.Before(start) {
+			continue
+		}
+		if !stop.IsZero() && !t.State.Time.Before(stop) {
+			continue
+		}
+		history = append(history, convertEventStateToAlert(t.EventID, t.State))
+	}
+	return history, nil
 }
 
 func (s *Service) persistTopicState(topic string) error {
@@ -487,6 +1099,9 @@ func (s *Service) DeleteTopic(topic string) error {
 	defer s.mu.Unlock()
 	delete(s.closedTopics, topic)
 	s.topics.DeleteTopic(topic)
+	if err := s.historyDAO.DeleteTopic(topic); err != nil {
+		return err
+	}
 	return s.topicsDAO.Delete(topic)
 }
 
@@ -495,22 +1110,199 @@ func (s *Service) UpdateEvent(topic string, event alert.EventState) error {
 	return s.persistTopicState(topic)
 }
 
-func (s *Service) RegisterAnonHandler(topic string, h alert.Handler) {
-	s.topics.RegisterHandler(topic, h)
-}
-
-func (s *Service) DeregisterAnonHandler(topic string, h alert.Handler) {
-	s.topics.DeregisterHandler(topic, h)
+func (s *Service) AcknowledgeEvent(topic, event string, ack alert.Acknowledgement) (alert.EventState, bool, error) {
+	state, ok := s.topics.Acknowledge(topic, event, ack)
+	if !ok {
+		return alert.EventState{}, false, nil
+	}
+	return state, true, s.persistTopicState(topic)
 }
 
-// loadHandlerSpec initializes a spec that already exists.
-// Caller must have the write lock.
-func (s *Service) loadHandlerSpec(spec HandlerSpec) error {
-	h, err := s.createHandlerFromSpec(spec)
-	if err != nil {
+func (s *Service) CreateSilence(silence alert.Silence) error {
+	if !validSilenceID.MatchString(silence.ID) {
+		return fmt.Errorf("silence ID must contain only letters, numbers, '-', '.' and '_'. %q", silence.ID)
+	}
+	if err := silence.Validate(); err != nil {
 		return err
 	}
-
+	if err := s.silencesDAO.Create(s.convertSilenceFromAlert(silence)); err != nil {
+		return err
+	}
+	s.topics.SetSilence(silence)
+	return nil
+}
+
+func (s *Service) DeleteSilence(id string) error {
+	if err := s.silencesDAO.Delete(id); err != nil {
+		return err
+	}
+	s.topics.DeleteSilence(id)
+	return nil
+}
+
+func (s *Service) Silence(id string) (alert.Silence, bool, error) {
+	if err := s.deleteExpiredSilences(); err != nil {
+		return alert.Silence{}, false, err
+	}
+	silence, ok := s.topics.Silence(id)
+	return silence, ok, nil
+}
+
+func (s *Service) Silences() ([]alert.Silence, error) {
+	if err := s.deleteExpiredSilences(); err != nil {
+		return nil, err
+	}
+	return s.topics.Silences(), nil
+}
+
+func (s *Service) CreateInhibition(inhibition alert.Inhibition) error {
+	if !validInhibitionID.MatchString(inhibition.ID) {
+		return fmt.Errorf("inhibition ID must contain only letters, numbers, '-', '.' and '_'. %q", inhibition.ID)
+	}
+	if err := inhibition.Validate(); err != nil {
+		return err
+	}
+	if err := s.inhibitionsDAO.Create(s.convertInhibitionFromAlert(inhibition)); err != nil {
+		return err
+	}
+	s.topics.SetInhibition(inhibition)
+	return nil
+}
+
+func (s *Service) DeleteInhibition(id string) error {
+	if err := s.inhibitionsDAO.Delete(id); err != nil {
+		return err
+	}
+	s.topics.DeleteInhibition(id)
+	return nil
+}
+
+func (s *Service) Inhibition(id string) (alert.Inhibition, bool, error) {
+	inhibition, ok := s.topics.Inhibition(id)
+	return inhibition, ok, nil
+}
+
+func (s *Service) Inhibitions() ([]alert.Inhibition, error) {
+	return s.topics.Inhibitions(), nil
+}
+
+func (s *Service) CreateMaintenanceWindow(window alert.MaintenanceWindow) error {
+	if !validMaintenanceWindowID.MatchString(window.ID) {
+		return fmt.Errorf("maintenance window ID must contain only letters, numbers, '-', '.' and '_'. %q", window.ID)
+	}
+	if err := window.Validate(); err != nil {
+		return err
+	}
+	if err := s.maintenanceWindowsDAO.Create(s.convertMaintenanceWindowFromAlert(window)); err != nil {
+		return err
+	}
+	s.topics.SetMaintenanceWindow(window)
+	return nil
+}
+
+func (s *Service) DeleteMaintenanceWindow(id string) error {
+	if err := s.maintenanceWindowsDAO.Delete(id); err != nil {
+		return err
+	}
+	s.topics.DeleteMaintenanceWindow(id)
+	return nil
+}
+
+func (s *Service) MaintenanceWindow(id string) (alert.MaintenanceWindow, bool, error) {
+	window, ok := s.topics.MaintenanceWindow(id)
+	return window, ok, nil
+}
+
+func (s *Service) MaintenanceWindows() ([]alert.MaintenanceWindow, error) {
+	return s.topics.MaintenanceWindows(), nil
+}
+
+func (s *Service) SetOnCallSchedule(schedule alert.OnCallSchedule) error {
+	if !validOnCallScheduleID.MatchString(schedule.ID) {
+		return fmt.Errorf("on-call schedule ID must contain only letters, numbers, '-', '.' and '_'. %q", schedule.ID)
+	}
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	if err := s.onCallSchedulesDAO.Put(s.convertOnCallScheduleFromAlert(schedule)); err != nil {
+		return err
+	}
+	s.onCallMu.Lock()
+	s.onCallSchedules[schedule.ID] = schedule
+	s.onCallMu.Unlock()
+	return nil
+}
+
+func (s *Service) DeleteOnCallSchedule(id string) error {
+	if err := s.onCallSchedulesDAO.Delete(id); err != nil {
+		return err
+	}
+	s.onCallMu.Lock()
+	delete(s.onCallSchedules, id)
+	s.onCallMu.Unlock()
+	return nil
+}
+
+func (s *Service) OnCallSchedule(id string) (alert.OnCallSchedule, bool, error) {
+	s.onCallMu.RLock()
+	schedule, ok := s.onCallSchedules[id]
+	s.onCallMu.RUnlock()
+	return schedule, ok, nil
+}
+
+func (s *Service) OnCallSchedules() ([]alert.OnCallSchedule, error) {
+	s.onCallMu.RLock()
+	schedules := make([]alert.OnCallSchedule, 0, len(s.onCallSchedules))
+	for _, schedule := range s.onCallSchedules {
+		schedules = append(schedules, schedule)
+	}
+	s.onCallMu.RUnlock()
+	return schedules, nil
+}
+
+func (s *Service) SetRouting(routing alert.Routing) error {
+	if err := routing.Validate(); err != nil {
+		return err
+	}
+	if err := s.routingsDAO.Put(s.convertRoutingFromAlert(routing)); err != nil {
+		return err
+	}
+	s.topics.SetRouting(routing)
+	return nil
+}
+
+func (s *Service) DeleteRouting(topic string) error {
+	if err := s.routingsDAO.Delete(topic); err != nil {
+		return err
+	}
+	s.topics.DeleteRouting(topic)
+	return nil
+}
+
+func (s *Service) Routing(topic string) (alert.Routing, bool, error) {
+	routing, ok := s.topics.Routing(topic)
+	return routing, ok, nil
+}
+
+func (s *Service) RegisterAnonHandler(topic string, h alert.Handler) {
+	s.topics.RegisterHandler(topic, h)
+}
+
+func (s *Service) DeregisterAnonHandler(topic string, h alert.Handler) {
+	s.topics.DeregisterHandler(topic, h)
+	if c, ok := h.(closer); ok {
+		c.Close()
+	}
+}
+
+// loadHandlerSpec initializes a spec that already exists.
+// Caller must have the write lock.
+func (s *Service) loadHandlerSpec(spec HandlerSpec) error {
+	h, err := s.createHandlerFromSpec(spec)
+	if err != nil {
+		return err
+	}
+
 	s.setTopicHandler(spec.Topic, spec.ID, h)
 	s.topics.RegisterHandler(spec.Topic, h.Handler)
 	return nil
@@ -565,6 +1357,12 @@ func (s *Service) DeregisterHandlerSpec(topic, handler string) error {
 		if ha, ok := h.Handler.(closer); ok {
 			ha.Close()
 		}
+		if err := s.deleteEscalations(topic, handler); err != nil {
+			return err
+		}
+		if err := s.deleteOutbox(topic, handler); err != nil {
+			return err
+		}
 
 		delete(s.handlers[h.Spec.Topic], handler)
 	}
@@ -579,34 +1377,247 @@ func (s *Service) UpdateHandlerSpec(oldSpec, newSpec HandlerSpec) error {
 		return errors.New("cannot change topic in update")
 	}
 	topic := newSpec.Topic
-	newH, err := s.createHandlerFromSpec(newSpec)
-	if err != nil {
-		return err
-	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	oldH := s.handlers[topic][oldSpec.ID]
 
+	// Close the old handler before creating the new one,
+	// so the escalations restored by the new handler are not also escalated by the old handler.
+	if ha, ok := oldH.Handler.(closer); ok {
+		ha.Close()
+	}
+	newH, err := s.createHandlerFromSpec(newSpec)
+	if err != nil {
+		s.reopenHandlerSpec(oldH)
+		return err
+	}
+
 	// Persist new handler specs
 	if newSpec.ID == oldSpec.ID {
-		if err := s.specsDAO.Replace(newSpec); err != nil {
-			return err
-		}
+		err = s.specsDAO.Replace(newSpec)
 	} else {
-		if err := s.specsDAO.Create(newSpec); err != nil {
-			return err
+		err = s.specsDAO.Create(newSpec)
+		if err == nil {
+			err = s.specsDAO.Delete(oldSpec.Topic, oldSpec.ID)
 		}
-		if err := s.specsDAO.Delete(oldSpec.Topic, oldSpec.ID); err != nil {
-			return err
+	}
+	if err != nil {
+		if ha, ok := newH.Handler.(closer); ok {
+			ha.Close()
 		}
+		s.reopenHandlerSpec(oldH)
+		return err
 	}
 
 	delete(s.handlers[topic], oldSpec.ID)
 	s.setTopicHandler(newSpec.Topic, newSpec.ID, newH)
 
 	s.topics.ReplaceHandler(topic, oldH.Handler, newH.Handler)
+
+	if newSpec.ID != oldSpec.ID {
+		// The new handler does not resume escalations of the old handler, nor retry its deliveries.
+		if err := s.deleteEscalations(topic, oldSpec.ID); err != nil {
+			return err
+		}
+		if err := s.deleteOutbox(topic, oldSpec.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reopenHandlerSpec replaces a closed handler with a new handler of the same spec,
+// after an update of the handler failed.
+// Caller must have the write lock.
+func (s *Service) reopenHandlerSpec(old handler) {
+	if old.Handler == nil {
+		return
+	}
+	h, err := s.createHandlerFromSpec(old.Spec)
+	if err != nil {
+		s.logger.Printf("E! failed to reopen handler %q of topic %q: %v", old.Spec.ID, old.Spec.Topic, err)
+		return
+	}
+	s.setTopicHandler(old.Spec.Topic, old.Spec.ID, h)
+	s.topics.ReplaceHandler(old.Spec.Topic, old.Handler, h.Handler)
+}
+
+// TopicEscalations returns the pending escalations of events in the topic.
+func (s *Service) TopicEscalations(topic string) ([]Escalation, error) {
+	return s.listEscalations(topic, "*")
+}
+
+func (s *Service) listEscalations(topic, handlerPattern string) ([]Escalation, error) {
+	var escalations []Escalation
+	offset := 0
+	limit := 100
+	for {
+		list, err := s.escalationsDAO.List(topic, handlerPattern, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		escalations = append(escalations, list...)
+
+		offset += limit
+		if len(list) != limit {
+			break
+		}
+	}
+	return escalations, nil
+}
+
+// deleteEscalations deletes all pending escalations of a handler.
+func (s *Service) deleteEscalations(topic, handler string) error {
+	escalations, err := s.listEscalations(topic, handler)
+	if err != nil {
+		return err
+	}
+	for _, e := range escalations {
+		if err := s.escalationsDAO.Delete(e.Topic, e.Handler, e.EventID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newOutbox wraps the handler in an outbox that retries its failed deliveries.
+func (s *Service) newOutbox(topic, handler string, h alert.FallibleHandler) (*outboxHandler, error) {
+	o, err := newOutboxHandler(topic, handler, h, s.config, s.outboxDAO, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	id := fullID(topic, handler)
+	o.release = func(o *outboxHandler) {
+		s.outboxMu.Lock()
+		defer s.outboxMu.Unlock()
+		delete(s.outboxes[id], o)
+		if len(s.outboxes[id]) == 0 {
+			delete(s.outboxes, id)
+		}
+	}
+	s.outboxMu.Lock()
+	defer s.outboxMu.Unlock()
+	if s.outboxes[id] == nil {
+		s.outboxes[id] = make(map[*outboxHandler]bool)
+	}
+	s.outboxes[id][o] = true
+	return o, nil
+}
+
+// reloadOutboxes lets the live outboxes of the handler know its stored entries have changed.
+func (s *Service) reloadOutboxes(topic, handler string) {
+	s.outboxMu.Lock()
+	outboxes := make([]*outboxHandler, 0, len(s.outboxes[fullID(topic, handler)]))
+	for o := range s.outboxes[fullID(topic, handler)] {
+		outboxes = append(outboxes, o)
+	}
+	s.outboxMu.Unlock()
+	for _, o := range outboxes {
+		o.reload()
+	}
+}
+
+func (s *Service) listOutbox(topic, handler string) ([]OutboxEntry, error) {
+	var entries []OutboxEntry
+	offset := 0
+	limit := 100
+	for {
+		list, err := s.outboxDAO.List(topic, handler, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, list...)
+		offset += limit
+		if len(list) != limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func (s *Service) deleteOutbox(topic, handler string) error {
+	entries, err := s.listOutbox(topic, handler)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := s.outboxDAO.Delete(e.Topic, e.Handler, e.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandlerOutbox returns the entries of the outbox of the handler, both queued and dead letters, in order.
+func (s *Service) HandlerOutbox(topic, handler string) ([]OutboxEntry, error) {
+	return s.listOutbox(topic, handler)
+}
+
+// ReplayDeadLetter queues a dead letter of the handler to be delivered again.
+func (s *Service) ReplayDeadLetter(topic, handler, id string) error {
+	e, err := s.outboxDAO.Get(topic, handler, id)
+	if err != nil {
+		return err
+	}
+	if !e.Dead {
+		return fmt.Errorf("outbox entry %s is not a dead letter", id)
+	}
+	if err := s.replay(e); err != nil {
+		return err
+	}
+	s.reloadOutboxes(topic, handler)
+	return nil
+}
+
+// ReplayDeadLetters queues all dead letters of the handler to be delivered again.
+// It returns the number of replayed dead letters.
+func (s *Service) ReplayDeadLetters(topic, handler string) (int, error) {
+	entries, err := s.listOutbox(topic, handler)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range entries {
+		if !e.Dead {
+			continue
+		}
+		if err := s.replay(e); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if n > 0 {
+		s.reloadOutboxes(topic, handler)
+	}
+	return n, nil
+}
+
+// replay resets the dead letter so that it is retried for another max age.
+func (s *Service) replay(e OutboxEntry) error {
+	now := time.Now()
+	e.Dead = false
+	e.Attempts = 0
+	e.LastError = ""
+	e.Queued = now
+	e.Next = now
+	return s.outboxDAO.Put(e)
+}
+
+// DeleteDeadLetter discards a dead letter of the handler.
+func (s *Service) DeleteDeadLetter(topic, handler, id string) error {
+	e, err := s.outboxDAO.Get(topic, handler, id)
+	if err != nil {
+		return err
+	}
+	if !e.Dead {
+		return fmt.Errorf("outbox entry %s is not a dead letter", id)
+	}
+	if err := s.outboxDAO.Delete(topic, handler, id); err != nil {
+		return err
+	}
+	s.reloadOutboxes(topic, handler)
 	return nil
 }
 
@@ -714,7 +1725,8 @@ func decodeStringToTextUnmarshaler(f, t reflect.Type, data interface{}) (interfa
 	return data, nil
 }
 
-func (s *Service) createHandlerFromSpec(spec HandlerSpec) (handler, error) {
+// createKindHandler creates the handler of the kind of the spec from its options.
+func (s *Service) createKindHandler(spec HandlerSpec) (alert.Handler, error) {
 	var h alert.Handler
 	var err error
 	switch spec.Kind {
@@ -722,172 +1734,290 @@ func (s *Service) createHandlerFromSpec(spec HandlerSpec) (handler, error) {
 		c := newDefaultAggregateHandlerConfig(s.EventCollector)
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h, err = NewAggregateHandler(c, s.logger)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 	case "alerta":
 		c := s.AlertaService.DefaultHandlerConfig()
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h, err = s.AlertaService.Handler(c, s.logger)
 		if err != nil {
-			return handler{}, err
+			return nil, err
+		}
+		h = NewExternalHandler(h)
+	case "alertmanager":
+		c := alertmanager.HandlerConfig{}
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return nil, err
+		}
+		h = s.AlertManagerService.Handler(c, s.logger)
+		h = NewExternalHandler(h)
+	case "amqp":
+		c := amqp.HandlerConfig{}
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return nil, err
+		}
+		h, err = s.AMQPService.Handler(c, s.logger)
+		if err != nil {
+			return nil, err
+		}
+		h = NewExternalHandler(h)
+	case "correlate":
+		c := newDefaultCorrelateHandlerConfig(s.EventCollector)
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return nil, err
+		}
+		h, err = NewCorrelateHandler(c, s.logger)
+		if err != nil {
+			return nil, err
+		}
+	case "discord":
+		c := discord.HandlerConfig{}
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return nil, err
+		}
+		h = s.DiscordService.Handler(c, s.logger)
+		h = NewExternalHandler(h)
+	case "escalate":
+		c := newDefaultEscalateHandlerConfig(spec.Topic, spec.ID, s.EventCollector, s, s.escalationsDAO)
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return nil, err
+		}
+		h, err = NewEscalateHandler(c, s.logger)
+		if err != nil {
+			return nil, err
 		}
-		h = newExternalHandler(h)
 	case "exec":
 		c := ExecHandlerConfig{
 			Commander: s.Commander,
 		}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = NewExecHandler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "hipchat":
 		c := hipchat.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = s.HipChatService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "log":
 		c := DefaultLogHandlerConfig()
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h, err = NewLogHandler(c, s.logger)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "mqtt":
 		c := mqtt.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = s.MQTTService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "opsgenie":
 		c := opsgenie.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = s.OpsGenieService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "pagerduty":
 		c := pagerduty.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = s.PagerDutyService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
+	case "pagerduty2":
+		c := pagerduty2.HandlerConfig{}
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return nil, err
+		}
+		h = s.PagerDuty2Service.Handler(c, s.logger)
+		h = NewExternalHandler(h)
 	case "pushover":
 		c := pushover.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = s.PushoverService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "post":
 		c := httppost.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
-		h = s.HTTPPostService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h, err = s.HTTPPostService.Handler(c, s.logger)
+		if err != nil {
+			return nil, err
+		}
+		h = NewExternalHandler(h)
 	case "publish":
 		c := PublishHandlerConfig{
 			ec: s.EventCollector,
 		}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = NewPublishHandler(c, s.logger)
 	case "sensu":
 		c := sensu.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h, err = s.SensuService.Handler(c, s.logger)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "slack":
 		c := slack.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = s.SlackService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "smtp":
 		c := smtp.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
+		}
+		h, err = s.SMTPService.Handler(c, s.logger)
+		if err != nil {
+			return nil, err
 		}
-		h = s.SMTPService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "snmptrap":
 		c := snmptrap.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h, err = s.SNMPTrapService.Handler(c, s.logger)
 		if err != nil {
-			return handler{}, err
+			return nil, err
+		}
+		h = NewExternalHandler(h)
+	case "syslog":
+		c := DefaultSyslogHandlerConfig()
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return nil, err
+		}
+		h, err = NewSyslogHandler(c, s.logger)
+		if err != nil {
+			return nil, err
 		}
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "talk":
 		h = s.TalkService.Handler(s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
+	case "teams":
+		c := teams.HandlerConfig{}
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return nil, err
+		}
+		h = s.TeamsService.Handler(c, s.logger)
+		h = NewExternalHandler(h)
+	case "throttle":
+		c := newDefaultThrottleHandlerConfig(spec.Topic, s.EventCollector)
+		err = decodeOptions(spec.Options, &c)
+		if err != nil {
+			return nil, err
+		}
+		h, err = NewThrottleHandler(c, s.logger)
+		if err != nil {
+			return nil, err
+		}
 	case "tcp":
 		c := TCPHandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = NewTCPHandler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "telegram":
 		c := telegram.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = s.TelegramService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	case "victorops":
 		c := victorops.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
 		if err != nil {
-			return handler{}, err
+			return nil, err
 		}
 		h = s.VictorOpsService.Handler(c, s.logger)
-		h = newExternalHandler(h)
+		h = NewExternalHandler(h)
 	default:
 		err = fmt.Errorf("unsupported action kind %q", spec.Kind)
 	}
-	if spec.Match != "" {
+	if err != nil {
+		return nil, err
+	}
+	// Retry the failed deliveries of external handlers that report them
+	if eh, ok := h.(*externalHandler); ok {
+		if fh, ok := eh.h.(alert.FallibleHandler); ok {
+			eh.h, err = s.newOutbox(spec.Topic, spec.ID, fh)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return h, nil
+}
+
+func (s *Service) createHandlerFromSpec(spec HandlerSpec) (handler, error) {
+	var h alert.Handler
+	var err error
+	if usesOnCall(spec) {
+		// Resolve the on-call recipients each time an event is handled
+		h, err = newOnCallHandler(spec, s, s.createKindHandler, s.logger)
+	} else {
+		h, err = s.createKindHandler(spec)
+	}
+	if spec.Match != "" && err == nil {
 		// Wrap handler in match handler
 		h, err = newMatchHandler(spec.Match, h, s.logger)
 	}
+	if err == nil {
+		// Wrap handler so it only handles the events routed to it
+		h = newRoutedHandler(spec.ID, h)
+	}
 	return handler{Spec: spec, Handler: h}, err
 }
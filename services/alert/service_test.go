@@ -0,0 +1,316 @@
+package alert_test
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	alertservice "github.com/influxdata/kapacitor/services/alert"
+	"github.com/influxdata/kapacitor/services/httpd/httpdtest"
+	"github.com/influxdata/kapacitor/services/storage/storagetest"
+)
+
+func newService(t *testing.T) (*alertservice.Service, *httpdtest.Server) {
+	s := alertservice.NewService(alertservice.NewConfig(), log.New(os.Stderr, "[alert] ", log.LstdFlags))
+	s.StorageService = storagetest.New()
+	server := httpdtest.NewServer(testing.Verbose())
+	s.HTTPDService = server
+	if err := s.Open(); err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return s, server
+}
+
+func TestService_EventHistory(t *testing.T) {
+	s, server := newService(t)
+	defer server.Close()
+	defer s.Close()
+
+	// The history spans the epoch, to check times before it are ordered.
+	start := time.Date(1969, 12, 31, 23, 30, 0, 0, time.UTC)
+	for i := 0; i < 60; i++ {
+		// Alternate the level so every event is a transition.
+		level := alert.Warning
+		if i%2 == 1 {
+			level = alert.Critical
+		}
+		if err := s.Collect(alert.Event{
+			Topic: "t",
+			State: alert.EventState{
+				ID:    "e",
+				Level: level,
+				Time:  start.Add(time.Duration(i) * time.Minute),
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Unchanged levels are not transitions.
+	if err := s.Collect(alert.Event{
+		Topic: "t",
+		State: alert.EventState{
+			ID:    "e",
+			Level: alert.Critical,
+			Time:  start.Add(time.Hour),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := s.EventHistory("t", "e", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the last 50 transitions are kept.
+	if got, exp := len(history), 50; got != exp {
+		t.Fatalf("unexpected number of transitions: got %d exp %d", got, exp)
+	}
+	for i, state := range history {
+		if exp := start.Add(time.Duration(i+10) * time.Minute); !state.Time.Equal(exp) {
+			t.Errorf("unexpected time of transition %d: got %v exp %v", i, state.Time, exp)
+		}
+	}
+
+	history, err = s.EventHistory("t", "e", start.Add(20*time.Minute), start.Add(40*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := len(history), 20; got != exp {
+		t.Fatalf("unexpected number of transitions in range: got %d exp %d", got, exp)
+	}
+	if exp := start.Add(20 * time.Minute); !history[0].Time.Equal(exp) {
+		t.Errorf("unexpected time of first transition in range: got %v exp %v", history[0].Time, exp)
+	}
+	if exp := start.Add(39 * time.Minute); !history[19].Time.Equal(exp) {
+		t.Errorf("unexpected time of last transition in range: got %v exp %v", history[19].Time, exp)
+	}
+
+	if history, err := s.EventHistory("t", "other", time.Time{}, time.Time{}); err != nil {
+		t.Fatal(err)
+	} else if len(history) != 0 {
+		t.Errorf("unexpected history of another event: %v", history)
+	}
+}
+
+func TestService_EventHistory_Concurrent(t *testing.T) {
+	s, server := newService(t)
+	defer server.Close()
+	defer s.Close()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := s.Collect(alert.Event{
+				Topic: "t",
+				State: alert.EventState{
+					ID:    "e",
+					Level: alert.Critical,
+					Time:  start.Add(time.Duration(i) * time.Second),
+				},
+			}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Only the first collect changed the level.
+	history, err := s.EventHistory("t", "e", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := len(history), 1; got != exp {
+		t.Errorf("unexpected number of transitions: got %d exp %d", got, exp)
+	}
+}
+
+// recordingHandler sends the events it handles on a channel.
+type recordingHandler chan alert.Event
+
+func (h recordingHandler) Handle(event alert.Event) {
+	h <- event
+}
+
+// receive returns the events received until the event with the given ID.
+func (h recordingHandler) receive(t *testing.T, id string) []alert.Event {
+	var events []alert.Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-h:
+			if e.State.ID == id {
+				return events
+			}
+			events = append(events, e)
+		case <-timeout:
+			t.Fatalf("timed out waiting for event %q, got %v", id, events)
+		}
+	}
+}
+
+// next returns the next event received.
+func (h recordingHandler) next(t *testing.T) alert.Event {
+	select {
+	case e := <-h:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return alert.Event{}
+}
+
+func TestService_Throttle_Recovery(t *testing.T) {
+	s, server := newService(t)
+	defer server.Close()
+	defer s.Close()
+
+	if err := s.RegisterHandlerSpec(alertservice.HandlerSpec{
+		ID:    "throttle",
+		Topic: "src",
+		Kind:  "throttle",
+		Options: map[string]interface{}{
+			"rate":     1,
+			"interval": time.Hour,
+			"topic":    "dst",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h := make(recordingHandler, 10)
+	s.RegisterAnonHandler("dst", h)
+
+	now := time.Now()
+	levels := []alert.Level{alert.Critical, alert.Critical, alert.Critical, alert.OK, alert.OK}
+	for i, level := range levels {
+		if err := s.Collect(alert.Event{
+			Topic: "src",
+			State: alert.EventState{
+				ID:    "e",
+				Level: level,
+				Time:  now.Add(time.Duration(i) * time.Second),
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Events are handled in order, so once the first event of another ID
+	// is published all the events before it have been throttled or published.
+	if err := s.Collect(alert.Event{
+		Topic: "src",
+		State: alert.EventState{
+			ID:    "done",
+			Level: alert.Critical,
+			Time:  now.Add(time.Duration(len(levels)) * time.Second),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	events := h.receive(t, "done")
+
+	// Level changes are never throttled, the first repeat takes the only token
+	// and the other repeats are throttled.
+	exp := []alert.Level{alert.Critical, alert.Critical, alert.OK}
+	if got := len(events); got != len(exp) {
+		t.Fatalf("unexpected number of events: got %d exp %d", got, len(exp))
+	}
+	for i, e := range events {
+		if e.State.Level != exp[i] {
+			t.Errorf("unexpected level of event %d: got %v exp %v", i, e.State.Level, exp[i])
+		}
+	}
+	if state, ok, err := s.EventState("dst", "e"); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Fatal("expected event state on throttled topic")
+	} else if state.Level != alert.OK {
+		t.Errorf("unexpected level on throttled topic: got %v exp %v", state.Level, alert.OK)
+	}
+}
+
+func TestService_Escalate_Recovery(t *testing.T) {
+	s, server := newService(t)
+	defer server.Close()
+	defer s.Close()
+
+	if err := s.RegisterHandlerSpec(alertservice.HandlerSpec{
+		ID:    "escalate",
+		Topic: "src",
+		Kind:  "escalate",
+		Options: map[string]interface{}{
+			"steps": []map[string]interface{}{
+				{"after": 0, "topic": "pager"},
+				{"after": 0, "topic": "phone"},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	pager := make(recordingHandler, 10)
+	s.RegisterAnonHandler("pager", pager)
+	phone := make(recordingHandler, 10)
+	s.RegisterAnonHandler("phone", phone)
+
+	now := time.Now()
+	if err := s.Collect(alert.Event{
+		Topic: "src",
+		State: alert.EventState{
+			ID:    "e",
+			Level: alert.Critical,
+			Time:  now,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for name, h := range map[string]recordingHandler{"pager": pager, "phone": phone} {
+		if e := h.next(t); e.State.ID != "e" || e.State.Level != alert.Critical {
+			t.Errorf("unexpected escalated event on %s: %v", name, e.State)
+		}
+	}
+
+	// The completed escalation is kept until the event recovers.
+	escalations, err := s.TopicEscalations("src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(escalations) != 1 {
+		t.Fatalf("unexpected number of escalations: got %d exp 1", len(escalations))
+	}
+	if got, exp := escalations[0].Published, []string{"pager", "phone"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected published topics: got %v exp %v", got, exp)
+	}
+
+	if err := s.Collect(alert.Event{
+		Topic: "src",
+		State: alert.EventState{
+			ID:    "e",
+			Level: alert.OK,
+			Time:  now.Add(time.Minute),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for name, h := range map[string]recordingHandler{"pager": pager, "phone": phone} {
+		if e := h.next(t); e.State.ID != "e" || e.State.Level != alert.OK {
+			t.Errorf("unexpected recovery on %s: %v", name, e.State)
+		}
+		if state, ok, err := s.EventState(name, "e"); err != nil {
+			t.Fatal(err)
+		} else if !ok || state.Level != alert.OK {
+			t.Errorf("unexpected state on %s: %v", name, state)
+		}
+	}
+
+	if escalations, err := s.TopicEscalations("src"); err != nil {
+		t.Fatal(err)
+	} else if len(escalations) != 0 {
+		t.Errorf("unexpected escalations after recovery: %v", escalations)
+	}
+}
@@ -1,6 +1,10 @@
 package alert
 
-import "github.com/influxdata/kapacitor/alert"
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
 
 // HandlerSpecRegistrar is responsible for registering and persisting handler spec definitions.
 type HandlerSpecRegistrar interface {
@@ -30,6 +34,96 @@ type Topics interface {
 	EventStates(topic string, minLevel alert.Level) (map[string]alert.EventState, error)
 }
 
+// EventAcknowledger is responsible for acknowledging events.
+type EventAcknowledger interface {
+	// AcknowledgeEvent acknowledges the current state of the event.
+	// Handlers skip the event until its level changes.
+	AcknowledgeEvent(topic, event string, ack alert.Acknowledgement) (alert.EventState, bool, error)
+}
+
+// Silencer is responsible for managing and persisting silences.
+type Silencer interface {
+	// CreateSilence saves the silence and starts silencing matching events.
+	CreateSilence(silence alert.Silence) error
+	// DeleteSilence deletes the silence.
+	DeleteSilence(id string) error
+	// Silence returns a silence.
+	Silence(id string) (alert.Silence, bool, error)
+	// Silences returns all silences.
+	Silences() ([]alert.Silence, error)
+}
+
+// Inhibitor is responsible for managing and persisting inhibitions.
+type Inhibitor interface {
+	// CreateInhibition saves the inhibition and starts inhibiting matching events.
+	CreateInhibition(inhibition alert.Inhibition) error
+	// DeleteInhibition deletes the inhibition.
+	DeleteInhibition(id string) error
+	// Inhibition returns an inhibition.
+	Inhibition(id string) (alert.Inhibition, bool, error)
+	// Inhibitions returns all inhibitions.
+	Inhibitions() ([]alert.Inhibition, error)
+}
+
+// EventHistory is responsible for reporting the history of events.
+type EventHistory interface {
+	// EventHistory returns the states of the event when its level changed between start and stop, in time order.
+	// Zero start or stop times leave the range open.
+	EventHistory(topic, event string, start, stop time.Time) ([]alert.EventState, error)
+}
+
+// Escalations is responsible for reporting pending escalations.
+type Escalations interface {
+	// TopicEscalations returns the pending escalations of events in the topic.
+	TopicEscalations(topic string) ([]Escalation, error)
+}
+
+// MaintenanceScheduler is responsible for managing and persisting maintenance windows.
+type MaintenanceScheduler interface {
+	// CreateMaintenanceWindow saves the window and suppresses external handling of matching events while it is active.
+	CreateMaintenanceWindow(window alert.MaintenanceWindow) error
+	// DeleteMaintenanceWindow deletes the maintenance window.
+	DeleteMaintenanceWindow(id string) error
+	// MaintenanceWindow returns a maintenance window.
+	MaintenanceWindow(id string) (alert.MaintenanceWindow, bool, error)
+	// MaintenanceWindows returns all maintenance windows.
+	MaintenanceWindows() ([]alert.MaintenanceWindow, error)
+}
+
+// OnCallScheduler is responsible for managing and persisting on-call schedules.
+type OnCallScheduler interface {
+	// SetOnCallSchedule saves the schedule, replacing any existing schedule with the same ID.
+	SetOnCallSchedule(schedule alert.OnCallSchedule) error
+	// DeleteOnCallSchedule deletes the on-call schedule.
+	DeleteOnCallSchedule(id string) error
+	// OnCallSchedule returns an on-call schedule.
+	OnCallSchedule(id string) (alert.OnCallSchedule, bool, error)
+	// OnCallSchedules returns all on-call schedules.
+	OnCallSchedules() ([]alert.OnCallSchedule, error)
+}
+
+// Outboxes is responsible for inspecting the deliveries handlers are retrying and replaying their dead letters.
+type Outboxes interface {
+	// HandlerOutbox returns the entries of the outbox of the handler in order.
+	HandlerOutbox(topic, handler string) ([]OutboxEntry, error)
+	// ReplayDeadLetter queues a dead letter of the handler to be delivered again.
+	ReplayDeadLetter(topic, handler, id string) error
+	// ReplayDeadLetters queues all dead letters of the handler to be delivered again, returning how many were replayed.
+	ReplayDeadLetters(topic, handler string) (int, error)
+	// DeleteDeadLetter discards a dead letter of the handler.
+	DeleteDeadLetter(topic, handler, id string) error
+}
+
+// TopicRouter is responsible for managing and persisting the routing of topics.
+type TopicRouter interface {
+	// SetRouting saves the routing of its topic, replacing any existing routing.
+	SetRouting(routing alert.Routing) error
+	// DeleteRouting deletes the routing of the topic, so that all of its handlers receive all events again.
+	DeleteRouting(topic string) error
+	// Routing returns the routing of the topic.
+	Routing(topic string) (alert.Routing, bool, error)
+}
+
 // AnonHandlerRegistrar is responsible for directly registering handlers for anonymous topics.
 // This is to be used only when the origin of the handler is not defined by a handler spec.
 type AnonHandlerRegistrar interface {
@@ -0,0 +1,96 @@
+package alertmanager
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/influxdata/kapacitor/listmap"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultURL            = "http://localhost:9093/api/v1/alerts"
+	DefaultResendInterval = time.Minute
+)
+
+type Config struct {
+	// Whether Alertmanager integration is enabled.
+	Enabled bool `toml:"enabled" override:"enabled"`
+	// Workspace is the name of the configuration, handlers select it by name.
+	Workspace string `toml:"workspace" override:"workspace"`
+	// Whether this is the configuration used when handlers do not select a workspace.
+	// Only needed if there is more than one configuration.
+	Default bool `toml:"default" override:"default"`
+	// The URL of the alerts endpoint of the Alertmanager API.
+	URL string `toml:"url" override:"url"`
+	// How often the active alerts are resent to Alertmanager.
+	// Alertmanager resolves alerts that are not resent,
+	// active alerts are sent with an end time of four resend intervals in the future.
+	ResendInterval toml.Duration `toml:"resend-interval" override:"resend-interval"`
+	// Whether every alert should automatically go to Alertmanager.
+	Global bool `toml:"global" override:"global"`
+}
+
+func NewConfig() Config {
+	c := Config{}
+	c.Init()
+	return c
+}
+
+// Init sets the default values of the configuration.
+func (c *Config) Init() {
+	c.URL = DefaultURL
+	c.ResendInterval = toml.Duration(DefaultResendInterval)
+}
+
+func (c Config) Validate() error {
+	if c.Enabled && c.URL == "" {
+		return errors.New("must specify url")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return errors.Wrapf(err, "invalid URL %q", c.URL)
+	}
+	if c.ResendInterval <= 0 {
+		return errors.New("resend-interval must be positive")
+	}
+	return nil
+}
+
+// Configs is the configuration for all [[alertmanager]] sections of the kapacitor
+// configuration file. A single [alertmanager] section is also accepted.
+type Configs []Config
+
+func (cs *Configs) UnmarshalTOML(data interface{}) error {
+	return listmap.DoUnmarshalTOML(cs, data)
+}
+
+// Validate calls config.Validate for each element in Configs
+// and checks that the workspaces are unique and there is a single default.
+func (cs Configs) Validate() error {
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return listmap.ValidateNames("workspace", len(cs), cs.workspace)
+}
+
+// workspace returns the workspace of the config i and whether it is the default.
+func (cs Configs) workspace(i int) (string, bool) {
+	return cs[i].Workspace, cs[i].Default
+}
+
+// index generates a map of configs by workspace
+func (cs Configs) index() map[string]Config {
+	m := make(map[string]Config, len(cs))
+	for _, c := range cs {
+		m[c.Workspace] = c
+	}
+	return m
+}
+
+// defaultWorkspace returns the workspace used when handlers do not select one.
+func (cs Configs) defaultWorkspace() string {
+	return listmap.DefaultName(len(cs), cs.workspace)
+}
@@ -0,0 +1,448 @@
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+const (
+	// Labels set on every alert, they take precedence over tags and handler labels of the same name.
+	alertNameLabel = "alertname"
+	levelLabel     = "level"
+	taskLabel      = "task"
+	topicLabel     = "topic"
+
+	messageAnnotation = "message"
+	detailsAnnotation = "details"
+
+	// resendPeriods is the number of resend intervals an active alert is valid for,
+	// so that Alertmanager resolves it only after several resends were missed.
+	resendPeriods = 4
+)
+
+type Service struct {
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
+
+	activeMu sync.Mutex
+	// active are the firing alerts of the handlers, they are resent until they are resolved.
+	active map[activeKey]*activeAlert
+	// wake notifies the resend loop that an alert was activated.
+	wake    chan struct{}
+	closing chan struct{}
+	wg      sync.WaitGroup
+
+	HTTPDService interface {
+		URL() string
+	}
+	logger *log.Logger
+}
+
+// activeKey identifies an alert of a handler.
+type activeKey struct {
+	h  *handler
+	id string
+}
+
+type activeAlert struct {
+	workspace string
+	alert     postableAlert
+	// sent is when the alert was last sent.
+	sent time.Time
+}
+
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		active:           make(map[activeKey]*activeAlert),
+		wake:             make(chan struct{}, 1),
+		logger:           l,
+	}
+}
+
+func (s *Service) Open() error {
+	s.closing = make(chan struct{})
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+func (s *Service) Close() error {
+	if s.closing != nil {
+		close(s.closing)
+		s.wg.Wait()
+		s.closing = nil
+	}
+	return nil
+}
+
+// config returns the configuration of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown Alertmanager workspace %q", workspace)
+	}
+	return c, nil
+}
+
+// defaultConfig returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	c, _ := s.config("")
+	return c
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
+	}
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
+	s.mu.Unlock()
+	// The resend intervals may have changed.
+	s.notify()
+	return nil
+}
+
+func (s *Service) Global() bool {
+	c := s.defaultConfig()
+	return c.Global
+}
+
+type testOptions struct {
+	Workspace string            `json:"workspace"`
+	AlertID   string            `json:"alert-id"`
+	Message   string            `json:"message"`
+	Level     alert.Level       `json:"level"`
+	Labels    map[string]string `json:"labels"`
+}
+
+func (s *Service) TestOptions() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &testOptions{
+		Workspace: s.defaultWorkspace,
+		AlertID:   "testAlertID",
+		Message:   "test alertmanager message",
+		Level:     alert.Critical,
+	}
+}
+
+func (s *Service) Test(options interface{}) error {
+	o, ok := options.(*testOptions)
+	if !ok {
+		return fmt.Errorf("unexpected options type %T", options)
+	}
+	c, err := s.config(o.Workspace)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	a := s.newAlert(alert.Event{
+		State: alert.EventState{
+			ID:      o.AlertID,
+			Message: o.Message,
+			Level:   o.Level,
+			Time:    now,
+		},
+	}, o.Labels)
+	a.EndsAt = now.Add(time.Duration(c.ResendInterval))
+	return s.post(o.Workspace, []postableAlert{a})
+}
+
+// postableAlert is an alert of an Alertmanager API request.
+type postableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// newAlert converts the event into an Alertmanager alert without an end time.
+// The labels are the tags of the event and the extra labels, plus the alert ID as the alertname
+// and the level, task and topic of the event. The alert starts when the event entered its state.
+func (s *Service) newAlert(event alert.Event, labels map[string]string) postableAlert {
+	ls := make(map[string]string, len(event.Data.Tags)+len(labels)+4)
+	for k, v := range event.Data.Tags {
+		ls[labelName(k)] = v
+	}
+	for k, v := range labels {
+		ls[labelName(k)] = v
+	}
+	ls[alertNameLabel] = event.State.ID
+	ls[levelLabel] = event.State.Level.String()
+	if event.Data.TaskName != "" {
+		ls[taskLabel] = event.Data.TaskName
+	}
+	if event.Topic != "" {
+		ls[topicLabel] = event.Topic
+	}
+
+	annotations := make(map[string]string, 2)
+	if event.State.Message != "" {
+		annotations[messageAnnotation] = event.State.Message
+	}
+	if event.State.Details != "" {
+		annotations[detailsAnnotation] = event.State.Details
+	}
+
+	a := postableAlert{
+		Labels:      ls,
+		Annotations: annotations,
+		StartsAt:    event.State.Time.Add(-event.State.Duration),
+	}
+	if s.HTTPDService != nil && event.Data.TaskName != "" {
+		if u := s.HTTPDService.URL(); u != "" {
+			a.GeneratorURL = u + "/tasks/" + event.Data.TaskName
+		}
+	}
+	return a
+}
+
+// labelName replaces the characters of name that are not valid in a Prometheus label name.
+func labelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// withLevel returns a copy of the alert with the level label set to level.
+func withLevel(a postableAlert, level alert.Level) postableAlert {
+	ls := make(map[string]string, len(a.Labels))
+	for k, v := range a.Labels {
+		ls[k] = v
+	}
+	ls[levelLabel] = level.String()
+	a.Labels = ls
+	return a
+}
+
+// update tracks the alert of the handler and returns the alerts to send for it.
+// Alertmanager identifies alerts by their labels, so when the labels change, i.e. the level,
+// the previous alert is sent resolved at the time of the event. An OK alert only resolves the previous alert.
+func (s *Service) update(key activeKey, workspace string, a postableAlert, level alert.Level, t, now time.Time, interval time.Duration) []postableAlert {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+
+	var alerts []postableAlert
+	prev, ok := s.active[key]
+	if ok && (level == alert.OK || !reflect.DeepEqual(prev.alert.Labels, a.Labels)) {
+		resolved := prev.alert
+		resolved.Annotations = a.Annotations
+		resolved.EndsAt = t
+		alerts = append(alerts, resolved)
+		delete(s.active, key)
+	}
+	if level == alert.OK {
+		if !ok {
+			// The firing level is not known, i.e. after a restart, so resolve all of them.
+			for _, l := range []alert.Level{alert.Info, alert.Warning, alert.Critical} {
+				resolved := withLevel(a, l)
+				resolved.EndsAt = t
+				alerts = append(alerts, resolved)
+			}
+		}
+		return alerts
+	}
+
+	a.EndsAt = now.Add(resendPeriods * interval)
+	s.active[key] = &activeAlert{
+		workspace: workspace,
+		alert:     a,
+		sent:      now,
+	}
+	s.notify()
+	return append(alerts, a)
+}
+
+// deactivate stops resending the alerts of the handler.
+func (s *Service) deactivate(h *handler) {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	for key := range s.active {
+		if key.h == h {
+			delete(s.active, key)
+		}
+	}
+}
+
+// notify wakes the resend loop, it does not block.
+func (s *Service) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run resends the active alerts every resend interval of their workspace.
+func (s *Service) run() {
+	defer s.wg.Done()
+	for {
+		var timer *time.Timer
+		var due <-chan time.Time
+		if next, ok := s.resend(time.Now()); ok {
+			timer = time.NewTimer(next.Sub(time.Now()))
+			due = timer.C
+		}
+		select {
+		case <-due:
+		case <-s.wake:
+		case <-s.closing:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// resend sends the active alerts that are due and returns when the next alert is due.
+func (s *Service) resend(now time.Time) (time.Time, bool) {
+	due := make(map[string][]postableAlert)
+	var next time.Time
+	s.activeMu.Lock()
+	for _, a := range s.active {
+		interval := DefaultResendInterval
+		if c, err := s.config(a.workspace); err == nil {
+			interval = time.Duration(c.ResendInterval)
+		}
+		at := a.sent.Add(interval)
+		if !at.After(now) {
+			a.sent = now
+			a.alert.EndsAt = now.Add(resendPeriods * interval)
+			due[a.workspace] = append(due[a.workspace], a.alert)
+			at = now.Add(interval)
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	s.activeMu.Unlock()
+
+	for workspace, alerts := range due {
+		if err := s.post(workspace, alerts); err != nil {
+			s.logger.Println("E! failed to resend alerts to Alertmanager", err)
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// post sends the alerts to the Alertmanager of the workspace, or of the default workspace if empty.
+func (s *Service) post(workspace string, alerts []postableAlert) error {
+	c, err := s.config(workspace)
+	if err != nil {
+		return err
+	}
+	if !c.Enabled {
+		return errors.New("service is not enabled")
+	}
+
+	var post bytes.Buffer
+	enc := json.NewEncoder(&post)
+	if err := enc.Encode(alerts); err != nil {
+		return err
+	}
+
+	resp, err := http.Post(c.URL, "application/json", &post)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		type response struct {
+			Error string `json:"error"`
+		}
+		r := &response{Error: fmt.Sprintf("failed to understand Alertmanager response. code: %d content: %s", resp.StatusCode, string(body))}
+		b := bytes.NewReader(body)
+		dec := json.NewDecoder(b)
+		dec.Decode(r)
+		return errors.New(r.Error)
+	}
+	return nil
+}
+
+type HandlerConfig struct {
+	// Workspace is the name of the Alertmanager configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
+	// Labels added to the labels of the alerts.
+	Labels map[string]string `mapstructure:"labels"`
+}
+
+type handler struct {
+	s      *Service
+	c      HandlerConfig
+	logger *log.Logger
+}
+
+func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
+	return &handler{
+		s:      s,
+		c:      c,
+		logger: l,
+	}
+}
+
+func (h *handler) Handle(event alert.Event) {
+	if err := h.TryHandle(event); err != nil {
+		h.logger.Println("E! failed to send event to Alertmanager", err)
+	}
+}
+
+func (h *handler) TryHandle(event alert.Event) error {
+	c, err := h.s.config(h.c.Workspace)
+	if err != nil {
+		return err
+	}
+	if !c.Enabled {
+		return errors.New("service is not enabled")
+	}
+	a := h.s.newAlert(event, h.c.Labels)
+	key := activeKey{h: h, id: event.State.ID}
+	alerts := h.s.update(key, h.c.Workspace, a, event.State.Level, event.State.Time, time.Now(), time.Duration(c.ResendInterval))
+	return h.s.post(h.c.Workspace, alerts)
+}
+
+// Close stops resending the alerts of the handler.
+func (h *handler) Close() {
+	h.s.deactivate(h)
+}
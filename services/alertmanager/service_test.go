@@ -0,0 +1,102 @@
+package alertmanager_test
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/services/alertmanager"
+	"github.com/influxdata/kapacitor/services/alertmanager/alertmanagertest"
+)
+
+func TestService_Resend(t *testing.T) {
+	ts := alertmanagertest.NewServer()
+	defer ts.Close()
+
+	c := alertmanager.NewConfig()
+	c.Enabled = true
+	c.URL = ts.URL
+	c.ResendInterval = toml.Duration(10 * time.Millisecond)
+	s := alertmanager.NewService(alertmanager.Configs{c}, log.New(os.Stderr, "[alertmanager] ", log.LstdFlags))
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	h := s.Handler(alertmanager.HandlerConfig{}, log.New(os.Stderr, "[alertmanager] ", log.LstdFlags))
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.Handle(alert.Event{
+		State: alert.EventState{
+			ID:    "id",
+			Level: alert.Critical,
+			Time:  start,
+		},
+	})
+
+	// Wait for the alert to be resent.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(ts.Requests()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("alert was not resent, got %d requests", len(ts.Requests()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i, r := range ts.Requests() {
+		if len(r.PostData) != 1 {
+			t.Fatalf("request %d: unexpected number of alerts: got %d exp 1", i, len(r.PostData))
+		}
+		if got, exp := r.PostData[0].Labels["level"], "CRITICAL"; got != exp {
+			t.Errorf("request %d: unexpected level label: got %s exp %s", i, got, exp)
+		}
+		if !r.PostData[0].EndsAt.After(time.Now()) {
+			t.Errorf("request %d: active alert ends in the past: %v", i, r.PostData[0].EndsAt)
+		}
+	}
+
+	// Changing the level resolves the alert of the previous level.
+	changed := start.Add(time.Minute)
+	h.Handle(alert.Event{
+		State: alert.EventState{
+			ID:       "id",
+			Level:    alert.Warning,
+			Time:     changed,
+			Duration: time.Minute,
+		},
+	})
+	var levelChange alertmanagertest.Request
+	for _, r := range ts.Requests() {
+		if len(r.PostData) == 2 {
+			levelChange = r
+		}
+	}
+	if len(levelChange.PostData) != 2 {
+		t.Fatal("missing request of the level change")
+	}
+	resolved, active := levelChange.PostData[0], levelChange.PostData[1]
+	if got, exp := resolved.Labels["level"], "CRITICAL"; got != exp {
+		t.Errorf("unexpected level label of resolved alert: got %s exp %s", got, exp)
+	}
+	if !resolved.EndsAt.Equal(changed) {
+		t.Errorf("unexpected end of resolved alert: got %v exp %v", resolved.EndsAt, changed)
+	}
+	if got, exp := active.Labels["level"], "WARNING"; got != exp {
+		t.Errorf("unexpected level label of active alert: got %s exp %s", got, exp)
+	}
+	if !active.StartsAt.Equal(start) {
+		t.Errorf("unexpected start of active alert: got %v exp %v", active.StartsAt, start)
+	}
+
+	// Closing the handler stops the resends.
+	h.(interface {
+		Close()
+	}).Close()
+	time.Sleep(20 * time.Millisecond)
+	count := len(ts.Requests())
+	time.Sleep(50 * time.Millisecond)
+	if got := len(ts.Requests()); got != count {
+		t.Errorf("alerts were resent after the handler was closed: got %d requests exp %d", got, count)
+	}
+}
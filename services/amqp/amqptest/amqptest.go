@@ -0,0 +1,556 @@
+// Package amqptest provides an in-process AMQP 0-9-1 broker for tests.
+// It implements just enough of the protocol to accept connections,
+// open channels, enable publisher confirms, receive published messages
+// and return unroutable mandatory messages.
+package amqptest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+	frameEnd       = 0xCE
+
+	frameMax = 131072
+)
+
+const (
+	classConnection = 10
+	classChannel    = 20
+	classExchange   = 40
+	classBasic      = 60
+	classConfirm    = 85
+)
+
+// Message is a message published to the server.
+type Message struct {
+	Exchange     string
+	RoutingKey   string
+	ContentType  string
+	DeliveryMode uint8
+	MessageID    string
+	Timestamp    time.Time
+	AppID        string
+	Body         []byte
+}
+
+type Server struct {
+	// URL of the server, without credentials or virtual host.
+	URL string
+
+	l net.Listener
+
+	mu         sync.Mutex
+	messages   []Message
+	conns      map[net.Conn]bool
+	nack       bool
+	noAck      bool
+	unroutable bool
+	closed     bool
+	wg         sync.WaitGroup
+}
+
+func NewServer() (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		URL:   "amqp://" + l.Addr().String(),
+		l:     l,
+		conns: make(map[net.Conn]bool),
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.accept()
+	}()
+	return s, nil
+}
+
+// Messages returns the messages received by the server.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Connections returns the number of open connections.
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// SetNack makes the server reject published messages in confirm mode.
+func (s *Server) SetNack(nack bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nack = nack
+}
+
+// SetNoAck makes the server never confirm published messages.
+func (s *Server) SetNoAck(noAck bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noAck = noAck
+}
+
+// SetUnroutable makes the server treat published messages as unroutable,
+// mandatory messages are returned and other messages are dropped.
+func (s *Server) SetUnroutable(unroutable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unroutable = unroutable
+}
+
+// CloseConnections drops all open connections without closing them gracefully.
+func (s *Server) CloseConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.l.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			c := &connection{
+				s: s,
+				r: bufio.NewReader(conn),
+				w: conn,
+			}
+			c.serve()
+		}()
+	}
+}
+
+// connection serves a single client connection.
+type connection struct {
+	s *Server
+	r *bufio.Reader
+	w io.Writer
+
+	// confirming records the channels in confirm mode and their last delivery tag.
+	confirming map[uint16]uint64
+	// publishing is the message whose content is being received, per channel.
+	publishing map[uint16]*publishing
+}
+
+type publishing struct {
+	Message
+	mandatory bool
+	size      uint64
+}
+
+func (c *connection) serve() {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return
+	}
+	if !bytes.Equal(header, []byte("AMQP\x00\x00\x09\x01")) {
+		c.w.Write([]byte("AMQP\x00\x00\x09\x01"))
+		return
+	}
+	c.confirming = make(map[uint16]uint64)
+	c.publishing = make(map[uint16]*publishing)
+
+	// connection.start
+	var args buffer
+	args.octet(0)
+	args.octet(9)
+	args.table()
+	args.longstr("PLAIN")
+	args.longstr("en_US")
+	if err := c.writeMethod(0, classConnection, 10, args.Bytes()); err != nil {
+		return
+	}
+	for {
+		typ, channel, payload, err := c.readFrame()
+		if err != nil {
+			return
+		}
+		var closed bool
+		switch typ {
+		case frameMethod:
+			closed, err = c.method(channel, payload)
+		case frameHeader:
+			err = c.header(channel, payload)
+		case frameBody:
+			err = c.body(channel, payload)
+		case frameHeartbeat:
+		default:
+			err = fmt.Errorf("unexpected frame type %d", typ)
+		}
+		if err != nil || closed {
+			return
+		}
+	}
+}
+
+func (c *connection) method(channel uint16, payload []byte) (closed bool, err error) {
+	r := reader{b: payload}
+	class, method := r.short(), r.short()
+	switch {
+	case class == classConnection && method == 11: // start-ok
+		var args buffer
+		args.short(0)
+		args.long(frameMax)
+		args.short(0)
+		return false, c.writeMethod(0, classConnection, 30, args.Bytes())
+	case class == classConnection && method == 31: // tune-ok
+		return false, nil
+	case class == classConnection && method == 40: // open
+		var args buffer
+		args.shortstr("")
+		return false, c.writeMethod(0, classConnection, 41, args.Bytes())
+	case class == classConnection && method == 50: // close
+		return true, c.writeMethod(0, classConnection, 51, nil)
+	case class == classChannel && method == 10: // open
+		var args buffer
+		args.longstr("")
+		return false, c.writeMethod(channel, classChannel, 11, args.Bytes())
+	case class == classChannel && method == 40: // close
+		delete(c.confirming, channel)
+		delete(c.publishing, channel)
+		return false, c.writeMethod(channel, classChannel, 41, nil)
+	case class == classChannel && method == 41: // close-ok
+		return false, nil
+	case class == classConfirm && method == 10: // select
+		c.confirming[channel] = 0
+		if noWait := r.octet()&1 != 0; noWait {
+			return false, nil
+		}
+		return false, c.writeMethod(channel, classConfirm, 11, nil)
+	case class == classExchange && method == 10: // declare
+		r.short()
+		r.shortstr()
+		r.shortstr()
+		if noWait := r.octet()&(1<<4) != 0; noWait {
+			return false, nil
+		}
+		return false, c.writeMethod(channel, classExchange, 11, nil)
+	case class == classBasic && method == 40: // publish
+		r.short()
+		p := &publishing{}
+		p.Exchange = r.shortstr()
+		p.RoutingKey = r.shortstr()
+		p.mandatory = r.octet()&1 != 0
+		c.publishing[channel] = p
+		return false, r.err
+	default:
+		return false, fmt.Errorf("unsupported method %d.%d", class, method)
+	}
+}
+
+// Flags of the content properties, see the basic class of the AMQP 0-9-1 specification.
+const (
+	flagContentType     = 0x8000
+	flagContentEncoding = 0x4000
+	flagHeaders         = 0x2000
+	flagDeliveryMode    = 0x1000
+	flagPriority        = 0x0800
+	flagCorrelationID   = 0x0400
+	flagReplyTo         = 0x0200
+	flagExpiration      = 0x0100
+	flagMessageID       = 0x0080
+	flagTimestamp       = 0x0040
+	flagType            = 0x0020
+	flagUserID          = 0x0010
+	flagAppID           = 0x0008
+)
+
+func (c *connection) header(channel uint16, payload []byte) error {
+	p := c.publishing[channel]
+	if p == nil {
+		return errors.New("unexpected content header")
+	}
+	r := reader{b: payload}
+	r.short() // class
+	r.short() // weight
+	p.size = r.longlong()
+	flags := r.short()
+	if flags&flagContentType != 0 {
+		p.ContentType = r.shortstr()
+	}
+	if flags&flagContentEncoding != 0 {
+		r.shortstr()
+	}
+	if flags&flagHeaders != 0 {
+		return errors.New("message headers are not supported")
+	}
+	if flags&flagDeliveryMode != 0 {
+		p.DeliveryMode = r.octet()
+	}
+	if flags&flagPriority != 0 {
+		r.octet()
+	}
+	if flags&flagCorrelationID != 0 {
+		r.shortstr()
+	}
+	if flags&flagReplyTo != 0 {
+		r.shortstr()
+	}
+	if flags&flagExpiration != 0 {
+		r.shortstr()
+	}
+	if flags&flagMessageID != 0 {
+		p.MessageID = r.shortstr()
+	}
+	if flags&flagTimestamp != 0 {
+		p.Timestamp = time.Unix(int64(r.longlong()), 0).UTC()
+	}
+	if flags&flagType != 0 {
+		r.shortstr()
+	}
+	if flags&flagUserID != 0 {
+		r.shortstr()
+	}
+	if flags&flagAppID != 0 {
+		p.AppID = r.shortstr()
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if p.size == 0 {
+		return c.published(channel)
+	}
+	return nil
+}
+
+func (c *connection) body(channel uint16, payload []byte) error {
+	p := c.publishing[channel]
+	if p == nil {
+		return errors.New("unexpected content body")
+	}
+	p.Body = append(p.Body, payload...)
+	if uint64(len(p.Body)) >= p.size {
+		return c.published(channel)
+	}
+	return nil
+}
+
+// published records the complete message and confirms it if the channel is in confirm mode.
+// Unroutable mandatory messages are returned before they are confirmed.
+func (c *connection) published(channel uint16) error {
+	p := c.publishing[channel]
+	delete(c.publishing, channel)
+
+	c.s.mu.Lock()
+	nack, noAck, unroutable := c.s.nack, c.s.noAck, c.s.unroutable
+	if !nack && !unroutable {
+		c.s.messages = append(c.s.messages, p.Message)
+	}
+	c.s.mu.Unlock()
+
+	if unroutable && p.mandatory {
+		if err := c.returned(channel, p); err != nil {
+			return err
+		}
+	}
+
+	tag, ok := c.confirming[channel]
+	if !ok || noAck {
+		return nil
+	}
+	tag++
+	c.confirming[channel] = tag
+	var args buffer
+	args.longlong(tag)
+	args.octet(0)
+	if nack {
+		// basic.nack
+		return c.writeMethod(channel, classBasic, 120, args.Bytes())
+	}
+	// basic.ack
+	return c.writeMethod(channel, classBasic, 80, args.Bytes())
+}
+
+// returned sends a basic.return of the message with its body.
+// Content properties are not sent back.
+func (c *connection) returned(channel uint16, p *publishing) error {
+	var args buffer
+	args.short(312) // NO_ROUTE
+	args.shortstr("NO_ROUTE")
+	args.shortstr(p.Exchange)
+	args.shortstr(p.RoutingKey)
+	if err := c.writeMethod(channel, classBasic, 50, args.Bytes()); err != nil {
+		return err
+	}
+	var header buffer
+	header.short(classBasic)
+	header.short(0)
+	header.longlong(uint64(len(p.Body)))
+	header.short(0)
+	if err := c.writeFrame(frameHeader, channel, header.Bytes()); err != nil {
+		return err
+	}
+	if len(p.Body) == 0 {
+		return nil
+	}
+	return c.writeFrame(frameBody, channel, p.Body)
+}
+
+func (c *connection) readFrame() (typ uint8, channel uint16, payload []byte, err error) {
+	var h [7]byte
+	if _, err = io.ReadFull(c.r, h[:]); err != nil {
+		return
+	}
+	typ = h[0]
+	channel = binary.BigEndian.Uint16(h[1:3])
+	size := binary.BigEndian.Uint32(h[3:7])
+	if size > frameMax {
+		err = fmt.Errorf("frame of %d bytes exceeds the maximum frame size", size)
+		return
+	}
+	payload = make([]byte, size+1)
+	if _, err = io.ReadFull(c.r, payload); err != nil {
+		return
+	}
+	if payload[size] != frameEnd {
+		err = errors.New("missing frame end")
+		return
+	}
+	payload = payload[:size]
+	return
+}
+
+func (c *connection) writeMethod(channel uint16, class, method uint16, args []byte) error {
+	var payload buffer
+	payload.short(class)
+	payload.short(method)
+	payload.Write(args)
+	return c.writeFrame(frameMethod, channel, payload.Bytes())
+}
+
+func (c *connection) writeFrame(typ uint8, channel uint16, payload []byte) error {
+	var b buffer
+	b.octet(typ)
+	b.short(channel)
+	b.long(uint32(len(payload)))
+	b.Write(payload)
+	b.octet(frameEnd)
+	_, err := c.w.Write(b.Bytes())
+	return err
+}
+
+// buffer encodes AMQP data types.
+type buffer struct {
+	bytes.Buffer
+}
+
+func (b *buffer) octet(v uint8) {
+	b.WriteByte(v)
+}
+
+func (b *buffer) short(v uint16) {
+	binary.Write(b, binary.BigEndian, v)
+}
+
+func (b *buffer) long(v uint32) {
+	binary.Write(b, binary.BigEndian, v)
+}
+
+func (b *buffer) longlong(v uint64) {
+	binary.Write(b, binary.BigEndian, v)
+}
+
+func (b *buffer) shortstr(v string) {
+	b.octet(uint8(len(v)))
+	b.WriteString(v)
+}
+
+func (b *buffer) longstr(v string) {
+	b.long(uint32(len(v)))
+	b.WriteString(v)
+}
+
+// table writes an empty field table.
+func (b *buffer) table() {
+	b.long(0)
+}
+
+// reader decodes AMQP data types, the first error is kept and later reads return zero values.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if len(r.b) < n {
+		r.err = io.ErrUnexpectedEOF
+		return make([]byte, n)
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *reader) octet() uint8 {
+	return r.next(1)[0]
+}
+
+func (r *reader) short() uint16 {
+	return binary.BigEndian.Uint16(r.next(2))
+}
+
+func (r *reader) longlong() uint64 {
+	return binary.BigEndian.Uint64(r.next(8))
+}
+
+func (r *reader) shortstr() string {
+	n := r.octet()
+	return string(r.next(int(n)))
+}
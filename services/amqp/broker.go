@@ -0,0 +1,201 @@
+package amqp
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/tlsconfig"
+	"github.com/pkg/errors"
+	streadwayamqp "github.com/streadway/amqp"
+)
+
+// ErrNotConnected is returned when publishing while the broker is not connected.
+var ErrNotConnected = errors.New("not connected to the AMQP broker")
+
+// broker maintains the connection to an AMQP broker,
+// it reconnects whenever the connection or its channel is closed.
+type broker struct {
+	c      Config
+	logger *log.Logger
+
+	// mu guards the connection and serializes publishing,
+	// every message is confirmed before the next one is published.
+	mu       sync.Mutex
+	conn     *streadwayamqp.Connection
+	ch       *streadwayamqp.Channel
+	confirms chan streadwayamqp.Confirmation
+	returns  chan streadwayamqp.Return
+
+	// ready is closed once the first connection attempt is done.
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newBroker(c Config, l *log.Logger) *broker {
+	return &broker{
+		c:      c,
+		logger: l,
+		ready:  make(chan struct{}),
+	}
+}
+
+// open connects to the broker in the background and keeps reconnecting,
+// so an unavailable broker does not delay startup.
+func (b *broker) open() {
+	b.closing = make(chan struct{})
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.run()
+	}()
+}
+
+func (b *broker) close() {
+	close(b.closing)
+	b.wg.Wait()
+}
+
+// run connects to the broker and reconnects whenever the connection is lost.
+func (b *broker) run() {
+	closed, err := b.connect()
+	b.readyOnce.Do(func() { close(b.ready) })
+	for {
+		if err != nil {
+			b.logger.Printf("E! failed to connect to AMQP broker %q: %v", b.c.Name, err)
+		} else {
+			b.logger.Printf("I! connected to AMQP broker %q", b.c.Name)
+			select {
+			case <-b.closing:
+				b.disconnect()
+				return
+			case err := <-closed:
+				b.logger.Printf("E! lost connection to AMQP broker %q: %v", b.c.Name, err)
+				b.disconnect()
+			}
+		}
+		select {
+		case <-b.closing:
+			return
+		case <-time.After(time.Duration(b.c.ReconnectInterval)):
+		}
+		closed, err = b.connect()
+	}
+}
+
+// connect opens a connection and a channel in confirm mode.
+// The returned channel receives an error when either of them is closed.
+func (b *broker) connect() (<-chan error, error) {
+	tlsConfig, err := tlsconfig.Create(b.c.SSLCA, b.c.SSLCert, b.c.SSLKey, b.c.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := streadwayamqp.DialConfig(b.c.URL, streadwayamqp.Config{
+		TLSClientConfig: tlsConfig,
+		Properties: streadwayamqp.Table{
+			"product": "Kapacitor",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "failed to open channel")
+	}
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "failed to enable publisher confirms")
+	}
+
+	closed := make(chan error, 2)
+	watch := func(c chan *streadwayamqp.Error) {
+		go func() {
+			if err, ok := <-c; ok {
+				closed <- err
+			} else {
+				closed <- errors.New("closed")
+			}
+		}()
+	}
+	watch(conn.NotifyClose(make(chan *streadwayamqp.Error, 1)))
+	watch(ch.NotifyClose(make(chan *streadwayamqp.Error, 1)))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.conn = conn
+	b.ch = ch
+	b.confirms = ch.NotifyPublish(make(chan streadwayamqp.Confirmation, 1))
+	b.returns = ch.NotifyReturn(make(chan streadwayamqp.Return, 1))
+	return closed, nil
+}
+
+func (b *broker) disconnect() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reset()
+}
+
+// reset closes the connection, publishing fails until the broker is reconnected.
+// Must be called with the lock held.
+func (b *broker) reset() {
+	if b.conn != nil {
+		b.conn.Close()
+	}
+	b.conn = nil
+	b.ch = nil
+	b.confirms = nil
+	b.returns = nil
+}
+
+// publish publishes the message and waits until the broker confirms it.
+// Messages are mandatory, a message the broker cannot route to any queue is returned and reported as an error.
+// Messages published before the first connection attempt is done wait for it, up to the confirm timeout.
+func (b *broker) publish(exchange, routingKey string, msg streadwayamqp.Publishing) error {
+	wait := time.NewTimer(time.Duration(b.c.ConfirmTimeout))
+	defer wait.Stop()
+	select {
+	case <-b.ready:
+	case <-wait.C:
+		return ErrNotConnected
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ch == nil {
+		return ErrNotConnected
+	}
+	if err := b.ch.Publish(exchange, routingKey, true, false, msg); err != nil {
+		b.reset()
+		return errors.Wrap(err, "failed to publish message")
+	}
+
+	timeout := time.NewTimer(time.Duration(b.c.ConfirmTimeout))
+	defer timeout.Stop()
+	select {
+	case c, ok := <-b.confirms:
+		if !ok {
+			b.reset()
+			return errors.New("connection closed before the broker confirmed the message")
+		}
+		if !c.Ack {
+			return errors.New("broker rejected the message")
+		}
+		// The broker sends the return of an unroutable message before its confirmation.
+		select {
+		case r := <-b.returns:
+			return fmt.Errorf("broker returned the message: %d %s", r.ReplyCode, r.ReplyText)
+		default:
+		}
+		return nil
+	case <-timeout.C:
+		// Reconnect so a late confirmation is not taken for the one of the next message.
+		b.reset()
+		return errors.New("timed out waiting for the broker to confirm the message")
+	}
+}
@@ -0,0 +1,277 @@
+package amqp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/pkg/errors"
+	streadwayamqp "github.com/streadway/amqp"
+)
+
+type Service struct {
+	mu                sync.RWMutex
+	configs           map[string]Config
+	brokers           map[string]*broker
+	defaultBrokerName string
+	opened            bool
+	logger            *log.Logger
+}
+
+func NewService(cs Configs, l *log.Logger) *Service {
+	configs := cs.index()
+	brokers := make(map[string]*broker, len(configs))
+	for name, c := range configs {
+		if c.Enabled {
+			brokers[name] = newBroker(c, l)
+		}
+	}
+	return &Service{
+		configs:           configs,
+		brokers:           brokers,
+		defaultBrokerName: cs.defaultBrokerName(),
+		logger:            l,
+	}
+}
+
+func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opened {
+		return nil
+	}
+	s.opened = true
+	// Brokers connect in the background, an unavailable broker does not prevent or delay startup.
+	for _, b := range s.brokers {
+		b.open()
+	}
+	return nil
+}
+
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.opened {
+		return nil
+	}
+	s.opened = false
+	for _, b := range s.brokers {
+		b.close()
+	}
+	return nil
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
+	}
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+	configs := cs.index()
+
+	s.mu.Lock()
+	brokers := make(map[string]*broker, len(configs))
+	for name, c := range configs {
+		if old, ok := s.brokers[name]; ok && s.configs[name] == c {
+			brokers[name] = old
+			continue
+		}
+		if c.Enabled {
+			b := newBroker(c, s.logger)
+			if s.opened {
+				b.open()
+			}
+			brokers[name] = b
+		}
+	}
+	var removed []*broker
+	if s.opened {
+		for name, b := range s.brokers {
+			if brokers[name] != b {
+				removed = append(removed, b)
+			}
+		}
+	}
+	s.configs = configs
+	s.brokers = brokers
+	s.defaultBrokerName = cs.defaultBrokerName()
+	s.mu.Unlock()
+
+	// Close the connections of the brokers that were removed or changed
+	// without holding the lock, closing waits for a pending publish to finish.
+	for _, b := range removed {
+		b.close()
+	}
+	return nil
+}
+
+// broker returns the configuration and the broker of the named broker,
+// or of the default broker if the name is empty.
+func (s *Service) broker(name string) (Config, *broker, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if name == "" {
+		name = s.defaultBrokerName
+	}
+	c, ok := s.configs[name]
+	if !ok {
+		return Config{}, nil, fmt.Errorf("unknown AMQP broker %q", name)
+	}
+	b, ok := s.brokers[name]
+	if !ok || !s.opened {
+		return Config{}, nil, fmt.Errorf("AMQP broker %q is not enabled", name)
+	}
+	return c, b, nil
+}
+
+// Alert publishes the alert to the broker, or the default broker if brokerName is empty.
+// Empty exchange and routing key templates are replaced by the ones of the broker configuration.
+func (s *Service) Alert(brokerName, exchange, routingKey string, event alert.Event) error {
+	c, b, err := s.broker(brokerName)
+	if err != nil {
+		return err
+	}
+	if exchange == "" {
+		exchange = c.Exchange
+	}
+	if routingKey == "" {
+		routingKey = c.RoutingKey
+	}
+	td := event.TemplateData()
+	exchange, err = render("exchange", exchange, td)
+	if err != nil {
+		return err
+	}
+	routingKey, err = render("routing-key", routingKey, td)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event.AlertData())
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal alert data json")
+	}
+	msg := streadwayamqp.Publishing{
+		ContentType: "application/json",
+		MessageId:   event.State.ID,
+		Timestamp:   event.State.Time,
+		AppId:       "kapacitor",
+		Body:        body,
+	}
+	if c.Persistent {
+		msg.DeliveryMode = streadwayamqp.Persistent
+	} else {
+		msg.DeliveryMode = streadwayamqp.Transient
+	}
+	if err := b.publish(exchange, routingKey, msg); err != nil {
+		return errors.Wrapf(err, "failed to publish alert to AMQP broker %q", c.Name)
+	}
+	return nil
+}
+
+// render executes the template text with the template data of an alert.
+func render(name, text string, td alert.TemplateData) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid %s template", name)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, td); err != nil {
+		return "", errors.Wrapf(err, "failed to render %s template", name)
+	}
+	return buf.String(), nil
+}
+
+type testOptions struct {
+	BrokerName string `json:"broker-name"`
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routing-key"`
+	ID         string `json:"id"`
+	Message    string `json:"message"`
+	Level      string `json:"level"`
+}
+
+func (s *Service) TestOptions() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &testOptions{
+		BrokerName: s.defaultBrokerName,
+		ID:         "testAMQP",
+		Message:    "test AMQP message",
+		Level:      alert.Critical.String(),
+	}
+}
+
+func (s *Service) Test(options interface{}) error {
+	o, ok := options.(*testOptions)
+	if !ok {
+		return fmt.Errorf("unexpected options type %T", options)
+	}
+	level, err := alert.ParseLevel(o.Level)
+	if err != nil {
+		return err
+	}
+	return s.Alert(o.BrokerName, o.Exchange, o.RoutingKey, alert.Event{
+		State: alert.EventState{
+			ID:      o.ID,
+			Message: o.Message,
+			Level:   level,
+			Time:    time.Now(),
+		},
+	})
+}
+
+type HandlerConfig struct {
+	// BrokerName is the name of the broker to publish to.
+	// If empty uses the default broker.
+	BrokerName string `mapstructure:"broker-name"`
+
+	// Exchange is a template of the exchange to publish to.
+	// If empty uses the exchange of the broker configuration.
+	Exchange string `mapstructure:"exchange"`
+
+	// RoutingKey is a template of the routing key of the alerts.
+	// If empty uses the routing key of the broker configuration.
+	RoutingKey string `mapstructure:"routing-key"`
+}
+
+type handler struct {
+	s      *Service
+	c      HandlerConfig
+	logger *log.Logger
+}
+
+func (s *Service) Handler(c HandlerConfig, l *log.Logger) (alert.Handler, error) {
+	if _, err := template.New("exchange").Parse(c.Exchange); err != nil {
+		return nil, errors.Wrap(err, "invalid exchange template")
+	}
+	if _, err := template.New("routing-key").Parse(c.RoutingKey); err != nil {
+		return nil, errors.Wrap(err, "invalid routing-key template")
+	}
+	return &handler{
+		s:      s,
+		c:      c,
+		logger: l,
+	}, nil
+}
+
+func (h *handler) Handle(event alert.Event) {
+	if err := h.TryHandle(event); err != nil {
+		h.logger.Println("E! failed to publish alert to AMQP", err)
+	}
+}
+
+func (h *handler) TryHandle(event alert.Event) error {
+	return h.s.Alert(h.c.BrokerName, h.c.Exchange, h.c.RoutingKey, event)
+}
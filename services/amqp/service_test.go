@@ -0,0 +1,249 @@
+package amqp_test
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/services/amqp"
+	"github.com/influxdata/kapacitor/services/amqp/amqptest"
+)
+
+func newService(t *testing.T, url string) *amqp.Service {
+	c := amqp.NewConfig()
+	c.Enabled = true
+	c.Name = "test"
+	c.URL = url
+	c.ConfirmTimeout = toml.Duration(100 * time.Millisecond)
+	c.ReconnectInterval = toml.Duration(10 * time.Millisecond)
+	s := amqp.NewService(amqp.Configs{c}, log.New(os.Stderr, "[amqp] ", log.LstdFlags))
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// tryHandle retries handling the event until the broker is connected.
+func tryHandle(t *testing.T, h alert.Handler, event alert.Event) error {
+	fh := h.(alert.FallibleHandler)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := fh.TryHandle(event)
+		if err == nil || !strings.Contains(err.Error(), amqp.ErrNotConnected.Error()) || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestService_Alert(t *testing.T) {
+	ts, err := amqptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	s := newService(t, ts.URL)
+	defer s.Close()
+
+	h, err := s.Handler(amqp.HandlerConfig{
+		Exchange:   "alerts",
+		RoutingKey: "{{ .TaskName }}.{{ .Level }}",
+	}, log.New(os.Stderr, "[amqp] ", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := tryHandle(t, h, alert.Event{
+		State: alert.EventState{
+			ID:      "id",
+			Message: "message",
+			Level:   alert.Critical,
+			Time:    now,
+		},
+		Data: alert.EventData{
+			TaskName: "task",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := ts.Messages()
+	if got, exp := len(msgs), 1; got != exp {
+		t.Fatalf("unexpected number of messages: got %d exp %d", got, exp)
+	}
+	msg := msgs[0]
+	if got, exp := msg.Exchange, "alerts"; got != exp {
+		t.Errorf("unexpected exchange: got %q exp %q", got, exp)
+	}
+	if got, exp := msg.RoutingKey, "task.CRITICAL"; got != exp {
+		t.Errorf("unexpected routing key: got %q exp %q", got, exp)
+	}
+	if got, exp := msg.DeliveryMode, uint8(2); got != exp {
+		t.Errorf("unexpected delivery mode: got %d exp %d", got, exp)
+	}
+	if got, exp := msg.ContentType, "application/json"; got != exp {
+		t.Errorf("unexpected content type: got %q exp %q", got, exp)
+	}
+	if got, exp := msg.MessageID, "id"; got != exp {
+		t.Errorf("unexpected message id: got %q exp %q", got, exp)
+	}
+	if !msg.Timestamp.Equal(now) {
+		t.Errorf("unexpected timestamp: got %v exp %v", msg.Timestamp, now)
+	}
+	var data alert.Data
+	if err := json.Unmarshal(msg.Body, &data); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := data.Message, "message"; got != exp {
+		t.Errorf("unexpected alert message: got %q exp %q", got, exp)
+	}
+}
+
+func TestService_Reconnect(t *testing.T) {
+	ts, err := amqptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	s := newService(t, ts.URL)
+	defer s.Close()
+
+	h, err := s.Handler(amqp.HandlerConfig{}, log.New(os.Stderr, "[amqp] ", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := alert.Event{
+		State: alert.EventState{
+			ID:    "id",
+			Level: alert.Warning,
+		},
+	}
+	if err := tryHandle(t, h, event); err != nil {
+		t.Fatal(err)
+	}
+
+	ts.CloseConnections()
+	// Wait for the service to notice the connection was lost.
+	time.Sleep(50 * time.Millisecond)
+	if err := tryHandle(t, h, event); err != nil {
+		t.Fatal(err)
+	}
+	msgs := ts.Messages()
+	if got, exp := len(msgs), 2; got != exp {
+		t.Fatalf("unexpected number of messages: got %d exp %d", got, exp)
+	}
+	if got, exp := msgs[1].RoutingKey, "kapacitor.WARNING"; got != exp {
+		t.Errorf("unexpected routing key: got %q exp %q", got, exp)
+	}
+}
+
+func TestService_Unconfirmed(t *testing.T) {
+	ts, err := amqptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	s := newService(t, ts.URL)
+	defer s.Close()
+
+	h, err := s.Handler(amqp.HandlerConfig{}, log.New(os.Stderr, "[amqp] ", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := alert.Event{
+		State: alert.EventState{
+			ID:    "id",
+			Level: alert.Critical,
+		},
+	}
+
+	ts.SetNack(true)
+	if err := tryHandle(t, h, event); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("expected rejected message error, got %v", err)
+	}
+	ts.SetNack(false)
+
+	ts.SetNoAck(true)
+	if err := tryHandle(t, h, event); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected confirmation timeout error, got %v", err)
+	}
+	ts.SetNoAck(false)
+
+	// The service reconnects after a timeout and confirms the next message.
+	if err := tryHandle(t, h, event); err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages the broker cannot route are returned instead of silently dropped.
+	ts.SetUnroutable(true)
+	if err := tryHandle(t, h, event); err == nil || !strings.Contains(err.Error(), "returned") {
+		t.Errorf("expected returned message error, got %v", err)
+	}
+	ts.SetUnroutable(false)
+
+	if err := tryHandle(t, h, event); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	ts, err := amqptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	s := newService(t, ts.URL)
+	defer s.Close()
+
+	h, err := s.Handler(amqp.HandlerConfig{}, log.New(os.Stderr, "[amqp] ", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := alert.Event{
+		State: alert.EventState{
+			ID:    "id",
+			Level: alert.Critical,
+		},
+	}
+	if err := tryHandle(t, h, event); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := amqptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer updated.Close()
+	c := amqp.NewConfig()
+	c.Enabled = true
+	c.Name = "test"
+	c.URL = updated.URL
+	c.ConfirmTimeout = toml.Duration(100 * time.Millisecond)
+	c.ReconnectInterval = toml.Duration(10 * time.Millisecond)
+	if err := s.Update([]interface{}{c}); err != nil {
+		t.Fatal(err)
+	}
+	// The broker of the old configuration is closed by the update.
+	deadline := time.Now().Add(5 * time.Second)
+	for ts.Connections() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("connection to the old server was not closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := tryHandle(t, h, event); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := len(ts.Messages()), 1; got != exp {
+		t.Errorf("unexpected number of messages on the old server: got %d exp %d", got, exp)
+	}
+	if got, exp := len(updated.Messages()), 1; got != exp {
+		t.Errorf("unexpected number of messages on the updated server: got %d exp %d", got, exp)
+	}
+}
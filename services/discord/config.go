@@ -0,0 +1,84 @@
+package discord
+
+import (
+	"net/url"
+
+	"github.com/influxdata/kapacitor/listmap"
+	"github.com/pkg/errors"
+)
+
+// Config is the configuration for a single [[discord]] section of the kapacitor
+// configuration file.
+type Config struct {
+	// Whether Discord integration is enabled.
+	Enabled bool `toml:"enabled" override:"enabled"`
+	// Workspace is the name of the configuration, handlers select it by name.
+	Workspace string `toml:"workspace" override:"workspace"`
+	// Whether this is the configuration used when handlers do not select a workspace.
+	// Only needed if there is more than one configuration.
+	Default bool `toml:"default" override:"default"`
+	// The Discord webhook URL, can be obtained by adding a webhook in the settings of a channel.
+	URL string `toml:"url" override:"url,redact"`
+	// The username of the Discord bot.
+	// If empty uses the name configured for the webhook.
+	Username string `toml:"username" override:"username"`
+	// The URL of the avatar of the Discord bot.
+	// If empty uses the avatar configured for the webhook.
+	AvatarURL string `toml:"avatar-url" override:"avatar-url"`
+	// The title of the embeds, the alert ID is used if empty.
+	EmbedTitle string `toml:"embed-title" override:"embed-title"`
+}
+
+func NewConfig() Config {
+	return Config{}
+}
+
+func (c Config) Validate() error {
+	if c.Workspace == "" {
+		return errors.New("must specify a workspace name")
+	}
+	if c.Enabled && c.URL == "" {
+		return errors.New("must specify url")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return errors.Wrapf(err, "invalid url %q", c.URL)
+	}
+	if _, err := url.Parse(c.AvatarURL); err != nil {
+		return errors.Wrapf(err, "invalid avatar-url %q", c.AvatarURL)
+	}
+	return nil
+}
+
+// Configs is the configuration for all [[discord]] sections of the kapacitor
+// configuration file.
+type Configs []Config
+
+// Validate calls config.Validate for each element in Configs
+// and checks that the workspaces are unique and there is a single default.
+func (cs Configs) Validate() error {
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return listmap.ValidateNames("workspace", len(cs), cs.workspace)
+}
+
+// workspace returns the workspace of the config i and whether it is the default.
+func (cs Configs) workspace(i int) (string, bool) {
+	return cs[i].Workspace, cs[i].Default
+}
+
+// index generates a map of configs by workspace
+func (cs Configs) index() map[string]Config {
+	m := make(map[string]Config, len(cs))
+	for _, c := range cs {
+		m[c.Workspace] = c
+	}
+	return m
+}
+
+// defaultWorkspace returns the workspace used when handlers do not select one.
+func (cs Configs) defaultWorkspace() string {
+	return listmap.DefaultName(len(cs), cs.workspace)
+}
@@ -0,0 +1,264 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/pkg/errors"
+)
+
+type Service struct {
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
+	logger           *log.Logger
+}
+
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
+	}
+}
+
+func (s *Service) Open() error {
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
+	}
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
+	return nil
+}
+
+// config returns the config of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown Discord workspace %q", workspace)
+	}
+	return c, nil
+}
+
+type testOptions struct {
+	Workspace string      `json:"workspace"`
+	AlertID   string      `json:"alert-id"`
+	Message   string      `json:"message"`
+	Level     alert.Level `json:"level"`
+}
+
+func (s *Service) TestOptions() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &testOptions{
+		Workspace: s.defaultWorkspace,
+		AlertID:   "testAlertID",
+		Message:   "test discord message",
+		Level:     alert.Critical,
+	}
+}
+
+func (s *Service) Test(options interface{}) error {
+	o, ok := options.(*testOptions)
+	if !ok {
+		return fmt.Errorf("unexpected options type %T", options)
+	}
+	return s.Alert(o.Workspace, "", "", "", o.AlertID, o.Message, o.Level, time.Now())
+}
+
+// Alert posts an embed for the alert to the webhook of the workspace.
+// The embed title defaults to the title from the configuration, or else the alert ID.
+// The title and message are truncated to the lengths Discord accepts.
+func (s *Service) Alert(workspace, username, avatarURL, embedTitle, alertID, message string, level alert.Level, t time.Time) error {
+	url, post, err := s.preparePost(workspace, username, avatarURL, embedTitle, alertID, message, level, t)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(url, "application/json", post)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		type response struct {
+			Message string `json:"message"`
+		}
+		r := &response{Message: fmt.Sprintf("failed to understand Discord response. code: %d content: %s", resp.StatusCode, string(body))}
+		b := bytes.NewReader(body)
+		dec := json.NewDecoder(b)
+		dec.Decode(r)
+		return errors.New(r.Message)
+	}
+	return nil
+}
+
+// message is the body of a Discord webhook request.
+type message struct {
+	Username  string  `json:"username,omitempty"`
+	AvatarURL string  `json:"avatar_url,omitempty"`
+	Embeds    []embed `json:"embeds"`
+}
+
+type embed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// Discord rejects embeds with longer titles or descriptions.
+const (
+	maxTitleLength       = 256
+	maxDescriptionLength = 4096
+)
+
+// truncate shortens s to at most max characters, ending it with an ellipsis if it was shortened.
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:max-1]) + "…"
+}
+
+// color returns the colour of embeds of the level.
+func color(level alert.Level) int {
+	switch level {
+	case alert.Critical:
+		return 0xF95F53
+	case alert.Warning:
+		return 0xEFB311
+	case alert.Info:
+		return 0x3A9AF9
+	default:
+		return 0x7AC36A
+	}
+}
+
+func (s *Service) preparePost(workspace, username, avatarURL, embedTitle, alertID, msg string, level alert.Level, t time.Time) (string, io.Reader, error) {
+	c, err := s.config(workspace)
+	if err != nil {
+		return "", nil, err
+	}
+	if !c.Enabled {
+		return "", nil, errors.New("service is not enabled")
+	}
+	if username == "" {
+		username = c.Username
+	}
+	if avatarURL == "" {
+		avatarURL = c.AvatarURL
+	}
+	if embedTitle == "" {
+		embedTitle = c.EmbedTitle
+	}
+	if embedTitle == "" {
+		embedTitle = alertID
+	}
+	m := message{
+		Username:  username,
+		AvatarURL: avatarURL,
+		Embeds: []embed{{
+			Title:       truncate(embedTitle, maxTitleLength),
+			Description: truncate(msg, maxDescriptionLength),
+			Color:       color(level),
+			Timestamp:   t.UTC().Format(time.RFC3339Nano),
+		}},
+	}
+
+	var post bytes.Buffer
+	enc := json.NewEncoder(&post)
+	if err := enc.Encode(m); err != nil {
+		return "", nil, err
+	}
+
+	return c.URL, &post, nil
+}
+
+type HandlerConfig struct {
+	// Workspace of the Discord webhook to post to.
+	// If empty uses the default workspace.
+	Workspace string `mapstructure:"workspace"`
+
+	// Username of the Discord bot.
+	// If empty uses the username from the configuration.
+	Username string `mapstructure:"username"`
+
+	// URL of the avatar of the Discord bot.
+	// If empty uses the avatar URL from the configuration.
+	AvatarURL string `mapstructure:"avatar-url"`
+
+	// Title of the embed.
+	// If empty uses the embed title from the configuration, or the alert ID.
+	EmbedTitle string `mapstructure:"embed-title"`
+}
+
+type handler struct {
+	s      *Service
+	c      HandlerConfig
+	logger *log.Logger
+}
+
+func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
+	return &handler{
+		s:      s,
+		c:      c,
+		logger: l,
+	}
+}
+
+func (h *handler) Handle(event alert.Event) {
+	if err := h.TryHandle(event); err != nil {
+		h.logger.Println("E! failed to send event to Discord", err)
+	}
+}
+
+func (h *handler) TryHandle(event alert.Event) error {
+	return h.s.Alert(
+		h.c.Workspace,
+		h.c.Username,
+		h.c.AvatarURL,
+		h.c.EmbedTitle,
+		event.State.ID,
+		event.State.Message,
+		event.State.Level,
+		event.State.Time,
+	)
+}
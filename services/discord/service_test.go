@@ -0,0 +1,68 @@
+package discord_test
+
+import (
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/services/discord"
+	"github.com/influxdata/kapacitor/services/discord/discordtest"
+)
+
+func TestService_Alert_Truncate(t *testing.T) {
+	ts := discordtest.NewServer()
+	defer ts.Close()
+
+	c := discord.NewConfig()
+	c.Enabled = true
+	c.URL = ts.URL
+	s := discord.NewService(discord.Configs{c}, log.New(os.Stderr, "[discord] ", log.LstdFlags))
+
+	testCases := []struct {
+		title, message           string
+		expTitle, expDescription string
+	}{
+		{
+			title:          "title",
+			message:        "message",
+			expTitle:       "title",
+			expDescription: "message",
+		},
+		{
+			title:          strings.Repeat("t", 256),
+			message:        strings.Repeat("m", 4096),
+			expTitle:       strings.Repeat("t", 256),
+			expDescription: strings.Repeat("m", 4096),
+		},
+		{
+			// Lengths are counted in characters, not bytes.
+			title:          strings.Repeat("é", 300),
+			message:        strings.Repeat("ü", 5000),
+			expTitle:       strings.Repeat("é", 255) + "…",
+			expDescription: strings.Repeat("ü", 4095) + "…",
+		},
+	}
+	for i, tc := range testCases {
+		if err := s.Alert("", "", "", tc.title, "id", tc.message, alert.Critical, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		requests := ts.Requests()
+		if len(requests) != i+1 {
+			t.Fatalf("%d: unexpected number of requests: got %d exp %d", i, len(requests), i+1)
+		}
+		embeds := requests[i].PostData.Embeds
+		if len(embeds) != 1 {
+			t.Fatalf("%d: unexpected number of embeds: got %d exp 1", i, len(embeds))
+		}
+		if got := embeds[0].Title; got != tc.expTitle {
+			t.Errorf("%d: unexpected title of %d characters, exp %d characters", i, utf8.RuneCountInString(got), utf8.RuneCountInString(tc.expTitle))
+		}
+		if got := embeds[0].Description; got != tc.expDescription {
+			t.Errorf("%d: unexpected description of %d characters, exp %d characters", i, utf8.RuneCountInString(got), utf8.RuneCountInString(tc.expDescription))
+		}
+	}
+}
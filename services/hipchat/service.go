@@ -11,22 +11,24 @@ import (
 	"net/http"
 	"net/url"
 	"path"
-	"sync/atomic"
+	"sync"
 
 	"github.com/influxdata/kapacitor/alert"
 )
 
 type Service struct {
-	configValue atomic.Value
-	logger      *log.Logger
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
+	logger           *log.Logger
 }
 
-func NewService(c Config, l *log.Logger) *Service {
-	s := &Service{
-		logger: l,
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
 	}
-	s.configValue.Store(c)
-	return s
 }
 
 func (s *Service) Open() error {
@@ -37,44 +39,73 @@ func (s *Service) Close() error {
 	return nil
 }
 
-func (s *Service) config() Config {
-	return s.configValue.Load().(Config)
+// config returns the configuration of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown HipChat workspace %q", workspace)
+	}
+	return c, nil
 }
 
-func (s *Service) Update(newConfig []interface{}) error {
-	if l := len(newConfig); l != 1 {
-		return fmt.Errorf("expected only one new config object, got %d", l)
+// defaultConfig returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	c, _ := s.config("")
+	return c
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
 	}
-	if c, ok := newConfig[0].(Config); !ok {
-		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
-	} else {
-		s.configValue.Store(c)
+	if err := cs.Validate(); err != nil {
+		return err
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
 	return nil
 }
 
 func (s *Service) Global() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.Global
 }
 
 func (s *Service) StateChangesOnly() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.StateChangesOnly
 }
 
 type testOptions struct {
-	Room    string      `json:"room"`
-	Message string      `json:"message"`
-	Level   alert.Level `json:"level"`
+	Workspace string      `json:"workspace"`
+	Room      string      `json:"room"`
+	Message   string      `json:"message"`
+	Level     alert.Level `json:"level"`
 }
 
 func (s *Service) TestOptions() interface{} {
-	c := s.config()
+	s.mu.RLock()
+	defaultWorkspace := s.defaultWorkspace
+	s.mu.RUnlock()
+	c := s.defaultConfig()
 	return &testOptions{
-		Room:    c.Room,
-		Message: "test hipchat message",
-		Level:   alert.Critical,
+		Workspace: defaultWorkspace,
+		Room:      c.Room,
+		Message:   "test hipchat message",
+		Level:     alert.Critical,
 	}
 }
 
@@ -83,12 +114,12 @@ func (s *Service) Test(options interface{}) error {
 	if !ok {
 		return fmt.Errorf("unexpected options type %T", options)
 	}
-	c := s.config()
-	return s.Alert(o.Room, c.Token, o.Message, o.Level)
+	return s.Alert(o.Workspace, o.Room, "", o.Message, o.Level)
 }
 
-func (s *Service) Alert(room, token, message string, level alert.Level) error {
-	url, post, err := s.preparePost(room, token, message, level)
+// Alert posts the message to HipChat using the workspace, or the default workspace if workspace is empty.
+func (s *Service) Alert(workspace, room, token, message string, level alert.Level) error {
+	url, post, err := s.preparePost(workspace, room, token, message, level)
 	if err != nil {
 		return err
 	}
@@ -115,8 +146,11 @@ func (s *Service) Alert(room, token, message string, level alert.Level) error {
 	return nil
 }
 
-func (s *Service) preparePost(room, token, message string, level alert.Level) (string, io.Reader, error) {
-	c := s.config()
+func (s *Service) preparePost(workspace, room, token, message string, level alert.Level) (string, io.Reader, error) {
+	c, err := s.config(workspace)
+	if err != nil {
+		return "", nil, err
+	}
 
 	if !c.Enabled {
 		return "", nil, errors.New("service is not enabled")
@@ -164,6 +198,10 @@ func (s *Service) preparePost(room, token, message string, level alert.Level) (s
 }
 
 type HandlerConfig struct {
+	// Workspace is the name of the HipChat configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
 	// HipChat room in which to post messages.
 	// If empty uses the channel from the configuration.
 	Room string `mapstructure:"room"`
@@ -189,6 +227,7 @@ func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
 
 func (h *handler) Handle(event alert.Event) {
 	if err := h.s.Alert(
+		h.c.Workspace,
 		h.c.Room,
 		h.c.Token,
 		event.State.Message,
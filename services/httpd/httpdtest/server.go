@@ -44,6 +44,10 @@ func (s *Server) AddRoutes(routes []httpd.Route) error {
 	return s.Handler.AddRoutes(routes)
 }
 
+func (s *Server) AddPreviewRoutes(routes []httpd.Route) error {
+	return s.Handler.AddPreviewRoutes(routes)
+}
+
 func (s *Server) DelRoutes(routes []httpd.Route) {
 	s.Handler.DelRoutes(routes)
 }
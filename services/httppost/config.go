@@ -1,7 +1,9 @@
 package httppost
 
 import (
+	"fmt"
 	"net/url"
+	"text/template"
 
 	"github.com/pkg/errors"
 )
@@ -30,6 +32,16 @@ type Config struct {
 	URL       string            `toml:"url" override:"url"`
 	Headers   map[string]string `toml:"headers" override:"headers"`
 	BasicAuth BasicAuth         `toml:"basic-auth" override:"basic-auth,redact"`
+	// ContentType of the request bodies, defaults to application/json.
+	ContentType string `toml:"content-type" override:"content-type"`
+	// Templates are named Go text/template templates for request bodies.
+	Templates map[string]string `toml:"templates" override:"templates"`
+	// AlertTemplate is the name of the template for the bodies of alerts.
+	// If empty the alert data is posted as JSON.
+	AlertTemplate string `toml:"alert-template" override:"alert-template"`
+	// RowTemplate is the name of the template for the bodies of rows posted by the httpPost node.
+	// If empty the row is posted as JSON.
+	RowTemplate string `toml:"row-template" override:"row-template"`
 }
 
 // Validate ensures that all configurations options are valid. The Endpoint,
@@ -47,9 +59,33 @@ func (c Config) Validate() error {
 		return errors.Wrapf(err, "invalid URL %q", c.URL)
 	}
 
+	templates, err := c.templates()
+	if err != nil {
+		return err
+	}
+	if _, ok := templates[c.AlertTemplate]; c.AlertTemplate != "" && !ok {
+		return fmt.Errorf("unknown alert-template %q", c.AlertTemplate)
+	}
+	if _, ok := templates[c.RowTemplate]; c.RowTemplate != "" && !ok {
+		return fmt.Errorf("unknown row-template %q", c.RowTemplate)
+	}
+
 	return nil
 }
 
+// templates parses the named templates.
+func (c Config) templates() (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template, len(c.Templates))
+	for name, text := range c.Templates {
+		t, err := parseTemplate(name, text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid template %q", name)
+		}
+		templates[name] = t
+	}
+	return templates, nil
+}
+
 // Configs is the configuration for all [[alertpost]] sections of the kapacitor
 // configuration file.
 type Configs []Config
@@ -70,7 +106,7 @@ func (cs Configs) index() map[string]*Endpoint {
 	m := map[string]*Endpoint{}
 
 	for _, c := range cs {
-		m[c.Endpoint] = NewEndpoint(c.URL, c.Headers, c.BasicAuth)
+		m[c.Endpoint] = newEndpoint(c)
 	}
 
 	return m
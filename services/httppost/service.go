@@ -9,18 +9,28 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"text/template"
 
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/bufpool"
+	"github.com/influxdata/kapacitor/models"
 )
 
+// defaultContentType is the content type of request bodies unless configured otherwise.
+const defaultContentType = "application/json"
+
 // Only one of name and url should be non-empty
 type Endpoint struct {
-	mu      sync.RWMutex
-	url     string
-	headers map[string]string
-	auth    BasicAuth
-	closed  bool
+	mu          sync.RWMutex
+	url         string
+	headers     map[string]string
+	auth        BasicAuth
+	contentType string
+	// templates by name
+	templates     map[string]*template.Template
+	alertTemplate string
+	rowTemplate   string
+	closed        bool
 }
 
 func NewEndpoint(url string, headers map[string]string, auth BasicAuth) *Endpoint {
@@ -30,6 +40,25 @@ func NewEndpoint(url string, headers map[string]string, auth BasicAuth) *Endpoin
 		auth:    auth,
 	}
 }
+
+// parseTemplate parses a body template.
+// Templates can use the json function to encode values as JSON.
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+}
+
+// newEndpoint creates an endpoint from a valid config.
+func newEndpoint(c Config) *Endpoint {
+	e := NewEndpoint(c.URL, c.Headers, c.BasicAuth)
+	e.Update(c)
+	return e
+}
+
 func (e *Endpoint) Close() {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -43,6 +72,57 @@ func (e *Endpoint) Update(c Config) {
 	e.url = c.URL
 	e.headers = c.Headers
 	e.auth = c.BasicAuth
+	e.contentType = c.ContentType
+	// The config has been validated
+	e.templates, _ = c.templates()
+	e.alertTemplate = c.AlertTemplate
+	e.rowTemplate = c.RowTemplate
+}
+
+// HasTemplate reports whether the endpoint has a template with the name.
+func (e *Endpoint) HasTemplate(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, ok := e.templates[name]
+	return ok
+}
+
+// AlertBody writes the request body of the alert data and returns its content type.
+// The template name and content type override the ones configured for the endpoint if not empty.
+func (e *Endpoint) AlertBody(w io.Writer, ad alert.Data, tmpl, contentType string) (string, error) {
+	return e.body(w, ad, ad, tmpl, e.alertTemplate, contentType)
+}
+
+// RowBody writes the request body of a row posted by the httpPost node and returns its content type.
+// The template name and content type override the ones configured for the endpoint if not empty.
+func (e *Endpoint) RowBody(w io.Writer, row *models.Row, tmpl, contentType string) (string, error) {
+	return e.body(w, models.Result{Series: models.Rows{row}}, row, tmpl, e.rowTemplate, contentType)
+}
+
+// body writes data as JSON, or executes the template with tmplData if a template is used.
+func (e *Endpoint) body(w io.Writer, data, tmplData interface{}, name, defaultName, contentType string) (string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if contentType == "" {
+		contentType = e.contentType
+	}
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	if name == "" {
+		name = defaultName
+	}
+	if name == "" {
+		return contentType, json.NewEncoder(w).Encode(data)
+	}
+	t, ok := e.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown template %q", name)
+	}
+	if err := t.Execute(w, tmplData); err != nil {
+		return "", fmt.Errorf("failed to execute template %q: %v", name, err)
+	}
+	return contentType, nil
 }
 
 func (e *Endpoint) NewHTTPRequest(body io.Reader) (req *http.Request, err error) {
@@ -103,7 +183,8 @@ func (s *Service) Update(newConfigs []interface{}) error {
 			}
 			e, ok := s.endpoints[c.Endpoint]
 			if !ok {
-				s.endpoints[c.Endpoint] = NewEndpoint(c.URL, c.Headers, c.BasicAuth)
+				s.endpoints[c.Endpoint] = newEndpoint(c)
+				endpointSet[c.Endpoint] = true
 				continue
 			}
 			e.Update(c)
@@ -134,9 +215,11 @@ func (s *Service) Close() error {
 }
 
 type testOptions struct {
-	Endpoint string            `json:"endpoint"`
-	URL      string            `json:"url"`
-	Headers  map[string]string `json:"headers"`
+	Endpoint    string            `json:"endpoint"`
+	URL         string            `json:"url"`
+	Headers     map[string]string `json:"headers"`
+	ContentType string            `json:"content-type"`
+	Template    string            `json:"template"`
 }
 
 func (s *Service) TestOptions() interface{} {
@@ -158,9 +241,23 @@ func (s *Service) Test(options interface{}) error {
 	body := bytes.NewBuffer(nil)
 	ad := event.AlertData()
 
-	err = json.NewEncoder(body).Encode(ad)
-	if err != nil {
-		return fmt.Errorf("failed to marshal alert data json: %v", err)
+	contentType := o.ContentType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	if o.Template != "" {
+		tmpl, err := parseTemplate("test", o.Template)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %v", err)
+		}
+		if err := tmpl.Execute(body, ad); err != nil {
+			return fmt.Errorf("failed to execute template: %v", err)
+		}
+	} else {
+		err = json.NewEncoder(body).Encode(ad)
+		if err != nil {
+			return fmt.Errorf("failed to marshal alert data json: %v", err)
+		}
 	}
 
 	// Create the HTTP request
@@ -170,9 +267,12 @@ func (s *Service) Test(options interface{}) error {
 		headers: o.Headers,
 	}
 	req, err = e.NewHTTPRequest(body)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %v", err)
+	}
 
 	// Execute the request
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to POST alert data: %v", err)
@@ -185,29 +285,42 @@ type HandlerConfig struct {
 	URL      string            `mapstructure:"url"`
 	Endpoint string            `mapstructure:"endpoint"`
 	Headers  map[string]string `mapstructure:"headers"`
+	// Template is the name of the endpoint template used to render the body.
+	Template string `mapstructure:"template"`
+	// ContentType overrides the content type of the endpoint.
+	ContentType string `mapstructure:"content-type"`
 }
 
 type handler struct {
-	s        *Service
-	bp       *bufpool.Pool
-	endpoint *Endpoint
-	logger   *log.Logger
-	headers  map[string]string
+	s           *Service
+	bp          *bufpool.Pool
+	endpoint    *Endpoint
+	logger      *log.Logger
+	headers     map[string]string
+	template    string
+	contentType string
 }
 
-func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
+func (s *Service) Handler(c HandlerConfig, l *log.Logger) (alert.Handler, error) {
 	e, ok := s.Endpoint(c.Endpoint)
 	if !ok {
+		if c.Template != "" {
+			return nil, fmt.Errorf("template %q requires an endpoint, %q does not exist", c.Template, c.Endpoint)
+		}
 		e = NewEndpoint(c.URL, nil, BasicAuth{})
+	} else if c.Template != "" && !e.HasTemplate(c.Template) {
+		return nil, fmt.Errorf("endpoint %q has no template %q", c.Endpoint, c.Template)
 	}
 
 	return &handler{
-		s:        s,
-		bp:       bufpool.New(),
-		endpoint: e,
-		logger:   l,
-		headers:  c.Headers,
-	}
+		s:           s,
+		bp:          bufpool.New(),
+		endpoint:    e,
+		logger:      l,
+		headers:     c.Headers,
+		template:    c.Template,
+		contentType: c.ContentType,
+	}, nil
 }
 
 func (h *handler) NewHTTPRequest(body io.Reader) (req *http.Request, err error) {
@@ -224,6 +337,12 @@ func (h *handler) NewHTTPRequest(body io.Reader) (req *http.Request, err error)
 }
 
 func (h *handler) Handle(event alert.Event) {
+	if err := h.TryHandle(event); err != nil {
+		h.logger.Printf("E! %v", err)
+	}
+}
+
+func (h *handler) TryHandle(event alert.Event) error {
 	var err error
 
 	// Construct the body of the HTTP request
@@ -231,24 +350,25 @@ func (h *handler) Handle(event alert.Event) {
 	defer h.bp.Put(body)
 	ad := event.AlertData()
 
-	err = json.NewEncoder(body).Encode(ad)
+	contentType, err := h.endpoint.AlertBody(body, ad, h.template, h.contentType)
 	if err != nil {
-		h.logger.Printf("E! failed to marshal alert data json: %v", err)
-		return
+		return err
 	}
 
 	req, err := h.NewHTTPRequest(body)
 	if err != nil {
-		h.logger.Printf("E! fail to create HTTP request: %v", err)
-		return
+		return fmt.Errorf("fail to create HTTP request: %v", err)
 	}
 
 	// Execute the request
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		h.logger.Printf("E! failed to POST alert data: %v", err)
-		return
+		return fmt.Errorf("failed to POST alert data: %v", err)
 	}
 	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to POST alert data: unexpected response code %d", resp.StatusCode)
+	}
+	return nil
 }
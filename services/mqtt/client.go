@@ -1,6 +1,7 @@
 package mqtt
 
 import (
+	"sync"
 	"time"
 
 	pahomqtt "github.com/eclipse/paho.mqtt.golang"
@@ -13,8 +14,14 @@ type Client interface {
 	Connect() error
 	Disconnect()
 	Publish(topic string, qos QoSLevel, retained bool, message []byte) error
+	// Subscribe calls the handler for each message received on the topic filter.
+	// The subscription is kept across reconnects.
+	Subscribe(topic string, qos QoSLevel, handler MessageHandler) error
 }
 
+// MessageHandler is called with the topic and the payload of a received message.
+type MessageHandler func(topic string, payload []byte)
+
 // newClient produces a disconnected MQTT client
 var newClient = func(c Config) (Client, error) {
 	opts := pahomqtt.NewClientOptions()
@@ -33,14 +40,27 @@ var newClient = func(c Config) (Client, error) {
 	}
 	opts.SetTLSConfig(tlsConfig)
 
-	return &PahoClient{
+	p := &PahoClient{
 		opts: opts,
-	}, nil
+	}
+	// The broker forgets the subscriptions of a clean session,
+	// so they are renewed whenever the client (re)connects.
+	opts.SetOnConnectHandler(p.resubscribe)
+	return p, nil
 }
 
 type PahoClient struct {
 	opts   *pahomqtt.ClientOptions
 	client pahomqtt.Client
+
+	mu            sync.Mutex
+	subscriptions []subscription
+}
+
+type subscription struct {
+	topic   string
+	qos     QoSLevel
+	handler MessageHandler
 }
 
 // DefaultQuiesceTimeout is the duration the client will wait for outstanding
@@ -72,3 +92,35 @@ func (p *PahoClient) Publish(topic string, qos QoSLevel, retained bool, message
 	token.Wait()
 	return token.Error()
 }
+
+func (p *PahoClient) Subscribe(topic string, qos QoSLevel, handler MessageHandler) error {
+	sub := subscription{
+		topic:   topic,
+		qos:     qos,
+		handler: handler,
+	}
+	p.mu.Lock()
+	p.subscriptions = append(p.subscriptions, sub)
+	p.mu.Unlock()
+	return p.subscribe(p.client, sub)
+}
+
+func (p *PahoClient) subscribe(client pahomqtt.Client, sub subscription) error {
+	token := client.Subscribe(sub.topic, byte(sub.qos), func(_ pahomqtt.Client, m pahomqtt.Message) {
+		sub.handler(m.Topic(), m.Payload())
+	})
+	token.Wait()
+	return token.Error()
+}
+
+// resubscribe renews the subscriptions after a reconnect.
+// Failures are not reported, the subscription is retried on the next reconnect.
+func (p *PahoClient) resubscribe(client pahomqtt.Client) {
+	p.mu.Lock()
+	subs := make([]subscription, len(p.subscriptions))
+	copy(subs, p.subscriptions)
+	p.mu.Unlock()
+	for _, sub := range subs {
+		p.subscribe(client, sub)
+	}
+}
@@ -1,7 +1,9 @@
 package mqtt
 
 import (
-	"errors"
+	"reflect"
+
+	"github.com/pkg/errors"
 )
 
 type Config struct {
@@ -29,6 +31,9 @@ type Config struct {
 	Username string `toml:"username" override:"username"`
 	Password string `toml:"password" override:"password,redact"`
 
+	// Subscriptions are the topic filters whose messages are written into the stream of the tasks.
+	Subscriptions []Subscription `toml:"subscriptions" override:"subscriptions"`
+
 	// NewClientF is a function that returns a client for a given config.
 	NewClientF func(c Config) (Client, error) `toml:"-" override:"-"`
 }
@@ -46,6 +51,11 @@ func (c Config) Validate() error {
 			return errors.New("must specify a url for mqtt service")
 		}
 	}
+	for i, s := range c.Subscriptions {
+		if err := s.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid subscription %d", i)
+		}
+	}
 	return nil
 }
 
@@ -94,6 +104,9 @@ func (c Config) Equal(o Config) bool {
 	if c.Password != o.Password {
 		return false
 	}
+	if !reflect.DeepEqual(c.Subscriptions, o.Subscriptions) {
+		return false
+	}
 	return true
 }
 
@@ -2,6 +2,7 @@ package mqtttest
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/influxdata/kapacitor/services/mqtt"
 )
@@ -23,7 +24,8 @@ func (s *ClientCreator) NewClient(c mqtt.Config) (mqtt.Client, error) {
 type MockClient struct {
 	connected bool
 
-	PublishData []PublishData
+	PublishData   []PublishData
+	SubscribeData []SubscribeData
 }
 
 func NewClient(mqtt.Config) (mqtt.Client, error) {
@@ -58,3 +60,45 @@ type PublishData struct {
 	Retained bool
 	Message  []byte
 }
+
+func (m *MockClient) Subscribe(topic string, qos mqtt.QoSLevel, handler mqtt.MessageHandler) error {
+	if !m.connected {
+		return errors.New("Subscribe() called before Connect()")
+	}
+	m.SubscribeData = append(m.SubscribeData, SubscribeData{
+		Topic:   topic,
+		QoS:     qos,
+		Handler: handler,
+	})
+	return nil
+}
+
+// Receive delivers a message to the handlers of the subscriptions whose topic filter matches the topic.
+func (m *MockClient) Receive(topic string, payload []byte) {
+	for _, s := range m.SubscribeData {
+		if matchTopic(s.Topic, topic) {
+			s.Handler(topic, payload)
+		}
+	}
+}
+
+// matchTopic reports whether the topic matches the filter, honoring the + and # wildcards.
+func matchTopic(filter, topic string) bool {
+	fs := strings.Split(filter, "/")
+	ts := strings.Split(topic, "/")
+	for i, f := range fs {
+		if f == "#" {
+			return true
+		}
+		if i >= len(ts) || (f != "+" && f != ts[i]) {
+			return false
+		}
+	}
+	return len(fs) == len(ts)
+}
+
+type SubscribeData struct {
+	Topic   string
+	QoS     mqtt.QoSLevel
+	Handler mqtt.MessageHandler
+}
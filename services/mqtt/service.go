@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
+	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/pkg/errors"
 )
@@ -61,6 +63,11 @@ type Service struct {
 	configs map[string]Config
 
 	defaultBrokerName string
+
+	// PointsWriter receives the points of the messages of the subscriptions.
+	PointsWriter interface {
+		WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error
+	}
 }
 
 func NewService(cs Configs, l *log.Logger) (*Service, error) {
@@ -102,10 +109,42 @@ func (s *Service) Open() error {
 		if err := client.Connect(); err != nil {
 			return errors.Wrapf(err, "failed to connect to MQTT broker %q", name)
 		}
+		if err := s.subscribe(s.configs[name], client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subscribe subscribes the client to the topic filters of the broker configuration.
+func (s *Service) subscribe(c Config, client Client) error {
+	for _, sub := range c.Subscriptions {
+		sub := sub
+		p, err := newParser(sub)
+		if err != nil {
+			return errors.Wrapf(err, "invalid subscription to %q of MQTT broker %q", sub.Topic, c.Name)
+		}
+		if err := client.Subscribe(sub.Topic, QoSLevel(sub.QoS), func(topic string, payload []byte) {
+			s.receive(c.Name, sub, p, topic, payload)
+		}); err != nil {
+			return errors.Wrapf(err, "failed to subscribe to %q of MQTT broker %q", sub.Topic, c.Name)
+		}
 	}
 	return nil
 }
 
+// receive writes the points of a message into the database and retention policy of the subscription.
+func (s *Service) receive(brokerName string, sub Subscription, p *parser, topic string, payload []byte) {
+	points, err := p.parse(topic, payload, time.Now().UTC())
+	if err != nil {
+		s.logger.Printf("E! failed to parse message on topic %q of MQTT broker %q: %v", topic, brokerName, err)
+		return
+	}
+	if err := s.PointsWriter.WritePoints(sub.Database, sub.RetentionPolicy, models.ConsistencyLevelAll, points); err != nil {
+		s.logger.Printf("E! failed to write points of topic %q of MQTT broker %q: %v", topic, brokerName, err)
+	}
+}
+
 func (s *Service) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -179,6 +218,10 @@ func (s *Service) update(cs Configs) error {
 			if err := client.Connect(); err != nil {
 				return err
 			}
+			if err := s.subscribe(c, client); err != nil {
+				client.Disconnect()
+				return err
+			}
 			s.clients[name] = client
 		}
 	}
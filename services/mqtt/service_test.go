@@ -0,0 +1,168 @@
+package mqtt_test
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/services/mqtt"
+	"github.com/influxdata/kapacitor/services/mqtt/mqtttest"
+)
+
+type pointsWriter struct {
+	database        string
+	retentionPolicy string
+	points          []models.Point
+}
+
+func (w *pointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	w.database = database
+	w.retentionPolicy = retentionPolicy
+	w.points = append(w.points, points...)
+	return nil
+}
+
+func newService(t *testing.T, subs ...mqtt.Subscription) (*mqtt.Service, *mqtttest.MockClient, *pointsWriter) {
+	cc := new(mqtttest.ClientCreator)
+	c := mqtt.NewConfig()
+	c.Enabled = true
+	c.Name = "test"
+	c.URL = "tcp://localhost:1883"
+	c.Subscriptions = subs
+	c.NewClientF = cc.NewClient
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := mqtt.NewService(mqtt.Configs{c}, log.New(os.Stderr, "[mqtt] ", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := new(pointsWriter)
+	s.PointsWriter = w
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	return s, cc.Clients[0], w
+}
+
+func TestService_Subscription_LineProtocol(t *testing.T) {
+	s, cli, w := newService(t, mqtt.Subscription{
+		Topic:           "sensors/#",
+		QoS:             1,
+		Database:        "db",
+		RetentionPolicy: "rp",
+		Precision:       "s",
+		TopicTags:       map[string]int{"site": 1},
+	})
+	defer s.Close()
+
+	if got, exp := len(cli.SubscribeData), 1; got != exp {
+		t.Fatalf("unexpected number of subscriptions: got %d exp %d", got, exp)
+	}
+	if got, exp := cli.SubscribeData[0].QoS, mqtt.AtLeastOnce; got != exp {
+		t.Errorf("unexpected qos: got %v exp %v", got, exp)
+	}
+
+	cli.Receive("sensors/east/1", []byte("temp,sensor=a value=21.5 60\ntemp,sensor=b value=22 61"))
+
+	if got, exp := w.database, "db"; got != exp {
+		t.Errorf("unexpected database: got %q exp %q", got, exp)
+	}
+	if got, exp := w.retentionPolicy, "rp"; got != exp {
+		t.Errorf("unexpected retention policy: got %q exp %q", got, exp)
+	}
+	exp := []string{
+		"temp,sensor=a,site=east value=21.5 60000000000",
+		"temp,sensor=b,site=east value=22 61000000000",
+	}
+	if got := pointStrings(w.points); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected points:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestService_Subscription_JSON(t *testing.T) {
+	s, cli, w := newService(t, mqtt.Subscription{
+		Topic:       "sensors/+/+",
+		Database:    "db",
+		Format:      mqtt.FormatJSON,
+		Precision:   "ms",
+		Measurement: "{{ index .Segments 2 }}",
+		TopicTags:   map[string]int{"site": 1},
+		TagKeys:     []string{"sensor"},
+		TimeKey:     "time",
+	})
+	defer s.Close()
+
+	cli.Receive("sensors/east/temp", []byte(`[
+		{"sensor": "a", "time": 1000, "value": 21.5, "ok": true, "status": {"code": 3}},
+		{"sensor": "b", "time": "1970-01-01T00:00:02Z", "value": 22, "note": "hot", "unit": null}
+	]`))
+	// Messages that are not objects are dropped.
+	cli.Receive("sensors/east/temp", []byte(`42`))
+	// Messages of other topics are not received.
+	cli.Receive("devices/east/temp", []byte(`{"value": 1}`))
+
+	exp := []string{
+		"temp,sensor=a,site=east ok=true,status_code=3,value=21.5 1000000000",
+		`temp,sensor=b,site=east note="hot",value=22 2000000000`,
+	}
+	if got := pointStrings(w.points); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected points:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestService_Subscription_ReceivedTime(t *testing.T) {
+	s, cli, w := newService(t, mqtt.Subscription{
+		Topic:       "sensors/+",
+		Database:    "db",
+		Format:      mqtt.FormatJSON,
+		Measurement: "{{ .Topic }}",
+	})
+	defer s.Close()
+
+	start := time.Now()
+	cli.Receive("sensors/temp", []byte(`{"value": 1}`))
+	if got, exp := len(w.points), 1; got != exp {
+		t.Fatalf("unexpected number of points: got %d exp %d", got, exp)
+	}
+	p := w.points[0]
+	if got, exp := p.Name(), "sensors/temp"; got != exp {
+		t.Errorf("unexpected measurement: got %q exp %q", got, exp)
+	}
+	if p.Time().Before(start) {
+		t.Errorf("unexpected time: got %v, expected the time the message was received", p.Time())
+	}
+}
+
+func TestSubscription_Validate(t *testing.T) {
+	testCases := []struct {
+		s   mqtt.Subscription
+		err bool
+	}{
+		{s: mqtt.Subscription{Topic: "t", Database: "db"}},
+		{s: mqtt.Subscription{Database: "db"}, err: true},
+		{s: mqtt.Subscription{Topic: "t"}, err: true},
+		{s: mqtt.Subscription{Topic: "t", Database: "db", QoS: 3}, err: true},
+		{s: mqtt.Subscription{Topic: "t", Database: "db", Format: "csv"}, err: true},
+		{s: mqtt.Subscription{Topic: "t", Database: "db", Format: mqtt.FormatJSON}, err: true},
+		{s: mqtt.Subscription{Topic: "t", Database: "db", Precision: "d"}, err: true},
+		{s: mqtt.Subscription{Topic: "t", Database: "db", Measurement: "{{ .Topic "}, err: true},
+		{s: mqtt.Subscription{Topic: "t", Database: "db", TopicTags: map[string]int{"k": -1}}, err: true},
+	}
+	for i, tc := range testCases {
+		if err := tc.s.Validate(); (err != nil) != tc.err {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func pointStrings(points []models.Point) []string {
+	s := make([]string, len(points))
+	for i, p := range points {
+		s[i] = p.String()
+	}
+	return s
+}
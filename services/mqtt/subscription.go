@@ -0,0 +1,265 @@
+package mqtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FormatLineProtocol parses messages as InfluxDB line protocol.
+	FormatLineProtocol = "line-protocol"
+	// FormatJSON parses messages as a JSON object, or an array of objects, per point.
+	FormatJSON = "json"
+)
+
+// Subscription is a topic filter of a broker,
+// the messages received are written as points into the stream of the tasks.
+type Subscription struct {
+	// Topic is the topic filter, it may contain the + and # wildcards.
+	Topic string `toml:"topic" mapstructure:"topic" json:"topic"`
+	// QoS is the maximum quality of service of the messages received, one of 0, 1 or 2.
+	QoS int `toml:"qos" mapstructure:"qos" json:"qos"`
+
+	// Database and RetentionPolicy the points are written to.
+	// If the retention policy is empty the default retention policy is used.
+	Database        string `toml:"database" mapstructure:"database" json:"database"`
+	RetentionPolicy string `toml:"retention-policy" mapstructure:"retention-policy" json:"retention-policy"`
+
+	// Format of the messages, either line-protocol or json.
+	Format string `toml:"format" mapstructure:"format" json:"format"`
+	// Precision of numeric timestamps, one of n, u, ms, s, m or h.
+	// Defaults to n.
+	Precision string `toml:"precision" mapstructure:"precision" json:"precision"`
+
+	// Measurement is a template of the measurement name, executed with the Topic and its Segments.
+	// Required for JSON messages, it overrides the measurement of line protocol messages.
+	Measurement string `toml:"measurement" mapstructure:"measurement" json:"measurement"`
+	// TopicTags are tags whose values are segments of the topic, by the index of the segment starting at 0.
+	TopicTags map[string]int `toml:"topic-tags" mapstructure:"topic-tags" json:"topic-tags"`
+
+	// TagKeys are the keys of JSON values that are tags instead of fields.
+	TagKeys []string `toml:"tag-keys" mapstructure:"tag-keys" json:"tag-keys"`
+	// TimeKey is the key of the JSON value of the time of the point,
+	// either an RFC3339 string or a number in the precision.
+	// If empty or missing the time the message was received is used.
+	TimeKey string `toml:"time-key" mapstructure:"time-key" json:"time-key"`
+}
+
+func (s Subscription) Validate() error {
+	if s.Topic == "" {
+		return errors.New("must specify a topic")
+	}
+	if s.QoS < 0 || s.QoS > 2 {
+		return fmt.Errorf("invalid qos %d, must be 0, 1 or 2", s.QoS)
+	}
+	if s.Database == "" {
+		return errors.New("must specify a database")
+	}
+	switch s.Format {
+	case "", FormatLineProtocol:
+	case FormatJSON:
+		if s.Measurement == "" {
+			return errors.New("must specify a measurement for json messages")
+		}
+	default:
+		return fmt.Errorf("invalid format %q, must be %s or %s", s.Format, FormatLineProtocol, FormatJSON)
+	}
+	switch s.Precision {
+	case "", "n", "u", "ms", "s", "m", "h":
+	default:
+		return fmt.Errorf("invalid precision %q", s.Precision)
+	}
+	if _, err := template.New("measurement").Parse(s.Measurement); err != nil {
+		return errors.Wrap(err, "invalid measurement template")
+	}
+	for k, i := range s.TopicTags {
+		if i < 0 {
+			return fmt.Errorf("invalid topic segment %d of tag %q", i, k)
+		}
+	}
+	return nil
+}
+
+// topicData is the data the measurement template is executed with.
+type topicData struct {
+	// Topic of the message.
+	Topic string
+	// Segments of the topic, split on /.
+	Segments []string
+}
+
+// parser parses the messages of a validated subscription into points.
+type parser struct {
+	s           Subscription
+	measurement *template.Template
+	tagKeys     map[string]bool
+}
+
+func newParser(s Subscription) (*parser, error) {
+	p := &parser{
+		s:       s,
+		tagKeys: make(map[string]bool, len(s.TagKeys)),
+	}
+	if s.Measurement != "" {
+		t, err := template.New("measurement").Parse(s.Measurement)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid measurement template")
+		}
+		p.measurement = t
+	}
+	for _, k := range s.TagKeys {
+		p.tagKeys[k] = true
+	}
+	return p, nil
+}
+
+// parse returns the points of a message received at now.
+func (p *parser) parse(topic string, payload []byte, now time.Time) ([]models.Point, error) {
+	td := topicData{
+		Topic:    topic,
+		Segments: strings.Split(topic, "/"),
+	}
+	var measurement string
+	if p.measurement != nil {
+		var buf bytes.Buffer
+		if err := p.measurement.Execute(&buf, td); err != nil {
+			return nil, errors.Wrap(err, "failed to render measurement")
+		}
+		measurement = buf.String()
+		if measurement == "" {
+			return nil, fmt.Errorf("empty measurement for topic %q", topic)
+		}
+	}
+	topicTags := make(map[string]string, len(p.s.TopicTags))
+	for k, i := range p.s.TopicTags {
+		if i < len(td.Segments) {
+			topicTags[k] = td.Segments[i]
+		}
+	}
+
+	if p.s.Format == FormatJSON {
+		return p.parseJSON(measurement, topicTags, payload, now)
+	}
+	precision := p.s.Precision
+	if precision == "" {
+		precision = "n"
+	}
+	points, err := models.ParsePointsWithPrecision(payload, now, precision)
+	if err != nil {
+		return nil, err
+	}
+	for _, pt := range points {
+		if measurement != "" {
+			pt.SetName(measurement)
+		}
+		for k, v := range topicTags {
+			pt.AddTag(k, v)
+		}
+	}
+	return points, nil
+}
+
+func (p *parser) parseJSON(measurement string, topicTags map[string]string, payload []byte, now time.Time) ([]models.Point, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, errors.Wrap(err, "invalid json")
+	}
+	var objects []interface{}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		objects = []interface{}{v}
+	case []interface{}:
+		objects = v
+	default:
+		return nil, errors.New("json message must be an object or an array of objects")
+	}
+
+	points := make([]models.Point, 0, len(objects))
+	for _, o := range objects {
+		obj, ok := o.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("json message must be an object or an array of objects")
+		}
+		tags := make(map[string]string, len(topicTags)+len(p.tagKeys))
+		for k, v := range topicTags {
+			tags[k] = v
+		}
+		fields := make(models.Fields, len(obj))
+		t := now
+		if tv, ok := obj[p.s.TimeKey]; ok && p.s.TimeKey != "" {
+			var err error
+			t, err = p.parseTime(tv)
+			if err != nil {
+				return nil, err
+			}
+			delete(obj, p.s.TimeKey)
+		}
+		p.flatten("", obj, tags, fields)
+		if len(fields) == 0 {
+			return nil, errors.New("json object has no fields")
+		}
+		pt, err := models.NewPoint(measurement, models.NewTags(tags), fields, t)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, pt)
+	}
+	return points, nil
+}
+
+// flatten adds the values of the object as tags or fields,
+// the keys of nested objects are joined with an underscore.
+func (p *parser) flatten(prefix string, obj map[string]interface{}, tags map[string]string, fields models.Fields) {
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + "_" + k
+		}
+		if p.tagKeys[key] {
+			if v != nil {
+				tags[key] = fmt.Sprint(v)
+			}
+			continue
+		}
+		switch v := v.(type) {
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				fields[key] = f
+			}
+		case bool:
+			fields[key] = v
+		case string:
+			fields[key] = v
+		case map[string]interface{}:
+			p.flatten(key, v, tags, fields)
+		}
+	}
+}
+
+func (p *parser) parseTime(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid time %q", v)
+		}
+		return t, nil
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid time %q", v)
+		}
+		return time.Unix(0, i*models.GetPrecisionMultiplier(p.s.Precision)).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid time %v", v)
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"sync/atomic"
+	"sync"
 	"time"
 
 	"github.com/influxdata/kapacitor/alert"
@@ -17,16 +17,18 @@ import (
 )
 
 type Service struct {
-	configValue atomic.Value
-	logger      *log.Logger
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
+	logger           *log.Logger
 }
 
-func NewService(c Config, l *log.Logger) *Service {
-	s := &Service{
-		logger: l,
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
 	}
-	s.configValue.Store(c)
-	return s
 }
 
 func (s *Service) Open() error {
@@ -37,28 +39,53 @@ func (s *Service) Close() error {
 	return nil
 }
 
-func (s *Service) config() Config {
-	return s.configValue.Load().(Config)
+// config returns the configuration of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown OpsGenie workspace %q", workspace)
+	}
+	return c, nil
 }
 
-func (s *Service) Update(newConfig []interface{}) error {
-	if l := len(newConfig); l != 1 {
-		return fmt.Errorf("expected only one new config object, got %d", l)
+// defaultConfig returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	c, _ := s.config("")
+	return c
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
 	}
-	if c, ok := newConfig[0].(Config); !ok {
-		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
-	} else {
-		s.configValue.Store(c)
+	if err := cs.Validate(); err != nil {
+		return err
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
 	return nil
 }
 
 func (s *Service) Global() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.Global
 }
 
 type testOptions struct {
+	Workspace   string   `json:"workspace"`
 	Teams       []string `json:"teams"`
 	Recipients  []string `json:"recipients"`
 	MessageType string   `json:"message-type"`
@@ -67,8 +94,12 @@ type testOptions struct {
 }
 
 func (s *Service) TestOptions() interface{} {
-	c := s.config()
+	s.mu.RLock()
+	defaultWorkspace := s.defaultWorkspace
+	s.mu.RUnlock()
+	c := s.defaultConfig()
 	return &testOptions{
+		Workspace:   defaultWorkspace,
 		Teams:       c.Teams,
 		Recipients:  c.Recipients,
 		MessageType: "CRITICAL",
@@ -83,6 +114,7 @@ func (s *Service) Test(options interface{}) error {
 		return fmt.Errorf("unexpected options type %T", options)
 	}
 	return s.Alert(
+		o.Workspace,
 		o.Teams,
 		o.Recipients,
 		o.MessageType,
@@ -93,8 +125,9 @@ func (s *Service) Test(options interface{}) error {
 	)
 }
 
-func (s *Service) Alert(teams []string, recipients []string, messageType, message, entityID string, t time.Time, details models.Result) error {
-	url, post, err := s.preparePost(teams, recipients, messageType, message, entityID, t, details)
+// Alert creates or closes the OpsGenie alert using the workspace, or the default workspace if workspace is empty.
+func (s *Service) Alert(workspace string, teams []string, recipients []string, messageType, message, entityID string, t time.Time, details models.Result) error {
+	url, post, err := s.preparePost(workspace, teams, recipients, messageType, message, entityID, t, details)
 	if err != nil {
 		return err
 	}
@@ -121,8 +154,11 @@ func (s *Service) Alert(teams []string, recipients []string, messageType, messag
 	return nil
 }
 
-func (s *Service) preparePost(teams []string, recipients []string, messageType, message, entityID string, t time.Time, details models.Result) (string, io.Reader, error) {
-	c := s.config()
+func (s *Service) preparePost(workspace string, teams []string, recipients []string, messageType, message, entityID string, t time.Time, details models.Result) (string, io.Reader, error) {
+	c, err := s.config(workspace)
+	if err != nil {
+		return "", nil, err
+	}
 	if !c.Enabled {
 		return "", nil, errors.New("service is not enabled")
 	}
@@ -184,6 +220,10 @@ func (s *Service) preparePost(teams []string, recipients []string, messageType,
 }
 
 type HandlerConfig struct {
+	// Workspace is the name of the OpsGenie configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
 	// OpsGenie Teams.
 	TeamsList []string `mapstructure:"teams-list"`
 
@@ -214,6 +254,7 @@ func (h *handler) Handle(event alert.Event) {
 		messageType = event.State.Level.String()
 	}
 	if err := h.s.Alert(
+		h.c.Workspace,
 		h.c.TeamsList,
 		h.c.RecipientsList,
 		messageType,
@@ -9,14 +9,16 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"sync/atomic"
+	"sync"
 
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/models"
 )
 
 type Service struct {
-	configValue atomic.Value
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
 
 	HTTPDService interface {
 		URL() string
@@ -24,12 +26,12 @@ type Service struct {
 	logger *log.Logger
 }
 
-func NewService(c Config, l *log.Logger) *Service {
-	s := &Service{
-		logger: l,
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
 	}
-	s.configValue.Store(c)
-	return s
 }
 
 func (s *Service) Open() error {
@@ -40,35 +42,63 @@ func (s *Service) Close() error {
 	return nil
 }
 
-func (s *Service) config() Config {
-	return s.configValue.Load().(Config)
+// config returns the configuration of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown PagerDuty workspace %q", workspace)
+	}
+	return c, nil
+}
+
+// defaultConfig returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	c, _ := s.config("")
+	return c
 }
 
-func (s *Service) Update(newConfig []interface{}) error {
-	if l := len(newConfig); l != 1 {
-		return fmt.Errorf("expected only one new config object, got %d", l)
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
 	}
-	if c, ok := newConfig[0].(Config); !ok {
-		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
-	} else {
-		s.configValue.Store(c)
+	if err := cs.Validate(); err != nil {
+		return err
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
 	return nil
 }
 
 func (s *Service) Global() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.Global
 }
 
 type testOptions struct {
+	Workspace   string      `json:"workspace"`
 	IncidentKey string      `json:"incident-key"`
 	Description string      `json:"description"`
 	Level       alert.Level `json:"level"`
 }
 
 func (s *Service) TestOptions() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return &testOptions{
+		Workspace:   s.defaultWorkspace,
 		IncidentKey: "testIncidentKey",
 		Description: "test pagerduty message",
 		Level:       alert.Critical,
@@ -80,9 +110,9 @@ func (s *Service) Test(options interface{}) error {
 	if !ok {
 		return fmt.Errorf("unexpected options type %T", options)
 	}
-	c := s.config()
 	return s.Alert(
-		c.ServiceKey,
+		o.Workspace,
+		"",
 		o.IncidentKey,
 		o.Description,
 		o.Level,
@@ -90,8 +120,9 @@ func (s *Service) Test(options interface{}) error {
 	)
 }
 
-func (s *Service) Alert(serviceKey, incidentKey, desc string, level alert.Level, details models.Result) error {
-	url, post, err := s.preparePost(serviceKey, incidentKey, desc, level, details)
+// Alert sends the event to PagerDuty using the workspace, or the default workspace if workspace is empty.
+func (s *Service) Alert(workspace, serviceKey, incidentKey, desc string, level alert.Level, details models.Result) error {
+	url, post, err := s.preparePost(workspace, serviceKey, incidentKey, desc, level, details)
 	if err != nil {
 		return err
 	}
@@ -118,9 +149,11 @@ func (s *Service) Alert(serviceKey, incidentKey, desc string, level alert.Level,
 	return nil
 }
 
-func (s *Service) preparePost(serviceKey, incidentKey, desc string, level alert.Level, details models.Result) (string, io.Reader, error) {
-
-	c := s.config()
+func (s *Service) preparePost(workspace, serviceKey, incidentKey, desc string, level alert.Level, details models.Result) (string, io.Reader, error) {
+	c, err := s.config(workspace)
+	if err != nil {
+		return "", nil, err
+	}
 	if !c.Enabled {
 		return "", nil, errors.New("service is not enabled")
 	}
@@ -165,6 +198,10 @@ func (s *Service) preparePost(serviceKey, incidentKey, desc string, level alert.
 }
 
 type HandlerConfig struct {
+	// Workspace is the name of the PagerDuty configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
 	// The service key to use for the alert.
 	// Defaults to the value in the configuration if empty.
 	ServiceKey string `mapstructure:"service-key"`
@@ -185,13 +222,18 @@ func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
 }
 
 func (h *handler) Handle(event alert.Event) {
-	if err := h.s.Alert(
+	if err := h.TryHandle(event); err != nil {
+		h.logger.Println("E! failed to send event to PagerDuty", err)
+	}
+}
+
+func (h *handler) TryHandle(event alert.Event) error {
+	return h.s.Alert(
+		h.c.Workspace,
 		h.c.ServiceKey,
 		event.State.ID,
 		event.State.Message,
 		event.State.Level,
 		event.Data.Result,
-	); err != nil {
-		h.logger.Println("E! failed to send event to PagerDuty", err)
-	}
+	)
 }
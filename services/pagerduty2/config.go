@@ -0,0 +1,85 @@
+package pagerduty2
+
+import (
+	"net/url"
+
+	"github.com/influxdata/kapacitor/listmap"
+	"github.com/pkg/errors"
+)
+
+const DefaultPagerDuty2APIURL = "https://events.pagerduty.com/v2/enqueue"
+
+type Config struct {
+	// Whether PagerDuty integration is enabled.
+	Enabled bool `toml:"enabled" override:"enabled"`
+	// Workspace is the name of the configuration, handlers select it by name.
+	Workspace string `toml:"workspace" override:"workspace"`
+	// Whether this is the configuration used when handlers do not select a workspace.
+	// Only needed if there is more than one configuration.
+	Default bool `toml:"default" override:"default"`
+	// The PagerDuty Events API v2 URL, should not need to be changed.
+	URL string `toml:"url" override:"url"`
+	// The PagerDuty integration key of the service, used as the routing key of events.
+	RoutingKey string `toml:"routing-key" override:"routing-key,redact"`
+	// Whether every alert should automatically go to PagerDuty
+	Global bool `toml:"global" override:"global"`
+}
+
+func NewConfig() Config {
+	c := Config{}
+	c.Init()
+	return c
+}
+
+// Init sets the default values of the configuration.
+func (c *Config) Init() {
+	c.URL = DefaultPagerDuty2APIURL
+}
+
+func (c Config) Validate() error {
+	if c.URL == "" {
+		return errors.New("url cannot be empty")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return errors.Wrapf(err, "invalid URL %q", c.URL)
+	}
+	return nil
+}
+
+// Configs is the configuration for all [[pagerduty2]] sections of the kapacitor
+// configuration file. A single [pagerduty2] section is also accepted.
+type Configs []Config
+
+func (cs *Configs) UnmarshalTOML(data interface{}) error {
+	return listmap.DoUnmarshalTOML(cs, data)
+}
+
+// Validate calls config.Validate for each element in Configs
+// and checks that the workspaces are unique and there is a single default.
+func (cs Configs) Validate() error {
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return listmap.ValidateNames("workspace", len(cs), cs.workspace)
+}
+
+// workspace returns the workspace of the config i and whether it is the default.
+func (cs Configs) workspace(i int) (string, bool) {
+	return cs[i].Workspace, cs[i].Default
+}
+
+// index generates a map of configs by workspace
+func (cs Configs) index() map[string]Config {
+	m := make(map[string]Config, len(cs))
+	for _, c := range cs {
+		m[c.Workspace] = c
+	}
+	return m
+}
+
+// defaultWorkspace returns the workspace used when handlers do not select one.
+func (cs Configs) defaultWorkspace() string {
+	return listmap.DefaultName(len(cs), cs.workspace)
+}
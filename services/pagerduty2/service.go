@@ -0,0 +1,307 @@
+package pagerduty2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+type Service struct {
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
+
+	HTTPDService interface {
+		URL() string
+	}
+	logger *log.Logger
+}
+
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
+	}
+}
+
+func (s *Service) Open() error {
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+// config returns the configuration of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown PagerDuty workspace %q", workspace)
+	}
+	return c, nil
+}
+
+// defaultConfig returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	c, _ := s.config("")
+	return c
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
+	}
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
+	return nil
+}
+
+func (s *Service) Global() bool {
+	c := s.defaultConfig()
+	return c.Global
+}
+
+type testOptions struct {
+	Workspace   string      `json:"workspace"`
+	AlertID     string      `json:"alert-id"`
+	Description string      `json:"description"`
+	Level       alert.Level `json:"level"`
+}
+
+func (s *Service) TestOptions() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &testOptions{
+		Workspace:   s.defaultWorkspace,
+		AlertID:     "testAlertID",
+		Description: "test pagerduty2 message",
+		Level:       alert.Critical,
+	}
+}
+
+func (s *Service) Test(options interface{}) error {
+	o, ok := options.(*testOptions)
+	if !ok {
+		return fmt.Errorf("unexpected options type %T", options)
+	}
+	return s.Alert(
+		o.Workspace,
+		"",
+		nil,
+		nil,
+		o.AlertID,
+		o.Description,
+		o.Level,
+		time.Now(),
+		defaultSource,
+		nil,
+	)
+}
+
+// Link is a link shown with the incident.
+type Link struct {
+	Href string `mapstructure:"href" json:"href"`
+	Text string `mapstructure:"text" json:"text,omitempty"`
+}
+
+// Image is an image shown with the incident.
+type Image struct {
+	Src  string `mapstructure:"src" json:"src"`
+	Href string `mapstructure:"href" json:"href,omitempty"`
+	Alt  string `mapstructure:"alt" json:"alt,omitempty"`
+}
+
+// defaultSource is the source of events that do not have a host tag.
+const defaultSource = "kapacitor"
+
+// event is the body of a PagerDuty Events API v2 request.
+type event struct {
+	RoutingKey  string   `json:"routing_key"`
+	EventAction string   `json:"event_action"`
+	DedupKey    string   `json:"dedup_key"`
+	Payload     *payload `json:"payload,omitempty"`
+	Client      string   `json:"client"`
+	ClientURL   string   `json:"client_url"`
+	Links       []Link   `json:"links,omitempty"`
+	Images      []Image  `json:"images,omitempty"`
+}
+
+type payload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// Alert sends an event for the alert to PagerDuty.
+// The alert ID is the dedup key of the event, so that the OK level resolves the incident the alert triggered.
+// The event is sent using the workspace, or the default workspace if workspace is empty.
+func (s *Service) Alert(workspace, routingKey string, links []Link, images []Image, alertID, desc string, level alert.Level, t time.Time, source string, details map[string]interface{}) error {
+	url, post, err := s.preparePost(workspace, routingKey, links, images, alertID, desc, level, t, source, details)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", post)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		type response struct {
+			Message string   `json:"message"`
+			Errors  []string `json:"errors"`
+		}
+		r := &response{Message: fmt.Sprintf("failed to understand PagerDuty response. code: %d content: %s", resp.StatusCode, string(body))}
+		b := bytes.NewReader(body)
+		dec := json.NewDecoder(b)
+		dec.Decode(r)
+		if len(r.Errors) > 0 {
+			return fmt.Errorf("%s: %s", r.Message, strings.Join(r.Errors, ", "))
+		}
+		return errors.New(r.Message)
+	}
+	return nil
+}
+
+// severity maps the alert level to the severity of a PagerDuty event.
+func severity(level alert.Level) string {
+	switch level {
+	case alert.Critical:
+		return "critical"
+	case alert.Warning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func (s *Service) preparePost(workspace, routingKey string, links []Link, images []Image, alertID, desc string, level alert.Level, t time.Time, source string, details map[string]interface{}) (string, io.Reader, error) {
+	c, err := s.config(workspace)
+	if err != nil {
+		return "", nil, err
+	}
+	if !c.Enabled {
+		return "", nil, errors.New("service is not enabled")
+	}
+
+	if routingKey == "" {
+		routingKey = c.RoutingKey
+	}
+	e := event{
+		RoutingKey: routingKey,
+		DedupKey:   alertID,
+		Client:     "kapacitor",
+		ClientURL:  s.HTTPDService.URL(),
+		Links:      links,
+		Images:     images,
+	}
+	if level == alert.OK {
+		e.EventAction = "resolve"
+	} else {
+		e.EventAction = "trigger"
+		e.Payload = &payload{
+			Summary:       desc,
+			Source:        source,
+			Severity:      severity(level),
+			Timestamp:     t.UTC().Format(time.RFC3339Nano),
+			CustomDetails: details,
+		}
+	}
+
+	// Post data to PagerDuty
+	var post bytes.Buffer
+	enc := json.NewEncoder(&post)
+	if err := enc.Encode(e); err != nil {
+		return "", nil, err
+	}
+
+	return c.URL, &post, nil
+}
+
+type HandlerConfig struct {
+	// Workspace is the name of the PagerDuty configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
+	// The routing key to use for the alert.
+	// Defaults to the value in the configuration if empty.
+	RoutingKey string `mapstructure:"routing-key"`
+
+	// Links to show with the incident.
+	Links []Link `mapstructure:"links"`
+
+	// Images to show with the incident.
+	Images []Image `mapstructure:"images"`
+}
+
+type handler struct {
+	s      *Service
+	c      HandlerConfig
+	logger *log.Logger
+}
+
+func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
+	return &handler{
+		s:      s,
+		c:      c,
+		logger: l,
+	}
+}
+
+func (h *handler) Handle(event alert.Event) {
+	if err := h.TryHandle(event); err != nil {
+		h.logger.Println("E! failed to send event to PagerDuty", err)
+	}
+}
+
+func (h *handler) TryHandle(event alert.Event) error {
+	source := event.Data.Tags["host"]
+	if source == "" {
+		source = defaultSource
+	}
+	return h.s.Alert(
+		h.c.Workspace,
+		h.c.RoutingKey,
+		h.c.Links,
+		h.c.Images,
+		event.State.ID,
+		event.State.Message,
+		event.State.Level,
+		event.State.Time,
+		source,
+		event.Data.Fields,
+	)
+}
@@ -3,6 +3,7 @@ package pushover
 import (
 	"net/url"
 
+	"github.com/influxdata/kapacitor/listmap"
 	"github.com/pkg/errors"
 )
 
@@ -15,6 +16,11 @@ const (
 type Config struct {
 	// Whether Pushover integration is enabled.
 	Enabled bool `toml:"enabled" override:"enabled"`
+	// Workspace is the name of the configuration, handlers select it by name.
+	Workspace string `toml:"workspace" override:"workspace"`
+	// Whether this is the configuration used when handlers do not select a workspace.
+	// Only needed if there is more than one configuration.
+	Default bool `toml:"default" override:"default"`
 	// The Pushover API token.
 	Token string `toml:"token" override:"token,redact"`
 	// The User/Group that will be alerted.
@@ -27,9 +33,14 @@ type Config struct {
 // NewConfig returns a new Pushover configuration with the URL set to be
 // the default pushover URL.
 func NewConfig() Config {
-	return Config{
-		URL: DefaultPushoverURL,
-	}
+	c := Config{}
+	c.Init()
+	return c
+}
+
+// Init sets the default values of the configuration.
+func (c *Config) Init() {
+	c.URL = DefaultPushoverURL
 }
 
 // Validate ensures that all configuration options are valid. The
@@ -55,3 +66,41 @@ func (c Config) Validate() error {
 
 	return nil
 }
+
+// Configs is the configuration for all [[pushover]] sections of the kapacitor
+// configuration file. A single [pushover] section is also accepted.
+type Configs []Config
+
+func (cs *Configs) UnmarshalTOML(data interface{}) error {
+	return listmap.DoUnmarshalTOML(cs, data)
+}
+
+// Validate calls config.Validate for each element in Configs
+// and checks that the workspaces are unique and there is a single default.
+func (cs Configs) Validate() error {
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return listmap.ValidateNames("workspace", len(cs), cs.workspace)
+}
+
+// workspace returns the workspace of the config i and whether it is the default.
+func (cs Configs) workspace(i int) (string, bool) {
+	return cs[i].Workspace, cs[i].Default
+}
+
+// index generates a map of configs by workspace
+func (cs Configs) index() map[string]Config {
+	m := make(map[string]Config, len(cs))
+	for _, c := range cs {
+		m[c.Workspace] = c
+	}
+	return m
+}
+
+// defaultWorkspace returns the workspace used when handlers do not select one.
+func (cs Configs) defaultWorkspace() string {
+	return listmap.DefaultName(len(cs), cs.workspace)
+}
@@ -11,22 +11,24 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
-	"sync/atomic"
+	"sync"
 
 	"github.com/influxdata/kapacitor/alert"
 )
 
 type Service struct {
-	configValue atomic.Value
-	logger      *log.Logger
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
+	logger           *log.Logger
 }
 
-func NewService(c Config, l *log.Logger) *Service {
-	s := &Service{
-		logger: l,
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
 	}
-	s.configValue.Store(c)
-	return s
 }
 
 func (s *Service) Open() error {
@@ -37,35 +39,48 @@ func (s *Service) Close() error {
 	return nil
 }
 
-func (s *Service) Update(newConfig []interface{}) error {
-	if l := len(newConfig); l != 1 {
-		return fmt.Errorf("expected only one new config object, got %d", l)
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
 	}
-	if c, ok := newConfig[0].(Config); !ok {
-		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
-	} else {
-		s.configValue.Store(c)
+	if err := cs.Validate(); err != nil {
+		return err
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
 	return nil
 }
 
 type testOptions struct {
-	UserKey  string      `json:"user-key"`
-	Message  string      `json:"message"`
-	Device   string      `json:"device"`
-	Title    string      `json:"title"`
-	URL      string      `json:"url"`
-	URLTitle string      `json:"url-title"`
-	Sound    string      `json:"sound"`
-	Level    alert.Level `json:"level"`
+	Workspace string      `json:"workspace"`
+	UserKey   string      `json:"user-key"`
+	Message   string      `json:"message"`
+	Device    string      `json:"device"`
+	Title     string      `json:"title"`
+	URL       string      `json:"url"`
+	URLTitle  string      `json:"url-title"`
+	Sound     string      `json:"sound"`
+	Level     alert.Level `json:"level"`
 }
 
 func (s *Service) TestOptions() interface{} {
-	c := s.config()
+	s.mu.RLock()
+	defaultWorkspace := s.defaultWorkspace
+	s.mu.RUnlock()
+	c := s.defaultConfig()
 	return &testOptions{
-		UserKey: c.UserKey,
-		Message: "test pushover message",
-		Level:   alert.Critical,
+		Workspace: defaultWorkspace,
+		UserKey:   c.UserKey,
+		Message:   "test pushover message",
+		Level:     alert.Critical,
 	}
 }
 
@@ -76,6 +91,7 @@ func (s *Service) Test(options interface{}) error {
 	}
 
 	return s.Alert(
+		o.Workspace,
 		o.Message,
 		o.Device,
 		o.Title,
@@ -86,12 +102,29 @@ func (s *Service) Test(options interface{}) error {
 	)
 }
 
-func (s *Service) config() Config {
-	return s.configValue.Load().(Config)
+// config returns the configuration of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown Pushover workspace %q", workspace)
+	}
+	return c, nil
+}
+
+// defaultConfig returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	c, _ := s.config("")
+	return c
 }
 
-func (s *Service) Alert(message, device, title, URL, URLTitle, sound string, level alert.Level) error {
-	url, post, err := s.preparePost(message, device, title, URL, URLTitle, sound, level)
+// Alert sends the message to Pushover using the workspace, or the default workspace if workspace is empty.
+func (s *Service) Alert(workspace, message, device, title, URL, URLTitle, sound string, level alert.Level) error {
+	url, post, err := s.preparePost(workspace, message, device, title, URL, URLTitle, sound, level)
 	if err != nil {
 		return err
 	}
@@ -191,8 +224,11 @@ func (p *postData) Values() url.Values {
 
 }
 
-func (s *Service) preparePost(message, device, title, URL, URLTitle, sound string, level alert.Level) (string, url.Values, error) {
-	c := s.config()
+func (s *Service) preparePost(workspace, message, device, title, URL, URLTitle, sound string, level alert.Level) (string, url.Values, error) {
+	c, err := s.config(workspace)
+	if err != nil {
+		return "", nil, err
+	}
 
 	if !c.Enabled {
 		return "", nil, errors.New("service is not enabled")
@@ -216,6 +252,10 @@ func (s *Service) preparePost(message, device, title, URL, URLTitle, sound strin
 }
 
 type HandlerConfig struct {
+	// Workspace is the name of the Pushover configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
 	// rather than all of a user's devices (multiple device names may
 	// be separated by a comma)
 	Device string `mapstructure:"device"`
@@ -250,6 +290,7 @@ func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
 
 func (h *handler) Handle(event alert.Event) {
 	if err := h.s.Alert(
+		h.c.Workspace,
 		event.State.Message,
 		h.c.Device,
 		h.c.Title,
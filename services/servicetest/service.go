@@ -18,6 +18,7 @@ import (
 const (
 	testPath         = "/service-tests"
 	testPathAnchored = "/service-tests/"
+	previewSuffix    = "/preview"
 	basePath         = httpd.BasePath + testPathAnchored
 )
 
@@ -31,6 +32,13 @@ type Tester interface {
 	Test(options interface{}) error
 }
 
+// Previewer is a Tester that can show what a test would send without sending it.
+type Previewer interface {
+	Tester
+	// Preview returns what Test would send with the provided options.
+	Preview(options interface{}) (string, error)
+}
+
 type Service struct {
 	testers map[string]Tester
 	routes  []httpd.Route
@@ -116,6 +124,12 @@ type ServiceTestResult struct {
 	Message string `json:"message"`
 }
 
+type ServicePreviewResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Preview string `json:"preview"`
+}
+
 func (s *Service) handleListTests(w http.ResponseWriter, r *http.Request) {
 	tests := ServiceTests{
 		Link: serviceTestsLink,
@@ -168,6 +182,8 @@ func (s *Service) handleTestOptions(w http.ResponseWriter, r *http.Request) {
 
 func (s *Service) handleTest(w http.ResponseWriter, r *http.Request) {
 	name := s.nameFromPath(r.URL.Path)
+	preview := strings.HasSuffix(name, previewSuffix)
+	name = strings.TrimSuffix(name, previewSuffix)
 	if name == "" {
 		httpd.HttpError(w, "must provide service name", true, http.StatusBadRequest)
 		return
@@ -187,6 +203,11 @@ func (s *Service) handleTest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if preview {
+		s.preview(w, name, test, options)
+		return
+	}
+
 	result := ServiceTestResult{}
 	err := test.Test(options)
 	if err != nil {
@@ -197,3 +218,21 @@ func (s *Service) handleTest(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(result)
 }
+
+func (s *Service) preview(w http.ResponseWriter, name string, test Tester, options interface{}) {
+	previewer, ok := test.(Previewer)
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("service %q does not support previews", name), true, http.StatusNotFound)
+		return
+	}
+	result := ServicePreviewResult{}
+	preview, err := previewer.Preview(options)
+	if err != nil {
+		result.Message = err.Error()
+	} else {
+		result.Success = true
+		result.Preview = preview
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
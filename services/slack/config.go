@@ -3,6 +3,7 @@ package slack
 import (
 	"net/url"
 
+	"github.com/influxdata/kapacitor/listmap"
 	"github.com/pkg/errors"
 )
 
@@ -11,6 +12,11 @@ const DefaultUsername = "kapacitor"
 type Config struct {
 	// Whether Slack integration is enabled.
 	Enabled bool `toml:"enabled" override:"enabled"`
+	// Workspace is the name of the configuration, handlers select it by name.
+	Workspace string `toml:"workspace" override:"workspace"`
+	// Whether this is the configuration used when handlers do not select a workspace.
+	// Only needed if there is more than one configuration.
+	Default bool `toml:"default" override:"default"`
 	// The Slack webhook URL, can be obtained by adding Incoming Webhook integration.
 	URL string `toml:"url" override:"url,redact"`
 	// The default channel, can be overridden per alert.
@@ -38,9 +44,14 @@ type Config struct {
 }
 
 func NewConfig() Config {
-	return Config{
-		Username: DefaultUsername,
-	}
+	c := Config{}
+	c.Init()
+	return c
+}
+
+// Init sets the default values of the configuration.
+func (c *Config) Init() {
+	c.Username = DefaultUsername
 }
 
 func (c Config) Validate() error {
@@ -52,3 +63,41 @@ func (c Config) Validate() error {
 	}
 	return nil
 }
+
+// Configs is the configuration for all [[slack]] sections of the kapacitor
+// configuration file. A single [slack] section is also accepted.
+type Configs []Config
+
+func (cs *Configs) UnmarshalTOML(data interface{}) error {
+	return listmap.DoUnmarshalTOML(cs, data)
+}
+
+// Validate calls config.Validate for each element in Configs
+// and checks that the workspaces are unique and there is a single default.
+func (cs Configs) Validate() error {
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return listmap.ValidateNames("workspace", len(cs), cs.workspace)
+}
+
+// workspace returns the workspace of the config i and whether it is the default.
+func (cs Configs) workspace(i int) (string, bool) {
+	return cs[i].Workspace, cs[i].Default
+}
+
+// index generates a map of configs by workspace
+func (cs Configs) index() map[string]Config {
+	m := make(map[string]Config, len(cs))
+	for _, c := range cs {
+		m[c.Workspace] = c
+	}
+	return m
+}
+
+// defaultWorkspace returns the workspace used when handlers do not select one.
+func (cs Configs) defaultWorkspace() string {
+	return listmap.DefaultName(len(cs), cs.workspace)
+}
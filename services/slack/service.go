@@ -8,7 +8,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"sync/atomic"
+	"sync"
 
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/tlsconfig"
@@ -16,31 +16,59 @@ import (
 )
 
 type Service struct {
-	configValue atomic.Value
-	clientValue atomic.Value
-	logger      *log.Logger
-	client      *http.Client
+	mu               sync.RWMutex
+	workspaces       map[string]*workspace
+	defaultWorkspace string
+	logger           *log.Logger
 }
 
-func NewService(c Config, l *log.Logger) (*Service, error) {
+// workspace is the configuration of a single workspace and the client used to post to it.
+type workspace struct {
+	config Config
+	client *http.Client
+}
+
+func newWorkspace(c Config, l *log.Logger) (*workspace, error) {
 	tlsConfig, err := tlsconfig.Create(c.SSLCA, c.SSLCert, c.SSLKey, c.InsecureSkipVerify)
 	if err != nil {
 		return nil, err
 	}
 	if tlsConfig.InsecureSkipVerify {
-		l.Println("W! Slack service is configured to skip ssl verification")
-	}
-	s := &Service{
-		logger: l,
+		l.Printf("W! Slack service is configured to skip ssl verification for workspace %q", c.Workspace)
 	}
-	s.configValue.Store(c)
-	s.clientValue.Store(&http.Client{
-		Transport: &http.Transport{
-			Proxy:           http.ProxyFromEnvironment,
-			TLSClientConfig: tlsConfig,
+	return &workspace{
+		config: c,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
 		},
-	})
-	return s, nil
+	}, nil
+}
+
+func newWorkspaces(cs Configs, l *log.Logger) (map[string]*workspace, error) {
+	workspaces := make(map[string]*workspace, len(cs))
+	for _, c := range cs {
+		w, err := newWorkspace(c, l)
+		if err != nil {
+			return nil, err
+		}
+		workspaces[c.Workspace] = w
+	}
+	return workspaces, nil
+}
+
+func NewService(cs Configs, l *log.Logger) (*Service, error) {
+	workspaces, err := newWorkspaces(cs, l)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		workspaces:       workspaces,
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
+	}, nil
 }
 
 func (s *Service) Open() error {
@@ -51,42 +79,60 @@ func (s *Service) Close() error {
 	return nil
 }
 
-func (s *Service) config() Config {
-	return s.configValue.Load().(Config)
+// workspace returns the named workspace, or the default workspace if name is empty.
+func (s *Service) workspace(name string) (*workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if name == "" {
+		name = s.defaultWorkspace
+	}
+	w, ok := s.workspaces[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown Slack workspace %q", name)
+	}
+	return w, nil
 }
 
-func (s *Service) Update(newConfig []interface{}) error {
-	if l := len(newConfig); l != 1 {
-		return fmt.Errorf("expected only one new config object, got %d", l)
+// config returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	w, err := s.workspace("")
+	if err != nil {
+		return Config{}
 	}
-	if c, ok := newConfig[0].(Config); !ok {
-		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
-	} else {
-		tlsConfig, err := tlsconfig.Create(c.SSLCA, c.SSLCert, c.SSLKey, c.InsecureSkipVerify)
-		if err != nil {
-			return err
-		}
-		if tlsConfig.InsecureSkipVerify {
-			s.logger.Println("W! Slack service is configured to skip ssl verification")
+	return w.config
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
 		}
-		s.configValue.Store(c)
-		s.clientValue.Store(&http.Client{
-			Transport: &http.Transport{
-				Proxy:           http.ProxyFromEnvironment,
-				TLSClientConfig: tlsConfig,
-			},
-		})
+		cs[i] = config
+	}
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+	workspaces, err := newWorkspaces(cs, s.logger)
+	if err != nil {
+		return err
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workspaces = workspaces
+	s.defaultWorkspace = cs.defaultWorkspace()
 	return nil
 }
 
 func (s *Service) Global() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.Global
 }
 
 func (s *Service) StateChangesOnly() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.StateChangesOnly
 }
 
@@ -99,6 +145,7 @@ type attachment struct {
 }
 
 type testOptions struct {
+	Workspace string      `json:"workspace"`
 	Channel   string      `json:"channel"`
 	Message   string      `json:"message"`
 	Level     alert.Level `json:"level"`
@@ -107,11 +154,15 @@ type testOptions struct {
 }
 
 func (s *Service) TestOptions() interface{} {
-	c := s.config()
+	s.mu.RLock()
+	defaultWorkspace := s.defaultWorkspace
+	s.mu.RUnlock()
+	c := s.defaultConfig()
 	return &testOptions{
-		Channel: c.Channel,
-		Message: "test slack message",
-		Level:   alert.Critical,
+		Workspace: defaultWorkspace,
+		Channel:   c.Channel,
+		Message:   "test slack message",
+		Level:     alert.Critical,
 	}
 }
 
@@ -120,16 +171,20 @@ func (s *Service) Test(options interface{}) error {
 	if !ok {
 		return fmt.Errorf("unexpected options type %T", options)
 	}
-	return s.Alert(o.Channel, o.Message, o.Username, o.IconEmoji, o.Level)
+	return s.Alert(o.Workspace, o.Channel, o.Message, o.Username, o.IconEmoji, o.Level)
 }
 
-func (s *Service) Alert(channel, message, username, iconEmoji string, level alert.Level) error {
-	url, post, err := s.preparePost(channel, message, username, iconEmoji, level)
+// Alert posts the message to the workspace, or to the default workspace if workspace is empty.
+func (s *Service) Alert(workspace, channel, message, username, iconEmoji string, level alert.Level) error {
+	w, err := s.workspace(workspace)
+	if err != nil {
+		return err
+	}
+	url, post, err := s.preparePost(w.config, channel, message, username, iconEmoji, level)
 	if err != nil {
 		return err
 	}
-	client := s.clientValue.Load().(*http.Client)
-	resp, err := client.Post(url, "application/json", post)
+	resp, err := w.client.Post(url, "application/json", post)
 	if err != nil {
 		return err
 	}
@@ -151,9 +206,7 @@ func (s *Service) Alert(channel, message, username, iconEmoji string, level aler
 	return nil
 }
 
-func (s *Service) preparePost(channel, message, username, iconEmoji string, level alert.Level) (string, io.Reader, error) {
-	c := s.config()
-
+func (s *Service) preparePost(c Config, channel, message, username, iconEmoji string, level alert.Level) (string, io.Reader, error) {
 	if !c.Enabled {
 		return "", nil, errors.New("service is not enabled")
 	}
@@ -202,6 +255,10 @@ func (s *Service) preparePost(channel, message, username, iconEmoji string, leve
 }
 
 type HandlerConfig struct {
+	// Workspace is the name of the Slack configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
 	// Slack channel in which to post messages.
 	// If empty uses the channel from the configuration.
 	Channel string `mapstructure:"channel"`
@@ -230,13 +287,18 @@ func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
 }
 
 func (h *handler) Handle(event alert.Event) {
-	if err := h.s.Alert(
+	if err := h.TryHandle(event); err != nil {
+		h.logger.Println("E! failed to send event to Slack", err)
+	}
+}
+
+func (h *handler) TryHandle(event alert.Event) error {
+	return h.s.Alert(
+		h.c.Workspace,
 		h.c.Channel,
 		event.State.Message,
 		h.c.Username,
 		h.c.IconEmoji,
 		event.State.Level,
-	); err != nil {
-		h.logger.Println("E! failed to send event to Slack", err)
-	}
+	)
 }
@@ -7,10 +7,16 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/toml"
+	"github.com/influxdata/kapacitor/listmap"
 )
 
 type Config struct {
-	Enabled  bool   `toml:"enabled" override:"enabled"`
+	Enabled bool `toml:"enabled" override:"enabled"`
+	// Workspace is the name of the configuration, handlers select it by name.
+	Workspace string `toml:"workspace" override:"workspace"`
+	// Whether this is the configuration used when handlers do not select a workspace.
+	// Only needed if there is more than one configuration.
+	Default  bool   `toml:"default" override:"default"`
 	Host     string `toml:"host" override:"host"`
 	Port     int    `toml:"port" override:"port"`
 	Username string `toml:"username" override:"username"`
@@ -28,14 +34,39 @@ type Config struct {
 	To []string `toml:"to" override:"to"`
 	// Close connection to SMTP server after idle timeout has elapsed
 	IdleTimeout toml.Duration `toml:"idle-timeout" override:"idle-timeout"`
+	// Templates are the named email templates handlers can use.
+	Templates map[string]Template `toml:"templates" override:"templates"`
+	// Template is the name of the template used by handlers that do not set one.
+	// If empty the alert message is the subject and the alert details are the HTML body.
+	Template string `toml:"template" override:"template"`
+}
+
+// Template is an email template, its parts are executed with the template data of the alert.
+// The data has the same fields as alert.TemplateData plus the Details of the alert.
+// If both the text and HTML bodies are set the email is a multipart/alternative message.
+type Template struct {
+	// Subject is a text template of the subject, if empty the alert message is the subject.
+	Subject string `toml:"subject" mapstructure:"subject" json:"subject"`
+	// Text is a text template of the plain-text body.
+	Text string `toml:"text" mapstructure:"text" json:"text"`
+	// HTML is an HTML template of the HTML body.
+	// If both bodies are empty the alert details are the HTML body.
+	HTML string `toml:"html" mapstructure:"html" json:"html"`
+	// AttachCSV attaches the points that triggered the alert as a CSV file.
+	AttachCSV bool `toml:"attach-csv" mapstructure:"attach-csv" json:"attach-csv"`
 }
 
 func NewConfig() Config {
-	return Config{
-		Host:        "localhost",
-		Port:        25,
-		IdleTimeout: toml.Duration(time.Second * 30),
-	}
+	c := Config{}
+	c.Init()
+	return c
+}
+
+// Init sets the default values of the configuration.
+func (c *Config) Init() {
+	c.Host = "localhost"
+	c.Port = 25
+	c.IdleTimeout = toml.Duration(time.Second * 30)
 }
 
 func (c Config) Validate() error {
@@ -61,5 +92,53 @@ func (c Config) Validate() error {
 			return fmt.Errorf("invalid to email address: %q", t)
 		}
 	}
+	if _, err := c.templates(); err != nil {
+		return err
+	}
+	if _, ok := c.Templates[c.Template]; c.Template != "" && !ok {
+		return fmt.Errorf("unknown template %q", c.Template)
+	}
 	return nil
 }
+
+// templates parses the named templates.
+func (c Config) templates() (map[string]*emailTemplate, error) {
+	templates := make(map[string]*emailTemplate, len(c.Templates))
+	for name, t := range c.Templates {
+		et, err := t.parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %q: %v", name, err)
+		}
+		templates[name] = et
+	}
+	return templates, nil
+}
+
+// Configs is the configuration for all [[smtp]] sections of the kapacitor
+// configuration file. A single [smtp] section is also accepted.
+type Configs []Config
+
+func (cs *Configs) UnmarshalTOML(data interface{}) error {
+	return listmap.DoUnmarshalTOML(cs, data)
+}
+
+// Validate calls config.Validate for each element in Configs
+// and checks that the workspaces are unique and there is a single default.
+func (cs Configs) Validate() error {
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return listmap.ValidateNames("workspace", len(cs), cs.workspace)
+}
+
+// workspace returns the workspace of the config i and whether it is the default.
+func (cs Configs) workspace(i int) (string, bool) {
+	return cs[i].Workspace, cs[i].Default
+}
+
+// defaultWorkspace returns the workspace used when handlers do not select one.
+func (cs Configs) defaultWorkspace() string {
+	return listmap.DefaultName(len(cs), cs.workspace)
+}
@@ -1,15 +1,17 @@
 package smtp
 
 import (
+	"bytes"
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/models"
 
 	"gopkg.in/gomail.v2"
 )
@@ -17,22 +19,19 @@ import (
 var ErrNoRecipients = errors.New("not sending email, no recipients defined")
 
 type Service struct {
-	mu          sync.Mutex
-	configValue atomic.Value
-	mail        chan *gomail.Message
-	updates     chan bool
-	logger      *log.Logger
-	wg          sync.WaitGroup
-	opened      bool
+	mu               sync.RWMutex
+	workspaces       map[string]*workspace
+	defaultWorkspace string
+	logger           *log.Logger
+	opened           bool
 }
 
-func NewService(c Config, l *log.Logger) *Service {
-	s := &Service{
-		updates: make(chan bool),
-		logger:  l,
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		workspaces:       newWorkspaces(cs, l),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
 	}
-	s.configValue.Store(c)
-	return s
 }
 
 func (s *Service) Open() error {
@@ -43,14 +42,9 @@ func (s *Service) Open() error {
 	}
 	s.opened = true
 
-	s.mail = make(chan *gomail.Message)
-
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-		s.runMailer()
-	}()
-
+	for _, w := range s.workspaces {
+		w.open()
+	}
 	return nil
 }
 
@@ -62,47 +56,102 @@ func (s *Service) Close() error {
 	}
 	s.opened = false
 
-	close(s.mail)
-	s.wg.Wait()
-
+	for _, w := range s.workspaces {
+		w.close()
+	}
 	return nil
 }
 
-func (s *Service) config() Config {
-	return s.configValue.Load().(Config)
+// config returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.workspaces[s.defaultWorkspace]
+	if !ok {
+		return Config{}
+	}
+	return w.config
 }
 
-func (s *Service) Update(newConfig []interface{}) error {
-	if l := len(newConfig); l != 1 {
-		return fmt.Errorf("expected only one new config object, got %d", l)
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
 	}
-	if c, ok := newConfig[0].(Config); !ok {
-		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
-	} else {
-		s.configValue.Store(c)
-		s.mu.Lock()
-		opened := s.opened
-		s.mu.Unlock()
-		if opened {
-			// Signal to create new dialer
-			s.updates <- true
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+	workspaces := newWorkspaces(cs, s.logger)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Replace the mailers of all workspaces, this closes the connections to the old SMTP servers.
+	if s.opened {
+		for _, w := range s.workspaces {
+			w.close()
+		}
+		for _, w := range workspaces {
+			w.open()
 		}
 	}
+	s.workspaces = workspaces
+	s.defaultWorkspace = cs.defaultWorkspace()
 	return nil
 }
 
 func (s *Service) Global() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.Global
 }
 
 func (s *Service) StateChangesOnly() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.StateChangesOnly
 }
 
-func (s *Service) dialer() (d *gomail.Dialer, idleTimeout time.Duration) {
-	c := s.config()
+// workspace is the configuration of a single workspace and the mailer sending its emails.
+type workspace struct {
+	config    Config
+	templates map[string]*emailTemplate
+	mail      chan *gomail.Message
+	logger    *log.Logger
+	wg        sync.WaitGroup
+}
+
+func newWorkspaces(cs Configs, l *log.Logger) map[string]*workspace {
+	workspaces := make(map[string]*workspace, len(cs))
+	for _, c := range cs {
+		// The configurations have been validated, so the templates parse.
+		templates, _ := c.templates()
+		workspaces[c.Workspace] = &workspace{
+			config:    c,
+			templates: templates,
+			logger:    l,
+		}
+	}
+	return workspaces
+}
+
+func (w *workspace) open() {
+	w.mail = make(chan *gomail.Message)
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.runMailer()
+	}()
+}
+
+func (w *workspace) close() {
+	close(w.mail)
+	w.wg.Wait()
+}
+
+func (w *workspace) dialer() (d *gomail.Dialer, idleTimeout time.Duration) {
+	c := w.config
 	if c.Username == "" {
 		d = &gomail.Dialer{Host: c.Host, Port: c.Port}
 	} else {
@@ -115,10 +164,8 @@ func (s *Service) dialer() (d *gomail.Dialer, idleTimeout time.Duration) {
 	return
 }
 
-func (s *Service) runMailer() {
-	var idleTimeout time.Duration
-	var d *gomail.Dialer
-	d, idleTimeout = s.dialer()
+func (w *workspace) runMailer() {
+	d, idleTimeout := w.dialer()
 
 	var conn gomail.SendCloser
 	defer func() {
@@ -132,38 +179,28 @@ func (s *Service) runMailer() {
 	for {
 		timer := time.NewTimer(idleTimeout)
 		select {
-		case <-s.updates:
-			// Close old connection
-			if conn != nil {
-				if err := conn.Close(); err != nil {
-					s.logger.Println("E! error closing connection to old SMTP server:", err)
-				}
-				conn = nil
-			}
-			// Create new dialer
-			d, idleTimeout = s.dialer()
-			open = false
-		case m, ok := <-s.mail:
+		case m, ok := <-w.mail:
 			if !ok {
 				return
 			}
 			if !open {
 				if conn, err = d.Dial(); err != nil {
-					s.logger.Println("E! error connecting to SMTP server", err)
+					w.logger.Println("E! error connecting to SMTP server", err)
 					break
 				}
 				open = true
 			}
 			if err := gomail.Send(conn, m); err != nil {
-				s.logger.Println("E!", err)
+				w.logger.Println("E!", err)
 			}
 		// Close the connection to the SMTP server if no email was sent in
 		// the last IdleTimeout duration.
 		case <-timer.C:
 			if open {
 				if err := conn.Close(); err != nil {
-					s.logger.Println("E! error closing connection to SMTP server:", err)
+					w.logger.Println("E! error closing connection to SMTP server:", err)
 				}
+				conn = nil
 				open = false
 			}
 		}
@@ -171,17 +208,73 @@ func (s *Service) runMailer() {
 	}
 }
 
-func (s *Service) SendMail(to []string, subject, body string) error {
-	m, err := s.prepareMessge(to, subject, body)
+// SendMail sends the email using the workspace ws, or the default workspace if ws is empty.
+func (s *Service) SendMail(ws string, to []string, subject, body string) error {
+	return s.send(ws, to, func(*workspace) (email, error) {
+		return email{subject: subject, html: body}, nil
+	})
+}
+
+// sendEvent renders the event with the template of the workspace and sends the email.
+// An empty template name selects the default template of the workspace,
+// the parts set in the inline template override the parts of the named template.
+func (s *Service) sendEvent(ws string, to []string, template string, inline *emailTemplate, event alert.Event) error {
+	return s.send(ws, to, func(w *workspace) (email, error) {
+		return w.render(template, inline, event)
+	})
+}
+
+func (s *Service) send(ws string, to []string, content func(*workspace) (email, error)) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, err := s.lookupWorkspace(ws)
+	if err != nil {
+		return err
+	}
+	e, err := content(w)
 	if err != nil {
 		return err
 	}
-	s.mail <- m
+	m, err := s.prepareMessge(w.config, to, e)
+	if err != nil {
+		return err
+	}
+	if !s.opened {
+		return errors.New("service is not open")
+	}
+	w.mail <- m
 	return nil
 }
 
-func (s *Service) prepareMessge(to []string, subject, body string) (*gomail.Message, error) {
-	c := s.config()
+// lookupWorkspace returns the workspace, or the default workspace if ws is empty.
+// Must be called with the lock held.
+func (s *Service) lookupWorkspace(ws string) (*workspace, error) {
+	if ws == "" {
+		ws = s.defaultWorkspace
+	}
+	w, ok := s.workspaces[ws]
+	if !ok {
+		return nil, fmt.Errorf("unknown SMTP workspace %q", ws)
+	}
+	return w, nil
+}
+
+func (w *workspace) render(template string, inline *emailTemplate, event alert.Event) (email, error) {
+	if template == "" {
+		template = w.config.Template
+	}
+	var t *emailTemplate
+	if template != "" {
+		var ok bool
+		t, ok = w.templates[template]
+		if !ok {
+			return email{}, fmt.Errorf("unknown email template %q", template)
+		}
+	}
+	return t.override(inline).render(event)
+}
+
+func (s *Service) prepareMessge(c Config, to []string, e email) (*gomail.Message, error) {
 	if !c.Enabled {
 		return nil, errors.New("service is not enabled")
 	}
@@ -191,26 +284,66 @@ func (s *Service) prepareMessge(to []string, subject, body string) (*gomail.Mess
 	if len(to) == 0 {
 		return nil, ErrNoRecipients
 	}
+	return newMessage(c.From, to, e), nil
+}
+
+// newMessage creates the message of the email.
+// If the email has both a text and an HTML body it is a multipart/alternative message.
+func newMessage(from string, to []string, e email) *gomail.Message {
 	m := gomail.NewMessage()
-	m.SetHeader("From", c.From)
-	m.SetHeader("To", to...)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
-	return m, nil
+	m.SetHeader("From", from)
+	if len(to) > 0 {
+		m.SetHeader("To", to...)
+	}
+	m.SetHeader("Subject", e.subject)
+	switch {
+	case e.text != "" && e.html != "":
+		m.SetBody("text/plain", e.text)
+		m.AddAlternative("text/html", e.html)
+	case e.text != "":
+		m.SetBody("text/plain", e.text)
+	default:
+		m.SetBody("text/html", e.html)
+	}
+	if e.csv != nil {
+		points := e.csv
+		m.Attach(
+			CSVAttachmentName,
+			gomail.SetHeader(map[string][]string{
+				"Content-Type": {`text/csv; charset=UTF-8; name="` + CSVAttachmentName + `"`},
+			}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(points)
+				return err
+			}),
+		)
+	}
+	return m
 }
 
 type testOptions struct {
-	To      []string `json:"to"`
-	Subject string   `json:"subject"`
-	Body    string   `json:"body"`
+	Workspace string   `json:"workspace"`
+	To        []string `json:"to"`
+	Subject   string   `json:"subject"`
+	Body      string   `json:"body"`
+	// Template is the name of the email template to render the test alert with.
+	// The subject and body are the message and details of the test alert.
+	Template string `json:"template"`
+	Level    string `json:"level"`
 }
 
 func (s *Service) TestOptions() interface{} {
-	c := s.config()
+	s.mu.RLock()
+	defaultWorkspace := s.defaultWorkspace
+	s.mu.RUnlock()
+	c := s.defaultConfig()
 	return &testOptions{
-		To:      c.To,
-		Subject: "test subject",
-		Body:    "test body",
+		Workspace: defaultWorkspace,
+		To:        c.To,
+		Subject:   "test subject",
+		Body:      "test body",
+		Template:  c.Template,
+		Level:     alert.Critical.String(),
 	}
 }
 
@@ -219,37 +352,148 @@ func (s *Service) Test(options interface{}) error {
 	if !ok {
 		return fmt.Errorf("unexpected options type %T", options)
 	}
-	return s.SendMail(
-		o.To,
-		o.Subject,
-		o.Body,
-	)
+	if o.Template == "" {
+		return s.SendMail(
+			o.Workspace,
+			o.To,
+			o.Subject,
+			o.Body,
+		)
+	}
+	event, err := o.event()
+	if err != nil {
+		return err
+	}
+	return s.sendEvent(o.Workspace, o.To, o.Template, nil, event)
+}
+
+// Preview returns the MIME message Test would send with the options.
+func (s *Service) Preview(options interface{}) (string, error) {
+	o, ok := options.(*testOptions)
+	if !ok {
+		return "", fmt.Errorf("unexpected options type %T", options)
+	}
+	event, err := o.event()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, err := s.lookupWorkspace(o.Workspace)
+	if err != nil {
+		return "", err
+	}
+	e := email{subject: o.Subject, html: o.Body}
+	if o.Template != "" {
+		e, err = w.render(o.Template, nil, event)
+		if err != nil {
+			return "", err
+		}
+	}
+	to := o.To
+	if len(to) == 0 {
+		to = w.config.To
+	}
+	var buf bytes.Buffer
+	if _, err := newMessage(w.config.From, to, e).WriteTo(&buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// event returns the test alert the templates are rendered with.
+func (o *testOptions) event() (alert.Event, error) {
+	level, err := alert.ParseLevel(o.Level)
+	if err != nil {
+		return alert.Event{}, err
+	}
+	now := time.Now().UTC()
+	return alert.Event{
+		Topic: "test",
+		State: alert.EventState{
+			ID:      "test",
+			Message: o.Subject,
+			Details: o.Body,
+			Time:    now,
+			Level:   level,
+		},
+		Data: alert.EventData{
+			Name:     "test",
+			TaskName: "test",
+			Group:    "nil",
+			Tags:     map[string]string{"host": "serverA"},
+			Fields:   map[string]interface{}{"value": 1.0},
+			Result: models.Result{
+				Series: models.Rows{{
+					Name:    "test",
+					Tags:    map[string]string{"host": "serverA"},
+					Columns: []string{"time", "value"},
+					Values:  [][]interface{}{{now, 1.0}},
+				}},
+			},
+		},
+	}, nil
 }
 
 type HandlerConfig struct {
+	// Workspace is the name of the SMTP configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
 	// List of email recipients.
 	To []string `mapstructure:"to"`
+
+	// Template is the name of the email template of the configuration.
+	// If empty uses the default template of the configuration.
+	Template string `mapstructure:"template"`
+
+	// Subject, Text and HTML are templates overriding the parts of the named template.
+	Subject string `mapstructure:"subject"`
+	Text    string `mapstructure:"text"`
+	HTML    string `mapstructure:"html"`
+
+	// AttachCSV attaches the points that triggered the alert as a CSV file.
+	AttachCSV bool `mapstructure:"attach-csv"`
 }
 
 type handler struct {
 	s      *Service
 	c      HandlerConfig
+	inline *emailTemplate
 	logger *log.Logger
 }
 
-func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
+func (s *Service) Handler(c HandlerConfig, l *log.Logger) (alert.Handler, error) {
+	var inline *emailTemplate
+	if c.Subject != "" || c.Text != "" || c.HTML != "" || c.AttachCSV {
+		t := Template{
+			Subject:   c.Subject,
+			Text:      c.Text,
+			HTML:      c.HTML,
+			AttachCSV: c.AttachCSV,
+		}
+		var err error
+		inline, err = t.parse("handler")
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &handler{
 		s:      s,
 		c:      c,
+		inline: inline,
 		logger: l,
-	}
+	}, nil
 }
 
 func (h *handler) Handle(event alert.Event) {
-	if err := h.s.SendMail(
+	if err := h.s.sendEvent(
+		h.c.Workspace,
 		h.c.To,
-		event.State.Message,
-		event.State.Details,
+		h.c.Template,
+		h.inline,
+		event,
 	); err != nil {
 		h.logger.Println("E! failed to send email", err)
 	}
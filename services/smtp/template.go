@@ -0,0 +1,217 @@
+package smtp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	html "html/template"
+	"sort"
+	"strings"
+	text "text/template"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/models"
+)
+
+// CSVAttachmentName is the file name of the attached points.
+const CSVAttachmentName = "points.csv"
+
+// templateData is the data the email templates are executed with.
+type templateData struct {
+	alert.TemplateData
+
+	// Details of the alert.
+	Details string
+}
+
+// emailTemplate is a parsed Template, parts that are not set are nil.
+type emailTemplate struct {
+	subject   *text.Template
+	text      *text.Template
+	html      *html.Template
+	attachCSV bool
+}
+
+func (t Template) parse(name string) (*emailTemplate, error) {
+	et := &emailTemplate{
+		attachCSV: t.AttachCSV,
+	}
+	var err error
+	if t.Subject != "" {
+		et.subject, err = text.New(name + "-subject").Parse(t.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject: %v", err)
+		}
+	}
+	if t.Text != "" {
+		et.text, err = text.New(name + "-text").Parse(t.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid text body: %v", err)
+		}
+	}
+	if t.HTML != "" {
+		et.html, err = html.New(name + "-html").Parse(t.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HTML body: %v", err)
+		}
+	}
+	return et, nil
+}
+
+// override returns a template with the parts of t replaced by the parts set in o.
+// Either template may be nil.
+func (t *emailTemplate) override(o *emailTemplate) *emailTemplate {
+	if t == nil {
+		return o
+	}
+	if o == nil {
+		return t
+	}
+	m := *t
+	if o.subject != nil {
+		m.subject = o.subject
+	}
+	if o.text != nil {
+		m.text = o.text
+	}
+	if o.html != nil {
+		m.html = o.html
+	}
+	m.attachCSV = m.attachCSV || o.attachCSV
+	return &m
+}
+
+// email is the rendered content of an email.
+type email struct {
+	subject string
+	text    string
+	html    string
+	// csv are the points attached as CSV, nil if they are not attached.
+	csv []byte
+}
+
+// render executes the template with the data of the event.
+// A nil template renders the alert message as the subject and the alert details as the HTML body.
+func (t *emailTemplate) render(event alert.Event) (email, error) {
+	e := email{
+		subject: event.State.Message,
+	}
+	if t == nil || (t.text == nil && t.html == nil) {
+		e.html = event.State.Details
+	}
+	if t == nil {
+		return e, nil
+	}
+
+	td := templateData{
+		TemplateData: event.TemplateData(),
+		Details:      event.State.Details,
+	}
+	var buf bytes.Buffer
+	if t.subject != nil {
+		if err := t.subject.Execute(&buf, td); err != nil {
+			return email{}, fmt.Errorf("failed to render subject: %v", err)
+		}
+		// Headers cannot span lines.
+		e.subject = strings.Join(strings.Fields(buf.String()), " ")
+		buf.Reset()
+	}
+	if t.text != nil {
+		if err := t.text.Execute(&buf, td); err != nil {
+			return email{}, fmt.Errorf("failed to render text body: %v", err)
+		}
+		e.text = buf.String()
+		buf.Reset()
+	}
+	if t.html != nil {
+		if err := t.html.Execute(&buf, td); err != nil {
+			return email{}, fmt.Errorf("failed to render HTML body: %v", err)
+		}
+		e.html = buf.String()
+		buf.Reset()
+	}
+	if t.attachCSV {
+		b, err := resultCSV(event.Data.Result)
+		if err != nil {
+			return email{}, fmt.Errorf("failed to write points as CSV: %v", err)
+		}
+		e.csv = b
+	}
+	return e, nil
+}
+
+// resultCSV writes the points of the result as CSV with a header row.
+// The columns are the series name, the sorted union of the tag keys
+// and the union of the columns of all series.
+func resultCSV(result models.Result) ([]byte, error) {
+	tagSet := make(map[string]bool)
+	columnSet := make(map[string]bool)
+	var columns []string
+	for _, s := range result.Series {
+		for k := range s.Tags {
+			tagSet[k] = true
+		}
+		for _, c := range s.Columns {
+			if !columnSet[c] {
+				columnSet[c] = true
+				columns = append(columns, c)
+			}
+		}
+	}
+	tags := make([]string, 0, len(tagSet))
+	for k := range tagSet {
+		tags = append(tags, k)
+	}
+	sort.Strings(tags)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	record := make([]string, 0, 1+len(tags)+len(columns))
+	record = append(record, "name")
+	record = append(record, tags...)
+	record = append(record, columns...)
+	if err := w.Write(record); err != nil {
+		return nil, err
+	}
+	for _, s := range result.Series {
+		index := make(map[string]int, len(s.Columns))
+		for i, c := range s.Columns {
+			index[c] = i
+		}
+		for _, values := range s.Values {
+			record = record[:0]
+			record = append(record, s.Name)
+			for _, k := range tags {
+				record = append(record, s.Tags[k])
+			}
+			for _, c := range columns {
+				i, ok := index[c]
+				if !ok || i >= len(values) {
+					record = append(record, "")
+					continue
+				}
+				record = append(record, csvValue(values[i]))
+			}
+			if err := w.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
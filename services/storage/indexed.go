@@ -323,9 +323,27 @@ func (s *IndexedStore) ReverseListTx(tx ReadOnlyTx, index, pattern string, offse
 	return s.list(tx, index, pattern, offset, limit, true)
 }
 
+// ListPrefix returns a list of objects whose index value starts with the prefix.
+// Only the index entries with the prefix are read.
+// If limit < 0, then no limit is enforced.
+func (s *IndexedStore) ListPrefix(index, prefix string, offset, limit int) (objects []BinaryObject, err error) {
+	err = s.store.View(func(tx ReadOnlyTx) error {
+		objects, err = s.listPrefix(tx, index, prefix, "", offset, limit, false)
+		return err
+	})
+	return
+}
+func (s *IndexedStore) ListPrefixTx(tx ReadOnlyTx, index, prefix string, offset, limit int) ([]BinaryObject, error) {
+	return s.listPrefix(tx, index, prefix, "", offset, limit, false)
+}
+
 func (s *IndexedStore) list(tx ReadOnlyTx, index, pattern string, offset, limit int, reverse bool) ([]BinaryObject, error) {
-	// List all object ids sorted by index
-	ids, err := tx.List(s.indexKey(index, "") + "/")
+	return s.listPrefix(tx, index, "", pattern, offset, limit, reverse)
+}
+
+func (s *IndexedStore) listPrefix(tx ReadOnlyTx, index, prefix, pattern string, offset, limit int, reverse bool) ([]BinaryObject, error) {
+	// List all object ids with the prefix sorted by index
+	ids, err := tx.List(s.indexKey(index, "") + "/" + prefix)
 	if err != nil {
 		return nil, err
 	}
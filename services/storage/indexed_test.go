@@ -218,3 +218,53 @@ func TestIndexedStore_CRUD(t *testing.T) {
 		})
 	}
 }
+
+func TestIndexedStore_ListPrefix(t *testing.T) {
+	for name, sc := range stores {
+		t.Run(name, func(t *testing.T) {
+			db, err := sc()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			s := db.Store("prefix")
+			c := storage.DefaultIndexedStoreConfig("prefix", func() storage.BinaryObject {
+				return new(object)
+			})
+			is, err := storage.NewIndexedStore(s, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			objects := []*object{
+				{ID: "a/1", Value: "obj1"},
+				{ID: "a/2", Value: "obj2"},
+				{ID: "ab/1", Value: "obj3"},
+				{ID: "b/1", Value: "obj4"},
+			}
+			for _, o := range objects {
+				if err := is.Create(o); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			exp := []storage.BinaryObject{objects[0], objects[1]}
+			got, err := is.ListPrefix("id", "a/", 0, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, exp) {
+				t.Errorf("unexpected object list by prefix:\ngot\n%s\nexp\n%s\n", spew.Sdump(got), spew.Sdump(exp))
+			}
+			exp = []storage.BinaryObject{objects[1]}
+			got, err = is.ListPrefix("id", "a/", 1, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, exp) {
+				t.Errorf("unexpected object list by prefix with offset:\ngot\n%s\nexp\n%s\n", spew.Sdump(got), spew.Sdump(exp))
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package teams
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/influxdata/kapacitor/listmap"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MessageCard is the legacy actionable message card format.
+	MessageCard = "MessageCard"
+	// AdaptiveCard is the Adaptive Card format.
+	AdaptiveCard = "AdaptiveCard"
+)
+
+type Config struct {
+	// Whether Microsoft Teams integration is enabled.
+	Enabled bool `toml:"enabled" override:"enabled"`
+	// Workspace is the name of the configuration, handlers select it by name.
+	Workspace string `toml:"workspace" override:"workspace"`
+	// Whether this is the configuration used when handlers do not select a workspace.
+	// Only needed if there is more than one configuration.
+	Default bool `toml:"default" override:"default"`
+	// The incoming webhook URL of the default channel, can be obtained by adding an Incoming Webhook connector to the channel.
+	ChannelURL string `toml:"channel-url" override:"channel-url,redact"`
+	// The format of the cards, either MessageCard or AdaptiveCard.
+	// Default: MessageCard
+	CardType string `toml:"card-type" override:"card-type"`
+	// Whether all alerts should automatically post to Teams
+	Global bool `toml:"global" override:"global"`
+	// Whether all alerts should automatically use stateChangesOnly mode.
+	// Only applies if global is also set.
+	StateChangesOnly bool `toml:"state-changes-only" override:"state-changes-only"`
+}
+
+func NewConfig() Config {
+	c := Config{}
+	c.Init()
+	return c
+}
+
+// Init sets the default values of the configuration.
+func (c *Config) Init() {
+	c.CardType = MessageCard
+}
+
+func (c Config) Validate() error {
+	if c.Enabled && c.ChannelURL == "" {
+		return errors.New("must specify channel-url")
+	}
+	if _, err := url.Parse(c.ChannelURL); err != nil {
+		return errors.Wrapf(err, "invalid channel-url %q", c.ChannelURL)
+	}
+	return validateCardType(c.CardType)
+}
+
+func validateCardType(cardType string) error {
+	switch cardType {
+	case "", MessageCard, AdaptiveCard:
+		return nil
+	default:
+		return fmt.Errorf("invalid card-type %q, must be one of %s or %s", cardType, MessageCard, AdaptiveCard)
+	}
+}
+
+// Configs is the configuration for all [[teams]] sections of the kapacitor
+// configuration file. A single [teams] section is also accepted.
+type Configs []Config
+
+func (cs *Configs) UnmarshalTOML(data interface{}) error {
+	return listmap.DoUnmarshalTOML(cs, data)
+}
+
+// Validate calls config.Validate for each element in Configs
+// and checks that the workspaces are unique and there is a single default.
+func (cs Configs) Validate() error {
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return listmap.ValidateNames("workspace", len(cs), cs.workspace)
+}
+
+// workspace returns the workspace of the config i and whether it is the default.
+func (cs Configs) workspace(i int) (string, bool) {
+	return cs[i].Workspace, cs[i].Default
+}
+
+// index generates a map of configs by workspace
+func (cs Configs) index() map[string]Config {
+	m := make(map[string]Config, len(cs))
+	for _, c := range cs {
+		m[c.Workspace] = c
+	}
+	return m
+}
+
+// defaultWorkspace returns the workspace used when handlers do not select one.
+func (cs Configs) defaultWorkspace() string {
+	return listmap.DefaultName(len(cs), cs.workspace)
+}
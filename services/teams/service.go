@@ -0,0 +1,328 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/pkg/errors"
+)
+
+type Service struct {
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
+	logger           *log.Logger
+}
+
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
+	}
+}
+
+func (s *Service) Open() error {
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+// config returns the configuration of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown Teams workspace %q", workspace)
+	}
+	return c, nil
+}
+
+// defaultConfig returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	c, _ := s.config("")
+	return c
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
+	}
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
+	return nil
+}
+
+func (s *Service) Global() bool {
+	c := s.defaultConfig()
+	return c.Global
+}
+
+func (s *Service) StateChangesOnly() bool {
+	c := s.defaultConfig()
+	return c.StateChangesOnly
+}
+
+type testOptions struct {
+	Workspace  string      `json:"workspace"`
+	ChannelURL string      `json:"channel-url"`
+	CardType   string      `json:"card-type"`
+	Title      string      `json:"title"`
+	Message    string      `json:"message"`
+	Level      alert.Level `json:"level"`
+}
+
+func (s *Service) TestOptions() interface{} {
+	s.mu.RLock()
+	defaultWorkspace := s.defaultWorkspace
+	s.mu.RUnlock()
+	c := s.defaultConfig()
+	return &testOptions{
+		Workspace:  defaultWorkspace,
+		ChannelURL: c.ChannelURL,
+		CardType:   c.CardType,
+		Title:      "testAlertID",
+		Message:    "test teams message",
+		Level:      alert.Critical,
+	}
+}
+
+func (s *Service) Test(options interface{}) error {
+	o, ok := options.(*testOptions)
+	if !ok {
+		return fmt.Errorf("unexpected options type %T", options)
+	}
+	return s.Alert(o.Workspace, o.ChannelURL, o.CardType, o.Title, o.Message, o.Level)
+}
+
+// Alert posts a card to the Teams channel using the workspace, or the default workspace if workspace is empty.
+func (s *Service) Alert(workspace, channelURL, cardType, title, message string, level alert.Level) error {
+	url, post, err := s.preparePost(workspace, channelURL, cardType, title, message, level)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(url, "application/json", post)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to post to Microsoft Teams. code: %d content: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// messageCard is a legacy actionable message card.
+type messageCard struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	ThemeColor string `json:"themeColor"`
+	Summary    string `json:"summary"`
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+}
+
+// adaptiveCardMessage is a message with a single Adaptive Card attachment.
+type adaptiveCardMessage struct {
+	Type        string       `json:"type"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	ContentType string       `json:"contentType"`
+	Content     adaptiveCard `json:"content"`
+}
+
+type adaptiveCard struct {
+	Schema  string    `json:"$schema"`
+	Type    string    `json:"type"`
+	Version string    `json:"version"`
+	Body    []element `json:"body"`
+}
+
+// element is an Adaptive Card element, either a Container or a TextBlock.
+type element struct {
+	Type   string    `json:"type"`
+	Style  string    `json:"style,omitempty"`
+	Items  []element `json:"items,omitempty"`
+	Text   string    `json:"text,omitempty"`
+	Weight string    `json:"weight,omitempty"`
+	Size   string    `json:"size,omitempty"`
+	Color  string    `json:"color,omitempty"`
+	Wrap   bool      `json:"wrap,omitempty"`
+}
+
+// themeColor returns the colour of message cards of the level.
+func themeColor(level alert.Level) string {
+	switch level {
+	case alert.Critical:
+		return "CC4A31"
+	case alert.Warning:
+		return "EABE58"
+	case alert.Info:
+		return "4A90D9"
+	default:
+		return "7AC36A"
+	}
+}
+
+// style returns the container style and text colour of Adaptive Cards of the level.
+func style(level alert.Level) string {
+	switch level {
+	case alert.Critical:
+		return "attention"
+	case alert.Warning:
+		return "warning"
+	case alert.Info:
+		return "accent"
+	default:
+		return "good"
+	}
+}
+
+func (s *Service) preparePost(workspace, channelURL, cardType, title, message string, level alert.Level) (string, io.Reader, error) {
+	c, err := s.config(workspace)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !c.Enabled {
+		return "", nil, errors.New("service is not enabled")
+	}
+	if channelURL == "" {
+		channelURL = c.ChannelURL
+	}
+	if cardType == "" {
+		cardType = c.CardType
+	}
+	if err := validateCardType(cardType); err != nil {
+		return "", nil, err
+	}
+
+	var postData interface{}
+	switch cardType {
+	case AdaptiveCard:
+		st := style(level)
+		postData = adaptiveCardMessage{
+			Type: "message",
+			Attachments: []attachment{{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content: adaptiveCard{
+					Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+					Type:    "AdaptiveCard",
+					Version: "1.2",
+					Body: []element{{
+						Type:  "Container",
+						Style: st,
+						Items: []element{
+							{
+								Type:   "TextBlock",
+								Text:   title,
+								Weight: "Bolder",
+								Size:   "Medium",
+								Color:  st,
+								Wrap:   true,
+							},
+							{
+								Type: "TextBlock",
+								Text: message,
+								Wrap: true,
+							},
+						},
+					}},
+				},
+			}},
+		}
+	default:
+		postData = messageCard{
+			Type:       "MessageCard",
+			Context:    "http://schema.org/extensions",
+			ThemeColor: themeColor(level),
+			Summary:    message,
+			Title:      title,
+			Text:       message,
+		}
+	}
+
+	var post bytes.Buffer
+	enc := json.NewEncoder(&post)
+	err = enc.Encode(postData)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return channelURL, &post, nil
+}
+
+type HandlerConfig struct {
+	// Workspace is the name of the Teams configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
+	// Incoming webhook URL of the Teams channel in which to post messages.
+	// If empty uses the channel URL from the configuration.
+	ChannelURL string `mapstructure:"channel-url"`
+
+	// Format of the cards, either MessageCard or AdaptiveCard.
+	// If empty uses the card type from the configuration.
+	CardType string `mapstructure:"card-type"`
+}
+
+type handler struct {
+	s      *Service
+	c      HandlerConfig
+	logger *log.Logger
+}
+
+func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
+	return &handler{
+		s:      s,
+		c:      c,
+		logger: l,
+	}
+}
+
+func (h *handler) Handle(event alert.Event) {
+	if err := h.TryHandle(event); err != nil {
+		h.logger.Println("E! failed to send event to Microsoft Teams", err)
+	}
+}
+
+func (h *handler) TryHandle(event alert.Event) error {
+	return h.s.Alert(
+		h.c.Workspace,
+		h.c.ChannelURL,
+		h.c.CardType,
+		event.State.ID,
+		event.State.Message,
+		event.State.Level,
+	)
+}
@@ -3,6 +3,7 @@ package telegram
 import (
 	"net/url"
 
+	"github.com/influxdata/kapacitor/listmap"
 	"github.com/pkg/errors"
 )
 
@@ -13,6 +14,11 @@ const DefaultTelegramNotificationDisable = false
 type Config struct {
 	// Whether Telegram integration is enabled.
 	Enabled bool `toml:"enabled" override:"enabled"`
+	// Workspace is the name of the configuration, handlers select it by name.
+	Workspace string `toml:"workspace" override:"workspace"`
+	// Whether this is the configuration used when handlers do not select a workspace.
+	// Only needed if there is more than one configuration.
+	Default bool `toml:"default" override:"default"`
 	// The Telegram Bot URL, should not need to be changed.
 	URL string `toml:"url" override:"url"`
 	// The Telegram Bot Token, can be obtained From @BotFather.
@@ -33,11 +39,16 @@ type Config struct {
 }
 
 func NewConfig() Config {
-	return Config{
-		URL: DefaultTelegramURL,
-		DisableWebPagePreview: DefaultTelegramLinksPreviewDisable,
-		DisableNotification:   DefaultTelegramNotificationDisable,
-	}
+	c := Config{}
+	c.Init()
+	return c
+}
+
+// Init sets the default values of the configuration.
+func (c *Config) Init() {
+	c.URL = DefaultTelegramURL
+	c.DisableWebPagePreview = DefaultTelegramLinksPreviewDisable
+	c.DisableNotification = DefaultTelegramNotificationDisable
 }
 
 func (c Config) Validate() error {
@@ -54,3 +65,41 @@ func (c Config) Validate() error {
 	}
 	return nil
 }
+
+// Configs is the configuration for all [[telegram]] sections of the kapacitor
+// configuration file. A single [telegram] section is also accepted.
+type Configs []Config
+
+func (cs *Configs) UnmarshalTOML(data interface{}) error {
+	return listmap.DoUnmarshalTOML(cs, data)
+}
+
+// Validate calls config.Validate for each element in Configs
+// and checks that the workspaces are unique and there is a single default.
+func (cs Configs) Validate() error {
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return listmap.ValidateNames("workspace", len(cs), cs.workspace)
+}
+
+// workspace returns the workspace of the config i and whether it is the default.
+func (cs Configs) workspace(i int) (string, bool) {
+	return cs[i].Workspace, cs[i].Default
+}
+
+// index generates a map of configs by workspace
+func (cs Configs) index() map[string]Config {
+	m := make(map[string]Config, len(cs))
+	for _, c := range cs {
+		m[c.Workspace] = c
+	}
+	return m
+}
+
+// defaultWorkspace returns the workspace used when handlers do not select one.
+func (cs Configs) defaultWorkspace() string {
+	return listmap.DefaultName(len(cs), cs.workspace)
+}
@@ -10,7 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
-	"sync/atomic"
+	"sync"
 
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/pkg/errors"
@@ -18,16 +18,18 @@ import (
 )
 
 type Service struct {
-	configValue atomic.Value
-	logger      *log.Logger
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
+	logger           *log.Logger
 }
 
-func NewService(c Config, l *log.Logger) *Service {
-	s := &Service{
-		logger: l,
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
 	}
-	s.configValue.Store(c)
-	return s
 }
 
 func (s *Service) Open() error {
@@ -38,32 +40,57 @@ func (s *Service) Close() error {
 	return nil
 }
 
-func (s *Service) config() Config {
-	return s.configValue.Load().(Config)
+// config returns the configuration of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown Telegram workspace %q", workspace)
+	}
+	return c, nil
 }
 
-func (s *Service) Update(newConfig []interface{}) error {
-	if l := len(newConfig); l != 1 {
-		return fmt.Errorf("expected only one new config object, got %d", l)
+// defaultConfig returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	c, _ := s.config("")
+	return c
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
 	}
-	if c, ok := newConfig[0].(Config); !ok {
-		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
-	} else {
-		s.configValue.Store(c)
+	if err := cs.Validate(); err != nil {
+		return err
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
 	return nil
 }
 
 func (s *Service) Global() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.Global
 }
 func (s *Service) StateChangesOnly() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.StateChangesOnly
 }
 
 type testOptions struct {
+	Workspace             string `json:"workspace"`
 	ChatId                string `json:"chat-id"`
 	ParseMode             string `json:"parse-mode"`
 	Message               string `json:"message"`
@@ -72,8 +99,12 @@ type testOptions struct {
 }
 
 func (s *Service) TestOptions() interface{} {
-	c := s.config()
+	s.mu.RLock()
+	defaultWorkspace := s.defaultWorkspace
+	s.mu.RUnlock()
+	c := s.defaultConfig()
 	return &testOptions{
+		Workspace:             defaultWorkspace,
 		ChatId:                c.ChatId,
 		ParseMode:             c.ParseMode,
 		Message:               "test telegram message",
@@ -88,6 +119,7 @@ func (s *Service) Test(options interface{}) error {
 		return fmt.Errorf("unexpected options type %T", options)
 	}
 	return s.Alert(
+		o.Workspace,
 		o.ChatId,
 		o.ParseMode,
 		o.Message,
@@ -96,8 +128,9 @@ func (s *Service) Test(options interface{}) error {
 	)
 }
 
-func (s *Service) Alert(chatId, parseMode, message string, disableWebPagePreview, disableNotification bool) error {
-	url, post, err := s.preparePost(chatId, parseMode, message, disableWebPagePreview, disableNotification)
+// Alert posts the message to Telegram using the workspace, or the default workspace if workspace is empty.
+func (s *Service) Alert(workspace, chatId, parseMode, message string, disableWebPagePreview, disableNotification bool) error {
+	url, post, err := s.preparePost(workspace, chatId, parseMode, message, disableWebPagePreview, disableNotification)
 	if err != nil {
 		return err
 	}
@@ -130,8 +163,11 @@ func (s *Service) Alert(chatId, parseMode, message string, disableWebPagePreview
 	return nil
 }
 
-func (s *Service) preparePost(chatId, parseMode, message string, disableWebPagePreview, disableNotification bool) (string, io.Reader, error) {
-	c := s.config()
+func (s *Service) preparePost(workspace, chatId, parseMode, message string, disableWebPagePreview, disableNotification bool) (string, io.Reader, error) {
+	c, err := s.config(workspace)
+	if err != nil {
+		return "", nil, err
+	}
 
 	if !c.Enabled {
 		return "", nil, errors.New("service is not enabled")
@@ -166,7 +202,7 @@ func (s *Service) preparePost(chatId, parseMode, message string, disableWebPageP
 
 	var post bytes.Buffer
 	enc := json.NewEncoder(&post)
-	err := enc.Encode(postData)
+	err = enc.Encode(postData)
 	if err != nil {
 		return "", nil, err
 	}
@@ -180,6 +216,10 @@ func (s *Service) preparePost(chatId, parseMode, message string, disableWebPageP
 }
 
 type HandlerConfig struct {
+	// Workspace is the name of the Telegram configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
 	// Telegram user/group ID to post messages to.
 	// If empty uses the chati-d from the configuration.
 	ChatId string `mapstructure:"chat-id"`
@@ -213,6 +253,7 @@ func (s *Service) Handler(c HandlerConfig, l *log.Logger) alert.Handler {
 
 func (h *handler) Handle(event alert.Event) {
 	if err := h.s.Alert(
+		h.c.Workspace,
 		h.c.ChatId,
 		h.c.ParseMode,
 		event.State.Message,
@@ -10,7 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
-	"sync/atomic"
+	"sync"
 	"time"
 
 	"github.com/influxdata/kapacitor/alert"
@@ -19,16 +19,18 @@ import (
 )
 
 type Service struct {
-	configValue atomic.Value
-	logger      *log.Logger
+	mu               sync.RWMutex
+	configs          map[string]Config
+	defaultWorkspace string
+	logger           *log.Logger
 }
 
-func NewService(c Config, l *log.Logger) *Service {
-	s := &Service{
-		logger: l,
+func NewService(cs Configs, l *log.Logger) *Service {
+	return &Service{
+		configs:          cs.index(),
+		defaultWorkspace: cs.defaultWorkspace(),
+		logger:           l,
 	}
-	s.configValue.Store(c)
-	return s
 }
 
 func (s *Service) Open() error {
@@ -39,28 +41,53 @@ func (s *Service) Close() error {
 	return nil
 }
 
-func (s *Service) config() Config {
-	return s.configValue.Load().(Config)
+// config returns the configuration of the workspace, or of the default workspace if empty.
+func (s *Service) config(workspace string) (Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if workspace == "" {
+		workspace = s.defaultWorkspace
+	}
+	c, ok := s.configs[workspace]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown VictorOps workspace %q", workspace)
+	}
+	return c, nil
 }
 
-func (s *Service) Update(newConfig []interface{}) error {
-	if l := len(newConfig); l != 1 {
-		return fmt.Errorf("expected only one new config object, got %d", l)
+// defaultConfig returns the configuration of the default workspace.
+func (s *Service) defaultConfig() Config {
+	c, _ := s.config("")
+	return c
+}
+
+func (s *Service) Update(newConfigs []interface{}) error {
+	cs := make(Configs, len(newConfigs))
+	for i, c := range newConfigs {
+		config, ok := c.(Config)
+		if !ok {
+			return fmt.Errorf("expected config object to be of type %T, got %T", config, c)
+		}
+		cs[i] = config
 	}
-	if c, ok := newConfig[0].(Config); !ok {
-		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
-	} else {
-		s.configValue.Store(c)
+	if err := cs.Validate(); err != nil {
+		return err
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = cs.index()
+	s.defaultWorkspace = cs.defaultWorkspace()
 	return nil
 }
 
 func (s *Service) Global() bool {
-	c := s.config()
+	c := s.defaultConfig()
 	return c.Global
 }
 
 type testOptions struct {
+	Workspace   string `json:"workspace"`
 	RoutingKey  string `json:"routingKey"`
 	MessageType string `json:"messageType"`
 	Message     string `json:"message"`
@@ -68,8 +95,12 @@ type testOptions struct {
 }
 
 func (s *Service) TestOptions() interface{} {
-	c := s.config()
+	s.mu.RLock()
+	defaultWorkspace := s.defaultWorkspace
+	s.mu.RUnlock()
+	c := s.defaultConfig()
 	return &testOptions{
+		Workspace:   defaultWorkspace,
 		RoutingKey:  c.RoutingKey,
 		MessageType: "CRITICAL",
 		Message:     "test victorops message",
@@ -83,6 +114,7 @@ func (s *Service) Test(options interface{}) error {
 		return fmt.Errorf("unexpected options type %T", options)
 	}
 	return s.Alert(
+		o.Workspace,
 		o.RoutingKey,
 		o.MessageType,
 		o.Message,
@@ -92,8 +124,9 @@ func (s *Service) Test(options interface{}) error {
 	)
 }
 
-func (s *Service) Alert(routingKey, messageType, message, entityID string, t time.Time, details models.Result) error {
-	url, post, err := s.preparePost(routingKey, messageType, message, entityID, t, details)
+// Alert sends the event to VictorOps using the workspace, or the default workspace if workspace is empty.
+func (s *Service) Alert(workspace, routingKey, messageType, message, entityID string, t time.Time, details models.Result) error {
+	url, post, err := s.preparePost(workspace, routingKey, messageType, message, entityID, t, details)
 	if err != nil {
 		return err
 	}
@@ -123,8 +156,11 @@ func (s *Service) Alert(routingKey, messageType, message, entityID string, t tim
 	return nil
 }
 
-func (s *Service) preparePost(routingKey, messageType, message, entityID string, t time.Time, details models.Result) (string, io.Reader, error) {
-	c := s.config()
+func (s *Service) preparePost(workspace, routingKey, messageType, message, entityID string, t time.Time, details models.Result) (string, io.Reader, error) {
+	c, err := s.config(workspace)
+	if err != nil {
+		return "", nil, err
+	}
 	if !c.Enabled {
 		return "", nil, errors.New("service is not enabled")
 	}
@@ -162,6 +198,10 @@ func (s *Service) preparePost(routingKey, messageType, message, entityID string,
 }
 
 type HandlerConfig struct {
+	// Workspace is the name of the VictorOps configuration to use.
+	// If empty uses the default configuration.
+	Workspace string `mapstructure:"workspace"`
+
 	// The routing key to use for the alert.
 	// Defaults to the value in the configuration if empty.
 	RoutingKey string `mapstructure:"routing-key"`
@@ -190,6 +230,7 @@ func (h *handler) Handle(event alert.Event) {
 		messageType = event.State.Level.String()
 	}
 	if err := h.s.Alert(
+		h.c.Workspace,
 		h.c.RoutingKey,
 		messageType,
 		event.State.Message,
@@ -0,0 +1,174 @@
+package sketch
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	// DefaultPrecision is the precision used by NewHyperLogLog when zero is given.
+	// It results in 2^14 registers and a standard error of about 0.8%.
+	DefaultPrecision = 14
+
+	MinPrecision = 4
+	MaxPrecision = 18
+)
+
+const hllVersion = 1
+
+// HyperLogLog estimates the number of distinct values in a stream
+// using a fixed amount of memory determined by its precision.
+//
+// See http://algo.inria.fr/flajolet/Publications/FlFuGaMe07.pdf for a description of the algorithm.
+type HyperLogLog struct {
+	p         uint8
+	registers []uint8
+}
+
+// NewHyperLogLog creates a new empty HyperLogLog with 2^precision registers.
+func NewHyperLogLog(precision uint8) (*HyperLogLog, error) {
+	if precision == 0 {
+		precision = DefaultPrecision
+	}
+	if precision < MinPrecision || precision > MaxPrecision {
+		return nil, fmt.Errorf("invalid hyperloglog precision %d, must be between %d and %d", precision, MinPrecision, MaxPrecision)
+	}
+	return &HyperLogLog{
+		p:         precision,
+		registers: make([]uint8, 1<<precision),
+	}, nil
+}
+
+// Precision returns the precision of the HyperLogLog.
+func (h *HyperLogLog) Precision() uint8 {
+	return h.p
+}
+
+// Add the hash of data to the set.
+func (h *HyperLogLog) Add(data []byte) {
+	hash := fnv.New64a()
+	hash.Write(data)
+	h.AddHash(mix64(hash.Sum64()))
+}
+
+// AddHash adds a pre-computed 64 bit hash to the set.
+// The hash must be uniformly distributed.
+func (h *HyperLogLog) AddHash(x uint64) {
+	idx := x >> (64 - h.p)
+	// Guard bit so the rank never exceeds 64 - p + 1.
+	w := x<<h.p | 1<<(h.p-1)
+	rank := uint8(leadingZeros64(w) + 1)
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// Merge the registers of o into h.
+// Both HyperLogLogs must have the same precision.
+func (h *HyperLogLog) Merge(o *HyperLogLog) error {
+	if o == nil {
+		return nil
+	}
+	if o.p != h.p {
+		return fmt.Errorf("cannot merge hyperloglog with precision %d into precision %d", o.p, h.p)
+	}
+	for i, r := range o.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Count returns the estimated number of distinct values added.
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := h.alpha() * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Use linear counting for small cardinalities.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+func (h *HyperLogLog) alpha() float64 {
+	switch m := len(h.registers); m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/float64(m))
+	}
+}
+
+// MarshalBinary encodes the HyperLogLog so that it can be merged elsewhere.
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 2+len(h.registers))
+	buf[0] = hllVersion
+	buf[1] = h.p
+	copy(buf[2:], h.registers)
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a HyperLogLog encoded with MarshalBinary, replacing the contents of h.
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errors.New("invalid hyperloglog encoding: too short")
+	}
+	if data[0] != hllVersion {
+		return errors.New("invalid hyperloglog encoding: unknown version")
+	}
+	p := data[1]
+	if p < MinPrecision || p > MaxPrecision {
+		return fmt.Errorf("invalid hyperloglog encoding: bad precision %d", p)
+	}
+	if len(data)-2 != 1<<p {
+		return errors.New("invalid hyperloglog encoding: wrong length")
+	}
+	// AddHash never produces a rank above 64 - p + 1.
+	maxRank := 64 - p + 1
+	for _, r := range data[2:] {
+		if r > maxRank {
+			return fmt.Errorf("invalid hyperloglog encoding: register rank %d exceeds %d", r, maxRank)
+		}
+	}
+	h.p = p
+	h.registers = make([]uint8, 1<<p)
+	copy(h.registers, data[2:])
+	return nil
+}
+
+// mix64 is the murmur3 finalizer, it improves the avalanche of weaker hashes.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func leadingZeros64(x uint64) int {
+	if x == 0 {
+		return 64
+	}
+	n := 0
+	for x&(1<<63) == 0 {
+		x <<= 1
+		n++
+	}
+	return n
+}
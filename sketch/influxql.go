@@ -0,0 +1,209 @@
+package sketch
+
+import (
+	"encoding/base64"
+	"math"
+	"strconv"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// EncodeTDigest encodes a TDigest as a base64 string suitable for a field value.
+func EncodeTDigest(t *TDigest) (string, error) {
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecodeTDigest decodes a TDigest encoded with EncodeTDigest.
+func DecodeTDigest(s string) (*TDigest, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	t := new(TDigest)
+	if err := t.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// EncodeHyperLogLog encodes a HyperLogLog as a base64 string suitable for a field value.
+func EncodeHyperLogLog(h *HyperLogLog) (string, error) {
+	data, err := h.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecodeHyperLogLog decodes a HyperLogLog encoded with EncodeHyperLogLog.
+func DecodeHyperLogLog(s string) (*HyperLogLog, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	h := new(HyperLogLog)
+	if err := h.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// TDigestReducer aggregates numeric points into a TDigest.
+// String points are expected to be encoded digests and are merged.
+// Invalid encoded digests are ignored.
+type TDigestReducer struct {
+	Digest *TDigest
+}
+
+func (r *TDigestReducer) AggregateFloat(p *influxql.FloatPoint) {
+	r.Digest.Add(p.Value)
+}
+
+func (r *TDigestReducer) AggregateInteger(p *influxql.IntegerPoint) {
+	r.Digest.Add(float64(p.Value))
+}
+
+func (r *TDigestReducer) AggregateString(p *influxql.StringPoint) {
+	t, err := DecodeTDigest(p.Value)
+	if err != nil {
+		return
+	}
+	r.Digest.Merge(t)
+}
+
+// TDigestQuantileReducer emits the estimated value at a quantile.
+type TDigestQuantileReducer struct {
+	TDigestReducer
+	Quantile float64
+}
+
+// NewTDigestQuantileReducer creates a reducer that estimates quantile q, in the range [0, 1].
+func NewTDigestQuantileReducer(q float64) *TDigestQuantileReducer {
+	return &TDigestQuantileReducer{
+		TDigestReducer: TDigestReducer{Digest: NewTDigest(DefaultCompression)},
+		Quantile:       q,
+	}
+}
+
+func (r *TDigestQuantileReducer) Emit() []influxql.FloatPoint {
+	v := r.Digest.Quantile(r.Quantile)
+	if math.IsNaN(v) {
+		return nil
+	}
+	return []influxql.FloatPoint{{
+		Time:  influxql.ZeroTime,
+		Value: v,
+	}}
+}
+
+// TDigestSketchReducer emits the encoded digest.
+type TDigestSketchReducer struct {
+	TDigestReducer
+}
+
+func NewTDigestSketchReducer() *TDigestSketchReducer {
+	return &TDigestSketchReducer{
+		TDigestReducer: TDigestReducer{Digest: NewTDigest(DefaultCompression)},
+	}
+}
+
+func (r *TDigestSketchReducer) Emit() []influxql.StringPoint {
+	s, err := EncodeTDigest(r.Digest)
+	if err != nil {
+		return nil
+	}
+	return []influxql.StringPoint{{
+		Time:  influxql.ZeroTime,
+		Value: s,
+	}}
+}
+
+// HyperLogLogReducer aggregates points of any type into a HyperLogLog.
+// If Merge is true, string points are expected to be encoded HyperLogLogs and are merged,
+// invalid encodings are ignored.
+type HyperLogLogReducer struct {
+	HLL   *HyperLogLog
+	Merge bool
+
+	buf []byte
+}
+
+func newHyperLogLogReducer(merge bool) HyperLogLogReducer {
+	h, _ := NewHyperLogLog(DefaultPrecision)
+	return HyperLogLogReducer{
+		HLL:   h,
+		Merge: merge,
+	}
+}
+
+func (r *HyperLogLogReducer) AggregateFloat(p *influxql.FloatPoint) {
+	r.buf = strconv.AppendFloat(r.buf[:0], p.Value, 'g', -1, 64)
+	r.HLL.Add(r.buf)
+}
+
+func (r *HyperLogLogReducer) AggregateInteger(p *influxql.IntegerPoint) {
+	r.buf = strconv.AppendInt(r.buf[:0], p.Value, 10)
+	r.HLL.Add(r.buf)
+}
+
+func (r *HyperLogLogReducer) AggregateString(p *influxql.StringPoint) {
+	if r.Merge {
+		h, err := DecodeHyperLogLog(p.Value)
+		if err != nil {
+			return
+		}
+		r.HLL.Merge(h)
+		return
+	}
+	r.buf = append(r.buf[:0], p.Value...)
+	r.HLL.Add(r.buf)
+}
+
+func (r *HyperLogLogReducer) AggregateBoolean(p *influxql.BooleanPoint) {
+	r.buf = strconv.AppendBool(r.buf[:0], p.Value)
+	r.HLL.Add(r.buf)
+}
+
+// HyperLogLogCountReducer emits the estimated number of distinct values.
+type HyperLogLogCountReducer struct {
+	HyperLogLogReducer
+}
+
+func NewHyperLogLogCountReducer(merge bool) *HyperLogLogCountReducer {
+	return &HyperLogLogCountReducer{
+		HyperLogLogReducer: newHyperLogLogReducer(merge),
+	}
+}
+
+func (r *HyperLogLogCountReducer) Emit() []influxql.IntegerPoint {
+	return []influxql.IntegerPoint{{
+		Time:  influxql.ZeroTime,
+		Value: int64(r.HLL.Count()),
+	}}
+}
+
+// HyperLogLogSketchReducer emits the encoded HyperLogLog.
+type HyperLogLogSketchReducer struct {
+	HyperLogLogReducer
+}
+
+func NewHyperLogLogSketchReducer(merge bool) *HyperLogLogSketchReducer {
+	return &HyperLogLogSketchReducer{
+		HyperLogLogReducer: newHyperLogLogReducer(merge),
+	}
+}
+
+func (r *HyperLogLogSketchReducer) Emit() []influxql.StringPoint {
+	s, err := EncodeHyperLogLog(r.HLL)
+	if err != nil {
+		return nil
+	}
+	return []influxql.StringPoint{{
+		Time:  influxql.ZeroTime,
+		Value: s,
+	}}
+}
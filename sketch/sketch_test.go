@@ -0,0 +1,232 @@
+package sketch_test
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/influxdata/kapacitor/sketch"
+)
+
+func TestTDigest_Quantile_Exact(t *testing.T) {
+	td := sketch.NewTDigest(0)
+	for _, v := range []float64{98, 91, 95, 93, 92, 95, 92, 96, 93, 95} {
+		td.Add(v)
+	}
+	testCases := []struct {
+		q   float64
+		exp float64
+	}{
+		{q: 0, exp: 91},
+		{q: 0.5, exp: 94},
+		{q: 1, exp: 98},
+	}
+	for _, tc := range testCases {
+		if got := td.Quantile(tc.q); got != tc.exp {
+			t.Errorf("unexpected quantile %v: got %v exp %v", tc.q, got, tc.exp)
+		}
+	}
+}
+
+func TestTDigest_Quantile_Uniform(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	td := sketch.NewTDigest(100)
+	const n = 100000
+	for i := 0; i < n; i++ {
+		td.Add(r.Float64())
+	}
+	if got, exp := len(td.Centroids()), 500; got > exp {
+		t.Errorf("too many centroids: got %d exp at most %d", got, exp)
+	}
+	for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+		if got := td.Quantile(q); math.Abs(got-q) > 0.01 {
+			t.Errorf("unexpected quantile %v: got %v", q, got)
+		}
+	}
+}
+
+func TestTDigest_MergeEncoded(t *testing.T) {
+	all := sketch.NewTDigest(100)
+	merged := sketch.NewTDigest(100)
+	for p := 0; p < 10; p++ {
+		part := sketch.NewTDigest(100)
+		for i := 0; i < 1000; i++ {
+			v := float64(p*1000 + i)
+			part.Add(v)
+			all.Add(v)
+		}
+		s, err := sketch.EncodeTDigest(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := sketch.DecodeTDigest(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		merged.Merge(decoded)
+	}
+	if got, exp := merged.Count(), all.Count(); got != exp {
+		t.Fatalf("unexpected count: got %v exp %v", got, exp)
+	}
+	for _, q := range []float64{0.1, 0.5, 0.9} {
+		if got, exp := merged.Quantile(q), all.Quantile(q); math.Abs(got-exp) > 50 {
+			t.Errorf("unexpected merged quantile %v: got %v exp %v", q, got, exp)
+		}
+	}
+}
+
+func TestTDigest_DecodeInvalid(t *testing.T) {
+	for _, s := range []string{"", "not base64!", "AAAA"} {
+		if _, err := sketch.DecodeTDigest(s); err == nil {
+			t.Errorf("expected error decoding %q", s)
+		}
+	}
+}
+
+func TestTDigest_UnmarshalBinary_Corrupt(t *testing.T) {
+	d := sketch.NewTDigest(0)
+	for i := 0; i < 100; i++ {
+		d.Add(float64(i))
+	}
+	valid, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every truncation of a valid encoding must be rejected.
+	for i := 0; i < len(valid); i++ {
+		if err := new(sketch.TDigest).UnmarshalBinary(valid[:i]); err == nil {
+			t.Errorf("expected error decoding encoding truncated to %d bytes", i)
+		}
+	}
+	if err := new(sketch.TDigest).UnmarshalBinary(append(valid, 0)); err == nil {
+		t.Error("expected error decoding encoding with trailing bytes")
+	}
+
+	// A centroid count whose byte length overflows must not pass the length check,
+	// 1<<60 + 1 centroids wrap around to exactly the 16 bytes that follow.
+	header := valid[:1+3*8]
+	for _, n := range []uint64{math.MaxUint64, math.MaxUint64/16 + 1, 1<<60 + 1} {
+		data := append([]byte(nil), header...)
+		var buf [binary.MaxVarintLen64]byte
+		data = append(data, buf[:binary.PutUvarint(buf[:], n)]...)
+		data = append(data, make([]byte, 16)...)
+		if err := new(sketch.TDigest).UnmarshalBinary(data); err == nil {
+			t.Errorf("expected error decoding centroid count %d", n)
+		}
+	}
+
+	// Random corruptions must either decode or fail, never panic.
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		data := append([]byte(nil), valid...)
+		for j := r.Intn(4); j >= 0; j-- {
+			data[r.Intn(len(data))] = byte(r.Intn(256))
+		}
+		data = data[:r.Intn(len(data)+1)]
+		new(sketch.TDigest).UnmarshalBinary(data)
+	}
+}
+
+func TestHyperLogLog_Count(t *testing.T) {
+	h, err := sketch.NewHyperLogLog(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"a", "b", "c", "a", "b", "a"} {
+		h.Add([]byte(v))
+	}
+	if got, exp := h.Count(), uint64(3); got != exp {
+		t.Errorf("unexpected small count: got %d exp %d", got, exp)
+	}
+
+	const n = 100000
+	for i := 0; i < n; i++ {
+		h.Add([]byte(strconv.Itoa(i)))
+	}
+	// Allow for 3 standard errors
+	if got := float64(h.Count()); math.Abs(got-n)/n > 0.025 {
+		t.Errorf("unexpected large count: got %v exp ~%d", got, n)
+	}
+}
+
+func TestHyperLogLog_MergeEncoded(t *testing.T) {
+	a, _ := sketch.NewHyperLogLog(12)
+	b, _ := sketch.NewHyperLogLog(12)
+	for i := 0; i < 1000; i++ {
+		a.Add([]byte(strconv.Itoa(i)))
+		b.Add([]byte(strconv.Itoa(i + 500)))
+	}
+	s, err := sketch.EncodeHyperLogLog(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := sketch.DecodeHyperLogLog(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Merge(decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := float64(a.Count()); math.Abs(got-1500)/1500 > 0.05 {
+		t.Errorf("unexpected merged count: got %v exp ~1500", got)
+	}
+
+	c, _ := sketch.NewHyperLogLog(14)
+	if err := a.Merge(c); err == nil {
+		t.Error("expected error merging different precisions")
+	}
+}
+
+func TestNewHyperLogLog_InvalidPrecision(t *testing.T) {
+	if _, err := sketch.NewHyperLogLog(sketch.MaxPrecision + 1); err == nil {
+		t.Error("expected error for invalid precision")
+	}
+}
+
+func TestHyperLogLog_UnmarshalBinary_Corrupt(t *testing.T) {
+	h, _ := sketch.NewHyperLogLog(sketch.MinPrecision)
+	for i := 0; i < 100; i++ {
+		h.Add([]byte(strconv.Itoa(i)))
+	}
+	valid, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(valid); i++ {
+		if err := new(sketch.HyperLogLog).UnmarshalBinary(valid[:i]); err == nil {
+			t.Errorf("expected error decoding encoding truncated to %d bytes", i)
+		}
+	}
+	if err := new(sketch.HyperLogLog).UnmarshalBinary(append(valid, 0)); err == nil {
+		t.Error("expected error decoding encoding with trailing bytes")
+	}
+	for _, p := range []byte{0, sketch.MinPrecision - 1, sketch.MaxPrecision + 1, 255} {
+		data := append([]byte(nil), valid...)
+		data[1] = p
+		if err := new(sketch.HyperLogLog).UnmarshalBinary(data); err == nil {
+			t.Errorf("expected error decoding precision %d", p)
+		}
+	}
+	for _, r := range []byte{64 - sketch.MinPrecision + 2, 255} {
+		data := append([]byte(nil), valid...)
+		data[2] = r
+		if err := new(sketch.HyperLogLog).UnmarshalBinary(data); err == nil {
+			t.Errorf("expected error decoding register rank %d", r)
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		data := append([]byte(nil), valid...)
+		for j := r.Intn(4); j >= 0; j-- {
+			data[r.Intn(len(data))] = byte(r.Intn(256))
+		}
+		data = data[:r.Intn(len(data)+1)]
+		decoded := new(sketch.HyperLogLog)
+		if err := decoded.UnmarshalBinary(data); err == nil {
+			decoded.Count()
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// DefaultCompression is the compression used by NewTDigest when a non positive compression is given.
+const DefaultCompression = 100
+
+const tdigestVersion = 1
+
+// Centroid is a weighted mean of a set of values.
+type Centroid struct {
+	Mean   float64
+	Weight float64
+}
+
+type centroids []Centroid
+
+func (c centroids) Len() int           { return len(c) }
+func (c centroids) Less(i, j int) bool { return c[i].Mean < c[j].Mean }
+func (c centroids) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// TDigest estimates quantiles of a stream of values using a bounded number of centroids.
+// Memory use is proportional to the compression and not the number of values added.
+//
+// See https://github.com/tdunning/t-digest for a description of the algorithm.
+type TDigest struct {
+	compression float64
+	merged      centroids
+	unmerged    centroids
+	count       float64
+	min         float64
+	max         float64
+}
+
+// NewTDigest creates a new empty TDigest.
+// Higher compression values yield more accurate estimates at the cost of more memory.
+func NewTDigest(compression float64) *TDigest {
+	if compression <= 0 {
+		compression = DefaultCompression
+	}
+	return &TDigest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// Compression returns the compression of the digest.
+func (t *TDigest) Compression() float64 {
+	return t.compression
+}
+
+// Count returns the total weight of all values added to the digest.
+func (t *TDigest) Count() float64 {
+	return t.count
+}
+
+// Add a single value to the digest.
+func (t *TDigest) Add(x float64) {
+	t.AddWeighted(x, 1)
+}
+
+// AddWeighted adds a value with the given weight to the digest.
+// NaN values and non positive weights are ignored.
+func (t *TDigest) AddWeighted(x, w float64) {
+	if math.IsNaN(x) || w <= 0 {
+		return
+	}
+	t.unmerged = append(t.unmerged, Centroid{Mean: x, Weight: w})
+	t.count += w
+	if x < t.min {
+		t.min = x
+	}
+	if x > t.max {
+		t.max = x
+	}
+	if len(t.unmerged) >= t.bufferSize() {
+		t.compress()
+	}
+}
+
+// Merge adds all centroids of o into the digest.
+func (t *TDigest) Merge(o *TDigest) {
+	if o == nil || o.count == 0 {
+		return
+	}
+	o.compress()
+	for _, c := range o.merged {
+		t.unmerged = append(t.unmerged, c)
+		t.count += c.Weight
+	}
+	if o.min < t.min {
+		t.min = o.min
+	}
+	if o.max > t.max {
+		t.max = o.max
+	}
+	if len(t.unmerged) >= t.bufferSize() {
+		t.compress()
+	}
+}
+
+// Centroids returns the compressed centroids of the digest ordered by mean.
+func (t *TDigest) Centroids() []Centroid {
+	t.compress()
+	return t.merged
+}
+
+// Quantile returns the estimated value at quantile q, where q is in the range [0, 1].
+// NaN is returned if the digest is empty.
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+	if len(t.merged) == 0 {
+		return math.NaN()
+	}
+	if len(t.merged) == 1 {
+		return t.merged[0].Mean
+	}
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+
+	index := q * t.count
+
+	// Interpolate between the minimum and the first centroid.
+	first := t.merged[0]
+	if index < first.Weight/2 {
+		return t.min + (first.Mean-t.min)*index/(first.Weight/2)
+	}
+
+	cumulative := 0.0
+	for i := 0; i < len(t.merged)-1; i++ {
+		c := t.merged[i]
+		next := t.merged[i+1]
+		left := cumulative + c.Weight/2
+		right := cumulative + c.Weight + next.Weight/2
+		if index < right {
+			return c.Mean + (next.Mean-c.Mean)*(index-left)/(right-left)
+		}
+		cumulative += c.Weight
+	}
+
+	// Interpolate between the last centroid and the maximum.
+	last := t.merged[len(t.merged)-1]
+	left := t.count - last.Weight/2
+	return last.Mean + (t.max-last.Mean)*(index-left)/(last.Weight/2)
+}
+
+func (t *TDigest) bufferSize() int {
+	return int(math.Ceil(t.compression)) * 5
+}
+
+// scale maps a quantile onto the k1 scale, which bounds the size of centroids near the tails.
+func (t *TDigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+func (t *TDigest) compress() {
+	if len(t.unmerged) == 0 {
+		return
+	}
+	all := append(t.merged, t.unmerged...)
+	sort.Stable(all)
+
+	merged := make(centroids, 0, len(all))
+	cur := all[0]
+	soFar := 0.0
+	for _, c := range all[1:] {
+		proposed := cur.Weight + c.Weight
+		q0 := soFar / t.count
+		q2 := (soFar + proposed) / t.count
+		if t.scale(q2)-t.scale(q0) <= 1 {
+			cur.Mean += (c.Mean - cur.Mean) * c.Weight / proposed
+			cur.Weight = proposed
+		} else {
+			merged = append(merged, cur)
+			soFar += cur.Weight
+			cur = c
+		}
+	}
+	merged = append(merged, cur)
+	t.merged = merged
+	t.unmerged = t.unmerged[:0]
+}
+
+// MarshalBinary encodes the digest so that it can be merged elsewhere.
+func (t *TDigest) MarshalBinary() ([]byte, error) {
+	t.compress()
+	buf := make([]byte, 1+3*8+binary.MaxVarintLen64+len(t.merged)*16)
+	buf[0] = tdigestVersion
+	i := 1
+	for _, f := range []float64{t.compression, t.min, t.max} {
+		binary.BigEndian.PutUint64(buf[i:], math.Float64bits(f))
+		i += 8
+	}
+	i += binary.PutUvarint(buf[i:], uint64(len(t.merged)))
+	for _, c := range t.merged {
+		binary.BigEndian.PutUint64(buf[i:], math.Float64bits(c.Mean))
+		binary.BigEndian.PutUint64(buf[i+8:], math.Float64bits(c.Weight))
+		i += 16
+	}
+	return buf[:i], nil
+}
+
+// UnmarshalBinary decodes a digest encoded with MarshalBinary, replacing the contents of the digest.
+func (t *TDigest) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+3*8 {
+		return errors.New("invalid t-digest encoding: too short")
+	}
+	if data[0] != tdigestVersion {
+		return errors.New("invalid t-digest encoding: unknown version")
+	}
+	i := 1
+	compression := math.Float64frombits(binary.BigEndian.Uint64(data[i:]))
+	min := math.Float64frombits(binary.BigEndian.Uint64(data[i+8:]))
+	max := math.Float64frombits(binary.BigEndian.Uint64(data[i+16:]))
+	i += 24
+	n, l := binary.Uvarint(data[i:])
+	if l <= 0 {
+		return errors.New("invalid t-digest encoding: bad centroid count")
+	}
+	i += l
+	// Check the count against the remaining bytes before multiplying so a huge count cannot overflow.
+	if rem := uint64(len(data) - i); n > rem/16 || rem != n*16 {
+		return errors.New("invalid t-digest encoding: wrong length")
+	}
+	merged := make(centroids, n)
+	count := 0.0
+	for j := range merged {
+		merged[j].Mean = math.Float64frombits(binary.BigEndian.Uint64(data[i:]))
+		merged[j].Weight = math.Float64frombits(binary.BigEndian.Uint64(data[i+8:]))
+		if math.IsNaN(merged[j].Mean) || !(merged[j].Weight > 0) || math.IsInf(merged[j].Weight, 1) {
+			return errors.New("invalid t-digest encoding: bad centroid")
+		}
+		count += merged[j].Weight
+		i += 16
+	}
+	*t = TDigest{
+		compression: compression,
+		merged:      merged,
+		count:       count,
+		min:         min,
+		max:         max,
+	}
+	if t.compression <= 0 {
+		t.compression = DefaultCompression
+	}
+	return nil
+}
@@ -471,6 +471,10 @@ func (et *ExecutingTask) createNode(p pipeline.Node, l *log.Logger) (n Node, err
 		n, err = newJoinNode(et, t, l)
 	case *pipeline.FlattenNode:
 		n, err = newFlattenNode(et, t, l)
+	case *pipeline.PivotNode:
+		n, err = newPivotNode(et, t, l)
+	case *pipeline.UnpivotNode:
+		n, err = newUnpivotNode(et, t, l)
 	case *pipeline.EvalNode:
 		n, err = newEvalNode(et, t, l)
 	case *pipeline.WhereNode:
@@ -479,6 +483,12 @@ func (et *ExecutingTask) createNode(p pipeline.Node, l *log.Logger) (n Node, err
 		n, err = newSampleNode(et, t, l)
 	case *pipeline.DerivativeNode:
 		n, err = newDerivativeNode(et, t, l)
+	case *pipeline.EWMANode:
+		n, err = newEWMANode(et, t, l)
+	case *pipeline.RateNode:
+		n, err = newRateNode(et, t, l)
+	case *pipeline.BaselineNode:
+		n, err = newBaselineNode(et, t, l)
 	case *pipeline.UDFNode:
 		n, err = newUDFNode(et, t, l)
 	case *pipeline.StatsNode:
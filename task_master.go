@@ -18,6 +18,9 @@ import (
 	"github.com/influxdata/kapacitor/server/vars"
 	alertservice "github.com/influxdata/kapacitor/services/alert"
 	"github.com/influxdata/kapacitor/services/alerta"
+	"github.com/influxdata/kapacitor/services/alertmanager"
+	"github.com/influxdata/kapacitor/services/amqp"
+	"github.com/influxdata/kapacitor/services/discord"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/httppost"
@@ -25,11 +28,13 @@ import (
 	"github.com/influxdata/kapacitor/services/mqtt"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/pagerduty"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
 	"github.com/influxdata/kapacitor/services/pushover"
 	"github.com/influxdata/kapacitor/services/sensu"
 	"github.com/influxdata/kapacitor/services/slack"
 	"github.com/influxdata/kapacitor/services/smtp"
 	"github.com/influxdata/kapacitor/services/snmptrap"
+	"github.com/influxdata/kapacitor/services/teams"
 	"github.com/influxdata/kapacitor/services/telegram"
 	"github.com/influxdata/kapacitor/services/victorops"
 	"github.com/influxdata/kapacitor/tick"
@@ -90,7 +95,7 @@ type TaskMaster struct {
 	SMTPService interface {
 		Global() bool
 		StateChangesOnly() bool
-		Handler(smtp.HandlerConfig, *log.Logger) alert.Handler
+		Handler(smtp.HandlerConfig, *log.Logger) (alert.Handler, error)
 	}
 	MQTTService interface {
 		Handler(mqtt.HandlerConfig, *log.Logger) alert.Handler
@@ -108,11 +113,22 @@ type TaskMaster struct {
 		Global() bool
 		Handler(pagerduty.HandlerConfig, *log.Logger) alert.Handler
 	}
+	PagerDuty2Service interface {
+		Global() bool
+		Handler(pagerduty2.HandlerConfig, *log.Logger) alert.Handler
+	}
+	AlertManagerService interface {
+		Global() bool
+		Handler(alertmanager.HandlerConfig, *log.Logger) alert.Handler
+	}
+	AMQPService interface {
+		Handler(amqp.HandlerConfig, *log.Logger) (alert.Handler, error)
+	}
 	PushoverService interface {
 		Handler(pushover.HandlerConfig, *log.Logger) alert.Handler
 	}
 	HTTPPostService interface {
-		Handler(httppost.HandlerConfig, *log.Logger) alert.Handler
+		Handler(httppost.HandlerConfig, *log.Logger) (alert.Handler, error)
 		Endpoint(string) (*httppost.Endpoint, bool)
 	}
 	SlackService interface {
@@ -137,12 +153,20 @@ type TaskMaster struct {
 		DefaultHandlerConfig() alerta.HandlerConfig
 		Handler(alerta.HandlerConfig, *log.Logger) (alert.Handler, error)
 	}
+	DiscordService interface {
+		Handler(discord.HandlerConfig, *log.Logger) alert.Handler
+	}
 	SensuService interface {
 		Handler(sensu.HandlerConfig, *log.Logger) (alert.Handler, error)
 	}
 	TalkService interface {
 		Handler(*log.Logger) alert.Handler
 	}
+	TeamsService interface {
+		Global() bool
+		StateChangesOnly() bool
+		Handler(teams.HandlerConfig, *log.Logger) alert.Handler
+	}
 	TimingService interface {
 		NewTimer(timer.Setter) timer.Timer
 	}
@@ -229,14 +253,19 @@ func (tm *TaskMaster) New(id string) *TaskMaster {
 	n.OpsGenieService = tm.OpsGenieService
 	n.VictorOpsService = tm.VictorOpsService
 	n.PagerDutyService = tm.PagerDutyService
+	n.PagerDuty2Service = tm.PagerDuty2Service
+	n.AlertManagerService = tm.AlertManagerService
+	n.AMQPService = tm.AMQPService
 	n.PushoverService = tm.PushoverService
 	n.SlackService = tm.SlackService
 	n.TelegramService = tm.TelegramService
 	n.SNMPTrapService = tm.SNMPTrapService
 	n.HipChatService = tm.HipChatService
 	n.AlertaService = tm.AlertaService
+	n.DiscordService = tm.DiscordService
 	n.SensuService = tm.SensuService
 	n.TalkService = tm.TalkService
+	n.TeamsService = tm.TeamsService
 	n.TimingService = tm.TimingService
 	n.K8sService = tm.K8sService
 	n.Commander = tm.Commander
@@ -0,0 +1,172 @@
+package kapacitor
+
+import (
+	"log"
+	"sort"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type UnpivotNode struct {
+	node
+	u *pipeline.UnpivotNode
+
+	// When the column key is a dimension of the current batch,
+	// the batch is buffered and split into a batch per field on end.
+	regroup edge.BeginBatchMessage
+	fields  map[string][]edge.BatchPointMessage
+}
+
+// Create a new UnpivotNode, which splits each point into a point per field.
+func newUnpivotNode(et *ExecutingTask, n *pipeline.UnpivotNode, l *log.Logger) (*UnpivotNode, error) {
+	un := &UnpivotNode{
+		u:    n,
+		node: node{Node: n, et: et, logger: l},
+	}
+	un.node.runF = un.runUnpivot
+	return un, nil
+}
+
+func (n *UnpivotNode) runUnpivot([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		n,
+	)
+	return consumer.Consume()
+}
+
+func (n *UnpivotNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if isDimension(begin.Dimensions(), n.u.ColumnKey) {
+		// Setting the column key would move the points out of the batch's group.
+		n.regroup = begin
+		n.fields = make(map[string][]edge.BatchPointMessage)
+		return nil
+	}
+	n.regroup = nil
+
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	n.timer.Pause()
+	err := edge.Forward(n.outs, begin)
+	n.timer.Resume()
+	return err
+}
+
+func (n *UnpivotNode) BatchPoint(bp edge.BatchPointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	for _, name := range sortedFieldNames(bp.Fields()) {
+		p := edge.NewBatchPointMessage(
+			models.Fields{n.u.ValueField: bp.Fields()[name]},
+			n.tags(bp.Tags(), name),
+			bp.Time(),
+		)
+		if n.regroup != nil {
+			n.fields[name] = append(n.fields[name], p)
+			continue
+		}
+		n.timer.Pause()
+		err := edge.Forward(n.outs, p)
+		n.timer.Resume()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *UnpivotNode) EndBatch(end edge.EndBatchMessage) error {
+	if n.regroup == nil {
+		return edge.Forward(n.outs, end)
+	}
+	begin := n.regroup
+	fields := n.fields
+	n.regroup = nil
+	n.fields = nil
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b := begin.ShallowCopy()
+		b.SetTags(n.tags(begin.Tags(), name))
+		b.SetSizeHint(len(fields[name]))
+		if err := edge.Forward(n.outs, b); err != nil {
+			return err
+		}
+		for _, p := range fields[name] {
+			if err := edge.Forward(n.outs, p); err != nil {
+				return err
+			}
+		}
+		if err := edge.Forward(n.outs, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *UnpivotNode) Point(p edge.PointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	// NewPointMessage derives the group ID from the new tags,
+	// so a column key that is a dimension regroups the point.
+	for _, name := range sortedFieldNames(p.Fields()) {
+		up := edge.NewPointMessage(
+			p.Name(), p.Database(), p.RetentionPolicy(),
+			p.Dimensions(),
+			models.Fields{n.u.ValueField: p.Fields()[name]},
+			n.tags(p.Tags(), name),
+			p.Time(),
+		)
+		n.timer.Pause()
+		err := edge.Forward(n.outs, up)
+		n.timer.Resume()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tags returns a copy of tags with the column key set to the field name.
+func (n *UnpivotNode) tags(tags models.Tags, field string) models.Tags {
+	t := tags.Copy()
+	t[n.u.ColumnKey] = field
+	return t
+}
+
+func (n *UnpivotNode) Barrier(b edge.BarrierMessage) error {
+	return edge.Forward(n.outs, b)
+}
+func (n *UnpivotNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	return edge.Forward(n.outs, d)
+}
+
+func isDimension(dims models.Dimensions, tag string) bool {
+	for _, d := range dims.TagNames {
+		if d == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedFieldNames returns the field names in sorted order so that points are emitted deterministically.
+func sortedFieldNames(fields models.Fields) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}